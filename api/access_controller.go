@@ -31,12 +31,18 @@ func NewAccessController() *AccessController {
 }
 
 // RegisterRoutes 注册访问相关的路由
+// /access/* 为节点接口，与操作员管理接口分离，不要求 API Key
 func (c *AccessController) RegisterRoutes(r *gin.Engine) {
 	g := r.Group("/access")
 	{
 		g.POST("/register", c.Register)
 		g.POST("/heartbeat", c.Heartbeat)
+		g.POST("/lease/verify", c.VerifyLease)
+		g.POST("/checkout", c.CheckoutSeat)
+		g.POST("/checkout/renew", c.RenewSeat)
+		g.POST("/checkin", c.CheckinSeat)
 	}
+	r.POST("/offline-activations", RequireOperator(service.PermissionLicenseManage), c.ActivateOffline)
 }
 
 // Register 自动注册
@@ -45,6 +51,7 @@ func (c *AccessController) RegisterRoutes(r *gin.Engine) {
 // @Tags access
 // @Accept json
 // @Produce json
+// @Param X-Node-Secret header string false "Node secret, required once issued"
 // @Param body body dto.RegisterCommand true "Register"
 // @Success 200 {object} api.CommonResponse
 // @Failure 400 {object} api.CommonResponse
@@ -62,6 +69,7 @@ func (c *AccessController) Register(ctx *gin.Context) {
 		LicenseKey:  cmd.LicenseKey,
 		ProductID:   cmd.ProductID,
 		VersionCode: cmd.VersionCode,
+		NodeSecret:  NodeSecret(ctx, cmd.NodeSecret),
 	})
 	if err != nil {
 		HandleError(ctx, err)
@@ -72,12 +80,13 @@ func (c *AccessController) Register(ctx *gin.Context) {
 }
 
 // Heartbeat 现在也很薄
-// 客户端定期发送心跳以验证许可证有效性并更新节点状态
+// 客户端定期发送心跳以验证许可证有效性并更新节点状态，需携带注册时签发的节点密钥
 // 若期间在线的节点解绑，或过期等操作会导致强制下线
 // @Summary Client heartbeat
 // @Tags access
 // @Accept json
 // @Produce json
+// @Param X-Node-Secret header string false "Node secret issued at register"
 // @Param body body dto.HeartbeatCommand true "Heartbeat"
 // @Success 200 {object} api.CommonResponse
 // @Failure 400 {object} api.CommonResponse
@@ -90,7 +99,14 @@ func (c *AccessController) Heartbeat(ctx *gin.Context) {
 		return
 	}
 
-	res, err := c.as.Heartbeat(ctx.Request.Context(), cmd.DeviceCode, cmd.ProductID, cmd.VersionCode, cmd.LicenseKey)
+	res, err := c.as.Heartbeat(ctx.Request.Context(), service.AccessCommand{
+		DeviceCode:  cmd.DeviceCode,
+		LicenseKey:  cmd.LicenseKey,
+		ProductID:   cmd.ProductID,
+		VersionCode: cmd.VersionCode,
+		NodeSecret:  NodeSecret(ctx, cmd.NodeSecret),
+		Usage:       cmd.Usage,
+	})
 	if err != nil {
 		HandleError(ctx, err)
 		return
@@ -98,3 +114,155 @@ func (c *AccessController) Heartbeat(ctx *gin.Context) {
 
 	Success(ctx, res)
 }
+
+// VerifyLease 校验租约令牌
+// 客户端恢复联网后可校验缓存的租约，吊销前签发的租约会被拒绝
+// @Summary Verify lease token
+// @Tags access
+// @Accept json
+// @Produce json
+// @Param body body dto.VerifyLeaseCommand true "Verify lease"
+// @Success 200 {object} api.CommonResponse
+// @Failure 400 {object} api.CommonResponse
+// @Failure 403 {object} api.CommonResponse
+// @Failure 500 {object} api.CommonResponse
+// @Router /access/lease/verify [post]
+func (c *AccessController) VerifyLease(ctx *gin.Context) {
+	var cmd dto.VerifyLeaseCommand
+	if err := ctx.ShouldBindJSON(&cmd); err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+
+	res, err := c.as.VerifyLease(ctx.Request.Context(), cmd.LeaseToken)
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+
+	Success(ctx, res)
+}
+
+// ActivateOffline 离线激活
+// 管理员上传无法联网节点生成的激活请求，返回签名的激活响应文件供节点导入
+// @Summary Offline activation
+// @Tags access
+// @Accept json
+// @Produce json
+// @Param body body dto.OfflineActivationCommand true "Offline activation request"
+// @Success 200 {object} api.CommonResponse
+// @Failure 400 {object} api.CommonResponse
+// @Failure 403 {object} api.CommonResponse
+// @Failure 409 {object} api.CommonResponse
+// @Failure 500 {object} api.CommonResponse
+// @Router /offline-activations [post]
+func (c *AccessController) ActivateOffline(ctx *gin.Context) {
+	var cmd dto.OfflineActivationCommand
+	if err := ctx.ShouldBindJSON(&cmd); err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+
+	res, err := c.as.ActivateOffline(ctx.Request.Context(), service.OfflineActivationCommand{
+		Request: cmd.Request,
+	})
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+
+	Success(ctx, res)
+}
+
+// CheckoutSeat 申请浮动席位
+// 席位占满时返回 granted=false 与排队位置，客户端需在 retry_after 秒后再次申请
+// @Summary Checkout floating seat
+// @Tags access
+// @Accept json
+// @Produce json
+// @Param X-Node-Secret header string true "Node secret"
+// @Param body body dto.SeatCheckoutCommand true "Checkout"
+// @Success 200 {object} service.SeatLeaseData
+// @Failure 400 {object} api.CommonResponse
+// @Failure 403 {object} api.CommonResponse
+// @Router /access/checkout [post]
+func (c *AccessController) CheckoutSeat(ctx *gin.Context) {
+	var cmd dto.SeatCheckoutCommand
+	if err := ctx.ShouldBindJSON(&cmd); err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	res, err := c.as.CheckoutSeat(ctx.Request.Context(), seatCheckoutCommand(ctx, cmd))
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, res)
+}
+
+// RenewSeat 续期浮动席位
+// @Summary Renew floating seat
+// @Tags access
+// @Accept json
+// @Produce json
+// @Param X-Node-Secret header string true "Node secret"
+// @Param body body dto.SeatCheckoutCommand true "Renew"
+// @Success 200 {object} service.SeatLeaseData
+// @Failure 404 {object} api.CommonResponse
+// @Failure 409 {object} api.CommonResponse
+// @Router /access/checkout/renew [post]
+func (c *AccessController) RenewSeat(ctx *gin.Context) {
+	var cmd dto.SeatCheckoutCommand
+	if err := ctx.ShouldBindJSON(&cmd); err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	res, err := c.as.RenewSeat(ctx.Request.Context(), seatCheckoutCommand(ctx, cmd))
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, res)
+}
+
+// CheckinSeat 归还浮动席位或退出排队
+// @Summary Checkin floating seat
+// @Tags access
+// @Accept json
+// @Produce json
+// @Param X-Node-Secret header string true "Node secret"
+// @Param body body dto.SeatCheckinCommand true "Checkin"
+// @Success 200 {object} api.CommonResponse
+// @Failure 403 {object} api.CommonResponse
+// @Failure 404 {object} api.CommonResponse
+// @Router /access/checkin [post]
+func (c *AccessController) CheckinSeat(ctx *gin.Context) {
+	var cmd dto.SeatCheckinCommand
+	if err := ctx.ShouldBindJSON(&cmd); err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	if err := c.as.CheckinSeat(ctx.Request.Context(), service.AccessCommand{
+		DeviceCode: cmd.DeviceCode,
+		LicenseKey: cmd.LicenseKey,
+		ProductID:  cmd.ProductID,
+		NodeSecret: NodeSecret(ctx, cmd.NodeSecret),
+	}); err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	SuccessMsg(ctx, "seat released")
+}
+
+func seatCheckoutCommand(ctx *gin.Context, cmd dto.SeatCheckoutCommand) service.SeatCheckoutCommand {
+	return service.SeatCheckoutCommand{
+		AccessCommand: service.AccessCommand{
+			DeviceCode:  cmd.DeviceCode,
+			LicenseKey:  cmd.LicenseKey,
+			ProductID:   cmd.ProductID,
+			VersionCode: cmd.VersionCode,
+			NodeSecret:  NodeSecret(ctx, cmd.NodeSecret),
+		},
+		TTLSeconds: cmd.TTLSeconds,
+	}
+}
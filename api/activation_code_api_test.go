@@ -0,0 +1,93 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestActivationCodeAPI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := setupControlAPITest(t)
+	_, productID, _ := seedControlAPITarget(t, ctx)
+
+	router := NewServer()
+	NewLicenseController().RegisterRoutes(router)
+	NewActivationCodeController().RegisterRoutes(router)
+
+	license := doJSON(t, router, http.MethodPost, "/licenses", map[string]interface{}{
+		"product_id":     productID,
+		"validity_hours": 24,
+		"max_nodes":      2,
+	}).Data.(map[string]interface{})
+
+	if status, _ := doJSONWithHeaders(t, router, http.MethodPost, "/activation-codes", nil, map[string]interface{}{"count": 2}); status != http.StatusBadRequest {
+		t.Fatalf("code without extras should be rejected, got %d", status)
+	}
+	batch := doJSON(t, router, http.MethodPost, "/activation-codes", map[string]interface{}{
+		"product_id":  productID,
+		"extra_hours": 24,
+		"extra_nodes": 1,
+		"count":       2,
+	}).Data.(map[string]interface{})
+	codes := batch["codes"].([]interface{})
+	if len(codes) != 2 {
+		t.Fatalf("generate codes: %#v", batch)
+	}
+	first := codes[0].(map[string]interface{})
+
+	redeemed := doJSON(t, router, http.MethodPost, "/activation-codes/redeem", map[string]interface{}{
+		"license_key": license["license_key"],
+		"code":        first["code"],
+	}).Data.(map[string]interface{})
+	renewed := redeemed["license"].(map[string]interface{})
+	if renewed["validity_hours"].(float64) != 48 || renewed["max_nodes"].(float64) != 3 {
+		t.Fatalf("unexpected license after redeem: %#v", renewed)
+	}
+	if status, _ := doJSONWithHeaders(t, router, http.MethodPost, "/activation-codes/redeem", nil, map[string]interface{}{
+		"license_key": license["license_key"],
+		"code":        first["code"],
+	}); status != http.StatusConflict {
+		t.Fatalf("second redeem should conflict, got %d", status)
+	}
+
+	second := codes[1].(map[string]interface{})
+	doJSON(t, router, http.MethodPost, "/activation-codes/"+uintString(uint(second["id"].(float64)))+"/disable", nil)
+	list := doJSON(t, router, http.MethodGet, "/activation-codes?batch="+batch["batch"].(string)+"&status=1", nil).Data.([]interface{})
+	if len(list) != 1 || list[0].(map[string]interface{})["redeemed_by"] == "" {
+		t.Fatalf("list redeemed codes: %#v", list)
+	}
+	// 连续兑换失败过多时返回 429
+	wrong := strings.Repeat("A", len(second["code"].(string)))
+	status := 0
+	for i := 0; i < 20 && status != http.StatusTooManyRequests; i++ {
+		status, _ = doJSONWithHeaders(t, router, http.MethodPost, "/activation-codes/redeem", nil, map[string]interface{}{
+			"license_key": license["license_key"],
+			"code":        wrong,
+		})
+	}
+	if status != http.StatusTooManyRequests {
+		t.Fatalf("repeated failed redeems should be throttled, got %d", status)
+	}
+}
+
+func TestClientIPIgnoresUntrustedForwardedFor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := NewServer()
+	router.GET("/client-ip", func(c *gin.Context) {
+		c.String(http.StatusOK, c.ClientIP())
+	})
+
+	// 未配置可信代理时伪造的 X-Forwarded-For 不能改变兑换限流与审计使用的客户端 IP
+	req := httptest.NewRequest(http.MethodGet, "/client-ip", nil)
+	req.RemoteAddr = "192.0.2.10:40000"
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Body.String() != "192.0.2.10" {
+		t.Fatalf("client ip should come from the connection, got %q", rec.Body.String())
+	}
+}
@@ -0,0 +1,166 @@
+package api
+
+import (
+	"nexus-core/api/dto"
+	"nexus-core/domain/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ActivationCodeController 管理续期兑换码
+type ActivationCodeController struct {
+	as *service.ActivationCodeService
+}
+
+func NewActivationCodeController() *ActivationCodeController {
+	return &ActivationCodeController{
+		as: service.NewActivationCodeService(),
+	}
+}
+
+// RegisterRoutes 注册兑换码路由
+// 兑换接口面向客户，凭注册码兑换，不要求 API Key，按客户端 IP 和注册码限制失败次数
+func (c *ActivationCodeController) RegisterRoutes(r *gin.Engine) {
+	r.POST("/activation-codes/redeem", c.RedeemActivationCode)
+	codes := r.Group("/activation-codes", RequireOperator(service.PermissionLicenseManage))
+	{
+		codes.POST("", c.GenerateActivationCodes)
+		codes.GET("", c.ListActivationCodes)
+		codes.POST("/:id/disable", c.DisableActivationCode)
+	}
+}
+
+// GenerateActivationCodes 批量生成兑换码，完整兑换码只在本次返回
+// @Summary Generate activation codes
+// @Tags activation-codes
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param body body dto.GenerateActivationCodesCommand true "Generate Activation Codes"
+// @Success 200 {object} service.ActivationCodeBatchData
+// @Failure 400 {object} api.CommonResponse
+// @Failure 404 {object} api.CommonResponse
+// @Router /activation-codes [post]
+func (c *ActivationCodeController) GenerateActivationCodes(ctx *gin.Context) {
+	var cmd dto.GenerateActivationCodesCommand
+	if err := ctx.ShouldBindJSON(&cmd); err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	data, err := c.as.GenerateActivationCodes(ctx.Request.Context(), service.GenerateActivationCodesCommand{
+		ProductID:       cmd.ProductID,
+		ExtraHours:      cmd.ExtraHours,
+		ExtraNodes:      cmd.ExtraNodes,
+		ExtraConcurrent: cmd.ExtraConcurrent,
+		ExpiresAt:       cmd.ExpiresAt,
+		Count:           cmd.Count,
+		Remark:          cmd.Remark,
+	})
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// ListActivationCodes 查询兑换码列表，兑换码以掩码展示
+// @Summary List activation codes
+// @Tags activation-codes
+// @Produce json
+// @Security ApiKeyAuth
+// @Param batch query string false "Batch"
+// @Param status query int false "Status"
+// @Param license_id query uint false "Redeemed License ID"
+// @Param page query int false "Page"
+// @Param page_size query int false "Page Size"
+// @Success 200 {object} api.CommonResponse
+// @Failure 400 {object} api.CommonResponse
+// @Router /activation-codes [get]
+func (c *ActivationCodeController) ListActivationCodes(ctx *gin.Context) {
+	page, err := PaginationQuery(ctx)
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	status, err := IntQueryPtr(ctx, "status")
+	if err != nil {
+		BadRequest(ctx, "invalid status")
+		return
+	}
+	licenseID, err := UintQuery(ctx, "license_id")
+	if err != nil {
+		BadRequest(ctx, "invalid license_id")
+		return
+	}
+	var batch *string
+	if value := ctx.Query("batch"); value != "" {
+		batch = &value
+	}
+	data, err := c.as.ListActivationCodes(ctx.Request.Context(), service.ListActivationCodesCommand{
+		Batch:     batch,
+		Status:    status,
+		LicenseID: licenseID,
+		Limit:     page.Limit,
+		Offset:    page.Offset,
+	})
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// DisableActivationCode 作废未使用的兑换码
+// @Summary Disable activation code
+// @Tags activation-codes
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path uint true "Activation Code ID"
+// @Success 200 {object} service.ActivationCodeData
+// @Failure 404 {object} api.CommonResponse
+// @Failure 409 {object} api.CommonResponse
+// @Router /activation-codes/{id}/disable [post]
+func (c *ActivationCodeController) DisableActivationCode(ctx *gin.Context) {
+	id, err := UintParamOrQuery(ctx, "id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	data, err := c.as.DisableActivationCode(ctx.Request.Context(), id)
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// RedeemActivationCode 使用注册码兑换兑换码，为 License 增加有效时长或节点数、并发数
+// @Summary Redeem activation code
+// @Tags activation-codes
+// @Accept json
+// @Produce json
+// @Param body body dto.RedeemActivationCodeCommand true "Redeem Activation Code"
+// @Success 200 {object} service.RedeemActivationCodeData
+// @Failure 400 {object} api.CommonResponse
+// @Failure 403 {object} api.CommonResponse
+// @Failure 404 {object} api.CommonResponse
+// @Failure 409 {object} api.CommonResponse
+// @Failure 429 {object} api.CommonResponse
+// @Router /activation-codes/redeem [post]
+func (c *ActivationCodeController) RedeemActivationCode(ctx *gin.Context) {
+	var cmd dto.RedeemActivationCodeCommand
+	if err := ctx.ShouldBindJSON(&cmd); err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	data, err := c.as.RedeemActivationCode(ctx.Request.Context(), service.RedeemActivationCodeCommand{
+		LicenseKey: cmd.LicenseKey,
+		Code:       cmd.Code,
+		ClientIP:   ctx.ClientIP(),
+	})
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
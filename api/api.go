@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"nexus-core/domain/service"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -27,12 +28,14 @@ type CommonResponse struct {
 type APIResponse = CommonResponse
 
 const (
-	CodeOK         = 200 // 成功状态码
-	CodeBadRequest = 400 // 请求错误状态码
-	CodeForbidden  = 403 // 权限不足状态码
-	CodeNotFound   = 404 // 未找到状态码
-	CodeConflict   = 409 // 状态冲突状态码
-	CodeInternal   = 500 // 内部错误状态码
+	CodeOK           = 200 // 成功状态码
+	CodeBadRequest   = 400 // 请求错误状态码
+	CodeUnauthorized = 401 // 未认证状态码
+	CodeForbidden    = 403 // 权限不足状态码
+	CodeNotFound     = 404 // 未找到状态码
+	CodeConflict     = 409 // 状态冲突状态码
+	CodeTooMany      = 429 // 请求过于频繁状态码
+	CodeInternal     = 500 // 内部错误状态码
 )
 
 // JSON 发送自定义响应
@@ -60,6 +63,11 @@ func BadRequest(ctx *gin.Context, message string) {
 	JSON(ctx, http.StatusBadRequest, CodeBadRequest, message, nil)
 }
 
+// Unauthorized 返回401错误响应
+func Unauthorized(ctx *gin.Context, message string) {
+	JSON(ctx, http.StatusUnauthorized, CodeUnauthorized, message, nil)
+}
+
 // Forbidden 返回403错误响应
 func Forbidden(ctx *gin.Context, message string) {
 	JSON(ctx, http.StatusForbidden, CodeForbidden, message, nil)
@@ -75,6 +83,11 @@ func Conflict(ctx *gin.Context, message string) {
 	JSON(ctx, http.StatusConflict, CodeConflict, message, nil)
 }
 
+// TooManyRequests 返回429错误响应
+func TooManyRequests(ctx *gin.Context, message string) {
+	JSON(ctx, http.StatusTooManyRequests, CodeTooMany, message, nil)
+}
+
 // InternalError 返回500错误响应
 func InternalError(ctx *gin.Context, message string) {
 	JSON(ctx, http.StatusInternalServerError, CodeInternal, message, nil)
@@ -85,20 +98,47 @@ func HandleError(ctx *gin.Context, err error) {
 	if err == nil {
 		return
 	}
+	if code := service.ErrorCodeOf(err); code != 0 {
+		JSON(ctx, errorHTTPStatus(service.ErrorKindOf(err)), code, err.Error(), nil)
+		return
+	}
 	switch service.ErrorKindOf(err) {
 	case service.ErrorKindBadRequest:
 		BadRequest(ctx, err.Error())
+	case service.ErrorKindUnauthorized:
+		Unauthorized(ctx, err.Error())
 	case service.ErrorKindNotFound:
 		NotFound(ctx, err.Error())
 	case service.ErrorKindForbidden:
 		Forbidden(ctx, err.Error())
 	case service.ErrorKindConflict:
 		Conflict(ctx, err.Error())
+	case service.ErrorKindTooManyRequests:
+		TooManyRequests(ctx, err.Error())
 	default:
 		InternalError(ctx, err.Error())
 	}
 }
 
+func errorHTTPStatus(kind service.ErrorKind) int {
+	switch kind {
+	case service.ErrorKindBadRequest:
+		return http.StatusBadRequest
+	case service.ErrorKindUnauthorized:
+		return http.StatusUnauthorized
+	case service.ErrorKindNotFound:
+		return http.StatusNotFound
+	case service.ErrorKindForbidden:
+		return http.StatusForbidden
+	case service.ErrorKindConflict:
+		return http.StatusConflict
+	case service.ErrorKindTooManyRequests:
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 func UintParamOrQuery(ctx *gin.Context, name string) (uint, error) {
 	value := ctx.Param(name)
 	if value == "" {
@@ -113,3 +153,18 @@ func UintParamOrQuery(ctx *gin.Context, name string) (uint, error) {
 	}
 	return uint(parsed), nil
 }
+
+// NodeSecretHeader 节点密钥请求头
+const NodeSecretHeader = "X-Node-Secret"
+
+// NodeSecret 读取节点密钥，优先使用请求头，其次使用请求体或 node_secret 查询参数
+// WebSocket 客户端通常无法设置请求头，因此允许通过查询参数传递
+func NodeSecret(ctx *gin.Context, bodyValue string) string {
+	if secret := strings.TrimSpace(ctx.GetHeader(NodeSecretHeader)); secret != "" {
+		return secret
+	}
+	if secret := strings.TrimSpace(bodyValue); secret != "" {
+		return secret
+	}
+	return strings.TrimSpace(ctx.Query("node_secret"))
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestBindingApprovalAPI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := setupControlAPITest(t)
+	_, productID, _ := seedControlAPITarget(t, ctx)
+
+	router := NewServer()
+	NewLicenseController().RegisterRoutes(router)
+	NewCustomerController().RegisterRoutes(router)
+	NewPortalController().RegisterRoutes(router)
+	NewAccessController().RegisterRoutes(router)
+
+	customer := doJSON(t, router, http.MethodPost, "/customers", map[string]interface{}{"name": "Approval Co"})
+	customerID := uint(customer.Data.(map[string]interface{})["id"].(float64))
+	license := doJSON(t, router, http.MethodPost, "/licenses", map[string]interface{}{
+		"product_id":     productID,
+		"validity_hours": 24,
+		"customer_id":    customerID,
+	})
+	licenseData := license.Data.(map[string]interface{})
+	licensePath := "/licenses/" + uintString(uint(licenseData["id"].(float64)))
+	enabled := doJSON(t, router, http.MethodPost, licensePath+"/approval", map[string]interface{}{"approval_required": true})
+	if !enabled.Data.(map[string]interface{})["approval_required"].(bool) {
+		t.Fatalf("enable approval: %#v", enabled)
+	}
+
+	accessBody := map[string]interface{}{
+		"device_code":  "approval-api-a",
+		"license_key":  licenseData["license_key"],
+		"product_id":   productID,
+		"version_code": "1.0.0",
+	}
+	registered := doJSON(t, router, http.MethodPost, "/access/register", accessBody)
+	if data := registered.Data.(map[string]interface{}); data["binding_status"] != "pending_approval" {
+		t.Fatalf("register should wait for approval: %#v", registered)
+	}
+	accessBody["node_secret"] = registered.Data.(map[string]interface{})["node_secret"]
+	heartbeat := doJSON(t, router, http.MethodPost, "/access/heartbeat", accessBody)
+	if data := heartbeat.Data.(map[string]interface{}); data["binding_status"] != "pending_approval" || data["online"].(bool) {
+		t.Fatalf("heartbeat should report pending approval: %#v", heartbeat)
+	}
+
+	requests := doJSON(t, router, http.MethodGet, licensePath+"/binding-requests", nil)
+	items := requests.Data.([]interface{})
+	if len(items) != 1 {
+		t.Fatalf("list binding requests: %#v", requests)
+	}
+	bindingID := uint(items[0].(map[string]interface{})["id"].(float64))
+
+	// 客户通过门户审批自己 License 的设备
+	issued := doJSON(t, router, http.MethodPost, "/customers/"+uintString(customerID)+"/portal-key/rotate", nil)
+	headers := map[string]string{CustomerKeyHeader: issued.Data.(map[string]interface{})["portal_key"].(string)}
+	portalPath := "/portal" + licensePath + "/binding-requests/" + uintString(bindingID)
+	status, approved := doJSONWithHeaders(t, router, http.MethodPost, portalPath+"/approve", headers, nil)
+	if status != http.StatusOK || approved.Data.(map[string]interface{})["status"] != "bound" {
+		t.Fatalf("portal approve status=%d response=%#v", status, approved)
+	}
+	if status, _ := doJSONWithHeaders(t, router, http.MethodPost, portalPath+"/reject", headers, nil); status != http.StatusConflict {
+		t.Fatalf("rejecting an approved binding should conflict, got %d", status)
+	}
+	heartbeat = doJSON(t, router, http.MethodPost, "/access/heartbeat", accessBody)
+	if data := heartbeat.Data.(map[string]interface{}); data["binding_status"] != "bound" || !data["online"].(bool) {
+		t.Fatalf("approved node should be online: %#v", heartbeat)
+	}
+}
@@ -25,10 +25,15 @@ func setupControlAPITest(t *testing.T) context.Context {
 	oldDB := global.DB
 	oldStat := monitor.GlobalStat
 	oldMonitor := monitor.GlobalMonitor
+	// 接口测试默认关闭管理认证，需要认证的用例自行开启
+	cfg := global.GetConfig()
+	oldAuth := cfg.AdminAuth
+	cfg.AdminAuth.Enabled = false
 	t.Cleanup(func() {
 		global.DB = oldDB
 		monitor.GlobalStat = oldStat
 		monitor.GlobalMonitor = oldMonitor
+		cfg.AdminAuth = oldAuth
 	})
 
 	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "control-api.db")), &gorm.Config{})
@@ -44,6 +49,9 @@ func setupControlAPITest(t *testing.T) context.Context {
 
 	base.AutoMigrate(db)
 	global.DB = db
+	if err := service.NewSigningService().EnsureActiveKey(context.Background()); err != nil {
+		t.Fatalf("ensure signing key: %v", err)
+	}
 	monitor.GlobalStat = monitor.NewOnlineStat()
 	monitor.GlobalMonitor = monitor.NewMonitor(monitor.GlobalStat)
 	return context.Background()
@@ -52,7 +60,7 @@ func setupControlAPITest(t *testing.T) context.Context {
 func TestControlAPIHTTPFlow(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	ctx := setupControlAPITest(t)
-	nodeID, productID := seedControlAPITarget(t, ctx)
+	nodeID, productID, nodeSecret := seedControlAPITarget(t, ctx)
 
 	router := NewServer()
 	NewControlController().RegisterRoutes(router)
@@ -83,6 +91,7 @@ func TestControlAPIHTTPFlow(t *testing.T) {
 
 	capability := doJSON(t, router, http.MethodPost, "/node-capabilities", map[string]interface{}{
 		"node_id":            nodeID,
+		"node_secret":        nodeSecret,
 		"service_identifier": "restart_process",
 		"protocol":           "http",
 		"endpoint":           nodeServer.URL,
@@ -130,7 +139,7 @@ func TestControlAPIHTTPFlow(t *testing.T) {
 func TestControlAPIManageAndCompleteCommand(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	ctx := setupControlAPITest(t)
-	nodeID, productID := seedControlAPITarget(t, ctx)
+	nodeID, productID, nodeSecret := seedControlAPITarget(t, ctx)
 
 	router := NewServer()
 	NewControlController().RegisterRoutes(router)
@@ -181,6 +190,7 @@ func TestControlAPIManageAndCompleteCommand(t *testing.T) {
 
 	capability := doJSON(t, router, http.MethodPost, "/node-capabilities", map[string]interface{}{
 		"node_id":            nodeID,
+		"node_secret":        nodeSecret,
 		"service_identifier": "restart_process",
 		"protocol":           "mqtt",
 		"endpoint":           "nodes/control-api-node/restart",
@@ -210,8 +220,9 @@ func TestControlAPIManageAndCompleteCommand(t *testing.T) {
 	}
 
 	completed := doJSON(t, router, http.MethodPost, "/control-commands/"+uintString(commandID)+"/complete", map[string]interface{}{
-		"status": "success",
-		"result": map[string]interface{}{"applied": true},
+		"node_secret": nodeSecret,
+		"status":      "success",
+		"result":      map[string]interface{}{"applied": true},
 	})
 	if completed.Code != CodeOK {
 		t.Fatalf("complete command code = %d body = %#v", completed.Code, completed)
@@ -240,7 +251,7 @@ func TestControlAPIManageAndCompleteCommand(t *testing.T) {
 	}
 }
 
-func seedControlAPITarget(t *testing.T, ctx context.Context) (uint, uint) {
+func seedControlAPITarget(t *testing.T, ctx context.Context) (uint, uint, string) {
 	t.Helper()
 
 	productService := service.NewProductService()
@@ -276,10 +287,16 @@ func seedControlAPITarget(t *testing.T, ctx context.Context) (uint, uint) {
 	if err != nil {
 		t.Fatalf("register: %v", err)
 	}
-	if _, err := accessService.Heartbeat(ctx, "control-api-node", product.ID, "1.0.0", license.LicenseKey); err != nil {
+	if _, err := accessService.Heartbeat(ctx, service.AccessCommand{
+		DeviceCode:  "control-api-node",
+		LicenseKey:  license.LicenseKey,
+		ProductID:   product.ID,
+		VersionCode: "1.0.0",
+		NodeSecret:  register.NodeSecret,
+	}); err != nil {
 		t.Fatalf("heartbeat: %v", err)
 	}
-	return register.NodeID, product.ID
+	return register.NodeID, product.ID, register.NodeSecret
 }
 
 func doJSON(t *testing.T, router http.Handler, method string, path string, payload interface{}) CommonResponse {
@@ -15,8 +15,11 @@ func NewControlController() *ControlController {
 	return &ControlController{cs: service.NewControlService()}
 }
 
+// RegisterRoutes 注册控制相关路由
+// 能力上报、指令回执和 WebSocket 由节点调用，使用节点密钥认证，不经过操作员权限校验
 func (c *ControlController) RegisterRoutes(r *gin.Engine) {
-	g := r.Group("/control-services")
+	requireNodeManage := RequireOperator(service.PermissionNodeManage)
+	g := r.Group("/control-services", requireNodeManage)
 	{
 		g.POST("", c.CreateControlService)
 		g.GET("", c.ListControlServices)
@@ -29,14 +32,14 @@ func (c *ControlController) RegisterRoutes(r *gin.Engine) {
 	capabilities := r.Group("/node-capabilities")
 	{
 		capabilities.POST("", c.ReportNodeCapability)
-		capabilities.GET("", c.ListNodeCapabilities)
+		capabilities.GET("", requireNodeManage, c.ListNodeCapabilities)
 	}
 
 	commands := r.Group("/control-commands")
 	{
-		commands.POST("", c.CreateControlCommand)
-		commands.GET("", c.ListControlCommands)
-		commands.GET("/:id", c.GetControlCommandByID)
+		commands.POST("", requireNodeManage, c.CreateControlCommand)
+		commands.GET("", requireNodeManage, c.ListControlCommands)
+		commands.GET("/:id", requireNodeManage, c.GetControlCommandByID)
 		commands.POST("/:id/complete", c.CompleteControlCommand)
 	}
 
@@ -194,6 +197,7 @@ func (c *ControlController) ListControlServices(ctx *gin.Context) {
 // @Tags node-capabilities
 // @Accept json
 // @Produce json
+// @Param X-Node-Secret header string false "Node secret"
 // @Param body body dto.ReportNodeCapabilityCommand true "Report Node Capability"
 // @Success 200 {object} api.CommonResponse
 // @Failure 400 {object} api.CommonResponse
@@ -209,6 +213,7 @@ func (c *ControlController) ReportNodeCapability(ctx *gin.Context) {
 
 	data, err := c.cs.ReportNodeCapability(ctx.Request.Context(), service.ReportNodeCapabilityCommand{
 		NodeID:            cmd.NodeID,
+		NodeSecret:        NodeSecret(ctx, cmd.NodeSecret),
 		ServiceIdentifier: cmd.ServiceIdentifier,
 		Schema:            cmd.Schema,
 		Protocol:          cmd.Protocol,
@@ -326,6 +331,7 @@ func (c *ControlController) GetControlCommandByID(ctx *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param node_id query uint true "Node ID"
+// @Param node_secret query string false "Node secret, or use X-Node-Secret header"
 // @Success 101 {string} string "Switching Protocols"
 // @Failure 400 {object} api.CommonResponse
 // @Failure 500 {object} api.CommonResponse
@@ -336,7 +342,7 @@ func (c *ControlController) ConnectNodeControlWebSocket(ctx *gin.Context) {
 		BadRequest(ctx, err.Error())
 		return
 	}
-	if err := service.DefaultControlWebSocketHub.ServeHTTP(ctx.Writer, ctx.Request, nodeID); err != nil {
+	if err := service.DefaultControlWebSocketHub.ServeHTTP(ctx.Writer, ctx.Request, nodeID, NodeSecret(ctx, "")); err != nil {
 		HandleError(ctx, err)
 		return
 	}
@@ -448,6 +454,7 @@ func (c *ControlController) DeleteControlService(ctx *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path uint true "Control Command ID"
+// @Param X-Node-Secret header string false "Node secret"
 // @Param body body dto.CompleteControlCommand true "Complete Control Command"
 // @Success 200 {object} api.CommonResponse
 // @Failure 400 {object} api.CommonResponse
@@ -469,6 +476,7 @@ func (c *ControlController) CompleteControlCommand(ctx *gin.Context) {
 
 	data, err := c.cs.CompleteControlCommand(ctx.Request.Context(), service.CompleteControlCommandCommand{
 		CommandID:    cmd.CommandID,
+		NodeSecret:   NodeSecret(ctx, cmd.NodeSecret),
 		Status:       cmd.Status,
 		Result:       cmd.Result,
 		ErrorMessage: cmd.ErrorMessage,
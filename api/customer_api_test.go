@@ -0,0 +1,76 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"nexus-core/domain/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCustomerPortalAPI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := setupControlAPITest(t)
+	nodeID, productID, _ := seedControlAPITarget(t, ctx)
+	licenses, err := service.NewLicenseService().ListLicenses(ctx, service.ListLicensesCommand{ProductID: &productID})
+	if err != nil || len(licenses) != 1 {
+		t.Fatalf("list seeded licenses: %#v err=%v", licenses, err)
+	}
+	licenseID := licenses[0].ID
+
+	router := NewServer()
+	NewLicenseController().RegisterRoutes(router)
+	NewCustomerController().RegisterRoutes(router)
+	NewPortalController().RegisterRoutes(router)
+	NewMonitorController().RegisterRoutes(router)
+
+	customer := doJSON(t, router, http.MethodPost, "/customers", map[string]interface{}{
+		"name":  "Acme",
+		"email": "ops@acme.test",
+	})
+	if customer.Code != CodeOK {
+		t.Fatalf("create customer: %#v", customer)
+	}
+	customerID := uint(customer.Data.(map[string]interface{})["id"].(float64))
+	assigned := doJSON(t, router, http.MethodPost, "/licenses/"+uintString(licenseID)+"/customer", map[string]interface{}{
+		"customer_id": customerID,
+	})
+	if assigned.Code != CodeOK {
+		t.Fatalf("assign customer: %#v", assigned)
+	}
+
+	owned := doJSON(t, router, http.MethodGet, "/customers/"+uintString(customerID)+"/licenses", nil)
+	rows := owned.Data.([]interface{})
+	if len(rows) != 1 || len(rows[0].(map[string]interface{})["nodes"].([]interface{})) != 1 {
+		t.Fatalf("customer licenses should include bound node: %#v", owned)
+	}
+
+	if status, _ := doJSONWithKey(t, router, http.MethodGet, "/portal/licenses", "", nil); status != http.StatusUnauthorized {
+		t.Fatalf("portal without key should be unauthorized, got %d", status)
+	}
+	issued := doJSON(t, router, http.MethodPost, "/customers/"+uintString(customerID)+"/portal-key/rotate", nil)
+	portalKey := issued.Data.(map[string]interface{})["portal_key"].(string)
+	headers := map[string]string{CustomerKeyHeader: portalKey}
+
+	status, portal := doJSONWithHeaders(t, router, http.MethodGet, "/portal/licenses", headers, nil)
+	if status != http.StatusOK || len(portal.Data.([]interface{})) != 1 {
+		t.Fatalf("portal licenses status=%d response=%#v", status, portal)
+	}
+	if status, _ := doJSONWithHeaders(t, router, http.MethodPost, "/portal/licenses/9999/unbind", headers, map[string]interface{}{"node_id": nodeID}); status != http.StatusNotFound {
+		t.Fatalf("unbind on foreign license should be not found, got %d", status)
+	}
+	status, unbind := doJSONWithHeaders(t, router, http.MethodPost, "/portal/licenses/"+uintString(licenseID)+"/unbind", headers, map[string]interface{}{"node_id": nodeID})
+	if status != http.StatusOK {
+		t.Fatalf("portal unbind status=%d response=%#v", status, unbind)
+	}
+	_, portal = doJSONWithHeaders(t, router, http.MethodGet, "/portal/licenses", headers, nil)
+	if nodes := portal.Data.([]interface{})[0].(map[string]interface{})["nodes"].([]interface{}); len(nodes) != 0 {
+		t.Fatalf("unbound node should disappear from portal: %#v", nodes)
+	}
+
+	logs := doJSON(t, router, http.MethodGet, "/audit-logs?operator=customer:"+uintString(customerID), nil)
+	if rows := logs.Data.([]interface{}); len(rows) != 1 {
+		t.Fatalf("portal unbind should be audited as the customer: %#v", rows)
+	}
+}
@@ -0,0 +1,230 @@
+package api
+
+import (
+	"nexus-core/api/dto"
+	"nexus-core/domain/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CustomerController 管理客户账号与门户访问密钥
+type CustomerController struct {
+	cs *service.CustomerService
+}
+
+func NewCustomerController() *CustomerController {
+	return &CustomerController{
+		cs: service.NewCustomerService(service.NewNodeService()),
+	}
+}
+
+func (c *CustomerController) RegisterRoutes(r *gin.Engine) {
+	customers := r.Group("/customers", RequireOperator(service.PermissionLicenseManage))
+	{
+		customers.POST("", c.CreateCustomer)
+		customers.GET("", c.ListCustomers)
+		customers.GET("/:id", c.GetCustomer)
+		customers.PATCH("/:id", c.UpdateCustomer)
+		customers.GET("/:id/licenses", c.ListCustomerLicenses)
+		customers.POST("/:id/portal-key/rotate", c.RotatePortalKey)
+		customers.POST("/:id/portal-key/revoke", c.RevokePortalKey)
+	}
+}
+
+// CreateCustomer 创建客户
+// @Summary Create customer
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param body body dto.CreateCustomerCommand true "Create Customer"
+// @Success 200 {object} service.CustomerData
+// @Failure 400 {object} api.CommonResponse
+// @Router /customers [post]
+func (c *CustomerController) CreateCustomer(ctx *gin.Context) {
+	var cmd dto.CreateCustomerCommand
+	if err := ctx.ShouldBindJSON(&cmd); err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	data, err := c.cs.CreateCustomer(ctx.Request.Context(), service.CreateCustomerCommand{
+		Name:        cmd.Name,
+		Company:     cmd.Company,
+		ContactName: cmd.ContactName,
+		Email:       cmd.Email,
+		Phone:       cmd.Phone,
+	})
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// ListCustomers 查询客户列表
+// @Summary List customers
+// @Tags customers
+// @Produce json
+// @Security ApiKeyAuth
+// @Param name query string false "Name fuzzy filter"
+// @Param email query string false "Email"
+// @Param status query int false "Status"
+// @Param page query int false "Page"
+// @Param page_size query int false "Page Size"
+// @Success 200 {object} api.CommonResponse
+// @Failure 400 {object} api.CommonResponse
+// @Router /customers [get]
+func (c *CustomerController) ListCustomers(ctx *gin.Context) {
+	page, err := PaginationQuery(ctx)
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	status, err := IntQueryPtr(ctx, "status")
+	if err != nil {
+		BadRequest(ctx, "invalid status")
+		return
+	}
+	data, err := c.cs.ListCustomers(ctx.Request.Context(), service.ListCustomersCommand{
+		Name:   StringQuery(ctx, "name"),
+		Email:  StringQuery(ctx, "email"),
+		Status: status,
+		Limit:  page.Limit,
+		Offset: page.Offset,
+	})
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// GetCustomer 查询客户详情
+// @Summary Get customer
+// @Tags customers
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path uint true "Customer ID"
+// @Success 200 {object} service.CustomerData
+// @Failure 404 {object} api.CommonResponse
+// @Router /customers/{id} [get]
+func (c *CustomerController) GetCustomer(ctx *gin.Context) {
+	id, err := UintParamOrQuery(ctx, "id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	data, err := c.cs.GetCustomer(ctx.Request.Context(), id)
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// UpdateCustomer 修改客户信息或状态
+// @Summary Update customer
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path uint true "Customer ID"
+// @Param body body dto.UpdateCustomerCommand true "Update Customer"
+// @Success 200 {object} service.CustomerData
+// @Failure 400 {object} api.CommonResponse
+// @Failure 404 {object} api.CommonResponse
+// @Router /customers/{id} [patch]
+func (c *CustomerController) UpdateCustomer(ctx *gin.Context) {
+	id, err := UintParamOrQuery(ctx, "id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	var cmd dto.UpdateCustomerCommand
+	if err := ctx.ShouldBindJSON(&cmd); err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	data, err := c.cs.UpdateCustomer(ctx.Request.Context(), service.UpdateCustomerCommand{
+		ID:          id,
+		Name:        cmd.Name,
+		Company:     cmd.Company,
+		ContactName: cmd.ContactName,
+		Email:       cmd.Email,
+		Phone:       cmd.Phone,
+		Status:      cmd.Status,
+	})
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// ListCustomerLicenses 查询客户的 License 及已绑定节点
+// @Summary List customer licenses with bound nodes
+// @Tags customers
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path uint true "Customer ID"
+// @Success 200 {object} api.CommonResponse
+// @Failure 404 {object} api.CommonResponse
+// @Router /customers/{id}/licenses [get]
+func (c *CustomerController) ListCustomerLicenses(ctx *gin.Context) {
+	id, err := UintParamOrQuery(ctx, "id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	data, err := c.cs.ListCustomerLicenses(ctx.Request.Context(), id)
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// RotatePortalKey 签发客户门户密钥，明文只返回一次
+// @Summary Rotate customer portal key
+// @Tags customers
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path uint true "Customer ID"
+// @Success 200 {object} service.CustomerPortalKeyData
+// @Failure 404 {object} api.CommonResponse
+// @Router /customers/{id}/portal-key/rotate [post]
+func (c *CustomerController) RotatePortalKey(ctx *gin.Context) {
+	id, err := UintParamOrQuery(ctx, "id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	data, err := c.cs.RotatePortalKey(ctx.Request.Context(), id)
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// RevokePortalKey 关闭客户门户访问
+// @Summary Revoke customer portal key
+// @Tags customers
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path uint true "Customer ID"
+// @Success 200 {object} api.CommonResponse
+// @Failure 404 {object} api.CommonResponse
+// @Router /customers/{id}/portal-key/revoke [post]
+func (c *CustomerController) RevokePortalKey(ctx *gin.Context) {
+	id, err := UintParamOrQuery(ctx, "id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	if err := c.cs.RevokePortalKey(ctx.Request.Context(), id); err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, "revoke success")
+}
@@ -5,15 +5,41 @@ type AccessBaseCommand struct {
 	LicenseKey  string `json:"license_key" binding:"required"`  // 许可证
 	ProductID   uint   `json:"product_id" binding:"required"`   // 产品ID
 	VersionCode string `json:"version_code" binding:"required"` // 产品版本号
+	NodeSecret  string `json:"node_secret"`                     // 注册时签发的节点密钥，也可通过 X-Node-Secret 请求头传递
 }
 
 // HeartbeatCommand 客户端心跳命令对象
 // @Description Heartbeat payload from client containing device and license info
 type HeartbeatCommand struct {
 	AccessBaseCommand
+	Usage map[string]int64 `json:"usage"` // 自上次心跳以来的功能用量增量，键为功能标识符
 }
 
 // RegisterCommand 自动绑定命令对象
 type RegisterCommand struct {
 	AccessBaseCommand
 }
+
+// SeatCheckoutCommand 浮动席位申请与续期命令对象
+type SeatCheckoutCommand struct {
+	AccessBaseCommand
+	TTLSeconds int `json:"ttl_seconds"` // 席位租约时长，为空时使用服务端默认值
+}
+
+// SeatCheckinCommand 浮动席位归还命令对象
+type SeatCheckinCommand struct {
+	DeviceCode string `json:"device_code" binding:"required"`
+	LicenseKey string `json:"license_key" binding:"required"`
+	ProductID  uint   `json:"product_id" binding:"required"`
+	NodeSecret string `json:"node_secret"`
+}
+
+// VerifyLeaseCommand 租约校验命令对象
+type VerifyLeaseCommand struct {
+	LeaseToken string `json:"lease_token" binding:"required"` // 注册或心跳返回的租约令牌
+}
+
+// OfflineActivationCommand 离线激活命令对象
+type OfflineActivationCommand struct {
+	Request string `json:"request" binding:"required"` // 节点生成的离线激活请求串
+}
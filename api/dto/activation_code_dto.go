@@ -0,0 +1,18 @@
+package dto
+
+import "time"
+
+type GenerateActivationCodesCommand struct {
+	ProductID       *uint      `json:"product_id"`       // 限定适用产品，为空表示不限制
+	ExtraHours      int        `json:"extra_hours"`      // 增加的有效时长（小时）
+	ExtraNodes      int        `json:"extra_nodes"`      // 增加的最大节点数，不限制节点数的 License 不受影响
+	ExtraConcurrent int        `json:"extra_concurrent"` // 增加的并发限制，不限制并发的 License 不受影响
+	ExpiresAt       *time.Time `json:"expires_at"`       // 兑换截止时间，为空表示不过期
+	Count           int        `json:"count" binding:"required"`
+	Remark          *string    `json:"remark"`
+}
+
+type RedeemActivationCodeCommand struct {
+	LicenseKey string `json:"license_key" binding:"required"`
+	Code       string `json:"code" binding:"required"`
+}
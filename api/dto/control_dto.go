@@ -27,6 +27,7 @@ type UpdateControlServiceStatusCommand struct {
 
 type ReportNodeCapabilityCommand struct {
 	NodeID            uint            `json:"node_id" binding:"required"`
+	NodeSecret        string          `json:"node_secret"`
 	ServiceIdentifier string          `json:"service_identifier" binding:"required"`
 	Schema            json.RawMessage `json:"schema" binding:"required" swaggertype:"object"`
 	Protocol          string          `json:"protocol" binding:"required"`
@@ -41,6 +42,7 @@ type CreateControlCommand struct {
 
 type CompleteControlCommand struct {
 	CommandID    uint            `json:"command_id"`
+	NodeSecret   string          `json:"node_secret"`
 	Status       string          `json:"status" binding:"required"`
 	Result       json.RawMessage `json:"result" swaggertype:"object"`
 	ErrorMessage *string         `json:"error_message"`
@@ -0,0 +1,22 @@
+package dto
+
+type CreateCustomerCommand struct {
+	Name        string `json:"name" binding:"required"`
+	Company     string `json:"company"`
+	ContactName string `json:"contact_name"`
+	Email       string `json:"email"`
+	Phone       string `json:"phone"`
+}
+
+type UpdateCustomerCommand struct {
+	Name        *string `json:"name"`
+	Company     *string `json:"company"`
+	ContactName *string `json:"contact_name"`
+	Email       *string `json:"email"`
+	Phone       *string `json:"phone"`
+	Status      *int    `json:"status"` // 1启用，2停用
+}
+
+type PortalUnbindCommand struct {
+	NodeID uint `json:"node_id" binding:"required"`
+}
@@ -0,0 +1,35 @@
+package dto
+
+import "time"
+
+type CreateFeatureCommand struct {
+	Identifier  string  `json:"identifier" binding:"required"` // 功能标识符，租户内唯一
+	Type        string  `json:"type"`                          // ability / command，默认 ability
+	Name        string  `json:"name" binding:"required"`
+	Description *string `json:"description"`
+	Data        *string `json:"data"` // 下发给客户端的附加数据
+}
+
+type UpdateFeatureCommand struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	Data        *string `json:"data"`
+	Status      *int    `json:"status"` // 1启用，2禁用
+}
+
+type SetProductFeatureCommand struct {
+	FeatureID uint `json:"feature_id" binding:"required"`
+	Default   bool `json:"default"` // 该产品的所有 License 默认拥有此功能
+}
+
+type GrantLicenseFeatureCommand struct {
+	FeatureID uint       `json:"feature_id" binding:"required"`
+	ExpiresAt *time.Time `json:"expires_at"` // 为空表示随 License 有效
+}
+
+type SetLicenseFeatureQuotaCommand struct {
+	FeatureID  uint   `json:"feature_id" binding:"required"`
+	Quota      int64  `json:"quota" binding:"required"` // 每个周期的可用量
+	Period     string `json:"period"`                   // day、month、total，默认 month
+	ResetUsage bool   `json:"reset_usage"`              // 清零当前周期已用量
+}
@@ -2,15 +2,49 @@ package dto
 
 import (
 	"errors"
+	"time"
 )
 
 type BatchCreateLicenseCommand struct {
-	ProductID     uint    `json:"product_id" binding:"required"`
-	ValidityHours int     `json:"validity_hours" binding:"required"`
-	MaxNodes      int     `json:"max_nodes"`
-	MaxConcurrent int     `json:"max_concurrent"`
-	Remark        *string `json:"remark"`
-	Count         int     `json:"count" binding:"required"`
+	ProductID         uint       `json:"product_id" binding:"required"`
+	CustomerID        *uint      `json:"customer_id"`
+	PlanID            *uint      `json:"plan_id"`
+	Type              int        `json:"type"`
+	TrialMaxHours     int        `json:"trial_max_hours"`
+	GracePeriodHours  *int       `json:"grace_period_hours"`
+	Perpetual         bool       `json:"perpetual"`
+	VersionCutoffAt   *time.Time `json:"version_cutoff_at"`
+	MinVersionID      *uint      `json:"min_version_id"`
+	MaxVersionID      *uint      `json:"max_version_id"`
+	NotBefore         *time.Time `json:"not_before"`
+	ScheduledRevokeAt *time.Time `json:"scheduled_revoke_at"`
+	ValidityHours     int        `json:"validity_hours"`
+	MaxNodes          int        `json:"max_nodes"`
+	MaxConcurrent     int        `json:"max_concurrent"`
+	Remark            *string    `json:"remark"`
+	Count             int        `json:"count" binding:"required"`
+}
+
+// ImportLicenseKeysCommand 导入外部生成的注册码，每个注册码创建一个 License
+type ImportLicenseKeysCommand struct {
+	ProductID         uint              `json:"product_id" binding:"required"`
+	CustomerID        *uint             `json:"customer_id"`
+	PlanID            *uint             `json:"plan_id"`
+	Type              int               `json:"type"`
+	TrialMaxHours     int               `json:"trial_max_hours"`
+	GracePeriodHours  *int              `json:"grace_period_hours"`
+	Perpetual         bool              `json:"perpetual"`
+	VersionCutoffAt   *time.Time        `json:"version_cutoff_at"`
+	MinVersionID      *uint             `json:"min_version_id"`
+	MaxVersionID      *uint             `json:"max_version_id"`
+	NotBefore         *time.Time        `json:"not_before"`
+	ScheduledRevokeAt *time.Time        `json:"scheduled_revoke_at"`
+	ValidityHours     int               `json:"validity_hours"`
+	MaxNodes          int               `json:"max_nodes"`
+	MaxConcurrent     int               `json:"max_concurrent"`
+	Remark            *string           `json:"remark"`
+	Format            *LicenseKeyFormat `json:"format"` // 注册码声明的格式，为空时使用产品的格式
+	Keys              []string          `json:"keys" binding:"required"`
 }
 
 type RestoreLicenseCommand struct {
@@ -23,11 +57,22 @@ type RestoreLicenseCommand struct {
 // @Description Command to create a license
 // @Tags License
 type CreateLicenseCommand struct {
-	ProductID     uint    `json:"product_id" binding:"required"`     // 授权范围列表
-	ValidityHours int     `json:"validity_hours" binding:"required"` // 有效时长（小时）
-	MaxNodes      int     `json:"max_nodes"`                         // 最大节点数
-	MaxConcurrent int     `json:"max_concurrent"`                    // 并发限制
-	Remark        *string `json:"remark"`                            // 备注
+	ProductID         uint       `json:"product_id" binding:"required"` // 授权范围列表
+	CustomerID        *uint      `json:"customer_id"`                   // 所属客户
+	PlanID            *uint      `json:"plan_id"`                       // 套餐，未填写的限制取套餐默认值
+	Type              int        `json:"type"`                          // 0正式，1试用
+	TrialMaxHours     int        `json:"trial_max_hours"`               // 试用许可证续期后的最长有效时长（小时），默认 720
+	GracePeriodHours  *int       `json:"grace_period_hours"`            // 过期后的宽限期（小时），为空时使用产品设置
+	Perpetual         bool       `json:"perpetual"`                     // 永久授权，激活后不过期
+	VersionCutoffAt   *time.Time `json:"version_cutoff_at"`             // 只授权在此时间及之前发布的版本
+	MinVersionID      *uint      `json:"min_version_id"`                // 授权的最低版本
+	MaxVersionID      *uint      `json:"max_version_id"`                // 授权的最高版本
+	NotBefore         *time.Time `json:"not_before"`                    // 合同开始时间，之前不能注册和心跳
+	ScheduledRevokeAt *time.Time `json:"scheduled_revoke_at"`           // 合同结束时间，到期自动吊销
+	ValidityHours     int        `json:"validity_hours"`                // 有效时长（小时），未指定套餐且非永久授权时必填
+	MaxNodes          int        `json:"max_nodes"`                     // 最大节点数
+	MaxConcurrent     int        `json:"max_concurrent"`                // 并发限制
+	Remark            *string    `json:"remark"`                        // 备注
 }
 
 // Validate 对 CreateLicenseCommand 做轻量校验，供 controller / service 使用
@@ -35,7 +80,7 @@ func (c *CreateLicenseCommand) Validate() error {
 	if c == nil {
 		return errors.New("command is nil")
 	}
-	if c.ValidityHours <= 0 {
+	if c.PlanID == nil && !c.Perpetual && c.ValidityHours <= 0 {
 		return errors.New("validity_hours must be > 0")
 	}
 	if c.MaxNodes < 0 {
@@ -75,6 +120,72 @@ type UpdateLicenseStatusCommand struct {
 	ID uint `json:"id" binding:"required"` // 许可证ID
 }
 
+type AssignLicenseCustomerCommand struct {
+	CustomerID *uint `json:"customer_id"` // 为空表示解除归属
+}
+
+type SetLicenseProductScopeCommand struct {
+	ProductID     uint `json:"product_id" binding:"required"`
+	MaxNodes      int  `json:"max_nodes"`      // 该产品的最大节点数，0 表示只受 License 总数限制
+	MaxConcurrent int  `json:"max_concurrent"` // 该产品的并发限制，0 表示只受 License 总数限制
+	Status        int  `json:"status"`         // 1启用，2禁用，默认启用
+}
+
+type SetLicenseVersionEntitlementCommand struct {
+	VersionCutoffAt *time.Time `json:"version_cutoff_at"`
+	MinVersionID    *uint      `json:"min_version_id"`
+	MaxVersionID    *uint      `json:"max_version_id"`
+}
+
+// SetLicenseScheduleCommand 设置合同起止时间，字段为空表示取消
+type SetLicenseScheduleCommand struct {
+	NotBefore         *time.Time `json:"not_before"`
+	ScheduledRevokeAt *time.Time `json:"scheduled_revoke_at"`
+}
+
+type SetLicenseGracePeriodCommand struct {
+	GracePeriodHours *int `json:"grace_period_hours"` // 为空表示使用产品设置
+}
+
+type SetLicenseInactiveUnbindCommand struct {
+	InactiveUnbindDays *int `json:"inactive_unbind_days"` // 为空表示使用产品设置，0 表示不自动解绑
+}
+
+type SetLicenseTransferPolicyCommand struct {
+	TransferLimit         int `json:"transfer_limit"`          // 统计窗口内最多绑定的不同设备数，0 表示不限制
+	TransferWindowDays    int `json:"transfer_window_days"`    // 统计窗口（天）
+	RebindCooldownMinutes int `json:"rebind_cooldown_minutes"` // 解绑释放的节点名额重新可用前的冷却时长（分钟）
+}
+
+type SetLicenseApprovalCommand struct {
+	ApprovalRequired bool `json:"approval_required"` // 新设备绑定需要审批
+}
+
+type AllowedDeviceItem struct {
+	DeviceCode string  `json:"device_code" binding:"required"`
+	Remark     *string `json:"remark"`
+}
+
+type AddAllowedDevicesCommand struct {
+	Devices []AllowedDeviceItem `json:"devices" binding:"required"`
+	Reserve bool                `json:"reserve"` // 同时预先创建节点并预留给该 License
+}
+
+type RejectBindingCommand struct {
+	Reason *string `json:"reason"` // 拒绝原因，记录到审计日志
+}
+
+type ConvertTrialLicenseCommand struct {
+	PlanID        *uint `json:"plan_id"`
+	ValidityHours int   `json:"validity_hours"` // 正式有效时长（小时），从转换时刻开始计算
+	MaxNodes      *int  `json:"max_nodes"`
+	MaxConcurrent *int  `json:"max_concurrent"`
+}
+
+type ChangeLicensePlanCommand struct {
+	PlanID uint `json:"plan_id" binding:"required"`
+}
+
 type RenewLicenseCommand struct {
 	ID         uint `json:"id"`
 	ExtraHours int  `json:"extra_hours" binding:"required"`
@@ -30,6 +30,7 @@ type GetNodeByDeviceCodeQuery struct {
 type UnbindCommand struct {
 	NodeID    uint `json:"node_id" binding:"required"`
 	LicenseID uint `json:"license_id" binding:"required"`
+	ProductID uint `json:"product_id"` // 为空时解除该 License 在节点上所有产品的绑定
 }
 
 type UpdateNodeStatusCommand struct {
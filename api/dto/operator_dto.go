@@ -0,0 +1,26 @@
+package dto
+
+import "time"
+
+type CreateOperatorCommand struct {
+	TenantID    uint   `json:"tenant_id"` // 为空时创建在当前租户
+	Username    string `json:"username" binding:"required"`
+	DisplayName string `json:"display_name"`
+	Role        string `json:"role" binding:"required"` // viewer、license-admin、node-operator、super-admin
+}
+
+type UpdateOperatorCommand struct {
+	DisplayName *string `json:"display_name"`
+	Role        *string `json:"role"`
+	Status      *int    `json:"status"` // 1启用，2停用
+}
+
+type CreateOperatorAPIKeyCommand struct {
+	Name      string     `json:"name"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type CreateTenantCommand struct {
+	Code string `json:"code" binding:"required"` // 租户编码，请求头 X-Tenant 使用
+	Name string `json:"name"`
+}
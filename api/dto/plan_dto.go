@@ -0,0 +1,21 @@
+package dto
+
+type CreatePlanCommand struct {
+	ProductID     uint     `json:"product_id" binding:"required"`
+	Name          string   `json:"name" binding:"required"`
+	Description   *string  `json:"description"`
+	ValidityHours int      `json:"validity_hours" binding:"required"` // 默认有效时长（小时）
+	MaxNodes      int      `json:"max_nodes"`                         // 默认最大节点数，0 表示不限制
+	MaxConcurrent int      `json:"max_concurrent"`                    // 默认并发限制，0 表示不限制
+	Services      []string `json:"services"`                          // 允许的控制服务标识，为空表示不限制
+}
+
+type UpdatePlanCommand struct {
+	Name          *string   `json:"name"`
+	Description   *string   `json:"description"`
+	ValidityHours *int      `json:"validity_hours"`
+	MaxNodes      *int      `json:"max_nodes"`
+	MaxConcurrent *int      `json:"max_concurrent"`
+	Services      *[]string `json:"services"` // 只影响之后创建或切换到该套餐的 License
+	Status        *int      `json:"status"`   // 1启用，2停用
+}
@@ -8,8 +8,20 @@ import (
 // @Description Command to create a product
 // @Tags Product
 type CreateProductCommand struct {
-	Name        string  `json:"name" binding:"required"` // 产品名称
-	Description *string `json:"description"`             // 产品描述
+	Name               string            `json:"name" binding:"required"` // 产品名称
+	Description        *string           `json:"description"`             // 产品描述
+	GracePeriodHours   int               `json:"grace_period_hours"`      // License 过期后的默认宽限期（小时）
+	GraceAllowControl  bool              `json:"grace_allow_control"`     // 宽限期内是否允许下发控制命令
+	InactiveUnbindDays int               `json:"inactive_unbind_days"`    // 节点连续多少天无心跳后自动解绑，0 表示不自动解绑
+	KeyFormat          *LicenseKeyFormat `json:"key_format"`              // 注册码格式，为空时使用去掉横线的 UUID
+}
+
+// LicenseKeyFormat 注册码格式：前缀 + 分组的 Base32 字符，可选末位校验位
+type LicenseKeyFormat struct {
+	Prefix     string `json:"prefix"`      // 前缀，仅限大写字母和数字
+	Groups     int    `json:"groups"`      // 分组数，0 表示 UUID 格式
+	GroupSize  int    `json:"group_size"`  // 每组字符数
+	CheckDigit bool   `json:"check_digit"` // 最后一位是否为校验位
 }
 
 type ProductData struct {
@@ -19,9 +31,13 @@ type ProductData struct {
 }
 
 type UpdateProductCommand struct {
-	ID          uint    `json:"id"`
-	Name        *string `json:"name"`
-	Description *string `json:"description"`
+	ID                 uint              `json:"id"`
+	Name               *string           `json:"name"`
+	Description        *string           `json:"description"`
+	GracePeriodHours   *int              `json:"grace_period_hours"`
+	GraceAllowControl  *bool             `json:"grace_allow_control"`
+	InactiveUnbindDays *int              `json:"inactive_unbind_days"`
+	KeyFormat          *LicenseKeyFormat `json:"key_format"` // 只影响之后创建的 License
 }
 
 // ReleaseMethod 表示版本发布方式
@@ -21,10 +21,16 @@ import (
 var WebEngine *gin.Engine
 
 // NewServer 创建并配置Gin服务器引擎
-// 包括跨域配置、请求ID中间件和简单日志中间件
+// 包括可信代理、跨域配置、操作人请求头、租户中间件和简单日志中间件
 func NewServer() *gin.Engine {
 	r := gin.Default()
+	// 客户端 IP 用于兑换失败限流与审计，只采信配置的代理转发的请求头
+	if err := r.SetTrustedProxies(global.GetConfig().TrustedProxies); err != nil {
+		panic(fmt.Sprintf("invalid trusted_proxies: %v", err))
+	}
 	r.Use(CorsMiddleware())
+	r.Use(OperatorHeaderMiddleware())
+	r.Use(TenantMiddleware())
 	// simple logger
 	r.Use(func(c *gin.Context) {
 		start := time.Now()
@@ -51,6 +57,14 @@ func RegisterDefaultRoutes() {
 	NewAccessController().RegisterRoutes(WebEngine)
 	NewControlController().RegisterRoutes(WebEngine)
 	NewMonitorController().RegisterRoutes(WebEngine)
+	NewSigningController().RegisterRoutes(WebEngine)
+	NewOperatorController().RegisterRoutes(WebEngine)
+	NewTenantController().RegisterRoutes(WebEngine)
+	NewCustomerController().RegisterRoutes(WebEngine)
+	NewPortalController().RegisterRoutes(WebEngine)
+	NewPlanController().RegisterRoutes(WebEngine)
+	NewFeatureController().RegisterRoutes(WebEngine)
+	NewActivationCodeController().RegisterRoutes(WebEngine)
 
 	// serve swagger UI under /swagger when enabled in config
 	cfg := global.GetConfig()
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestFeatureAPI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := setupControlAPITest(t)
+	_, productID, _ := seedControlAPITarget(t, ctx)
+
+	router := NewServer()
+	NewProductController().RegisterRoutes(router)
+	NewLicenseController().RegisterRoutes(router)
+	NewFeatureController().RegisterRoutes(router)
+
+	created := doJSON(t, router, http.MethodPost, "/features", map[string]interface{}{
+		"identifier": "export",
+		"name":       "Export",
+	})
+	feature := created.Data.(map[string]interface{})
+	if feature["type"] != "ability" {
+		t.Fatalf("feature type should default to ability: %#v", feature)
+	}
+	featureID := uint(feature["id"].(float64))
+
+	doJSON(t, router, http.MethodPost, "/products/"+uintString(productID)+"/features", map[string]interface{}{
+		"feature_id": featureID,
+	})
+	list := doJSON(t, router, http.MethodGet, "/products/"+uintString(productID)+"/features", nil)
+	if items := list.Data.([]interface{}); len(items) != 1 || items[0].(map[string]interface{})["default"].(bool) {
+		t.Fatalf("list product features: %#v", list)
+	}
+
+	license := doJSON(t, router, http.MethodPost, "/licenses", map[string]interface{}{
+		"product_id":     productID,
+		"validity_hours": 24,
+	})
+	licenseID := uint(license.Data.(map[string]interface{})["id"].(float64))
+	grantsPath := "/licenses/" + uintString(licenseID) + "/features"
+	doJSON(t, router, http.MethodPost, grantsPath, map[string]interface{}{"feature_id": featureID})
+	grants := doJSON(t, router, http.MethodGet, grantsPath, nil)
+	if len(grants.Data.([]interface{})) != 1 {
+		t.Fatalf("list license features: %#v", grants)
+	}
+
+	if status, _ := doJSONWithHeaders(t, router, http.MethodDelete, "/features/"+uintString(featureID), nil, nil); status != http.StatusConflict {
+		t.Fatalf("deleting a feature in use should conflict, got %d", status)
+	}
+	doJSON(t, router, http.MethodDelete, grantsPath+"/"+uintString(featureID), nil)
+	doJSON(t, router, http.MethodDelete, "/products/"+uintString(productID)+"/features/"+uintString(featureID), nil)
+	doJSON(t, router, http.MethodDelete, "/features/"+uintString(featureID), nil)
+}
+
+func TestLicenseFeatureQuotaAPI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := setupControlAPITest(t)
+	_, productID, _ := seedControlAPITarget(t, ctx)
+
+	router := NewServer()
+	NewLicenseController().RegisterRoutes(router)
+	NewFeatureController().RegisterRoutes(router)
+
+	created := doJSON(t, router, http.MethodPost, "/features", map[string]interface{}{
+		"identifier": "api-calls",
+		"name":       "API Calls",
+	})
+	featureID := uint(created.Data.(map[string]interface{})["id"].(float64))
+	doJSON(t, router, http.MethodPost, "/products/"+uintString(productID)+"/features", map[string]interface{}{
+		"feature_id": featureID,
+		"default":    true,
+	})
+	license := doJSON(t, router, http.MethodPost, "/licenses", map[string]interface{}{
+		"product_id":     productID,
+		"validity_hours": 24,
+	})
+	quotasPath := "/licenses/" + uintString(uint(license.Data.(map[string]interface{})["id"].(float64))) + "/quotas"
+
+	set := doJSON(t, router, http.MethodPost, quotasPath, map[string]interface{}{
+		"feature_id": featureID,
+		"quota":      1000,
+		"period":     "day",
+	})
+	if quota := set.Data.(map[string]interface{}); quota["remaining"].(float64) != 1000 || quota["resets_at"] == nil {
+		t.Fatalf("set quota: %#v", set)
+	}
+	if status, _ := doJSONWithHeaders(t, router, http.MethodPost, quotasPath, nil, map[string]interface{}{
+		"feature_id": featureID,
+		"quota":      10,
+		"period":     "hourly",
+	}); status != http.StatusBadRequest {
+		t.Fatalf("invalid period should be rejected, got %d", status)
+	}
+	list := doJSON(t, router, http.MethodGet, quotasPath, nil)
+	if items := list.Data.([]interface{}); len(items) != 1 || items[0].(map[string]interface{})["identifier"] != "api-calls" {
+		t.Fatalf("list quotas: %#v", list)
+	}
+	doJSON(t, router, http.MethodDelete, quotasPath+"/"+uintString(featureID), nil)
+	if status, _ := doJSONWithHeaders(t, router, http.MethodDelete, quotasPath+"/"+uintString(featureID), nil, nil); status != http.StatusNotFound {
+		t.Fatalf("removing missing quota should return 404, got %d", status)
+	}
+}
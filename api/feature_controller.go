@@ -0,0 +1,459 @@
+package api
+
+import (
+	"nexus-core/api/dto"
+	"nexus-core/domain/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FeatureController 管理功能目录、产品功能与 License 功能授予
+type FeatureController struct {
+	fs *service.FeatureService
+}
+
+func NewFeatureController() *FeatureController {
+	return &FeatureController{
+		fs: service.NewFeatureService(),
+	}
+}
+
+func (c *FeatureController) RegisterRoutes(r *gin.Engine) {
+	admin := r.Group("", RequireOperator(service.PermissionLicenseManage))
+	features := admin.Group("/features")
+	{
+		features.POST("", c.CreateFeature)
+		features.GET("", c.ListFeatures)
+		features.GET("/:id", c.GetFeature)
+		features.PATCH("/:id", c.UpdateFeature)
+		features.DELETE("/:id", c.DeleteFeature)
+	}
+	admin.GET("/products/:id/features", c.ListProductFeatures)
+	admin.POST("/products/:id/features", c.SetProductFeature)
+	admin.DELETE("/products/:id/features/:feature_id", c.RemoveProductFeature)
+	admin.GET("/licenses/:id/features", c.ListLicenseFeatures)
+	admin.POST("/licenses/:id/features", c.GrantLicenseFeature)
+	admin.DELETE("/licenses/:id/features/:feature_id", c.RevokeLicenseFeature)
+	admin.GET("/licenses/:id/quotas", c.ListLicenseFeatureQuotas)
+	admin.POST("/licenses/:id/quotas", c.SetLicenseFeatureQuota)
+	admin.DELETE("/licenses/:id/quotas/:feature_id", c.RemoveLicenseFeatureQuota)
+}
+
+// CreateFeature 创建功能
+// @Summary Create feature
+// @Tags features
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param body body dto.CreateFeatureCommand true "Create Feature"
+// @Success 200 {object} service.FeatureData
+// @Failure 400 {object} api.CommonResponse
+// @Failure 409 {object} api.CommonResponse
+// @Router /features [post]
+func (c *FeatureController) CreateFeature(ctx *gin.Context) {
+	var cmd dto.CreateFeatureCommand
+	if err := ctx.ShouldBindJSON(&cmd); err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	data, err := c.fs.CreateFeature(ctx.Request.Context(), service.CreateFeatureCommand{
+		Identifier:  cmd.Identifier,
+		Type:        cmd.Type,
+		Name:        cmd.Name,
+		Description: cmd.Description,
+		Data:        cmd.Data,
+	})
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// ListFeatures 查询功能列表
+// @Summary List features
+// @Tags features
+// @Produce json
+// @Security ApiKeyAuth
+// @Param type query string false "Feature Type"
+// @Param status query int false "Status"
+// @Param page query int false "Page"
+// @Param page_size query int false "Page Size"
+// @Success 200 {array} service.FeatureData
+// @Failure 400 {object} api.CommonResponse
+// @Router /features [get]
+func (c *FeatureController) ListFeatures(ctx *gin.Context) {
+	page, err := PaginationQuery(ctx)
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	status, err := IntQueryPtr(ctx, "status")
+	if err != nil {
+		BadRequest(ctx, "invalid status")
+		return
+	}
+	data, err := c.fs.ListFeatures(ctx.Request.Context(), service.ListFeaturesCommand{
+		Type:   StringQuery(ctx, "type"),
+		Status: status,
+		Limit:  page.Limit,
+		Offset: page.Offset,
+	})
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// GetFeature 查询功能详情
+// @Summary Get feature
+// @Tags features
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path uint true "Feature ID"
+// @Success 200 {object} service.FeatureData
+// @Failure 404 {object} api.CommonResponse
+// @Router /features/{id} [get]
+func (c *FeatureController) GetFeature(ctx *gin.Context) {
+	id, err := UintParamOrQuery(ctx, "id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	data, err := c.fs.GetFeature(ctx.Request.Context(), id)
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// UpdateFeature 修改功能，标识符与类型不可修改
+// @Summary Update feature
+// @Tags features
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path uint true "Feature ID"
+// @Param body body dto.UpdateFeatureCommand true "Update Feature"
+// @Success 200 {object} service.FeatureData
+// @Failure 400 {object} api.CommonResponse
+// @Failure 404 {object} api.CommonResponse
+// @Router /features/{id} [patch]
+func (c *FeatureController) UpdateFeature(ctx *gin.Context) {
+	id, err := UintParamOrQuery(ctx, "id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	var cmd dto.UpdateFeatureCommand
+	if err := ctx.ShouldBindJSON(&cmd); err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	data, err := c.fs.UpdateFeature(ctx.Request.Context(), service.UpdateFeatureCommand{
+		ID:          id,
+		Name:        cmd.Name,
+		Description: cmd.Description,
+		Data:        cmd.Data,
+		Status:      cmd.Status,
+	})
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// DeleteFeature 删除未被引用的功能
+// @Summary Delete feature
+// @Tags features
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path uint true "Feature ID"
+// @Success 200 {object} api.CommonResponse
+// @Failure 404 {object} api.CommonResponse
+// @Failure 409 {object} api.CommonResponse
+// @Router /features/{id} [delete]
+func (c *FeatureController) DeleteFeature(ctx *gin.Context) {
+	id, err := UintParamOrQuery(ctx, "id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	if err := c.fs.DeleteFeature(ctx.Request.Context(), id); err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	SuccessMsg(ctx, "feature deleted")
+}
+
+// ListProductFeatures 查询产品提供的功能
+// @Summary List product features
+// @Tags features
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path uint true "Product ID"
+// @Success 200 {array} service.ProductFeatureData
+// @Failure 404 {object} api.CommonResponse
+// @Router /products/{id}/features [get]
+func (c *FeatureController) ListProductFeatures(ctx *gin.Context) {
+	id, err := UintParamOrQuery(ctx, "id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	data, err := c.fs.ListProductFeatures(ctx.Request.Context(), id)
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// SetProductFeature 将功能加入产品
+// @Summary Set product feature
+// @Tags features
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path uint true "Product ID"
+// @Param body body dto.SetProductFeatureCommand true "Product Feature"
+// @Success 200 {object} service.ProductFeatureData
+// @Failure 400 {object} api.CommonResponse
+// @Failure 404 {object} api.CommonResponse
+// @Router /products/{id}/features [post]
+func (c *FeatureController) SetProductFeature(ctx *gin.Context) {
+	id, err := UintParamOrQuery(ctx, "id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	var cmd dto.SetProductFeatureCommand
+	if err := ctx.ShouldBindJSON(&cmd); err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	data, err := c.fs.SetProductFeature(ctx.Request.Context(), service.SetProductFeatureCommand{
+		ProductID: id,
+		FeatureID: cmd.FeatureID,
+		Default:   cmd.Default,
+	})
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// RemoveProductFeature 将功能移出产品
+// @Summary Remove product feature
+// @Tags features
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path uint true "Product ID"
+// @Param feature_id path uint true "Feature ID"
+// @Success 200 {object} api.CommonResponse
+// @Failure 404 {object} api.CommonResponse
+// @Router /products/{id}/features/{feature_id} [delete]
+func (c *FeatureController) RemoveProductFeature(ctx *gin.Context) {
+	id, err := UintParamOrQuery(ctx, "id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	featureID, err := UintParamOrQuery(ctx, "feature_id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	if err := c.fs.RemoveProductFeature(ctx.Request.Context(), service.RemoveProductFeatureCommand{
+		ProductID: id,
+		FeatureID: featureID,
+	}); err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	SuccessMsg(ctx, "product feature removed")
+}
+
+// ListLicenseFeatures 查询 License 单独授予的功能
+// @Summary List license feature grants
+// @Tags features
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path uint true "License ID"
+// @Success 200 {array} service.LicenseFeatureData
+// @Failure 404 {object} api.CommonResponse
+// @Router /licenses/{id}/features [get]
+func (c *FeatureController) ListLicenseFeatures(ctx *gin.Context) {
+	id, err := UintParamOrQuery(ctx, "id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	data, err := c.fs.ListLicenseFeatures(ctx.Request.Context(), id)
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// GrantLicenseFeature 为 License 授予功能
+// @Summary Grant license feature
+// @Tags features
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path uint true "License ID"
+// @Param body body dto.GrantLicenseFeatureCommand true "Feature Grant"
+// @Success 200 {object} service.LicenseFeatureData
+// @Failure 400 {object} api.CommonResponse
+// @Failure 404 {object} api.CommonResponse
+// @Router /licenses/{id}/features [post]
+func (c *FeatureController) GrantLicenseFeature(ctx *gin.Context) {
+	id, err := UintParamOrQuery(ctx, "id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	var cmd dto.GrantLicenseFeatureCommand
+	if err := ctx.ShouldBindJSON(&cmd); err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	data, err := c.fs.GrantLicenseFeature(ctx.Request.Context(), service.GrantLicenseFeatureCommand{
+		LicenseID: id,
+		FeatureID: cmd.FeatureID,
+		ExpiresAt: cmd.ExpiresAt,
+	})
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// RevokeLicenseFeature 收回 License 单独授予的功能
+// @Summary Revoke license feature
+// @Tags features
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path uint true "License ID"
+// @Param feature_id path uint true "Feature ID"
+// @Success 200 {object} api.CommonResponse
+// @Failure 404 {object} api.CommonResponse
+// @Router /licenses/{id}/features/{feature_id} [delete]
+func (c *FeatureController) RevokeLicenseFeature(ctx *gin.Context) {
+	id, err := UintParamOrQuery(ctx, "id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	featureID, err := UintParamOrQuery(ctx, "feature_id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	if err := c.fs.RevokeLicenseFeature(ctx.Request.Context(), service.RevokeLicenseFeatureCommand{
+		LicenseID: id,
+		FeatureID: featureID,
+	}); err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	SuccessMsg(ctx, "license feature revoked")
+}
+
+// ListLicenseFeatureQuotas 查询 License 的功能用量配额
+// @Summary List license feature quotas
+// @Tags features
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path uint true "License ID"
+// @Success 200 {array} service.FeatureQuotaData
+// @Failure 404 {object} api.CommonResponse
+// @Router /licenses/{id}/quotas [get]
+func (c *FeatureController) ListLicenseFeatureQuotas(ctx *gin.Context) {
+	id, err := UintParamOrQuery(ctx, "id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	data, err := c.fs.ListLicenseFeatureQuotas(ctx.Request.Context(), id)
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// SetLicenseFeatureQuota 设置 License 的功能用量配额
+// @Summary Set license feature quota
+// @Tags features
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path uint true "License ID"
+// @Param body body dto.SetLicenseFeatureQuotaCommand true "Feature Quota"
+// @Success 200 {object} service.FeatureQuotaData
+// @Failure 400 {object} api.CommonResponse
+// @Failure 404 {object} api.CommonResponse
+// @Router /licenses/{id}/quotas [post]
+func (c *FeatureController) SetLicenseFeatureQuota(ctx *gin.Context) {
+	id, err := UintParamOrQuery(ctx, "id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	var cmd dto.SetLicenseFeatureQuotaCommand
+	if err := ctx.ShouldBindJSON(&cmd); err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	data, err := c.fs.SetLicenseFeatureQuota(ctx.Request.Context(), service.SetLicenseFeatureQuotaCommand{
+		LicenseID:  id,
+		FeatureID:  cmd.FeatureID,
+		Quota:      cmd.Quota,
+		Period:     cmd.Period,
+		ResetUsage: cmd.ResetUsage,
+	})
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// RemoveLicenseFeatureQuota 删除 License 的功能用量配额
+// @Summary Remove license feature quota
+// @Tags features
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path uint true "License ID"
+// @Param feature_id path uint true "Feature ID"
+// @Success 200 {object} api.CommonResponse
+// @Failure 404 {object} api.CommonResponse
+// @Router /licenses/{id}/quotas/{feature_id} [delete]
+func (c *FeatureController) RemoveLicenseFeatureQuota(ctx *gin.Context) {
+	id, err := UintParamOrQuery(ctx, "id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	featureID, err := UintParamOrQuery(ctx, "feature_id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	if err := c.fs.RemoveLicenseFeatureQuota(ctx.Request.Context(), service.RemoveLicenseFeatureQuotaCommand{
+		LicenseID: id,
+		FeatureID: featureID,
+	}); err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	SuccessMsg(ctx, "license feature quota removed")
+}
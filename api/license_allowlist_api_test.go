@@ -0,0 +1,83 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLicenseAllowlistAPI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := setupControlAPITest(t)
+	_, productID, _ := seedControlAPITarget(t, ctx)
+
+	router := NewServer()
+	NewLicenseController().RegisterRoutes(router)
+	NewAccessController().RegisterRoutes(router)
+
+	license := doJSON(t, router, http.MethodPost, "/licenses", map[string]interface{}{
+		"product_id":     productID,
+		"validity_hours": 24,
+	})
+	licenseData := license.Data.(map[string]interface{})
+	allowlistPath := "/licenses/" + uintString(uint(licenseData["id"].(float64))) + "/allowed-devices"
+
+	upload := func(request *http.Request) map[string]interface{} {
+		t.Helper()
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		var response CommonResponse
+		if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil || recorder.Code != http.StatusOK {
+			t.Fatalf("import status=%d body=%s err=%v", recorder.Code, recorder.Body.String(), err)
+		}
+		return response.Data.(map[string]interface{})
+	}
+
+	raw := httptest.NewRequest(http.MethodPost, allowlistPath+"/import", strings.NewReader("device_code\nallow-api-a\n"))
+	raw.Header.Set("Content-Type", "text/csv")
+	if data := upload(raw); data["added"].(float64) != 1 {
+		t.Fatalf("raw csv import: %#v", data)
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", "devices.csv")
+	if err != nil {
+		t.Fatalf("create form file: %v", err)
+	}
+	_, _ = part.Write([]byte("allow-api-a,updated\nallow-api-b,spare\n"))
+	_ = writer.Close()
+	multipartRequest := httptest.NewRequest(http.MethodPost, allowlistPath+"/import?reserve=true", &body)
+	multipartRequest.Header.Set("Content-Type", writer.FormDataContentType())
+	if data := upload(multipartRequest); data["added"].(float64) != 1 || data["updated"].(float64) != 1 || data["reserved"].(float64) != 2 {
+		t.Fatalf("multipart csv import: %#v", data)
+	}
+
+	list := doJSON(t, router, http.MethodGet, allowlistPath, nil)
+	if items := list.Data.([]interface{}); len(items) != 2 || !items[1].(map[string]interface{})["reserved"].(bool) {
+		t.Fatalf("list allowed devices: %#v", list)
+	}
+
+	registerBody := func(device string) map[string]interface{} {
+		return map[string]interface{}{
+			"device_code":  device,
+			"license_key":  licenseData["license_key"],
+			"product_id":   productID,
+			"version_code": "1.0.0",
+		}
+	}
+	doJSON(t, router, http.MethodPost, "/access/register", registerBody("allow-api-b"))
+	if status, _ := doJSONWithHeaders(t, router, http.MethodPost, "/access/register", nil, registerBody("allow-api-c")); status != http.StatusForbidden {
+		t.Fatalf("device outside the allowlist should be rejected, got %d", status)
+	}
+	doJSON(t, router, http.MethodDelete, allowlistPath+"/allow-api-a", nil)
+	if status, _ := doJSONWithHeaders(t, router, http.MethodDelete, allowlistPath+"/allow-api-a", nil, nil); status != http.StatusNotFound {
+		t.Fatalf("removing a missing device should return 404, got %d", status)
+	}
+}
@@ -1,6 +1,9 @@
 package api
 
 import (
+	"io"
+	"strings"
+
 	"nexus-core/api/dto"
 	"nexus-core/domain/service"
 
@@ -25,10 +28,12 @@ func NewLicenseController() *LicenseController {
 // RegisterRoutes 注册许可证相关的路由
 // 包括创建、查询、更新和删除等操作的路由
 func (c *LicenseController) RegisterRoutes(r *gin.Engine) {
-	licenses := r.Group("/licenses")
+	admin := r.Group("", RequireOperator(service.PermissionLicenseManage))
+	licenses := admin.Group("/licenses")
 	{
 		licenses.POST("", c.CreateLicense)
 		licenses.POST("/batch", c.BatchCreateLicenses)
+		licenses.POST("/import", c.ImportLicenseKeys)
 		licenses.GET("", c.ListLicenses)
 		licenses.GET("/:id", c.GetByID)
 		licenses.PATCH("/:id", c.UpdateLicense)
@@ -36,12 +41,38 @@ func (c *LicenseController) RegisterRoutes(r *gin.Engine) {
 		licenses.POST("/:id/revoke", c.RevokeLicense)
 		licenses.POST("/:id/restore", c.RestoreLicense)
 		licenses.POST("/:id/renew", c.RenewLicense)
+		licenses.POST("/:id/customer", c.AssignCustomer)
+		licenses.POST("/:id/plan", c.ChangePlan)
+		licenses.POST("/:id/convert", c.ConvertTrial)
+		licenses.POST("/:id/schedule", c.SetSchedule)
+		licenses.POST("/:id/grace-period", c.SetGracePeriod)
+		licenses.POST("/:id/inactive-unbind", c.SetInactiveUnbind)
+		licenses.GET("/:id/allowed-devices", c.ListAllowedDevices)
+		licenses.POST("/:id/allowed-devices", c.AddAllowedDevices)
+		licenses.POST("/:id/allowed-devices/import", c.ImportAllowedDevices)
+		licenses.DELETE("/:id/allowed-devices/:device_code", c.RemoveAllowedDevice)
+		licenses.POST("/:id/approval", c.SetApprovalRequired)
+		licenses.GET("/:id/binding-requests", c.ListBindingRequests)
+		licenses.POST("/:id/binding-requests/:binding_id/approve", c.ApproveBinding)
+		licenses.POST("/:id/binding-requests/:binding_id/reject", c.RejectBinding)
+		licenses.GET("/:id/transfer-policy", c.GetTransferPolicy)
+		licenses.POST("/:id/transfer-policy", c.SetTransferPolicy)
+		licenses.POST("/:id/transfer-policy/reset", c.ResetTransfer)
+		licenses.POST("/:id/version-entitlement", c.SetVersionEntitlement)
+		licenses.GET("/:id/products", c.ListProductScopes)
+		licenses.POST("/:id/products", c.SetProductScope)
+		licenses.DELETE("/:id/products/:product_id", c.RemoveProductScope)
+		licenses.GET("/:id/history", c.ListHistory)
+		licenses.GET("/:id/status-at", c.GetStatusAt)
+		licenses.GET("/:id/seats", c.ListSeats)
+		licenses.DELETE("/:id/seats/:seat_id", c.ReleaseSeat)
+		licenses.GET("/:id/file", RequirePermission(service.PermissionLicenseManage), c.ExportLicenseFile) // 导出会激活 License
 		licenses.DELETE("/:id/bindings", c.CleanLicenseBindings)
 	}
-	r.GET("/license-keys/:key", c.GetByKey)
-	r.DELETE("/license-cleanups/invalid", c.CleanInvalidLicense)
+	admin.GET("/license-keys/:key", c.GetByKey)
+	admin.DELETE("/license-cleanups/invalid", c.CleanInvalidLicense)
 
-	licenseGroup := r.Group("/license")
+	licenseGroup := admin.Group("/license")
 	{
 		licenseGroup.POST("/createLicense", c.CreateLicense)               // 创建单个许可证
 		licenseGroup.GET("/getByID", c.GetByID)                            // 根据ID查询许可证
@@ -61,8 +92,10 @@ func (c *LicenseController) RegisterRoutes(r *gin.Engine) {
 // @Accept json
 // @Produce json
 // @Param product_id query uint false "Product ID"
+// @Param customer_id query uint false "Customer ID"
+// @Param type query int false "License type"
 // @Param status query int false "License status"
-// @Param license_key query string false "License key fuzzy filter"
+// @Param license_key query string false "Full license key or display prefix filter"
 // @Param page query int false "Page"
 // @Param page_size query int false "Page Size"
 // @Param limit query int false "Limit"
@@ -81,6 +114,16 @@ func (c *LicenseController) ListLicenses(ctx *gin.Context) {
 		BadRequest(ctx, "invalid product_id")
 		return
 	}
+	customerID, err := UintQuery(ctx, "customer_id")
+	if err != nil {
+		BadRequest(ctx, "invalid customer_id")
+		return
+	}
+	licenseType, err := IntQueryPtr(ctx, "type")
+	if err != nil {
+		BadRequest(ctx, "invalid type")
+		return
+	}
 	status, err := IntQueryPtr(ctx, "status")
 	if err != nil {
 		BadRequest(ctx, "invalid status")
@@ -88,6 +131,8 @@ func (c *LicenseController) ListLicenses(ctx *gin.Context) {
 	}
 	data, err := c.ls.ListLicenses(ctx.Request.Context(), service.ListLicensesCommand{
 		ProductID:  productID,
+		CustomerID: customerID,
+		Type:       licenseType,
 		Status:     status,
 		LicenseKey: StringQuery(ctx, "license_key"),
 		Limit:      page.Limit,
@@ -118,11 +163,22 @@ func (c *LicenseController) CreateLicense(ctx *gin.Context) {
 		return
 	}
 	license, err := c.ls.CreateLicense(ctx.Request.Context(), service.CreateLicenseCommand{
-		ProductID:     cmd.ProductID,
-		ValidityHours: cmd.ValidityHours,
-		MaxNodes:      cmd.MaxNodes,
-		MaxConcurrent: cmd.MaxConcurrent,
-		Remark:        cmd.Remark,
+		ProductID:         cmd.ProductID,
+		CustomerID:        cmd.CustomerID,
+		PlanID:            cmd.PlanID,
+		Type:              cmd.Type,
+		TrialMaxHours:     cmd.TrialMaxHours,
+		GracePeriodHours:  cmd.GracePeriodHours,
+		Perpetual:         cmd.Perpetual,
+		VersionCutoffAt:   cmd.VersionCutoffAt,
+		MinVersionID:      cmd.MinVersionID,
+		MaxVersionID:      cmd.MaxVersionID,
+		NotBefore:         cmd.NotBefore,
+		ScheduledRevokeAt: cmd.ScheduledRevokeAt,
+		ValidityHours:     cmd.ValidityHours,
+		MaxNodes:          cmd.MaxNodes,
+		MaxConcurrent:     cmd.MaxConcurrent,
+		Remark:            cmd.Remark,
 	})
 	if err != nil {
 		HandleError(ctx, err)
@@ -286,105 +342,161 @@ func (c *LicenseController) RenewLicense(ctx *gin.Context) {
 	Success(ctx, "renew success")
 }
 
-// CleanLicenseBindings 清理该许可证相关的绑定
-// @Summary Remove all node bindings of a license
+// AssignCustomer 设置或解除 License 的归属客户
+// @Summary Assign license customer
 // @Tags licenses
 // @Accept json
 // @Produce json
-// @Param body body dto.UpdateLicenseCommand true "CleanLicenseBindings"
-// @Success 200 {object} api.CommonResponse
+// @Param id path uint true "License ID"
+// @Param body body dto.AssignLicenseCustomerCommand true "Assign Customer"
+// @Success 200 {object} service.LicenseData
 // @Failure 400 {object} api.CommonResponse
-// @Failure 500 {object} api.CommonResponse
-// @Router /licenses/{id}/bindings [delete]
-func (c *LicenseController) CleanLicenseBindings(ctx *gin.Context) {
+// @Failure 404 {object} api.CommonResponse
+// @Router /licenses/{id}/customer [post]
+func (c *LicenseController) AssignCustomer(ctx *gin.Context) {
 	id, err := UintParamOrQuery(ctx, "id")
 	if err != nil {
-		var cmd struct {
-			ID uint `json:"id" binding:"required"`
-		}
-		if bindErr := ctx.ShouldBindJSON(&cmd); bindErr != nil {
-			BadRequest(ctx, err.Error())
-			return
-		}
-		id = cmd.ID
+		BadRequest(ctx, err.Error())
+		return
 	}
-
-	if err := c.ls.RemoveBindings(ctx.Request.Context(), id); err != nil {
+	var cmd dto.AssignLicenseCustomerCommand
+	if err := ctx.ShouldBindJSON(&cmd); err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	data, err := c.ls.AssignCustomer(ctx.Request.Context(), service.AssignLicenseCustomerCommand{
+		LicenseID:  id,
+		CustomerID: cmd.CustomerID,
+	})
+	if err != nil {
 		HandleError(ctx, err)
 		return
 	}
-	Success(ctx, "renew success")
+	Success(ctx, data)
 }
 
-// CleanInvalidLicense 清理无效的 License 包括过期，和已经被吊销
-// @Summary Delete expired licenses
+// ChangePlan 将 License 切换到另一个套餐，限制和服务范围随之更新
+// @Summary Change license plan
 // @Tags licenses
 // @Accept json
 // @Produce json
-// @Success 200 {object} api.CommonResponse
-// @Failure 500 {object} api.CommonResponse
-// @Router /license-cleanups/invalid [delete]
-func (c *LicenseController) CleanInvalidLicense(ctx *gin.Context) {
-	if err := c.ls.CleanInvalidLicense(ctx.Request.Context()); err != nil {
+// @Param id path uint true "License ID"
+// @Param body body dto.ChangeLicensePlanCommand true "Change Plan"
+// @Success 200 {object} service.LicenseData
+// @Failure 400 {object} api.CommonResponse
+// @Failure 404 {object} api.CommonResponse
+// @Failure 409 {object} api.CommonResponse
+// @Router /licenses/{id}/plan [post]
+func (c *LicenseController) ChangePlan(ctx *gin.Context) {
+	id, err := UintParamOrQuery(ctx, "id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	var cmd dto.ChangeLicensePlanCommand
+	if err := ctx.ShouldBindJSON(&cmd); err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	data, err := c.ls.ChangeLicensePlan(ctx.Request.Context(), service.ChangeLicensePlanCommand{
+		LicenseID: id,
+		PlanID:    cmd.PlanID,
+	})
+	if err != nil {
 		HandleError(ctx, err)
 		return
 	}
-	SuccessMsg(ctx, "invalid licenses deleted")
+	Success(ctx, data)
 }
 
-// DeleteLicense 删除单个license
-// @Summary Delete expired licenses
+// ConvertTrial 将试用许可证转为正式许可证，保留已有节点绑定
+// @Summary Convert trial license
 // @Tags licenses
 // @Accept json
 // @Produce json
-// @Success 200 {object} api.CommonResponse
-// @Failure 500 {object} api.CommonResponse
-// @Router /licenses/{id} [delete]
-func (c *LicenseController) DeleteLicense(ctx *gin.Context) {
+// @Param id path uint true "License ID"
+// @Param body body dto.ConvertTrialLicenseCommand true "Convert Trial"
+// @Success 200 {object} service.LicenseData
+// @Failure 400 {object} api.CommonResponse
+// @Failure 404 {object} api.CommonResponse
+// @Failure 409 {object} api.CommonResponse
+// @Router /licenses/{id}/convert [post]
+func (c *LicenseController) ConvertTrial(ctx *gin.Context) {
 	id, err := UintParamOrQuery(ctx, "id")
 	if err != nil {
-		var cmd struct {
-			ID uint `json:"id" binding:"required"`
-		}
-		if bindErr := ctx.ShouldBindJSON(&cmd); bindErr != nil {
-			BadRequest(ctx, err.Error())
-			return
-		}
-		id = cmd.ID
+		BadRequest(ctx, err.Error())
+		return
+	}
+	var cmd dto.ConvertTrialLicenseCommand
+	if err := ctx.ShouldBindJSON(&cmd); err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	data, err := c.ls.ConvertTrialLicense(ctx.Request.Context(), service.ConvertTrialLicenseCommand{
+		LicenseID:     id,
+		PlanID:        cmd.PlanID,
+		ValidityHours: cmd.ValidityHours,
+		MaxNodes:      cmd.MaxNodes,
+		MaxConcurrent: cmd.MaxConcurrent,
+	})
+	if err != nil {
+		HandleError(ctx, err)
+		return
 	}
+	Success(ctx, data)
+}
 
-	if err := c.ls.DeleteLicense(ctx.Request.Context(), id); err != nil {
+// ListProductScopes 查询 License 授权的产品及各产品的绑定、在线数量
+// @Summary List license product scopes
+// @Tags licenses
+// @Produce json
+// @Param id path uint true "License ID"
+// @Success 200 {array} service.LicenseProductScopeData
+// @Failure 400 {object} api.CommonResponse
+// @Failure 404 {object} api.CommonResponse
+// @Router /licenses/{id}/products [get]
+func (c *LicenseController) ListProductScopes(ctx *gin.Context) {
+	id, err := UintParamOrQuery(ctx, "id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	data, err := c.ls.ListLicenseProductScopes(ctx.Request.Context(), id)
+	if err != nil {
 		HandleError(ctx, err)
 		return
 	}
-	Success(ctx, "renew success")
+	Success(ctx, data)
 }
 
-// BatchCreateLicenses 批量创建 License
-// @Summary Batch create licenses
+// SetProductScope 为 License 新增或更新授权产品
+// @Summary Set license product scope
 // @Tags licenses
 // @Accept json
 // @Produce json
-// @Param body body dto.BatchCreateLicenseCommand true "Batch Create Licenses"
-// @Success 200 {object} api.CommonResponse
+// @Param id path uint true "License ID"
+// @Param body body dto.SetLicenseProductScopeCommand true "Product Scope"
+// @Success 200 {object} service.LicenseProductScopeData
 // @Failure 400 {object} api.CommonResponse
 // @Failure 404 {object} api.CommonResponse
-// @Failure 500 {object} api.CommonResponse
-// @Router /licenses/batch [post]
-func (c *LicenseController) BatchCreateLicenses(ctx *gin.Context) {
-	var cmd dto.BatchCreateLicenseCommand
+// @Router /licenses/{id}/products [post]
+func (c *LicenseController) SetProductScope(ctx *gin.Context) {
+	id, err := UintParamOrQuery(ctx, "id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	var cmd dto.SetLicenseProductScopeCommand
 	if err := ctx.ShouldBindJSON(&cmd); err != nil {
 		BadRequest(ctx, err.Error())
 		return
 	}
-
-	data, err := c.ls.BatchCreateLicenses(ctx.Request.Context(), service.BatchCreateLicenseCommand{
+	data, err := c.ls.SetLicenseProductScope(ctx.Request.Context(), service.SetLicenseProductScopeCommand{
+		LicenseID:     id,
 		ProductID:     cmd.ProductID,
-		ValidityHours: cmd.ValidityHours,
 		MaxNodes:      cmd.MaxNodes,
 		MaxConcurrent: cmd.MaxConcurrent,
-		Remark:        cmd.Remark,
-		Count:         cmd.Count,
+		Status:        cmd.Status,
 	})
 	if err != nil {
 		HandleError(ctx, err)
@@ -393,32 +505,846 @@ func (c *LicenseController) BatchCreateLicenses(ctx *gin.Context) {
 	Success(ctx, data)
 }
 
-// RestoreLicense 恢复已吊销 License
-// @Summary Restore a revoked license
+// RemoveProductScope 移除 License 授权的产品
+// @Summary Remove license product scope
 // @Tags licenses
-// @Accept json
 // @Produce json
 // @Param id path uint true "License ID"
+// @Param product_id path uint true "Product ID"
 // @Success 200 {object} api.CommonResponse
+// @Failure 404 {object} api.CommonResponse
+// @Failure 409 {object} api.CommonResponse
+// @Router /licenses/{id}/products/{product_id} [delete]
+func (c *LicenseController) RemoveProductScope(ctx *gin.Context) {
+	id, err := UintParamOrQuery(ctx, "id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	productID, err := UintParamOrQuery(ctx, "product_id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	if err := c.ls.RemoveLicenseProductScope(ctx.Request.Context(), service.RemoveLicenseProductScopeCommand{
+		LicenseID: id,
+		ProductID: productID,
+	}); err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	SuccessMsg(ctx, "license product scope removed")
+}
+
+// SetVersionEntitlement 设置 License 可使用的版本范围
+// @Summary Set license version entitlement
+// @Tags licenses
+// @Accept json
+// @Produce json
+// @Param id path uint true "License ID"
+// @Param body body dto.SetLicenseVersionEntitlementCommand true "Version Entitlement"
+// @Success 200 {object} service.LicenseData
 // @Failure 400 {object} api.CommonResponse
 // @Failure 404 {object} api.CommonResponse
-// @Failure 500 {object} api.CommonResponse
-// @Router /licenses/{id}/restore [post]
-func (c *LicenseController) RestoreLicense(ctx *gin.Context) {
+// @Router /licenses/{id}/version-entitlement [post]
+func (c *LicenseController) SetVersionEntitlement(ctx *gin.Context) {
 	id, err := UintParamOrQuery(ctx, "id")
 	if err != nil {
-		var cmd dto.RestoreLicenseCommand
-		if bindErr := ctx.ShouldBindJSON(&cmd); bindErr != nil {
-			BadRequest(ctx, err.Error())
-			return
-		}
-		id = cmd.ID
+		BadRequest(ctx, err.Error())
+		return
+	}
+	var cmd dto.SetLicenseVersionEntitlementCommand
+	if err := ctx.ShouldBindJSON(&cmd); err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	data, err := c.ls.SetLicenseVersionEntitlement(ctx.Request.Context(), service.SetLicenseVersionEntitlementCommand{
+		LicenseID:       id,
+		VersionCutoffAt: cmd.VersionCutoffAt,
+		MinVersionID:    cmd.MinVersionID,
+		MaxVersionID:    cmd.MaxVersionID,
+	})
+	if err != nil {
+		HandleError(ctx, err)
+		return
 	}
+	Success(ctx, data)
+}
 
-	data, err := c.ls.RestoreLicense(ctx.Request.Context(), service.RestoreLicenseCommand{ID: id})
+// SetSchedule 设置 License 的合同开始时间和计划吊销时间
+// @Summary Set license start date and scheduled revoke
+// @Tags licenses
+// @Accept json
+// @Produce json
+// @Param id path uint true "License ID"
+// @Param body body dto.SetLicenseScheduleCommand true "Schedule"
+// @Success 200 {object} service.LicenseData
+// @Failure 400 {object} api.CommonResponse
+// @Failure 404 {object} api.CommonResponse
+// @Router /licenses/{id}/schedule [post]
+func (c *LicenseController) SetSchedule(ctx *gin.Context) {
+	id, err := UintParamOrQuery(ctx, "id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	var cmd dto.SetLicenseScheduleCommand
+	if err := ctx.ShouldBindJSON(&cmd); err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	data, err := c.ls.SetLicenseSchedule(ctx.Request.Context(), service.SetLicenseScheduleCommand{
+		LicenseID:         id,
+		NotBefore:         cmd.NotBefore,
+		ScheduledRevokeAt: cmd.ScheduledRevokeAt,
+	})
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// SetGracePeriod 单独设置 License 过期后的宽限期
+// @Summary Set license grace period
+// @Tags licenses
+// @Accept json
+// @Produce json
+// @Param id path uint true "License ID"
+// @Param body body dto.SetLicenseGracePeriodCommand true "Grace Period"
+// @Success 200 {object} service.LicenseData
+// @Failure 400 {object} api.CommonResponse
+// @Failure 404 {object} api.CommonResponse
+// @Router /licenses/{id}/grace-period [post]
+func (c *LicenseController) SetGracePeriod(ctx *gin.Context) {
+	id, err := UintParamOrQuery(ctx, "id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	var cmd dto.SetLicenseGracePeriodCommand
+	if err := ctx.ShouldBindJSON(&cmd); err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	data, err := c.ls.SetLicenseGracePeriod(ctx.Request.Context(), service.SetLicenseGracePeriodCommand{
+		LicenseID:        id,
+		GracePeriodHours: cmd.GracePeriodHours,
+	})
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// SetInactiveUnbind 单独设置不活跃节点的自动解绑天数
+// @Summary Set license inactive node unbind policy
+// @Tags licenses
+// @Accept json
+// @Produce json
+// @Param id path uint true "License ID"
+// @Param body body dto.SetLicenseInactiveUnbindCommand true "Inactive Unbind Policy"
+// @Success 200 {object} service.LicenseData
+// @Failure 400 {object} api.CommonResponse
+// @Failure 404 {object} api.CommonResponse
+// @Router /licenses/{id}/inactive-unbind [post]
+func (c *LicenseController) SetInactiveUnbind(ctx *gin.Context) {
+	id, err := UintParamOrQuery(ctx, "id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	var cmd dto.SetLicenseInactiveUnbindCommand
+	if err := ctx.ShouldBindJSON(&cmd); err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	data, err := c.ls.SetLicenseInactiveUnbind(ctx.Request.Context(), service.SetLicenseInactiveUnbindCommand{
+		LicenseID:          id,
+		InactiveUnbindDays: cmd.InactiveUnbindDays,
+	})
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// ListAllowedDevices 查询设备白名单
+// @Summary List license allowed devices
+// @Tags licenses
+// @Accept json
+// @Produce json
+// @Param id path uint true "License ID"
+// @Success 200 {array} service.AllowedDeviceData
+// @Failure 400 {object} api.CommonResponse
+// @Failure 404 {object} api.CommonResponse
+// @Router /licenses/{id}/allowed-devices [get]
+func (c *LicenseController) ListAllowedDevices(ctx *gin.Context) {
+	id, err := UintParamOrQuery(ctx, "id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	data, err := c.ls.ListAllowedDevices(ctx.Request.Context(), id)
 	if err != nil {
 		HandleError(ctx, err)
 		return
 	}
 	Success(ctx, data)
 }
+
+// AddAllowedDevices 添加设备白名单，设置后只有名单内的设备可以注册
+// @Summary Add license allowed devices
+// @Tags licenses
+// @Accept json
+// @Produce json
+// @Param id path uint true "License ID"
+// @Param body body dto.AddAllowedDevicesCommand true "Allowed Devices"
+// @Success 200 {object} service.AllowedDeviceImportData
+// @Failure 400 {object} api.CommonResponse
+// @Failure 404 {object} api.CommonResponse
+// @Failure 409 {object} api.CommonResponse
+// @Router /licenses/{id}/allowed-devices [post]
+func (c *LicenseController) AddAllowedDevices(ctx *gin.Context) {
+	id, err := UintParamOrQuery(ctx, "id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	var cmd dto.AddAllowedDevicesCommand
+	if err := ctx.ShouldBindJSON(&cmd); err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	devices := make([]service.AllowedDeviceEntry, 0, len(cmd.Devices))
+	for _, device := range cmd.Devices {
+		devices = append(devices, service.AllowedDeviceEntry{DeviceCode: device.DeviceCode, Remark: device.Remark})
+	}
+	data, err := c.ls.AddAllowedDevices(ctx.Request.Context(), service.AddAllowedDevicesCommand{
+		LicenseID: id,
+		Devices:   devices,
+		Reserve:   cmd.Reserve,
+	})
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// ImportAllowedDevices 从 CSV 批量导入设备白名单
+// @Summary Import license allowed devices from CSV
+// @Description Upload a CSV as multipart field "file" or as the raw request body. Each line is device_code[,remark]; a device_code header line is skipped
+// @Tags licenses
+// @Accept text/csv
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path uint true "License ID"
+// @Param reserve query bool false "Pre-create nodes reserved for the license"
+// @Param file formData file false "CSV file"
+// @Success 200 {object} service.AllowedDeviceImportData
+// @Failure 400 {object} api.CommonResponse
+// @Failure 404 {object} api.CommonResponse
+// @Failure 409 {object} api.CommonResponse
+// @Router /licenses/{id}/allowed-devices/import [post]
+func (c *LicenseController) ImportAllowedDevices(ctx *gin.Context) {
+	id, err := UintParamOrQuery(ctx, "id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	reserve, err := BoolQuery(ctx, "reserve")
+	if err != nil {
+		BadRequest(ctx, "invalid reserve")
+		return
+	}
+	content := io.Reader(ctx.Request.Body)
+	if strings.HasPrefix(ctx.ContentType(), "multipart/") {
+		header, err := ctx.FormFile("file")
+		if err != nil {
+			BadRequest(ctx, "file is required")
+			return
+		}
+		file, err := header.Open()
+		if err != nil {
+			BadRequest(ctx, err.Error())
+			return
+		}
+		defer file.Close()
+		content = file
+	}
+	data, err := c.ls.ImportAllowedDevices(ctx.Request.Context(), service.ImportAllowedDevicesCommand{
+		LicenseID: id,
+		Content:   content,
+		Reserve:   reserve,
+	})
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// RemoveAllowedDevice 从设备白名单移除设备
+// @Summary Remove a license allowed device
+// @Tags licenses
+// @Accept json
+// @Produce json
+// @Param id path uint true "License ID"
+// @Param device_code path string true "Device Code"
+// @Success 200 {object} api.CommonResponse
+// @Failure 400 {object} api.CommonResponse
+// @Failure 404 {object} api.CommonResponse
+// @Router /licenses/{id}/allowed-devices/{device_code} [delete]
+func (c *LicenseController) RemoveAllowedDevice(ctx *gin.Context) {
+	id, err := UintParamOrQuery(ctx, "id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	if err := c.ls.RemoveAllowedDevice(ctx.Request.Context(), id, ctx.Param("device_code")); err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	SuccessMsg(ctx, "allowed device removed")
+}
+
+// SetApprovalRequired 设置新设备绑定是否需要审批
+// @Summary Set license device approval mode
+// @Description When enabled, new devices registering with the license wait for approval before taking a node seat
+// @Tags licenses
+// @Accept json
+// @Produce json
+// @Param id path uint true "License ID"
+// @Param body body dto.SetLicenseApprovalCommand true "Approval Mode"
+// @Success 200 {object} service.LicenseData
+// @Failure 400 {object} api.CommonResponse
+// @Failure 404 {object} api.CommonResponse
+// @Router /licenses/{id}/approval [post]
+func (c *LicenseController) SetApprovalRequired(ctx *gin.Context) {
+	id, err := UintParamOrQuery(ctx, "id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	var cmd dto.SetLicenseApprovalCommand
+	if err := ctx.ShouldBindJSON(&cmd); err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	data, err := c.ls.SetLicenseApprovalRequired(ctx.Request.Context(), service.SetLicenseApprovalCommand{
+		LicenseID:        id,
+		ApprovalRequired: cmd.ApprovalRequired,
+	})
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// ListBindingRequests 查询待审批与已拒绝的设备绑定申请
+// @Summary List license device binding requests
+// @Tags licenses
+// @Accept json
+// @Produce json
+// @Param id path uint true "License ID"
+// @Success 200 {array} service.BindingRequestData
+// @Failure 400 {object} api.CommonResponse
+// @Failure 404 {object} api.CommonResponse
+// @Router /licenses/{id}/binding-requests [get]
+func (c *LicenseController) ListBindingRequests(ctx *gin.Context) {
+	id, err := UintParamOrQuery(ctx, "id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	data, err := c.ls.ListBindingRequests(ctx.Request.Context(), id)
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// ApproveBinding 审批通过设备绑定申请
+// @Summary Approve a device binding request
+// @Tags licenses
+// @Accept json
+// @Produce json
+// @Param id path uint true "License ID"
+// @Param binding_id path uint true "Binding ID"
+// @Success 200 {object} service.BindingRequestData
+// @Failure 400 {object} api.CommonResponse
+// @Failure 404 {object} api.CommonResponse
+// @Failure 409 {object} api.CommonResponse
+// @Router /licenses/{id}/binding-requests/{binding_id}/approve [post]
+func (c *LicenseController) ApproveBinding(ctx *gin.Context) {
+	cmd, ok := bindingDecisionCommand(ctx)
+	if !ok {
+		return
+	}
+	data, err := c.ls.ApproveBinding(ctx.Request.Context(), cmd)
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// RejectBinding 拒绝设备绑定申请
+// @Summary Reject a device binding request
+// @Tags licenses
+// @Accept json
+// @Produce json
+// @Param id path uint true "License ID"
+// @Param binding_id path uint true "Binding ID"
+// @Param body body dto.RejectBindingCommand false "Reject Reason"
+// @Success 200 {object} service.BindingRequestData
+// @Failure 400 {object} api.CommonResponse
+// @Failure 404 {object} api.CommonResponse
+// @Failure 409 {object} api.CommonResponse
+// @Router /licenses/{id}/binding-requests/{binding_id}/reject [post]
+func (c *LicenseController) RejectBinding(ctx *gin.Context) {
+	cmd, ok := bindingDecisionCommand(ctx)
+	if !ok {
+		return
+	}
+	data, err := c.ls.RejectBinding(ctx.Request.Context(), cmd)
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// bindingDecisionCommand 解析审批接口的 License、绑定申请与可选的拒绝原因，失败时已写入响应
+func bindingDecisionCommand(ctx *gin.Context) (service.BindingDecisionCommand, bool) {
+	licenseID, err := UintParamOrQuery(ctx, "id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return service.BindingDecisionCommand{}, false
+	}
+	bindingID, err := UintParamOrQuery(ctx, "binding_id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return service.BindingDecisionCommand{}, false
+	}
+	var body dto.RejectBindingCommand
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			BadRequest(ctx, err.Error())
+			return service.BindingDecisionCommand{}, false
+		}
+	}
+	return service.BindingDecisionCommand{LicenseID: licenseID, BindingID: bindingID, Reason: body.Reason}, true
+}
+
+// GetTransferPolicy 查询换绑限制策略及当前计数
+// @Summary Get license transfer policy
+// @Tags licenses
+// @Accept json
+// @Produce json
+// @Param id path uint true "License ID"
+// @Success 200 {object} service.LicenseTransferData
+// @Failure 400 {object} api.CommonResponse
+// @Failure 404 {object} api.CommonResponse
+// @Router /licenses/{id}/transfer-policy [get]
+func (c *LicenseController) GetTransferPolicy(ctx *gin.Context) {
+	id, err := UintParamOrQuery(ctx, "id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	data, err := c.ls.GetLicenseTransfer(ctx.Request.Context(), id)
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// SetTransferPolicy 设置换绑限制策略
+// @Summary Set license transfer policy
+// @Description Limit distinct devices bound within a rolling window and the cooldown before a released seat can be reused
+// @Tags licenses
+// @Accept json
+// @Produce json
+// @Param id path uint true "License ID"
+// @Param body body dto.SetLicenseTransferPolicyCommand true "Transfer Policy"
+// @Success 200 {object} service.LicenseTransferData
+// @Failure 400 {object} api.CommonResponse
+// @Failure 404 {object} api.CommonResponse
+// @Router /licenses/{id}/transfer-policy [post]
+func (c *LicenseController) SetTransferPolicy(ctx *gin.Context) {
+	id, err := UintParamOrQuery(ctx, "id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	var cmd dto.SetLicenseTransferPolicyCommand
+	if err := ctx.ShouldBindJSON(&cmd); err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	data, err := c.ls.SetLicenseTransferPolicy(ctx.Request.Context(), service.SetLicenseTransferPolicyCommand{
+		LicenseID:             id,
+		TransferLimit:         cmd.TransferLimit,
+		TransferWindowDays:    cmd.TransferWindowDays,
+		RebindCooldownMinutes: cmd.RebindCooldownMinutes,
+	})
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// ResetTransfer 重置换绑计数，冷却中的名额立即可用
+// @Summary Reset license transfer counter
+// @Tags licenses
+// @Accept json
+// @Produce json
+// @Param id path uint true "License ID"
+// @Success 200 {object} service.LicenseTransferData
+// @Failure 400 {object} api.CommonResponse
+// @Failure 404 {object} api.CommonResponse
+// @Router /licenses/{id}/transfer-policy/reset [post]
+func (c *LicenseController) ResetTransfer(ctx *gin.Context) {
+	id, err := UintParamOrQuery(ctx, "id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	data, err := c.ls.ResetLicenseTransfer(ctx.Request.Context(), id)
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// ExportLicenseFile 导出签名的离线 License 文件
+// @Summary Export signed license file
+// @Description Export an Ed25519 signed license file that clients can verify offline
+// @Tags licenses
+// @Accept json
+// @Produce json
+// @Param id path uint true "License ID"
+// @Success 200 {object} api.CommonResponse
+// @Failure 400 {object} api.CommonResponse
+// @Failure 403 {object} api.CommonResponse
+// @Failure 404 {object} api.CommonResponse
+// @Failure 500 {object} api.CommonResponse
+// @Router /licenses/{id}/file [get]
+func (c *LicenseController) ExportLicenseFile(ctx *gin.Context) {
+	id, err := UintParamOrQuery(ctx, "id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	data, err := c.ls.ExportLicenseFile(ctx.Request.Context(), id)
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// CleanLicenseBindings 清理该许可证相关的绑定
+// @Summary Remove all node bindings of a license
+// @Tags licenses
+// @Accept json
+// @Produce json
+// @Param body body dto.UpdateLicenseCommand true "CleanLicenseBindings"
+// @Success 200 {object} api.CommonResponse
+// @Failure 400 {object} api.CommonResponse
+// @Failure 500 {object} api.CommonResponse
+// @Router /licenses/{id}/bindings [delete]
+func (c *LicenseController) CleanLicenseBindings(ctx *gin.Context) {
+	id, err := UintParamOrQuery(ctx, "id")
+	if err != nil {
+		var cmd struct {
+			ID uint `json:"id" binding:"required"`
+		}
+		if bindErr := ctx.ShouldBindJSON(&cmd); bindErr != nil {
+			BadRequest(ctx, err.Error())
+			return
+		}
+		id = cmd.ID
+	}
+
+	if err := c.ls.RemoveBindings(ctx.Request.Context(), id); err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, "renew success")
+}
+
+// CleanInvalidLicense 清理无效的 License 包括过期，和已经被吊销
+// @Summary Delete expired licenses
+// @Tags licenses
+// @Accept json
+// @Produce json
+// @Success 200 {object} api.CommonResponse
+// @Failure 500 {object} api.CommonResponse
+// @Router /license-cleanups/invalid [delete]
+func (c *LicenseController) CleanInvalidLicense(ctx *gin.Context) {
+	if err := c.ls.CleanInvalidLicense(ctx.Request.Context()); err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	SuccessMsg(ctx, "invalid licenses deleted")
+}
+
+// DeleteLicense 删除单个license
+// @Summary Delete expired licenses
+// @Tags licenses
+// @Accept json
+// @Produce json
+// @Success 200 {object} api.CommonResponse
+// @Failure 500 {object} api.CommonResponse
+// @Router /licenses/{id} [delete]
+func (c *LicenseController) DeleteLicense(ctx *gin.Context) {
+	id, err := UintParamOrQuery(ctx, "id")
+	if err != nil {
+		var cmd struct {
+			ID uint `json:"id" binding:"required"`
+		}
+		if bindErr := ctx.ShouldBindJSON(&cmd); bindErr != nil {
+			BadRequest(ctx, err.Error())
+			return
+		}
+		id = cmd.ID
+	}
+
+	if err := c.ls.DeleteLicense(ctx.Request.Context(), id); err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, "renew success")
+}
+
+// BatchCreateLicenses 批量创建 License
+// @Summary Batch create licenses
+// @Tags licenses
+// @Accept json
+// @Produce json
+// @Param body body dto.BatchCreateLicenseCommand true "Batch Create Licenses"
+// @Success 200 {object} api.CommonResponse
+// @Failure 400 {object} api.CommonResponse
+// @Failure 404 {object} api.CommonResponse
+// @Failure 500 {object} api.CommonResponse
+// @Router /licenses/batch [post]
+func (c *LicenseController) BatchCreateLicenses(ctx *gin.Context) {
+	var cmd dto.BatchCreateLicenseCommand
+	if err := ctx.ShouldBindJSON(&cmd); err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+
+	data, err := c.ls.BatchCreateLicenses(ctx.Request.Context(), service.BatchCreateLicenseCommand{
+		ProductID:         cmd.ProductID,
+		CustomerID:        cmd.CustomerID,
+		PlanID:            cmd.PlanID,
+		Type:              cmd.Type,
+		TrialMaxHours:     cmd.TrialMaxHours,
+		GracePeriodHours:  cmd.GracePeriodHours,
+		Perpetual:         cmd.Perpetual,
+		VersionCutoffAt:   cmd.VersionCutoffAt,
+		MinVersionID:      cmd.MinVersionID,
+		MaxVersionID:      cmd.MaxVersionID,
+		NotBefore:         cmd.NotBefore,
+		ScheduledRevokeAt: cmd.ScheduledRevokeAt,
+		ValidityHours:     cmd.ValidityHours,
+		MaxNodes:          cmd.MaxNodes,
+		MaxConcurrent:     cmd.MaxConcurrent,
+		Remark:            cmd.Remark,
+		Count:             cmd.Count,
+	})
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// ImportLicenseKeys 按声明的格式导入外部生成的注册码
+// @Summary Import externally generated license keys
+// @Tags licenses
+// @Accept json
+// @Produce json
+// @Param body body dto.ImportLicenseKeysCommand true "Import License Keys"
+// @Success 200 {object} api.CommonResponse
+// @Failure 400 {object} api.CommonResponse
+// @Failure 404 {object} api.CommonResponse
+// @Failure 409 {object} api.CommonResponse
+// @Failure 500 {object} api.CommonResponse
+// @Router /licenses/import [post]
+func (c *LicenseController) ImportLicenseKeys(ctx *gin.Context) {
+	var cmd dto.ImportLicenseKeysCommand
+	if err := ctx.ShouldBindJSON(&cmd); err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+
+	data, err := c.ls.ImportLicenseKeys(ctx.Request.Context(), service.ImportLicenseKeysCommand{
+		BatchCreateLicenseCommand: service.BatchCreateLicenseCommand{
+			ProductID:         cmd.ProductID,
+			CustomerID:        cmd.CustomerID,
+			PlanID:            cmd.PlanID,
+			Type:              cmd.Type,
+			TrialMaxHours:     cmd.TrialMaxHours,
+			GracePeriodHours:  cmd.GracePeriodHours,
+			Perpetual:         cmd.Perpetual,
+			VersionCutoffAt:   cmd.VersionCutoffAt,
+			MinVersionID:      cmd.MinVersionID,
+			MaxVersionID:      cmd.MaxVersionID,
+			NotBefore:         cmd.NotBefore,
+			ScheduledRevokeAt: cmd.ScheduledRevokeAt,
+			ValidityHours:     cmd.ValidityHours,
+			MaxNodes:          cmd.MaxNodes,
+			MaxConcurrent:     cmd.MaxConcurrent,
+			Remark:            cmd.Remark,
+		},
+		Format: toLicenseKeyFormat(cmd.Format),
+		Keys:   cmd.Keys,
+	})
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// RestoreLicense 恢复已吊销 License
+// @Summary Restore a revoked license
+// @Tags licenses
+// @Accept json
+// @Produce json
+// @Param id path uint true "License ID"
+// @Success 200 {object} api.CommonResponse
+// @Failure 400 {object} api.CommonResponse
+// @Failure 404 {object} api.CommonResponse
+// @Failure 500 {object} api.CommonResponse
+// @Router /licenses/{id}/restore [post]
+func (c *LicenseController) RestoreLicense(ctx *gin.Context) {
+	id, err := UintParamOrQuery(ctx, "id")
+	if err != nil {
+		var cmd dto.RestoreLicenseCommand
+		if bindErr := ctx.ShouldBindJSON(&cmd); bindErr != nil {
+			BadRequest(ctx, err.Error())
+			return
+		}
+		id = cmd.ID
+	}
+
+	data, err := c.ls.RestoreLicense(ctx.Request.Context(), service.RestoreLicenseCommand{ID: id})
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// ListHistory 查询 License 的状态历史
+// @Summary List license status history
+// @Tags licenses
+// @Produce json
+// @Param id path uint true "License ID"
+// @Success 200 {array} service.LicenseHistoryData
+// @Failure 404 {object} api.CommonResponse
+// @Router /licenses/{id}/history [get]
+func (c *LicenseController) ListHistory(ctx *gin.Context) {
+	id, err := UintParamOrQuery(ctx, "id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	data, err := c.ls.ListLicenseHistory(ctx.Request.Context(), id)
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// GetStatusAt 回溯 License 在指定时刻的状态和有效期
+// @Summary Get license status at a point in time
+// @Tags licenses
+// @Produce json
+// @Param id path uint true "License ID"
+// @Param at query string true "RFC3339 time, e.g. 2026-01-02T15:04:05+08:00"
+// @Success 200 {object} service.LicenseStatusAtData
+// @Failure 400 {object} api.CommonResponse
+// @Failure 404 {object} api.CommonResponse
+// @Router /licenses/{id}/status-at [get]
+func (c *LicenseController) GetStatusAt(ctx *gin.Context) {
+	id, err := UintParamOrQuery(ctx, "id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	at, err := TimeQuery(ctx, "at")
+	if err != nil {
+		BadRequest(ctx, "at must be an RFC3339 time")
+		return
+	}
+	data, err := c.ls.GetLicenseStatusAt(ctx.Request.Context(), id, at)
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// ListSeats 查询 License 的浮动席位占用与排队情况
+// @Summary List license floating seats
+// @Tags licenses
+// @Produce json
+// @Param id path uint true "License ID"
+// @Success 200 {object} service.LicenseSeatsData
+// @Failure 404 {object} api.CommonResponse
+// @Router /licenses/{id}/seats [get]
+func (c *LicenseController) ListSeats(ctx *gin.Context) {
+	id, err := UintParamOrQuery(ctx, "id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	data, err := c.ls.ListLicenseSeats(ctx.Request.Context(), id)
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// ReleaseSeat 强制释放浮动席位
+// @Summary Release license floating seat
+// @Tags licenses
+// @Produce json
+// @Param id path uint true "License ID"
+// @Param seat_id path uint true "Seat ID"
+// @Success 200 {object} api.CommonResponse
+// @Failure 404 {object} api.CommonResponse
+// @Router /licenses/{id}/seats/{seat_id} [delete]
+func (c *LicenseController) ReleaseSeat(ctx *gin.Context) {
+	id, err := UintParamOrQuery(ctx, "id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	seatID, err := UintParamOrQuery(ctx, "seat_id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	if err := c.ls.ReleaseSeat(ctx.Request.Context(), id, seatID); err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	SuccessMsg(ctx, "seat released")
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLicenseHistoryAPI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := setupControlAPITest(t)
+	_, productID, _ := seedControlAPITarget(t, ctx)
+
+	router := NewServer()
+	NewLicenseController().RegisterRoutes(router)
+
+	license := doJSON(t, router, http.MethodPost, "/licenses", map[string]interface{}{
+		"product_id":     productID,
+		"validity_hours": 24,
+		"max_nodes":      1,
+	})
+	licenseID := uint(license.Data.(map[string]interface{})["id"].(float64))
+	licensePath := "/licenses/" + uintString(licenseID)
+
+	doJSON(t, router, http.MethodPost, licensePath+"/renew", map[string]interface{}{"extra_hours": 12})
+	beforeRevoke := time.Now().Add(time.Millisecond).Format(time.RFC3339Nano)
+	time.Sleep(2 * time.Millisecond)
+	doJSON(t, router, http.MethodPost, licensePath+"/revoke", nil)
+
+	history := doJSON(t, router, http.MethodGet, licensePath+"/history", nil).Data.([]interface{})
+	if len(history) != 3 {
+		t.Fatalf("expected create, renew and revoke, got %#v", history)
+	}
+	renew := history[1].(map[string]interface{})
+	if renew["event"] != "renew" || renew["extra_hours"].(float64) != 12 {
+		t.Fatalf("unexpected renew entry: %#v", renew)
+	}
+
+	statusAt := doJSON(t, router, http.MethodGet, licensePath+"/status-at?at="+url.QueryEscape(beforeRevoke), nil).Data.(map[string]interface{})
+	if statusAt["status"].(float64) != 0 || statusAt["last_event"].(map[string]interface{})["event"] != "renew" {
+		t.Fatalf("license should still be inactive before revoke: %#v", statusAt)
+	}
+	if status, _ := doJSONWithHeaders(t, router, http.MethodGet, licensePath+"/status-at?at=yesterday", nil, nil); status != http.StatusBadRequest {
+		t.Fatalf("malformed time should be rejected, got %d", status)
+	}
+	if status, _ := doJSONWithHeaders(t, router, http.MethodGet, licensePath+"/status-at?at=2000-01-01T00:00:00Z", nil, nil); status != http.StatusNotFound {
+		t.Fatalf("time before creation should be not found, got %d", status)
+	}
+}
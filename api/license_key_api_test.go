@@ -0,0 +1,64 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLicenseKeyFormatAPI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := setupControlAPITest(t)
+	_, productID, _ := seedControlAPITarget(t, ctx)
+
+	router := NewServer()
+	NewLicenseController().RegisterRoutes(router)
+	NewProductController().RegisterRoutes(router)
+
+	productPath := "/products/" + uintString(productID)
+	if status, _ := doJSONWithHeaders(t, router, http.MethodPatch, productPath, nil, map[string]interface{}{
+		"key_format": map[string]interface{}{"prefix": "bad!", "groups": 4, "group_size": 5},
+	}); status != http.StatusBadRequest {
+		t.Fatalf("invalid key format should be rejected, got %d", status)
+	}
+	product := doJSON(t, router, http.MethodPatch, productPath, map[string]interface{}{
+		"key_format": map[string]interface{}{"prefix": "API", "groups": 3, "group_size": 5, "check_digit": true},
+	})
+	if format := product.Data.(map[string]interface{})["key_format"].(map[string]interface{}); format["prefix"] != "API" {
+		t.Fatalf("unexpected product key format: %#v", format)
+	}
+
+	license := doJSON(t, router, http.MethodPost, "/licenses", map[string]interface{}{
+		"product_id":     productID,
+		"validity_hours": 24,
+	})
+	licenseKey := license.Data.(map[string]interface{})["license_key"].(string)
+	if !strings.HasPrefix(licenseKey, "API-") || len(licenseKey) != len("API-XXXXX-XXXXX-XXXXX") {
+		t.Fatalf("unexpected license key: %s", licenseKey)
+	}
+	doJSON(t, router, http.MethodGet, "/license-keys/"+licenseKey, nil)
+	if status, _ := doJSONWithHeaders(t, router, http.MethodGet, "/license-keys/-bad-", nil, nil); status != http.StatusBadRequest {
+		t.Fatalf("malformed key should be rejected, got %d", status)
+	}
+
+	imported := doJSON(t, router, http.MethodPost, "/licenses/import", map[string]interface{}{
+		"product_id":     productID,
+		"validity_hours": 24,
+		"format":         map[string]interface{}{"prefix": "OLD", "groups": 4, "group_size": 4},
+		"keys":           []string{"old-1234-5678-9abc-defg"},
+	})
+	items := imported.Data.([]interface{})
+	if len(items) != 1 || items[0].(map[string]interface{})["license_key"] != "OLD-1234-5678-9ABC-DEFG" {
+		t.Fatalf("unexpected imported licenses: %#v", items)
+	}
+	if status, _ := doJSONWithHeaders(t, router, http.MethodPost, "/licenses/import", nil, map[string]interface{}{
+		"product_id":     productID,
+		"validity_hours": 24,
+		"format":         map[string]interface{}{"prefix": "OLD", "groups": 4, "group_size": 4},
+		"keys":           []string{"OLD-1234-5678-9ABC-DEFG"},
+	}); status != http.StatusConflict {
+		t.Fatalf("duplicate import should conflict, got %d", status)
+	}
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"nexus-core/domain/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLicenseScheduleAPI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := setupControlAPITest(t)
+	_, productID, _ := seedControlAPITarget(t, ctx)
+
+	router := NewServer()
+	NewLicenseController().RegisterRoutes(router)
+	NewAccessController().RegisterRoutes(router)
+
+	startsAt := time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339)
+	if status, _ := doJSONWithHeaders(t, router, http.MethodPost, "/licenses", nil, map[string]interface{}{
+		"product_id":          productID,
+		"validity_hours":      24,
+		"not_before":          startsAt,
+		"scheduled_revoke_at": startsAt,
+	}); status != http.StatusBadRequest {
+		t.Fatalf("revoke at start date should be rejected, got %d", status)
+	}
+	license := doJSON(t, router, http.MethodPost, "/licenses", map[string]interface{}{
+		"product_id":     productID,
+		"validity_hours": 24,
+		"not_before":     startsAt,
+	})
+	licenseData := license.Data.(map[string]interface{})
+	if licenseData["not_before"] != startsAt {
+		t.Fatalf("not_before should be returned: %#v", licenseData)
+	}
+
+	status, resp := doJSONWithHeaders(t, router, http.MethodPost, "/access/register", nil, map[string]interface{}{
+		"device_code":  "schedule-api-a",
+		"license_key":  licenseData["license_key"],
+		"product_id":   productID,
+		"version_code": "1.0.0",
+	})
+	if status != http.StatusConflict || resp.Code != service.CodeLicenseNotYetValid {
+		t.Fatalf("register before start should be rejected, got %d %#v", status, resp)
+	}
+
+	schedulePath := "/licenses/" + uintString(uint(licenseData["id"].(float64))) + "/schedule"
+	endsAt := time.Now().Add(48 * time.Hour).UTC().Format(time.RFC3339)
+	updated := doJSON(t, router, http.MethodPost, schedulePath, map[string]interface{}{
+		"scheduled_revoke_at": endsAt,
+	}).Data.(map[string]interface{})
+	if _, ok := updated["not_before"]; ok || updated["scheduled_revoke_at"] != endsAt {
+		t.Fatalf("schedule should be replaced: %#v", updated)
+	}
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"nexus-core/domain/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLicenseProductScopeAPI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := setupControlAPITest(t)
+	_, productID, _ := seedControlAPITarget(t, ctx)
+	addon, err := service.NewProductService().CreateProduct(ctx, service.CreateProductCommand{Name: "addon-api-product"})
+	if err != nil {
+		t.Fatalf("create addon product: %v", err)
+	}
+
+	router := NewServer()
+	NewLicenseController().RegisterRoutes(router)
+
+	license := doJSON(t, router, http.MethodPost, "/licenses", map[string]interface{}{
+		"product_id":     productID,
+		"validity_hours": 24,
+	})
+	licenseID := uint(license.Data.(map[string]interface{})["id"].(float64))
+	scopesPath := "/licenses/" + uintString(licenseID) + "/products"
+
+	set := doJSON(t, router, http.MethodPost, scopesPath, map[string]interface{}{
+		"product_id": addon.ID,
+		"max_nodes":  2,
+	})
+	if set.Code != CodeOK || set.Data.(map[string]interface{})["max_nodes"].(float64) != 2 {
+		t.Fatalf("set product scope: %#v", set)
+	}
+
+	list := doJSON(t, router, http.MethodGet, scopesPath, nil)
+	items := list.Data.([]interface{})
+	if len(items) != 2 || !items[0].(map[string]interface{})["primary"].(bool) {
+		t.Fatalf("list product scopes: %#v", list)
+	}
+
+	if status, _ := doJSONWithHeaders(t, router, http.MethodPost, scopesPath, nil, map[string]interface{}{"product_id": 9999}); status != http.StatusNotFound {
+		t.Fatalf("unknown product should be rejected, got %d", status)
+	}
+
+	doJSON(t, router, http.MethodDelete, scopesPath+"/"+uintString(addon.ID), nil)
+	if status, _ := doJSONWithHeaders(t, router, http.MethodDelete, scopesPath+"/"+uintString(addon.ID), nil, nil); status != http.StatusNotFound {
+		t.Fatalf("removing missing scope should return 404, got %d", status)
+	}
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"nexus-core/domain/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLicenseTransferPolicyAPI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := setupControlAPITest(t)
+	_, productID, _ := seedControlAPITarget(t, ctx)
+
+	router := NewServer()
+	NewLicenseController().RegisterRoutes(router)
+	NewAccessController().RegisterRoutes(router)
+	NewNodeController().RegisterRoutes(router)
+
+	license := doJSON(t, router, http.MethodPost, "/licenses", map[string]interface{}{
+		"product_id":     productID,
+		"validity_hours": 24,
+		"max_nodes":      1,
+	})
+	licenseData := license.Data.(map[string]interface{})
+	licenseID := uint(licenseData["id"].(float64))
+	policyPath := "/licenses/" + uintString(licenseID) + "/transfer-policy"
+
+	if status, _ := doJSONWithHeaders(t, router, http.MethodPost, policyPath, nil, map[string]interface{}{
+		"transfer_limit": 2,
+	}); status != http.StatusBadRequest {
+		t.Fatalf("transfer limit without window should be rejected, got %d", status)
+	}
+	doJSON(t, router, http.MethodPost, policyPath, map[string]interface{}{
+		"transfer_limit":          5,
+		"transfer_window_days":    30,
+		"rebind_cooldown_minutes": 60,
+	})
+
+	registerBody := func(device string) map[string]interface{} {
+		return map[string]interface{}{
+			"device_code":  device,
+			"license_key":  licenseData["license_key"],
+			"product_id":   productID,
+			"version_code": "1.0.0",
+		}
+	}
+	registered := doJSON(t, router, http.MethodPost, "/access/register", registerBody("transfer-api-a"))
+	doJSON(t, router, http.MethodDelete, "/node-bindings", map[string]interface{}{
+		"node_id":    registered.Data.(map[string]interface{})["node_id"],
+		"license_id": licenseID,
+	})
+
+	status, resp := doJSONWithHeaders(t, router, http.MethodPost, "/access/register", nil, registerBody("transfer-api-b"))
+	if status != http.StatusConflict || resp.Code != service.CodeRebindCooldown {
+		t.Fatalf("released seat should be cooling down, got %d %#v", status, resp)
+	}
+	policy := doJSON(t, router, http.MethodGet, policyPath, nil)
+	if data := policy.Data.(map[string]interface{}); data["cooling_seats"].(float64) != 1 || data["cooldown_ends_at"] == nil {
+		t.Fatalf("get transfer policy: %#v", policy)
+	}
+
+	doJSON(t, router, http.MethodPost, policyPath+"/reset", nil)
+	doJSON(t, router, http.MethodPost, "/access/register", registerBody("transfer-api-b"))
+}
@@ -2,6 +2,12 @@ package api
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
+
+	"nexus-core/domain/service"
+	"nexus-core/global"
+	"nexus-core/persistence/model"
 
 	"github.com/gin-gonic/gin"
 )
@@ -17,7 +23,7 @@ func CorsMiddleware() gin.HandlerFunc {
 	return func(context *gin.Context) {
 		method := context.Request.Method
 		context.Header("Access-Control-Allow-Origin", "*")
-		context.Header("Access-Control-Allow-Headers", "Content-Type,AccessToken,X-CSRF-Token, Authorization, Token, x-token")
+		context.Header("Access-Control-Allow-Headers", "Content-Type,AccessToken,X-CSRF-Token, Authorization, Token, x-token, X-API-Key, X-Node-Secret, X-Tenant, X-Customer-Key")
 		context.Header("Access-Control-Allow-Methods", "POST, GET, OPTIONS, DELETE, PATCH, PUT")
 		context.Header("Access-Control-Expose-Headers", "Content-Length, Access-Control-Allow-Origin, Access-Control-Allow-Headers, Content-Type")
 		context.Header("Access-Control-Allow-Credentials", "true")
@@ -27,3 +33,158 @@ func CorsMiddleware() gin.HandlerFunc {
 		context.Next()
 	}
 }
+
+// OperatorHeaderMiddleware 读取可信网关写入的操作人请求头并写入请求上下文
+// API Key 认证成功后会以认证身份覆盖该值
+func OperatorHeaderMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		header := global.GetConfig().AdminAuth.TrustedOperatorHeader
+		if header != "" {
+			if operator := strings.TrimSpace(ctx.GetHeader(header)); operator != "" {
+				ctx.Request = ctx.Request.WithContext(model.WithOperator(ctx.Request.Context(), operator))
+			}
+		}
+		ctx.Next()
+	}
+}
+
+// TenantHeader 租户编码请求头，开启管理认证后节点接口据此确定租户
+// WebSocket 客户端可以使用 tenant 查询参数
+const TenantHeader = "X-Tenant"
+
+var tenantService = service.NewTenantService()
+
+// TenantMiddleware 解析请求租户并写入请求上下文，未指定时为默认租户
+// 开启管理认证后，管理接口以操作员所属租户为准；未开启认证时忽略请求头，避免任意调用方切换租户
+func TenantMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		code := ""
+		if global.GetConfig().AdminAuth.Enabled {
+			code = strings.TrimSpace(ctx.GetHeader(TenantHeader))
+			if code == "" {
+				code = strings.TrimSpace(ctx.Query("tenant"))
+			}
+		}
+		tenantID, err := tenantService.ResolveTenant(ctx.Request.Context(), code)
+		if err != nil {
+			HandleError(ctx, err)
+			ctx.Abort()
+			return
+		}
+		ctx.Request = ctx.Request.WithContext(model.WithTenant(ctx.Request.Context(), tenantID))
+		ctx.Next()
+	}
+}
+
+// APIKeyHeader 管理接口 API Key 请求头，也可使用 Authorization: Bearer <key>
+const APIKeyHeader = "X-API-Key"
+
+const operatorContextKey = "operator"
+
+var operatorAuthService = service.NewOperatorService()
+
+// RequireOperator 管理路由组的权限中间件
+// 查询类请求（GET/HEAD）只需要 read 权限，其他请求需要 write 指定的权限
+func RequireOperator(write service.Permission) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		permission := write
+		if ctx.Request.Method == http.MethodGet || ctx.Request.Method == http.MethodHead {
+			permission = service.PermissionRead
+		}
+		authorize(ctx, permission)
+	}
+}
+
+// RequirePermission 不区分请求方法，要求操作员拥有指定权限
+// 用于有副作用的 GET 接口或整组仅限特定角色访问的路由
+func RequirePermission(permission service.Permission) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		authorize(ctx, permission)
+	}
+}
+
+func authorize(ctx *gin.Context, permission service.Permission) {
+	if !global.GetConfig().AdminAuth.Enabled {
+		ctx.Next()
+		return
+	}
+	identity := CurrentOperator(ctx)
+	if identity == nil {
+		var err error
+		identity, err = operatorAuthService.Authenticate(ctx.Request.Context(), apiKeyFromRequest(ctx))
+		if err != nil {
+			HandleError(ctx, err)
+			ctx.Abort()
+			return
+		}
+		ctx.Set(operatorContextKey, identity)
+		requestCtx := service.WithOperator(ctx.Request.Context(), identity)
+		ctx.Request = ctx.Request.WithContext(model.WithTenant(requestCtx, identity.TenantID))
+	}
+	if !identity.Can(permission) {
+		Forbidden(ctx, "permission denied: "+string(permission))
+		ctx.Abort()
+		return
+	}
+	ctx.Next()
+}
+
+// CustomerKeyHeader 客户门户密钥请求头，也可使用 Authorization: Bearer <key>
+const CustomerKeyHeader = "X-Customer-Key"
+
+const customerContextKey = "customer"
+
+var customerAuthService = service.NewCustomerService(service.NewNodeService())
+
+// RequireCustomer 客户门户认证中间件，与管理接口认证开关无关，始终要求门户密钥
+// 认证后以客户所属租户作为请求租户，操作人记为 customer:<id>
+func RequireCustomer() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		key := strings.TrimSpace(ctx.GetHeader(CustomerKeyHeader))
+		if key == "" {
+			key = apiKeyFromRequest(ctx)
+		}
+		identity, err := customerAuthService.Authenticate(ctx.Request.Context(), key)
+		if err != nil {
+			HandleError(ctx, err)
+			ctx.Abort()
+			return
+		}
+		ctx.Set(customerContextKey, identity)
+		requestCtx := model.WithTenant(ctx.Request.Context(), identity.TenantID)
+		requestCtx = model.WithOperator(requestCtx, "customer:"+strconv.FormatUint(uint64(identity.CustomerID), 10))
+		ctx.Request = ctx.Request.WithContext(requestCtx)
+		ctx.Next()
+	}
+}
+
+// CurrentCustomer 返回当前门户请求已认证的客户
+func CurrentCustomer(ctx *gin.Context) *service.CustomerIdentity {
+	value, ok := ctx.Get(customerContextKey)
+	if !ok {
+		return nil
+	}
+	identity, _ := value.(*service.CustomerIdentity)
+	return identity
+}
+
+// CurrentOperator 返回当前请求已认证的操作员，未开启认证时为 nil
+func CurrentOperator(ctx *gin.Context) *service.OperatorIdentity {
+	value, ok := ctx.Get(operatorContextKey)
+	if !ok {
+		return nil
+	}
+	identity, _ := value.(*service.OperatorIdentity)
+	return identity
+}
+
+func apiKeyFromRequest(ctx *gin.Context) string {
+	if key := strings.TrimSpace(ctx.GetHeader(APIKeyHeader)); key != "" {
+		return key
+	}
+	auth := strings.TrimSpace(ctx.GetHeader("Authorization"))
+	if len(auth) > 7 && strings.EqualFold(auth[:7], "Bearer ") {
+		return strings.TrimSpace(auth[7:])
+	}
+	return ""
+}
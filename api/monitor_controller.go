@@ -19,12 +19,12 @@ func NewMonitorController() *MonitorController {
 }
 
 func (c *MonitorController) RegisterRoutes(r *gin.Engine) {
-	monitorGroup := r.Group("/monitor")
+	monitorGroup := r.Group("/monitor", RequireOperator(service.PermissionRead))
 	{
 		monitorGroup.GET("/online", c.GetOnlineSummary)
 		monitorGroup.GET("/nodes/heartbeats", c.ListNodeHeartbeats)
 	}
-	r.GET("/audit-logs", c.ListAuditLogs)
+	r.GET("/audit-logs", RequireOperator(service.PermissionRead), c.ListAuditLogs)
 }
 
 // GetOnlineSummary 查询在线节点统计
@@ -77,6 +77,7 @@ func (c *MonitorController) ListNodeHeartbeats(ctx *gin.Context) {
 // @Param resource_type query string false "Resource Type"
 // @Param resource_id query uint false "Resource ID"
 // @Param action query string false "Action"
+// @Param operator query string false "Operator username"
 // @Param page query int false "Page"
 // @Param page_size query int false "Page Size"
 // @Param limit query int false "Limit"
@@ -101,6 +102,7 @@ func (c *MonitorController) ListAuditLogs(ctx *gin.Context) {
 		ResourceType: StringQuery(ctx, "resource_type"),
 		ResourceID:   resourceID,
 		Action:       StringQuery(ctx, "action"),
+		Operator:     StringQuery(ctx, "operator"),
 		Limit:        page.Limit,
 		Offset:       page.Offset,
 	})
@@ -21,7 +21,8 @@ func NewNodeController() *NodeController {
 // RegisterRoutes 注册节点相关的路由
 // 包括节点创建、查询、绑定等操作的路由
 func (c *NodeController) RegisterRoutes(r *gin.Engine) {
-	nodes := r.Group("/nodes")
+	admin := r.Group("", RequireOperator(service.PermissionNodeManage))
+	nodes := admin.Group("/nodes")
 	{
 		nodes.POST("", c.CreateNode)
 		nodes.GET("", c.ListNodes)
@@ -32,13 +33,15 @@ func (c *NodeController) RegisterRoutes(r *gin.Engine) {
 		nodes.POST("/:id/unban", c.UnbanNode)
 		nodes.POST("/:id/force-offline", c.ForceOfflineNode)
 		nodes.POST("/:id/restore-online", c.RestoreOnlineNode)
+		nodes.POST("/:id/credential/rotate", c.RotateCredential)
+		nodes.POST("/:id/credential/revoke", c.RevokeCredential)
 	}
-	r.GET("/node-devices/:device_code", c.GetByDeviceCode)
-	r.POST("/node-bindings", c.AddBinding)
-	r.DELETE("/node-bindings", c.Unbind)
-	r.DELETE("/node-cleanups/unbound", c.CleanUnboundNode)
+	admin.GET("/node-devices/:device_code", c.GetByDeviceCode)
+	admin.POST("/node-bindings", c.AddBinding)
+	admin.DELETE("/node-bindings", c.Unbind)
+	admin.DELETE("/node-cleanups/unbound", c.CleanUnboundNode)
 
-	g := r.Group("/node")
+	g := admin.Group("/node")
 	{
 		g.POST("/createNode", c.CreateNode)      // 创建节点
 		g.GET("/getByID", c.GetByID)             // 根据ID获取节点
@@ -220,6 +223,7 @@ func (c *NodeController) Unbind(ctx *gin.Context) {
 	if err := c.ns.UnbindByID(ctx.Request.Context(), service.UnbindCommand{
 		NodeID:    cmd.NodeID,
 		LicenseID: cmd.LicenseID,
+		ProductID: cmd.ProductID,
 	}); err != nil {
 		HandleError(ctx, err)
 		return
@@ -336,6 +340,55 @@ func (c *NodeController) RestoreOnlineNode(ctx *gin.Context) {
 	SuccessMsg(ctx, "node restored online")
 }
 
+// RotateCredential 重新签发节点密钥
+// @Summary Rotate node credential
+// @Tags nodes
+// @Accept json
+// @Produce json
+// @Param id path uint true "Node ID"
+// @Success 200 {object} service.NodeCredentialData
+// @Failure 400 {object} api.CommonResponse
+// @Failure 404 {object} api.CommonResponse
+// @Failure 500 {object} api.CommonResponse
+// @Router /nodes/{id}/credential/rotate [post]
+func (c *NodeController) RotateCredential(ctx *gin.Context) {
+	id, err := UintParamOrQuery(ctx, "id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	data, err := c.ns.RotateNodeCredential(ctx.Request.Context(), id)
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// RevokeCredential 吊销节点密钥，重新轮换前节点无法注册
+// @Summary Revoke node credential
+// @Tags nodes
+// @Accept json
+// @Produce json
+// @Param id path uint true "Node ID"
+// @Success 200 {object} api.CommonResponse
+// @Failure 400 {object} api.CommonResponse
+// @Failure 404 {object} api.CommonResponse
+// @Failure 500 {object} api.CommonResponse
+// @Router /nodes/{id}/credential/revoke [post]
+func (c *NodeController) RevokeCredential(ctx *gin.Context) {
+	id, err := UintParamOrQuery(ctx, "id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	if err := c.ns.RevokeNodeCredential(ctx.Request.Context(), id); err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	SuccessMsg(ctx, "node credential revoked")
+}
+
 func (c *NodeController) nodeStatusCommandFromParamOrBody(ctx *gin.Context) (dto.UpdateNodeStatusCommand, bool) {
 	var cmd dto.UpdateNodeStatusCommand
 	id, err := UintParamOrQuery(ctx, "id")
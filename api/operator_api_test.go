@@ -0,0 +1,194 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"nexus-core/domain/service"
+	"nexus-core/global"
+	"nexus-core/persistence/model"
+	"nexus-core/persistence/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAdminAuthRoleBasedAccessAPI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := setupControlAPITest(t)
+	cfg := global.GetConfig()
+	oldAuth := cfg.AdminAuth
+	cfg.AdminAuth.Enabled = true
+	t.Cleanup(func() { cfg.AdminAuth = oldAuth })
+
+	operatorService := service.NewOperatorService()
+	if err := operatorService.EnsureBootstrapOperator(ctx, "root-key"); err != nil {
+		t.Fatalf("bootstrap operator: %v", err)
+	}
+
+	router := NewServer()
+	NewProductController().RegisterRoutes(router)
+	NewLicenseController().RegisterRoutes(router)
+	NewNodeController().RegisterRoutes(router)
+	NewAccessController().RegisterRoutes(router)
+	NewOperatorController().RegisterRoutes(router)
+	NewMonitorController().RegisterRoutes(router)
+
+	keys := map[string]string{}
+	for _, role := range []string{service.RoleViewer, service.RoleLicenseAdmin, service.RoleNodeOperator} {
+		status, operator := doJSONWithKey(t, router, http.MethodPost, "/operators", "root-key", map[string]interface{}{
+			"username": role + "-user",
+			"role":     role,
+		})
+		if status != http.StatusOK {
+			t.Fatalf("create %s operator status=%d response=%#v", role, status, operator)
+		}
+		operatorID := uint(operator.Data.(map[string]interface{})["id"].(float64))
+		_, key := doJSONWithKey(t, router, http.MethodPost, "/operators/"+uintString(operatorID)+"/api-keys", "root-key", nil)
+		keys[role] = key.Data.(map[string]interface{})["key"].(string)
+	}
+
+	if status, _ := doJSONWithKey(t, router, http.MethodGet, "/products", "", nil); status != http.StatusUnauthorized {
+		t.Fatalf("missing api key should be unauthorized, got %d", status)
+	}
+	if status, _ := doJSONWithKey(t, router, http.MethodGet, "/products", "bad-key", nil); status != http.StatusUnauthorized {
+		t.Fatalf("invalid api key should be unauthorized, got %d", status)
+	}
+	if status, _ := doJSONWithKey(t, router, http.MethodGet, "/products", keys[service.RoleViewer], nil); status != http.StatusOK {
+		t.Fatalf("viewer should list products, got %d", status)
+	}
+	productBody := map[string]interface{}{"name": "rbac-product"}
+	if status, _ := doJSONWithKey(t, router, http.MethodPost, "/products", keys[service.RoleViewer], productBody); status != http.StatusForbidden {
+		t.Fatalf("viewer should not create products, got %d", status)
+	}
+	if status, _ := doJSONWithKey(t, router, http.MethodPost, "/products", keys[service.RoleNodeOperator], productBody); status != http.StatusForbidden {
+		t.Fatalf("node operator should not create products, got %d", status)
+	}
+	status, product := doJSONWithKey(t, router, http.MethodPost, "/products", keys[service.RoleLicenseAdmin], productBody)
+	if status != http.StatusOK {
+		t.Fatalf("license admin should create products, got %d %#v", status, product)
+	}
+	productID := uint(product.Data.(map[string]interface{})["id"].(float64))
+	doJSONWithKey(t, router, http.MethodPost, "/products/versions", keys[service.RoleLicenseAdmin], map[string]interface{}{
+		"product_id":   productID,
+		"version_code": "1.0.0",
+	})
+	_, license := doJSONWithKey(t, router, http.MethodPost, "/licenses", keys[service.RoleLicenseAdmin], map[string]interface{}{
+		"product_id":     productID,
+		"validity_hours": 24,
+		"max_nodes":      1,
+	})
+	licenseKey := license.Data.(map[string]interface{})["license_key"].(string)
+
+	// 节点接口不需要 API Key
+	status, register := doJSONWithKey(t, router, http.MethodPost, "/access/register", "", map[string]interface{}{
+		"device_code":  "rbac-node",
+		"license_key":  licenseKey,
+		"product_id":   productID,
+		"version_code": "1.0.0",
+	})
+	if status != http.StatusOK {
+		t.Fatalf("node register should not require api key, got %d %#v", status, register)
+	}
+	nodeID := uint(register.Data.(map[string]interface{})["node_id"].(float64))
+
+	banPath := "/nodes/" + uintString(nodeID) + "/ban"
+	if status, _ := doJSONWithKey(t, router, http.MethodPost, banPath, keys[service.RoleLicenseAdmin], nil); status != http.StatusForbidden {
+		t.Fatalf("license admin should not ban nodes, got %d", status)
+	}
+	if status, _ := doJSONWithKey(t, router, http.MethodPost, banPath, keys[service.RoleNodeOperator], nil); status != http.StatusOK {
+		t.Fatalf("node operator should ban nodes, got %d", status)
+	}
+	if status, _ := doJSONWithKey(t, router, http.MethodGet, "/operators", keys[service.RoleLicenseAdmin], nil); status != http.StatusForbidden {
+		t.Fatalf("license admin should not list operators, got %d", status)
+	}
+	status, profile := doJSONWithKey(t, router, http.MethodGet, "/operator-profile", keys[service.RoleNodeOperator], nil)
+	if status != http.StatusOK || profile.Data.(map[string]interface{})["role"] != service.RoleNodeOperator {
+		t.Fatalf("operator profile mismatch: %d %#v", status, profile)
+	}
+
+	var storedLicense model.License
+	if err := global.DB.Where("key_digest = ?", repository.LicenseKeyDigest(licenseKey)).First(&storedLicense).Error; err != nil {
+		t.Fatalf("get license: %v", err)
+	}
+	if storedLicense.CreatedBy != "license-admin-user" {
+		t.Fatalf("license created_by = %q, want license-admin-user", storedLicense.CreatedBy)
+	}
+	_, logs := doJSONWithKey(t, router, http.MethodGet, "/audit-logs?operator=node-operator-user", keys[service.RoleViewer], nil)
+	rows := logs.Data.([]interface{})
+	if len(rows) != 1 {
+		t.Fatalf("node operator should have one audit log, got %#v", rows)
+	}
+	if row := rows[0].(map[string]interface{}); row["action"] != "ban" || row["operator"] != "node-operator-user" {
+		t.Fatalf("node operator audit log mismatch: %#v", row)
+	}
+}
+
+func TestTrustedOperatorHeaderAPI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupControlAPITest(t)
+	cfg := global.GetConfig()
+	oldAuth := cfg.AdminAuth
+	cfg.AdminAuth.TrustedOperatorHeader = "X-Operator"
+	t.Cleanup(func() { cfg.AdminAuth = oldAuth })
+
+	router := NewServer()
+	NewProductController().RegisterRoutes(router)
+	NewMonitorController().RegisterRoutes(router)
+
+	req := httptest.NewRequest(http.MethodPost, "/products", bytes.NewReader([]byte(`{"name":"header-product"}`)))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Operator", "gateway-user")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	var product model.Product
+	if err := global.DB.Where("name = ?", "header-product").First(&product).Error; err != nil {
+		t.Fatalf("get product: %v", err)
+	}
+	if product.CreatedBy != "gateway-user" {
+		t.Fatalf("product created_by = %q, want gateway-user", product.CreatedBy)
+	}
+	logs := doJSON(t, router, http.MethodGet, "/audit-logs?operator=gateway-user", nil)
+	if rows := logs.Data.([]interface{}); len(rows) != 1 {
+		t.Fatalf("gateway user should have one audit log, got %#v", rows)
+	}
+}
+
+func doJSONWithKey(t *testing.T, router http.Handler, method string, path string, apiKey string, payload interface{}) (int, CommonResponse) {
+	t.Helper()
+	headers := map[string]string{}
+	if apiKey != "" {
+		headers["Authorization"] = "Bearer " + apiKey
+	}
+	return doJSONWithHeaders(t, router, method, path, headers, payload)
+}
+
+func doJSONWithHeaders(t *testing.T, router http.Handler, method string, path string, headers map[string]string, payload interface{}) (int, CommonResponse) {
+	t.Helper()
+
+	var body []byte
+	if payload != nil {
+		var err error
+		body, err = json.Marshal(payload)
+		if err != nil {
+			t.Fatalf("marshal payload: %v", err)
+		}
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(body))
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	var response CommonResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("decode response %s %s status %d body %s: %v", method, path, recorder.Code, recorder.Body.String(), err)
+	}
+	return recorder.Code, response
+}
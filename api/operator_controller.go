@@ -0,0 +1,223 @@
+package api
+
+import (
+	"nexus-core/api/dto"
+	"nexus-core/domain/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OperatorController 管理操作员账号与 API Key
+type OperatorController struct {
+	os *service.OperatorService
+}
+
+func NewOperatorController() *OperatorController {
+	return &OperatorController{
+		os: service.NewOperatorService(),
+	}
+}
+
+// RegisterRoutes 注册操作员相关路由，除查询当前身份外仅超级管理员可访问
+func (c *OperatorController) RegisterRoutes(r *gin.Engine) {
+	r.GET("/operator-profile", RequireOperator(service.PermissionRead), c.Me)
+
+	admin := r.Group("", RequirePermission(service.PermissionOperatorManage))
+	operators := admin.Group("/operators")
+	{
+		operators.POST("", c.CreateOperator)
+		operators.GET("", c.ListOperators)
+		operators.PATCH("/:id", c.UpdateOperator)
+		operators.POST("/:id/api-keys", c.CreateAPIKey)
+		operators.GET("/:id/api-keys", c.ListAPIKeys)
+	}
+	admin.POST("/operator-api-keys/:id/revoke", c.RevokeAPIKey)
+}
+
+// Me 查询当前请求的操作员身份
+// @Summary Get current operator
+// @Tags operators
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} service.OperatorIdentity
+// @Failure 400 {object} api.CommonResponse
+// @Failure 401 {object} api.CommonResponse
+// @Router /operator-profile [get]
+func (c *OperatorController) Me(ctx *gin.Context) {
+	identity := CurrentOperator(ctx)
+	if identity == nil {
+		BadRequest(ctx, "admin auth is disabled")
+		return
+	}
+	Success(ctx, identity)
+}
+
+// CreateOperator 创建操作员
+// @Summary Create operator
+// @Tags operators
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param body body dto.CreateOperatorCommand true "Create Operator"
+// @Success 200 {object} service.OperatorData
+// @Failure 400 {object} api.CommonResponse
+// @Failure 401 {object} api.CommonResponse
+// @Failure 403 {object} api.CommonResponse
+// @Failure 409 {object} api.CommonResponse
+// @Router /operators [post]
+func (c *OperatorController) CreateOperator(ctx *gin.Context) {
+	var cmd dto.CreateOperatorCommand
+	if err := ctx.ShouldBindJSON(&cmd); err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	data, err := c.os.CreateOperator(ctx.Request.Context(), service.CreateOperatorCommand{
+		TenantID:    cmd.TenantID,
+		Username:    cmd.Username,
+		DisplayName: cmd.DisplayName,
+		Role:        cmd.Role,
+	})
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// ListOperators 查询操作员列表
+// @Summary List operators
+// @Tags operators
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} api.CommonResponse
+// @Failure 401 {object} api.CommonResponse
+// @Failure 403 {object} api.CommonResponse
+// @Router /operators [get]
+func (c *OperatorController) ListOperators(ctx *gin.Context) {
+	data, err := c.os.ListOperators(ctx.Request.Context())
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// UpdateOperator 修改操作员角色、状态或显示名
+// @Summary Update operator
+// @Tags operators
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path uint true "Operator ID"
+// @Param body body dto.UpdateOperatorCommand true "Update Operator"
+// @Success 200 {object} service.OperatorData
+// @Failure 400 {object} api.CommonResponse
+// @Failure 404 {object} api.CommonResponse
+// @Failure 409 {object} api.CommonResponse
+// @Router /operators/{id} [patch]
+func (c *OperatorController) UpdateOperator(ctx *gin.Context) {
+	id, err := UintParamOrQuery(ctx, "id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	var cmd dto.UpdateOperatorCommand
+	if err := ctx.ShouldBindJSON(&cmd); err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	data, err := c.os.UpdateOperator(ctx.Request.Context(), service.UpdateOperatorCommand{
+		ID:          id,
+		DisplayName: cmd.DisplayName,
+		Role:        cmd.Role,
+		Status:      cmd.Status,
+	})
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// CreateAPIKey 为操作员签发 API Key，明文只返回一次
+// @Summary Create operator API key
+// @Tags operators
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path uint true "Operator ID"
+// @Param body body dto.CreateOperatorAPIKeyCommand false "Create API Key"
+// @Success 200 {object} service.OperatorAPIKeyData
+// @Failure 400 {object} api.CommonResponse
+// @Failure 404 {object} api.CommonResponse
+// @Router /operators/{id}/api-keys [post]
+func (c *OperatorController) CreateAPIKey(ctx *gin.Context) {
+	id, err := UintParamOrQuery(ctx, "id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	var cmd dto.CreateOperatorAPIKeyCommand
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&cmd); err != nil {
+			BadRequest(ctx, err.Error())
+			return
+		}
+	}
+	data, err := c.os.CreateAPIKey(ctx.Request.Context(), service.CreateOperatorAPIKeyCommand{
+		OperatorID: id,
+		Name:       cmd.Name,
+		ExpiresAt:  cmd.ExpiresAt,
+	})
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// ListAPIKeys 查询操作员的 API Key，不包含明文
+// @Summary List operator API keys
+// @Tags operators
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path uint true "Operator ID"
+// @Success 200 {object} api.CommonResponse
+// @Failure 400 {object} api.CommonResponse
+// @Router /operators/{id}/api-keys [get]
+func (c *OperatorController) ListAPIKeys(ctx *gin.Context) {
+	id, err := UintParamOrQuery(ctx, "id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	data, err := c.os.ListAPIKeys(ctx.Request.Context(), id)
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// RevokeAPIKey 吊销 API Key
+// @Summary Revoke operator API key
+// @Tags operators
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path uint true "API Key ID"
+// @Success 200 {object} api.CommonResponse
+// @Failure 400 {object} api.CommonResponse
+// @Failure 404 {object} api.CommonResponse
+// @Router /operator-api-keys/{id}/revoke [post]
+func (c *OperatorController) RevokeAPIKey(ctx *gin.Context) {
+	id, err := UintParamOrQuery(ctx, "id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	if err := c.os.RevokeAPIKey(ctx.Request.Context(), id); err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	SuccessMsg(ctx, "api key revoked")
+}
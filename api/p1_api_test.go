@@ -53,12 +53,33 @@ func TestP1AccessLicenseNodeAndMonitorAPI(t *testing.T) {
 	if nodeID == 0 {
 		t.Fatalf("register should return node id: %#v", registerData)
 	}
+	nodeSecret, _ := registerData["node_secret"].(string)
+	if nodeSecret == "" {
+		t.Fatalf("register should issue node secret: %#v", registerData)
+	}
+
+	status, response, err := doJSONRaw(router, http.MethodPost, "/access/heartbeat", map[string]interface{}{
+		"device_code":  "p1-api-node",
+		"license_key":  licenseKey,
+		"product_id":   productID,
+		"version_code": "1.0.0",
+	})
+	if err != nil || status != http.StatusForbidden || response.Code != CodeForbidden {
+		t.Fatalf("heartbeat without node secret should be rejected, status=%d response=%#v err=%v", status, response, err)
+	}
+
+	rotated := doJSON(t, router, http.MethodPost, "/nodes/"+uintString(nodeID)+"/credential/rotate", nil)
+	rotatedSecret, _ := rotated.Data.(map[string]interface{})["node_secret"].(string)
+	if rotatedSecret == "" || rotatedSecret == nodeSecret {
+		t.Fatalf("rotate should issue a new node secret: %#v", rotated.Data)
+	}
 
 	heartbeat := doJSON(t, router, http.MethodPost, "/access/heartbeat", map[string]interface{}{
 		"device_code":  "p1-api-node",
 		"license_key":  licenseKey,
 		"product_id":   productID,
 		"version_code": "1.0.0",
+		"node_secret":  rotatedSecret,
 	})
 	heartbeatData := heartbeat.Data.(map[string]interface{})
 	if heartbeatData["online"] != true {
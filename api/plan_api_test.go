@@ -0,0 +1,60 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestPlanAPI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := setupControlAPITest(t)
+	_, productID, _ := seedControlAPITarget(t, ctx)
+
+	router := NewServer()
+	NewLicenseController().RegisterRoutes(router)
+	NewPlanController().RegisterRoutes(router)
+
+	created := doJSON(t, router, http.MethodPost, "/plans", map[string]interface{}{
+		"product_id":     productID,
+		"name":           "standard",
+		"validity_hours": 720,
+		"max_nodes":      3,
+	})
+	if created.Code != CodeOK {
+		t.Fatalf("create plan: %#v", created)
+	}
+	planID := uint(created.Data.(map[string]interface{})["id"].(float64))
+
+	license := doJSON(t, router, http.MethodPost, "/licenses", map[string]interface{}{
+		"product_id": productID,
+		"plan_id":    planID,
+	})
+	if license.Code != CodeOK {
+		t.Fatalf("create license from plan: %#v", license)
+	}
+	data := license.Data.(map[string]interface{})
+	if data["validity_hours"].(float64) != 720 || data["max_nodes"].(float64) != 3 {
+		t.Fatalf("license should use plan defaults: %#v", data)
+	}
+
+	if status, _ := doJSONWithHeaders(t, router, http.MethodPost, "/licenses", nil, map[string]interface{}{"product_id": productID}); status != http.StatusBadRequest {
+		t.Fatalf("license without plan or validity should be rejected, got %d", status)
+	}
+
+	updated := doJSON(t, router, http.MethodPatch, "/plans/"+uintString(planID), map[string]interface{}{"max_nodes": 5})
+	if updated.Code != CodeOK || updated.Data.(map[string]interface{})["max_nodes"].(float64) != 5 {
+		t.Fatalf("update plan: %#v", updated)
+	}
+	licenseID := uint(data["id"].(float64))
+	changed := doJSON(t, router, http.MethodPost, "/licenses/"+uintString(licenseID)+"/plan", map[string]interface{}{"plan_id": planID})
+	if changed.Code != CodeOK || changed.Data.(map[string]interface{})["max_nodes"].(float64) != 5 {
+		t.Fatalf("change plan: %#v", changed)
+	}
+
+	list := doJSON(t, router, http.MethodGet, "/plans?product_id="+uintString(productID), nil)
+	if list.Code != CodeOK || len(list.Data.([]interface{})) != 1 {
+		t.Fatalf("list plans: %#v", list)
+	}
+}
@@ -0,0 +1,168 @@
+package api
+
+import (
+	"nexus-core/api/dto"
+	"nexus-core/domain/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PlanController 管理订阅套餐
+type PlanController struct {
+	ps *service.PlanService
+}
+
+func NewPlanController() *PlanController {
+	return &PlanController{
+		ps: service.NewPlanService(),
+	}
+}
+
+func (c *PlanController) RegisterRoutes(r *gin.Engine) {
+	plans := r.Group("/plans", RequireOperator(service.PermissionLicenseManage))
+	{
+		plans.POST("", c.CreatePlan)
+		plans.GET("", c.ListPlans)
+		plans.GET("/:id", c.GetPlan)
+		plans.PATCH("/:id", c.UpdatePlan)
+	}
+}
+
+// CreatePlan 创建套餐
+// @Summary Create plan
+// @Tags plans
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param body body dto.CreatePlanCommand true "Create Plan"
+// @Success 200 {object} service.PlanData
+// @Failure 400 {object} api.CommonResponse
+// @Failure 404 {object} api.CommonResponse
+// @Failure 409 {object} api.CommonResponse
+// @Router /plans [post]
+func (c *PlanController) CreatePlan(ctx *gin.Context) {
+	var cmd dto.CreatePlanCommand
+	if err := ctx.ShouldBindJSON(&cmd); err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	data, err := c.ps.CreatePlan(ctx.Request.Context(), service.CreatePlanCommand{
+		ProductID:     cmd.ProductID,
+		Name:          cmd.Name,
+		Description:   cmd.Description,
+		ValidityHours: cmd.ValidityHours,
+		MaxNodes:      cmd.MaxNodes,
+		MaxConcurrent: cmd.MaxConcurrent,
+		Services:      cmd.Services,
+	})
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// ListPlans 查询套餐列表
+// @Summary List plans
+// @Tags plans
+// @Produce json
+// @Security ApiKeyAuth
+// @Param product_id query uint false "Product ID"
+// @Param status query int false "Status"
+// @Param page query int false "Page"
+// @Param page_size query int false "Page Size"
+// @Success 200 {object} api.CommonResponse
+// @Failure 400 {object} api.CommonResponse
+// @Router /plans [get]
+func (c *PlanController) ListPlans(ctx *gin.Context) {
+	page, err := PaginationQuery(ctx)
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	productID, err := UintQuery(ctx, "product_id")
+	if err != nil {
+		BadRequest(ctx, "invalid product_id")
+		return
+	}
+	status, err := IntQueryPtr(ctx, "status")
+	if err != nil {
+		BadRequest(ctx, "invalid status")
+		return
+	}
+	data, err := c.ps.ListPlans(ctx.Request.Context(), service.ListPlansCommand{
+		ProductID: productID,
+		Status:    status,
+		Limit:     page.Limit,
+		Offset:    page.Offset,
+	})
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// GetPlan 查询套餐详情
+// @Summary Get plan
+// @Tags plans
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path uint true "Plan ID"
+// @Success 200 {object} service.PlanData
+// @Failure 404 {object} api.CommonResponse
+// @Router /plans/{id} [get]
+func (c *PlanController) GetPlan(ctx *gin.Context) {
+	id, err := UintParamOrQuery(ctx, "id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	data, err := c.ps.GetPlan(ctx.Request.Context(), id)
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// UpdatePlan 修改套餐，已创建的 License 不受影响
+// @Summary Update plan
+// @Tags plans
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path uint true "Plan ID"
+// @Param body body dto.UpdatePlanCommand true "Update Plan"
+// @Success 200 {object} service.PlanData
+// @Failure 400 {object} api.CommonResponse
+// @Failure 404 {object} api.CommonResponse
+// @Failure 409 {object} api.CommonResponse
+// @Router /plans/{id} [patch]
+func (c *PlanController) UpdatePlan(ctx *gin.Context) {
+	id, err := UintParamOrQuery(ctx, "id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	var cmd dto.UpdatePlanCommand
+	if err := ctx.ShouldBindJSON(&cmd); err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	data, err := c.ps.UpdatePlan(ctx.Request.Context(), service.UpdatePlanCommand{
+		ID:            id,
+		Name:          cmd.Name,
+		Description:   cmd.Description,
+		ValidityHours: cmd.ValidityHours,
+		MaxNodes:      cmd.MaxNodes,
+		MaxConcurrent: cmd.MaxConcurrent,
+		Services:      cmd.Services,
+		Status:        cmd.Status,
+	})
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
@@ -0,0 +1,175 @@
+package api
+
+import (
+	"nexus-core/api/dto"
+	"nexus-core/domain/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PortalController 客户自助门户，客户使用门户密钥访问，只能看到自己的 License
+type PortalController struct {
+	cs *service.CustomerService
+}
+
+func NewPortalController() *PortalController {
+	return &PortalController{
+		cs: service.NewCustomerService(service.NewNodeService()),
+	}
+}
+
+func (c *PortalController) RegisterRoutes(r *gin.Engine) {
+	portal := r.Group("/portal", RequireCustomer())
+	{
+		portal.GET("/profile", c.Profile)
+		portal.GET("/licenses", c.ListLicenses)
+		portal.POST("/licenses/:id/unbind", c.Unbind)
+		portal.GET("/licenses/:id/binding-requests", c.ListBindingRequests)
+		portal.POST("/licenses/:id/binding-requests/:binding_id/approve", c.ApproveBinding)
+		portal.POST("/licenses/:id/binding-requests/:binding_id/reject", c.RejectBinding)
+	}
+}
+
+// Profile 查询当前客户信息
+// @Summary Get portal customer profile
+// @Tags portal
+// @Produce json
+// @Param X-Customer-Key header string true "Customer portal key"
+// @Success 200 {object} service.CustomerData
+// @Failure 401 {object} api.CommonResponse
+// @Router /portal/profile [get]
+func (c *PortalController) Profile(ctx *gin.Context) {
+	data, err := c.cs.GetCustomer(ctx.Request.Context(), CurrentCustomer(ctx).CustomerID)
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// ListLicenses 查询客户的 License 及已绑定节点
+// @Summary List portal licenses with bound nodes
+// @Tags portal
+// @Produce json
+// @Param X-Customer-Key header string true "Customer portal key"
+// @Success 200 {object} api.CommonResponse
+// @Failure 401 {object} api.CommonResponse
+// @Router /portal/licenses [get]
+func (c *PortalController) ListLicenses(ctx *gin.Context) {
+	data, err := c.cs.ListCustomerLicenses(ctx.Request.Context(), CurrentCustomer(ctx).CustomerID)
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// Unbind 客户自助解绑丢失的设备
+// @Summary Unbind a device from own license
+// @Tags portal
+// @Accept json
+// @Produce json
+// @Param X-Customer-Key header string true "Customer portal key"
+// @Param id path uint true "License ID"
+// @Param body body dto.PortalUnbindCommand true "Unbind"
+// @Success 200 {object} api.CommonResponse
+// @Failure 401 {object} api.CommonResponse
+// @Failure 404 {object} api.CommonResponse
+// @Router /portal/licenses/{id}/unbind [post]
+func (c *PortalController) Unbind(ctx *gin.Context) {
+	licenseID, err := UintParamOrQuery(ctx, "id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	var cmd dto.PortalUnbindCommand
+	if err := ctx.ShouldBindJSON(&cmd); err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	if err := c.cs.UnbindCustomerNode(ctx.Request.Context(), service.CustomerUnbindCommand{
+		CustomerID: CurrentCustomer(ctx).CustomerID,
+		LicenseID:  licenseID,
+		NodeID:     cmd.NodeID,
+	}); err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, "unbind success")
+}
+
+// ListBindingRequests 客户查询自己 License 的设备绑定申请
+// @Summary List device binding requests of own license
+// @Tags portal
+// @Produce json
+// @Param X-Customer-Key header string true "Customer portal key"
+// @Param id path uint true "License ID"
+// @Success 200 {array} service.BindingRequestData
+// @Failure 401 {object} api.CommonResponse
+// @Failure 404 {object} api.CommonResponse
+// @Router /portal/licenses/{id}/binding-requests [get]
+func (c *PortalController) ListBindingRequests(ctx *gin.Context) {
+	licenseID, err := UintParamOrQuery(ctx, "id")
+	if err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	data, err := c.cs.ListCustomerBindingRequests(ctx.Request.Context(), CurrentCustomer(ctx).CustomerID, licenseID)
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// ApproveBinding 客户审批通过自己 License 的设备绑定申请
+// @Summary Approve a device binding request of own license
+// @Tags portal
+// @Produce json
+// @Param X-Customer-Key header string true "Customer portal key"
+// @Param id path uint true "License ID"
+// @Param binding_id path uint true "Binding ID"
+// @Success 200 {object} service.BindingRequestData
+// @Failure 401 {object} api.CommonResponse
+// @Failure 404 {object} api.CommonResponse
+// @Failure 409 {object} api.CommonResponse
+// @Router /portal/licenses/{id}/binding-requests/{binding_id}/approve [post]
+func (c *PortalController) ApproveBinding(ctx *gin.Context) {
+	cmd, ok := bindingDecisionCommand(ctx)
+	if !ok {
+		return
+	}
+	data, err := c.cs.ApproveCustomerBinding(ctx.Request.Context(), CurrentCustomer(ctx).CustomerID, cmd)
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// RejectBinding 客户拒绝自己 License 的设备绑定申请
+// @Summary Reject a device binding request of own license
+// @Tags portal
+// @Accept json
+// @Produce json
+// @Param X-Customer-Key header string true "Customer portal key"
+// @Param id path uint true "License ID"
+// @Param binding_id path uint true "Binding ID"
+// @Param body body dto.RejectBindingCommand false "Reject Reason"
+// @Success 200 {object} service.BindingRequestData
+// @Failure 401 {object} api.CommonResponse
+// @Failure 404 {object} api.CommonResponse
+// @Failure 409 {object} api.CommonResponse
+// @Router /portal/licenses/{id}/binding-requests/{binding_id}/reject [post]
+func (c *PortalController) RejectBinding(ctx *gin.Context) {
+	cmd, ok := bindingDecisionCommand(ctx)
+	if !ok {
+		return
+	}
+	data, err := c.cs.RejectCustomerBinding(ctx.Request.Context(), CurrentCustomer(ctx).CustomerID, cmd)
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
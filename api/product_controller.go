@@ -3,6 +3,7 @@ package api
 import (
 	"nexus-core/api/dto"
 	"nexus-core/domain/service"
+	"nexus-core/licensekey"
 
 	"github.com/gin-gonic/gin"
 )
@@ -23,7 +24,8 @@ func NewProductController() *ProductController {
 // RegisterRoutes 注册产品相关的路由
 // 包括产品创建、查询、版本控制等操作的路由
 func (c *ProductController) RegisterRoutes(r *gin.Engine) {
-	products := r.Group("/products")
+	admin := r.Group("", RequireOperator(service.PermissionLicenseManage))
+	products := admin.Group("/products")
 	{
 		products.POST("", c.CreateProduct)
 		products.GET("", c.ListProducts)
@@ -37,7 +39,7 @@ func (c *ProductController) RegisterRoutes(r *gin.Engine) {
 		products.POST("/min-supported-version", c.SetMinVersion)
 	}
 
-	g := r.Group("/product")
+	g := admin.Group("/product")
 	{
 		g.POST("/createProduct", c.CreateProduct)               // 创建产品
 		g.POST("/createProductVersion", c.CreateProductVersion) // 创建产品版本
@@ -105,8 +107,12 @@ func (c *ProductController) CreateProduct(ctx *gin.Context) {
 		return
 	}
 	p, err := c.ps.CreateProduct(ctx.Request.Context(), service.CreateProductCommand{
-		Name:        cmd.Name,
-		Description: cmd.Description,
+		Name:               cmd.Name,
+		Description:        cmd.Description,
+		GracePeriodHours:   cmd.GracePeriodHours,
+		GraceAllowControl:  cmd.GraceAllowControl,
+		InactiveUnbindDays: cmd.InactiveUnbindDays,
+		KeyFormat:          toLicenseKeyFormat(cmd.KeyFormat),
 	})
 	if err != nil {
 		HandleError(ctx, err)
@@ -331,9 +337,13 @@ func (c *ProductController) UpdateProduct(ctx *gin.Context) {
 	}
 
 	data, err := c.ps.UpdateProduct(ctx.Request.Context(), service.UpdateProductCommand{
-		ID:          cmd.ID,
-		Name:        cmd.Name,
-		Description: cmd.Description,
+		ID:                 cmd.ID,
+		Name:               cmd.Name,
+		Description:        cmd.Description,
+		GracePeriodHours:   cmd.GracePeriodHours,
+		GraceAllowControl:  cmd.GraceAllowControl,
+		InactiveUnbindDays: cmd.InactiveUnbindDays,
+		KeyFormat:          toLicenseKeyFormat(cmd.KeyFormat),
 	})
 	if err != nil {
 		HandleError(ctx, err)
@@ -341,3 +351,15 @@ func (c *ProductController) UpdateProduct(ctx *gin.Context) {
 	}
 	Success(ctx, data)
 }
+
+func toLicenseKeyFormat(format *dto.LicenseKeyFormat) *licensekey.Format {
+	if format == nil {
+		return nil
+	}
+	return &licensekey.Format{
+		Prefix:     format.Prefix,
+		Groups:     format.Groups,
+		GroupSize:  format.GroupSize,
+		CheckDigit: format.CheckDigit,
+	}
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -98,3 +99,20 @@ func PaginationQuery(ctx *gin.Context) (PageQuery, error) {
 		Offset:   (page - 1) * pageSize,
 	}, nil
 }
+
+func BoolQuery(ctx *gin.Context, name string) (bool, error) {
+	value := ctx.Query(name)
+	if value == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
+
+// TimeQuery 解析 RFC3339 格式的时间参数，未传时返回零值
+func TimeQuery(ctx *gin.Context, name string) (time.Time, error) {
+	value := ctx.Query(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
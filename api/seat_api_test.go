@@ -0,0 +1,73 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestFloatingSeatAPI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := setupControlAPITest(t)
+	_, productID, _ := seedControlAPITarget(t, ctx)
+
+	router := NewServer()
+	NewLicenseController().RegisterRoutes(router)
+	NewAccessController().RegisterRoutes(router)
+
+	license := doJSON(t, router, http.MethodPost, "/licenses", map[string]interface{}{
+		"product_id":     productID,
+		"validity_hours": 24,
+		"max_concurrent": 1,
+	})
+	licenseData := license.Data.(map[string]interface{})
+	licenseID := uint(licenseData["id"].(float64))
+	licenseKey := licenseData["license_key"].(string)
+
+	secrets := map[string]string{}
+	for _, device := range []string{"seat-api-a", "seat-api-b"} {
+		register := doJSON(t, router, http.MethodPost, "/access/register", map[string]interface{}{
+			"device_code":  device,
+			"license_key":  licenseKey,
+			"product_id":   productID,
+			"version_code": "1.0.0",
+		})
+		secrets[device] = register.Data.(map[string]interface{})["node_secret"].(string)
+	}
+	seatBody := func(device string) map[string]interface{} {
+		return map[string]interface{}{
+			"device_code":  device,
+			"license_key":  licenseKey,
+			"product_id":   productID,
+			"version_code": "1.0.0",
+			"node_secret":  secrets[device],
+		}
+	}
+
+	granted := doJSON(t, router, http.MethodPost, "/access/checkout", seatBody("seat-api-a"))
+	if !granted.Data.(map[string]interface{})["granted"].(bool) {
+		t.Fatalf("first checkout should be granted: %#v", granted)
+	}
+	queued := doJSON(t, router, http.MethodPost, "/access/checkout", seatBody("seat-api-b"))
+	if data := queued.Data.(map[string]interface{}); data["granted"].(bool) || data["queue_position"].(float64) != 1 {
+		t.Fatalf("second checkout should be queued: %#v", queued)
+	}
+	doJSON(t, router, http.MethodPost, "/access/checkout/renew", seatBody("seat-api-a"))
+
+	seats := doJSON(t, router, http.MethodGet, "/licenses/"+uintString(licenseID)+"/seats", nil)
+	if data := seats.Data.(map[string]interface{}); len(data["seats"].([]interface{})) != 1 || len(data["queue"].([]interface{})) != 1 {
+		t.Fatalf("list seats: %#v", seats)
+	}
+
+	doJSON(t, router, http.MethodPost, "/access/checkin", seatBody("seat-api-a"))
+	promoted := doJSON(t, router, http.MethodPost, "/access/checkout", seatBody("seat-api-b"))
+	data := promoted.Data.(map[string]interface{})
+	if !data["granted"].(bool) {
+		t.Fatalf("queued node should get the released seat: %#v", promoted)
+	}
+	doJSON(t, router, http.MethodDelete, "/licenses/"+uintString(licenseID)+"/seats/"+uintString(uint(data["seat_id"].(float64))), nil)
+	if status, _ := doJSONWithHeaders(t, router, http.MethodPost, "/access/checkout/renew", nil, seatBody("seat-api-b")); status != http.StatusNotFound {
+		t.Fatalf("renewing a released seat should return 404, got %d", status)
+	}
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"nexus-core/domain/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SigningController 管理离线文件签名密钥
+type SigningController struct {
+	ss *service.SigningService
+}
+
+func NewSigningController() *SigningController {
+	return &SigningController{
+		ss: service.NewSigningService(),
+	}
+}
+
+func (c *SigningController) RegisterRoutes(r *gin.Engine) {
+	signingKeys := r.Group("/signing-keys", RequireOperator(service.PermissionOperatorManage))
+	{
+		signingKeys.GET("", c.ListKeys)
+		signingKeys.POST("/rotate", c.RotateKey)
+	}
+}
+
+// ListKeys 查询签名公钥列表
+// @Summary List signing public keys
+// @Description Public keys used by clients to verify license files offline
+// @Tags signing
+// @Accept json
+// @Produce json
+// @Success 200 {object} api.CommonResponse
+// @Failure 500 {object} api.CommonResponse
+// @Router /signing-keys [get]
+func (c *SigningController) ListKeys(ctx *gin.Context) {
+	data, err := c.ss.ListKeys(ctx.Request.Context())
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// RotateKey 轮换签名密钥
+// @Summary Rotate signing key
+// @Tags signing
+// @Accept json
+// @Produce json
+// @Success 200 {object} api.CommonResponse
+// @Failure 500 {object} api.CommonResponse
+// @Router /signing-keys/rotate [post]
+func (c *SigningController) RotateKey(ctx *gin.Context) {
+	data, err := c.ss.RotateKey(ctx.Request.Context())
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
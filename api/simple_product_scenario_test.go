@@ -19,6 +19,7 @@ type simpleProductScenario struct {
 	licenseID  uint
 	licenseKey string
 	nodes      map[string]uint
+	secrets    map[string]string
 }
 
 func newSimpleProductScenario(t *testing.T, maxNodes int, maxConcurrent int) *simpleProductScenario {
@@ -63,6 +64,7 @@ func newSimpleProductScenario(t *testing.T, maxNodes int, maxConcurrent int) *si
 		licenseID:  uint(licenseData["id"].(float64)),
 		licenseKey: licenseData["license_key"].(string),
 		nodes:      map[string]uint{},
+		secrets:    map[string]string{},
 	}
 }
 
@@ -73,9 +75,13 @@ func (s *simpleProductScenario) register(t *testing.T, deviceCode string) map[st
 		"license_key":  s.licenseKey,
 		"product_id":   s.productID,
 		"version_code": "1.0.0",
+		"node_secret":  s.secrets[deviceCode],
 	})
 	data := response.Data.(map[string]interface{})
 	s.nodes[deviceCode] = uint(data["node_id"].(float64))
+	if secret, ok := data["node_secret"].(string); ok {
+		s.secrets[deviceCode] = secret
+	}
 	return data
 }
 
@@ -85,6 +91,7 @@ func (s *simpleProductScenario) heartbeatRaw(deviceCode string) (int, CommonResp
 		"license_key":  s.licenseKey,
 		"product_id":   s.productID,
 		"version_code": "1.0.0",
+		"node_secret":  s.secrets[deviceCode],
 	})
 }
 
@@ -253,13 +260,7 @@ func TestForceOfflineRejectsHeartbeatAndRegisterRestoresNodeAPI(t *testing.T) {
 		t.Fatalf("heartbeat after force offline should be rejected, status=%d response=%#v", status, response)
 	}
 
-	restored := doJSON(t, scenario.router, http.MethodPost, "/access/register", map[string]interface{}{
-		"device_code":  deviceCode,
-		"license_key":  scenario.licenseKey,
-		"product_id":   scenario.productID,
-		"version_code": "1.0.0",
-	})
-	restoredData := restored.Data.(map[string]interface{})
+	restoredData := scenario.register(t, deviceCode)
 	if got := uint(restoredData["node_id"].(float64)); got != nodeID {
 		t.Fatalf("register should restore same node id = %d, want %d: %#v", got, nodeID, restoredData)
 	}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"nexus-core/domain/service"
+	"nexus-core/global"
+	"nexus-core/persistence/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTenantIsolationAPI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := setupControlAPITest(t)
+	cfg := global.GetConfig()
+	oldAuth := cfg.AdminAuth
+	cfg.AdminAuth.Enabled = true
+	t.Cleanup(func() { cfg.AdminAuth = oldAuth })
+
+	if err := service.NewOperatorService().EnsureBootstrapOperator(ctx, "root-key"); err != nil {
+		t.Fatalf("bootstrap operator: %v", err)
+	}
+
+	router := NewServer()
+	NewProductController().RegisterRoutes(router)
+	NewLicenseController().RegisterRoutes(router)
+	NewAccessController().RegisterRoutes(router)
+	NewOperatorController().RegisterRoutes(router)
+	NewTenantController().RegisterRoutes(router)
+
+	status, tenant := doJSONWithKey(t, router, http.MethodPost, "/tenants", "root-key", map[string]interface{}{
+		"code": "bu-a",
+		"name": "Business Unit A",
+	})
+	if status != http.StatusOK {
+		t.Fatalf("create tenant status=%d response=%#v", status, tenant)
+	}
+	tenantID := uint(tenant.Data.(map[string]interface{})["id"].(float64))
+
+	status, operator := doJSONWithKey(t, router, http.MethodPost, "/operators", "root-key", map[string]interface{}{
+		"tenant_id": tenantID,
+		"username":  "bu-a-admin",
+		"role":      service.RoleSuperAdmin,
+	})
+	if status != http.StatusOK {
+		t.Fatalf("create tenant operator status=%d response=%#v", status, operator)
+	}
+	operatorID := uint(operator.Data.(map[string]interface{})["id"].(float64))
+	_, key := doJSONWithKey(t, router, http.MethodPost, "/operators/"+uintString(operatorID)+"/api-keys", "root-key", nil)
+	tenantKey := key.Data.(map[string]interface{})["key"].(string)
+
+	if status, _ := doJSONWithKey(t, router, http.MethodPost, "/tenants", tenantKey, map[string]interface{}{"code": "bu-b"}); status != http.StatusForbidden {
+		t.Fatalf("tenant operator should not create tenants, got %d", status)
+	}
+
+	productBody := map[string]interface{}{"name": "shared-product"}
+	status, rootProduct := doJSONWithKey(t, router, http.MethodPost, "/products", "root-key", productBody)
+	if status != http.StatusOK {
+		t.Fatalf("root create product status=%d response=%#v", status, rootProduct)
+	}
+	rootProductID := uint(rootProduct.Data.(map[string]interface{})["id"].(float64))
+	status, tenantProduct := doJSONWithKey(t, router, http.MethodPost, "/products", tenantKey, productBody)
+	if status != http.StatusOK {
+		t.Fatalf("same product name should be allowed in another tenant, status=%d response=%#v", status, tenantProduct)
+	}
+	tenantProductID := uint(tenantProduct.Data.(map[string]interface{})["id"].(float64))
+
+	if status, _ := doJSONWithKey(t, router, http.MethodGet, "/products/"+uintString(rootProductID), tenantKey, nil); status != http.StatusNotFound {
+		t.Fatalf("tenant should not see default tenant product, got %d", status)
+	}
+	_, products := doJSONWithKey(t, router, http.MethodGet, "/products", tenantKey, nil)
+	if rows := products.Data.([]interface{}); len(rows) != 1 {
+		t.Fatalf("tenant should list only its own products, got %#v", rows)
+	}
+
+	doJSONWithKey(t, router, http.MethodPost, "/products/versions", tenantKey, map[string]interface{}{
+		"product_id":   tenantProductID,
+		"version_code": "1.0.0",
+	})
+	_, license := doJSONWithKey(t, router, http.MethodPost, "/licenses", tenantKey, map[string]interface{}{
+		"product_id":     tenantProductID,
+		"validity_hours": 24,
+		"max_nodes":      1,
+	})
+	licenseKey := license.Data.(map[string]interface{})["license_key"].(string)
+
+	registerBody := map[string]interface{}{
+		"device_code":  "tenant-node",
+		"license_key":  licenseKey,
+		"product_id":   tenantProductID,
+		"version_code": "1.0.0",
+	}
+	if status, _ := doJSONWithHeaders(t, router, http.MethodPost, "/access/register", nil, registerBody); status == http.StatusOK {
+		t.Fatal("register without tenant header should not find tenant license")
+	}
+	if status, _ := doJSONWithHeaders(t, router, http.MethodPost, "/access/register", map[string]string{TenantHeader: "missing"}, registerBody); status != http.StatusNotFound {
+		t.Fatalf("unknown tenant should be not found, got %d", status)
+	}
+	status, registered := doJSONWithHeaders(t, router, http.MethodPost, "/access/register", map[string]string{TenantHeader: "bu-a"}, registerBody)
+	if status != http.StatusOK {
+		t.Fatalf("register with tenant header status=%d response=%#v", status, registered)
+	}
+}
+
+func TestTenantHeaderIgnoredWithoutAdminAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := setupControlAPITest(t)
+	if _, err := service.NewTenantService().CreateTenant(ctx, service.CreateTenantCommand{Code: "bu-a", Name: "Business Unit A"}); err != nil {
+		t.Fatalf("create tenant: %v", err)
+	}
+
+	router := NewServer()
+	NewProductController().RegisterRoutes(router)
+
+	status, created := doJSONWithHeaders(t, router, http.MethodPost, "/products", map[string]string{TenantHeader: "bu-a"}, map[string]interface{}{"name": "header-product"})
+	if status != http.StatusOK {
+		t.Fatalf("create product status=%d response=%#v", status, created)
+	}
+	var product model.Product
+	if err := global.DB.First(&product, uint(created.Data.(map[string]interface{})["id"].(float64))).Error; err != nil {
+		t.Fatalf("load product: %v", err)
+	}
+	if product.TenantID != model.DefaultTenantID {
+		t.Fatalf("X-Tenant should be ignored without admin auth, got tenant %d", product.TenantID)
+	}
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"nexus-core/api/dto"
+	"nexus-core/domain/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TenantController 管理租户
+type TenantController struct {
+	ts *service.TenantService
+}
+
+func NewTenantController() *TenantController {
+	return &TenantController{
+		ts: service.NewTenantService(),
+	}
+}
+
+// RegisterRoutes 注册租户路由，仅超级管理员可访问，创建租户还要求属于默认租户
+func (c *TenantController) RegisterRoutes(r *gin.Engine) {
+	tenants := r.Group("/tenants", RequirePermission(service.PermissionOperatorManage))
+	{
+		tenants.POST("", c.CreateTenant)
+		tenants.GET("", c.ListTenants)
+	}
+}
+
+// CreateTenant 创建租户
+// @Summary Create tenant
+// @Tags tenants
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param body body dto.CreateTenantCommand true "Create Tenant"
+// @Success 200 {object} service.TenantData
+// @Failure 400 {object} api.CommonResponse
+// @Failure 403 {object} api.CommonResponse
+// @Failure 409 {object} api.CommonResponse
+// @Router /tenants [post]
+func (c *TenantController) CreateTenant(ctx *gin.Context) {
+	var cmd dto.CreateTenantCommand
+	if err := ctx.ShouldBindJSON(&cmd); err != nil {
+		BadRequest(ctx, err.Error())
+		return
+	}
+	data, err := c.ts.CreateTenant(ctx.Request.Context(), service.CreateTenantCommand{
+		Code: cmd.Code,
+		Name: cmd.Name,
+	})
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// ListTenants 查询租户列表，非默认租户只返回自身
+// @Summary List tenants
+// @Tags tenants
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} api.CommonResponse
+// @Failure 401 {object} api.CommonResponse
+// @Failure 403 {object} api.CommonResponse
+// @Router /tenants [get]
+func (c *TenantController) ListTenants(ctx *gin.Context) {
+	data, err := c.ts.ListTenants(ctx.Request.Context())
+	if err != nil {
+		HandleError(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
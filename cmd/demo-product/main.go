@@ -49,6 +49,7 @@ type registerResult struct {
 	MaxConcurrent      int    `json:"max_concurrent"`
 	HeartbeatInterval  int    `json:"heartbeat_interval"`
 	BindingEstablished bool   `json:"binding_established"`
+	NodeSecret         string `json:"node_secret"`
 }
 
 type heartbeatResult struct {
@@ -57,11 +58,13 @@ type heartbeatResult struct {
 
 type client struct {
 	baseURL string
+	apiKey  string // 管理接口 API Key，服务端开启管理认证时需要
 	http    *http.Client
 }
 
 func main() {
 	baseURL := flag.String("server", "http://localhost:8080", "nexus-core server base URL")
+	apiKey := flag.String("api-key", "", "operator API key for management APIs")
 	productName := flag.String("product-name", "", "demo product name; default creates a unique name")
 	versionCode := flag.String("version", "1.0.0", "demo product version")
 	deviceCode := flag.String("device", "demo-device-001", "demo node device code")
@@ -79,6 +82,7 @@ func main() {
 
 	c := client{
 		baseURL: strings.TrimRight(*baseURL, "/"),
+		apiKey:  *apiKey,
 		http: &http.Client{
 			Timeout: 10 * time.Second,
 		},
@@ -150,6 +154,7 @@ func (c client) run(productName, versionCode, deviceCode string, heartbeatCount
 			"license_key":  license.LicenseKey,
 			"product_id":   product.ID,
 			"version_code": versionCode,
+			"node_secret":  register.NodeSecret,
 		})
 		if err != nil {
 			return fmt.Errorf("heartbeat %d: %w", i, err)
@@ -176,6 +181,9 @@ func postJSON[T any](c client, path string, payload interface{}) (T, error) {
 		return zero, err
 	}
 	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
 
 	resp, err := c.http.Do(req)
 	if err != nil {
@@ -38,7 +38,8 @@ type licenseData struct {
 }
 
 type registerResult struct {
-	NodeID uint `json:"node_id"`
+	NodeID     uint   `json:"node_id"`
+	NodeSecret string `json:"node_secret"`
 }
 
 type controlServiceData struct {
@@ -63,17 +64,20 @@ type dispatchMessage struct {
 
 type client struct {
 	baseURL string
+	apiKey  string // 管理接口 API Key，服务端开启管理认证时需要
 	http    *http.Client
 }
 
 func main() {
 	baseURL := flag.String("server", "http://localhost:8080", "nexus-core server base URL")
+	apiKey := flag.String("api-key", "", "operator API key for management APIs")
 	deviceCode := flag.String("device", "protocol-demo-node-001", "demo node device code")
 	httpListen := flag.String("http-listen", "127.0.0.1:0", "local HTTP node listen address")
 	flag.Parse()
 
 	c := client{
 		baseURL: strings.TrimRight(*baseURL, "/"),
+		apiKey:  *apiKey,
 		http:    &http.Client{Timeout: 10 * time.Second},
 	}
 
@@ -134,25 +138,26 @@ func run(c client, deviceCode string, httpListen string) error {
 		"license_key":  license.LicenseKey,
 		"product_id":   product.ID,
 		"version_code": versionCode,
+		"node_secret":  register.NodeSecret,
 	}); err != nil {
 		return fmt.Errorf("heartbeat: %w", err)
 	}
 	fmt.Printf("node: id=%d device=%s\n", register.NodeID, deviceCode)
 
 	httpIdentifier := "demo_http_config_" + suffix
-	if err := runHTTPConversion(c, product.ID, register.NodeID, httpIdentifier, httpListen); err != nil {
+	if err := runHTTPConversion(c, product.ID, register.NodeID, register.NodeSecret, httpIdentifier, httpListen); err != nil {
 		return err
 	}
 
 	wsIdentifier := "demo_ws_config_" + suffix
-	if err := runWebSocketConversion(c, product.ID, register.NodeID, wsIdentifier); err != nil {
+	if err := runWebSocketConversion(c, product.ID, register.NodeID, register.NodeSecret, wsIdentifier); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func runHTTPConversion(c client, productID uint, nodeID uint, identifier string, listenAddr string) error {
+func runHTTPConversion(c client, productID uint, nodeID uint, nodeSecret string, identifier string, listenAddr string) error {
 	serviceDef, err := createControlService(c, productID, identifier, "HTTP Config Conversion")
 	if err != nil {
 		return err
@@ -168,6 +173,7 @@ func runHTTPConversion(c client, productID uint, nodeID uint, identifier string,
 
 	if _, err := postJSON[json.RawMessage](c, "/node-capabilities", map[string]interface{}{
 		"node_id":            nodeID,
+		"node_secret":        nodeSecret,
 		"service_identifier": serviceDef.Identifier,
 		"protocol":           "http",
 		"endpoint":           endpoint,
@@ -229,13 +235,14 @@ func runHTTPConversion(c client, productID uint, nodeID uint, identifier string,
 	return nil
 }
 
-func runWebSocketConversion(c client, productID uint, nodeID uint, identifier string) error {
+func runWebSocketConversion(c client, productID uint, nodeID uint, nodeSecret string, identifier string) error {
 	serviceDef, err := createControlService(c, productID, identifier, "WebSocket Config Conversion")
 	if err != nil {
 		return err
 	}
 	if _, err := postJSON[json.RawMessage](c, "/node-capabilities", map[string]interface{}{
 		"node_id":            nodeID,
+		"node_secret":        nodeSecret,
 		"service_identifier": serviceDef.Identifier,
 		"protocol":           "websocket",
 		"schema": map[string]interface{}{
@@ -254,7 +261,7 @@ func runWebSocketConversion(c client, productID uint, nodeID uint, identifier st
 	if err != nil {
 		return err
 	}
-	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, http.Header{"X-Node-Secret": []string{nodeSecret}})
 	if err != nil {
 		return fmt.Errorf("connect websocket node: %w", err)
 	}
@@ -466,6 +473,9 @@ func postJSON[T any](c client, path string, payload interface{}) (T, error) {
 		return zero, err
 	}
 	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
 	return doJSON[T](c, req)
 }
 
@@ -37,7 +37,8 @@ type licenseData struct {
 }
 
 type registerResult struct {
-	NodeID uint `json:"node_id"`
+	NodeID     uint   `json:"node_id"`
+	NodeSecret string `json:"node_secret"`
 }
 
 type controlServiceData struct {
@@ -68,11 +69,13 @@ type shellResult struct {
 
 type client struct {
 	baseURL string
+	apiKey  string // 管理接口 API Key，服务端开启管理认证时需要
 	http    *http.Client
 }
 
 func main() {
 	baseURL := flag.String("server", "http://localhost:8080", "nexus-core server base URL")
+	apiKey := flag.String("api-key", "", "operator API key for management APIs")
 	deviceCode := flag.String("device", "shell-demo-node-001", "demo node device code")
 	httpListen := flag.String("http-listen", "127.0.0.1:0", "local HTTP shell node listen address")
 	command := flag.String("command", "echo", "read-only command to test: echo, dir, pwd, whoami")
@@ -81,6 +84,7 @@ func main() {
 
 	c := client{
 		baseURL: strings.TrimRight(*baseURL, "/"),
+		apiKey:  *apiKey,
 		http:    &http.Client{Timeout: 10 * time.Second},
 	}
 
@@ -145,6 +149,7 @@ func run(c client, deviceCode string, httpListen string, testCommand string, tes
 		"license_key":  license.LicenseKey,
 		"product_id":   product.ID,
 		"version_code": versionCode,
+		"node_secret":  register.NodeSecret,
 	}); err != nil {
 		return fmt.Errorf("heartbeat: %w", err)
 	}
@@ -163,6 +168,7 @@ func run(c client, deviceCode string, httpListen string, testCommand string, tes
 	}
 	if _, err := postJSON[json.RawMessage](c, "/node-capabilities", map[string]interface{}{
 		"node_id":            register.NodeID,
+		"node_secret":        register.NodeSecret,
 		"service_identifier": serviceDef.Identifier,
 		"protocol":           "http",
 		"endpoint":           endpoint,
@@ -374,6 +380,9 @@ func postJSON[T any](c client, path string, payload interface{}) (T, error) {
 		return zero, err
 	}
 	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
 	return doJSON[T](c, req)
 }
 
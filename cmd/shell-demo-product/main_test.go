@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	"nexus-core/api"
+	"nexus-core/domain/service"
 	"nexus-core/global"
 	"nexus-core/monitor"
 	"nexus-core/persistence/base"
@@ -25,6 +26,7 @@ func TestShellDemoProductEndToEnd(t *testing.T) {
 
 	c := client{
 		baseURL: server.URL,
+		apiKey:  testAPIKey,
 		http:    server.Client(),
 	}
 
@@ -63,6 +65,7 @@ func TestShellDemoDirCommandThroughServer(t *testing.T) {
 
 	c := client{
 		baseURL: server.URL,
+		apiKey:  testAPIKey,
 		http:    server.Client(),
 	}
 
@@ -104,6 +107,7 @@ func TestShellDemoDirCommandThroughServer(t *testing.T) {
 		"license_key":  license.LicenseKey,
 		"product_id":   product.ID,
 		"version_code": "1.0.0",
+		"node_secret":  register.NodeSecret,
 	}); err != nil {
 		t.Fatalf("heartbeat: %v", err)
 	}
@@ -120,6 +124,7 @@ func TestShellDemoDirCommandThroughServer(t *testing.T) {
 	}
 	if _, err := postJSON[json.RawMessage](c, "/node-capabilities", map[string]interface{}{
 		"node_id":            register.NodeID,
+		"node_secret":        register.NodeSecret,
 		"service_identifier": serviceDef.Identifier,
 		"protocol":           "http",
 		"endpoint":           endpoint,
@@ -152,6 +157,9 @@ func TestShellDemoDirCommandThroughServer(t *testing.T) {
 	}
 }
 
+// testAPIKey 测试服务端开启管理认证时登记的初始 API Key
+const testAPIKey = "shell-demo-test-key"
+
 func startTestNexusServer(t *testing.T) *httptest.Server {
 	t.Helper()
 	gin.SetMode(gin.TestMode)
@@ -159,10 +167,14 @@ func startTestNexusServer(t *testing.T) *httptest.Server {
 	oldDB := global.DB
 	oldStat := monitor.GlobalStat
 	oldMonitor := monitor.GlobalMonitor
+	cfg := global.GetConfig()
+	oldAuth := cfg.AdminAuth
+	cfg.AdminAuth.Enabled = true
 	t.Cleanup(func() {
 		global.DB = oldDB
 		monitor.GlobalStat = oldStat
 		monitor.GlobalMonitor = oldMonitor
+		cfg.AdminAuth = oldAuth
 	})
 
 	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "shell-demo.db")), &gorm.Config{})
@@ -178,6 +190,12 @@ func startTestNexusServer(t *testing.T) *httptest.Server {
 
 	base.AutoMigrate(db)
 	global.DB = db
+	if err := service.NewSigningService().EnsureActiveKey(context.Background()); err != nil {
+		t.Fatalf("ensure signing key: %v", err)
+	}
+	if err := service.NewOperatorService().EnsureBootstrapOperator(context.Background(), testAPIKey); err != nil {
+		t.Fatalf("bootstrap operator: %v", err)
+	}
 	monitor.GlobalStat = monitor.NewOnlineStat()
 	monitor.GlobalMonitor = monitor.NewMonitor(monitor.GlobalStat)
 
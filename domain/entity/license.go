@@ -17,24 +17,84 @@ const (
 	StatusActive                        // 1 已激活
 	StatusExpired                       // 2 已过期
 	StatusRevoked                       // 3 已吊销
+	StatusGrace                         // 4 已过期但处于宽限期
+)
+
+// GracePolicy 过期后的宽限期策略
+type GracePolicy struct {
+	Hours        int  // 宽限时长（小时），0 表示无宽限期
+	AllowControl bool // 宽限期内是否允许下发控制命令
+}
+
+// TransferPolicy 限制节点换绑的策略，防止通过反复解绑换绑在多台设备间轮换使用同一 License
+type TransferPolicy struct {
+	Limit           int        // 统计窗口内最多绑定的不同设备数，0 表示不限制
+	WindowDays      int        // 统计窗口（天）
+	CooldownMinutes int        // 解绑释放的节点名额重新可用前的冷却时长（分钟），0 表示不冷却
+	ResetAt         *time.Time // 最近一次重置计数的时间，之前的绑定与解绑不再计入
+}
+
+// CountSince 返回统计换绑设备数的起始时间
+func (p TransferPolicy) CountSince(now time.Time) time.Time {
+	return p.laterThanReset(now.Add(-time.Duration(p.WindowDays) * 24 * time.Hour))
+}
+
+// CooldownSince 返回仍处于冷却期的解绑记录的最早解绑时间
+func (p TransferPolicy) CooldownSince(now time.Time) time.Time {
+	return p.laterThanReset(now.Add(-time.Duration(p.CooldownMinutes) * time.Minute))
+}
+
+func (p TransferPolicy) laterThanReset(t time.Time) time.Time {
+	if p.ResetAt != nil && p.ResetAt.After(t) {
+		return *p.ResetAt
+	}
+	return t
+}
+
+// LicenseType 许可证类型
+type LicenseType int
+
+const (
+	LicenseTypeStandard LicenseType = iota // 0 正式
+	LicenseTypeTrial                       // 1 试用
 )
 
 // License 表示许可证领域的核心实体
 // 包含许可证的基本信息、激活状态、有效期和授权范围
 type License struct {
-	ID               uint
-	ProductID        uint          // 产品id
-	LicenseKey       string        // 许可证密钥，用于客户端验证
-	ValidityHours    int           // 有效时长（小时），从激活时刻开始计算
-	IssuedAt         time.Time     // 颁发时间，许可证创建时设置
-	ActivatedAt      *time.Time    // 激活时间，首次激活时设置
-	ExpiredAt        *time.Time    // 过期时间，基于激活时间和有效时长计算
-	Status           LicenseStatus // 许可证状态
-	Remark           *string       // 备注信息
-	MaxNodes         int           // 最大节点数 (0 = 不限制)
-	CurrentNodeCount int           // 当前绑定数量
-	MaxConcurrent    int           // 并发限制 (0 = 不限制)
-	FeatureMask      string        // 功能模块掩码
+	ID                 uint
+	TenantID           uint           // 所属租户
+	ProductID          uint           // 产品id
+	CustomerID         *uint          // 所属客户
+	PlanID             *uint          // 所属套餐
+	Type               LicenseType    // 许可证类型
+	TrialMaxHours      int            // 试用许可证的最长有效时长（小时）
+	ConvertedAt        *time.Time     // 试用转正式的时间
+	GracePeriodHours   *int           // 单独设置的宽限期（小时），为空时使用产品设置
+	InactiveUnbindDays *int           // 单独设置的不活跃节点自动解绑天数，为空时使用产品设置
+	Grace              GracePolicy    // 生效的宽限期策略
+	Transfer           TransferPolicy // 换绑限制策略
+	ApprovalRequired   bool           // 新设备绑定需要审批
+	LicenseKey         string         // 客户端提交的注册码，只有按注册码查询时才有值
+	KeyDigest          string         // 注册码摘要，数据库不保存明文
+	KeyPrefix          string         // 注册码展示前缀
+	ValidityHours      int            // 有效时长（小时），从激活时刻开始计算
+	Perpetual          bool           // 永久授权，激活后不过期
+	VersionCutoffAt    *time.Time     // 只授权在此时间及之前发布的版本
+	MinVersionID       *uint          // 授权的最低版本
+	MaxVersionID       *uint          // 授权的最高版本
+	IssuedAt           time.Time      // 颁发时间，许可证创建时设置
+	ActivatedAt        *time.Time     // 激活时间，首次激活时设置
+	ExpiredAt          *time.Time     // 过期时间，基于激活时间和有效时长计算
+	RevokedAt          *time.Time     // 最近一次吊销时间
+	NotBefore          *time.Time     // 合同开始时间，之前不可使用
+	ScheduledRevokeAt  *time.Time     // 计划吊销时间
+	Status             LicenseStatus  // 许可证状态
+	Remark             *string        // 备注信息
+	MaxNodes           int            // 最大节点数 (0 = 不限制)
+	CurrentNodeCount   int            // 当前绑定数量
+	MaxConcurrent      int            // 并发限制 (0 = 不限制)
+	FeatureMask        string         // 功能模块掩码
 }
 
 // CalculateStatus 根据当前时间返回状态
@@ -42,8 +102,11 @@ func (l *License) CalculateStatus(now time.Time) LicenseStatus {
 	switch l.Status {
 	case StatusInactive, StatusRevoked:
 		return l.Status
-	case StatusActive, StatusExpired:
+	case StatusActive, StatusExpired, StatusGrace:
 		if l.ExpiredAt != nil && now.After(*l.ExpiredAt) {
+			if graceEndsAt := l.GraceEndsAt(); graceEndsAt != nil && !now.After(*graceEndsAt) {
+				return StatusGrace
+			}
 			return StatusExpired
 		} else {
 			return StatusActive
@@ -52,6 +115,41 @@ func (l *License) CalculateStatus(now time.Time) LicenseStatus {
 	return StatusInactive
 }
 
+// GraceEndsAt 宽限期结束时间，未设置过期时间或无宽限期时返回 nil
+func (l *License) GraceEndsAt() *time.Time {
+	if l.ExpiredAt == nil || l.Grace.Hours <= 0 {
+		return nil
+	}
+	endsAt := l.ExpiredAt.Add(time.Duration(l.Grace.Hours) * time.Hour)
+	return &endsAt
+}
+
+// ServiceEndsAt 服务截止时间，即宽限期结束时间，无宽限期时为过期时间
+func (l *License) ServiceEndsAt() *time.Time {
+	if endsAt := l.GraceEndsAt(); endsAt != nil {
+		return endsAt
+	}
+	return l.ExpiredAt
+}
+
+// AccessEndsAt 节点可使用许可证的截止时间，取服务截止时间与计划吊销时间中较早的一个
+func (l *License) AccessEndsAt() *time.Time {
+	endsAt := l.ServiceEndsAt()
+	if l.ScheduledRevokeAt != nil && (endsAt == nil || l.ScheduledRevokeAt.Before(*endsAt)) {
+		return l.ScheduledRevokeAt
+	}
+	return endsAt
+}
+
+// GraceDaysRemaining 宽限期剩余天数，不足一天按一天计算
+func (l *License) GraceDaysRemaining(now time.Time) int {
+	endsAt := l.GraceEndsAt()
+	if endsAt == nil || !now.Before(*endsAt) {
+		return 0
+	}
+	return int((endsAt.Sub(now) + 24*time.Hour - 1) / (24 * time.Hour))
+}
+
 // IsActive 检查许可证是否处于激活状态
 func (l *License) IsActive() bool {
 	return l.CalculateStatus(time.Now()) == StatusActive
@@ -64,6 +162,14 @@ func (l *License) Activate(now time.Time) bool {
 	if l.Status != StatusInactive {
 		return false
 	}
+	if l.Perpetual {
+		if l.ActivatedAt == nil {
+			l.ActivatedAt = &now
+		}
+		l.ExpiredAt = nil
+		l.Status = StatusActive
+		return true
+	}
 	if l.ValidityHours <= 0 {
 		return false
 	}
@@ -104,10 +210,100 @@ func (l *License) Renew(now time.Time, extraHours int) {
 	l.Status = l.CalculateStatus(now)
 }
 
+// IsTrial 是否为试用许可证
+func (l *License) IsTrial() bool {
+	return l.Type == LicenseTypeTrial
+}
+
+// AllowsRenew 判断续期后的有效时长是否在试用上限内，正式许可证不受限制
+func (l *License) AllowsRenew(extraHours int) bool {
+	if !l.IsTrial() || extraHours <= 0 {
+		return true
+	}
+	return l.ValidityHours+extraHours <= l.TrialMaxHours
+}
+
+// ConvertToStandard 将试用许可证原地转为正式许可证
+// 正式有效期从转换时刻开始计算，未激活的许可证仍在首次激活时计算过期时间
+func (l *License) ConvertToStandard(now time.Time, validityHours int) bool {
+	if !l.IsTrial() || validityHours <= 0 {
+		return false
+	}
+	l.Type = LicenseTypeStandard
+	l.TrialMaxHours = 0
+	l.ConvertedAt = &now
+	l.ValidityHours = validityHours
+	if l.Status == StatusInactive || l.Status == StatusRevoked {
+		return true
+	}
+	expired := now.Add(time.Duration(validityHours) * time.Hour)
+	l.ExpiredAt = &expired
+	l.Status = l.CalculateStatus(now)
+	return true
+}
+
+// HasVersionEntitlement 是否限制了可使用的版本
+func (l *License) HasVersionEntitlement() bool {
+	return l.VersionCutoffAt != nil || l.MinVersionID != nil || l.MaxVersionID != nil
+}
+
+// CoversVersion 判断许可证的版本授权是否覆盖指定版本
+// 版本先后按发布时间比较，与产品最低支持版本的判断方式一致
+// 版本范围的边界属于许可证的主产品，只约束主产品；截止时间对所有产品生效
+func (l *License) CoversVersion(product *Product, target Version) bool {
+	checkRange := product.ID == l.ProductID && (l.MinVersionID != nil || l.MaxVersionID != nil)
+	if l.VersionCutoffAt == nil && !checkRange {
+		return true
+	}
+	if target.ReleaseDate == nil {
+		return false
+	}
+	if l.VersionCutoffAt != nil && target.ReleaseDate.After(*l.VersionCutoffAt) {
+		return false
+	}
+	if !checkRange {
+		return true
+	}
+	if l.MinVersionID != nil {
+		minVersion, err := product.GetVersionByID(*l.MinVersionID)
+		if err != nil || minVersion.ReleaseDate == nil || target.ReleaseDate.Before(*minVersion.ReleaseDate) {
+			return false
+		}
+	}
+	if l.MaxVersionID != nil && target.ID != *l.MaxVersionID {
+		maxVersion, err := product.GetVersionByID(*l.MaxVersionID)
+		if err != nil {
+			return false
+		}
+		// 最高版本尚未发布时，已发布的版本都早于它
+		if maxVersion.ReleaseDate != nil && target.ReleaseDate.After(*maxVersion.ReleaseDate) {
+			return false
+		}
+	}
+	return true
+}
+
 // Revoke 吊销许可证
 // 将许可证状态设置为已吊销，使其立即失效
-func (l *License) Revoke() {
+func (l *License) Revoke(now time.Time) {
 	l.Status = StatusRevoked
+	l.RevokedAt = &now
+}
+
+// NotYetValid 是否尚未到合同开始时间
+func (l *License) NotYetValid(now time.Time) bool {
+	return l.NotBefore != nil && now.Before(*l.NotBefore)
+}
+
+// RevokeDue 计划吊销时间已到但尚未吊销
+func (l *License) RevokeDue(now time.Time) bool {
+	return l.Status != StatusRevoked && l.ScheduledRevokeAt != nil && !now.Before(*l.ScheduledRevokeAt)
+}
+
+// AcceptsLeaseIssuedAt 判断指定时间签发的租约是否仍被接受
+// 吊销之前签发的租约一律拒绝，即使许可证之后被恢复
+func (l *License) AcceptsLeaseIssuedAt(issuedAt time.Time) bool {
+	return l.RevokedAt == nil || issuedAt.After(*l.RevokedAt)
 }
 
 // UnRevoke UnRevoke恢复
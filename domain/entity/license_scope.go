@@ -8,10 +8,27 @@ const (
 )
 
 type LicenseProductScope struct {
-	ID        uint
-	LicenseID uint
-	ProductID uint
-	Status    ScopeStatus
+	ID            uint
+	LicenseID     uint
+	ProductID     uint
+	Status        ScopeStatus
+	MaxNodes      int // 该产品的最大节点数 (0 = 仅受 License 总数限制)
+	MaxConcurrent int // 该产品的并发限制 (0 = 仅受 License 总数限制)
+}
+
+// IsEnabled 判断产品范围是否启用
+func (s *LicenseProductScope) IsEnabled() bool {
+	return s.Status == ScopeStatusEnabled
+}
+
+// ValidateNodeLimit 检查该产品已绑定节点数是否还有余量
+func (s *LicenseProductScope) ValidateNodeLimit(currentNodes int) bool {
+	return validateLimit(s.MaxNodes, currentNodes)
+}
+
+// ValidateConcurrentLimit 检查该产品当前并发数是否还有余量
+func (s *LicenseProductScope) ValidateConcurrentLimit(currentConcurrent int) bool {
+	return validateLimit(s.MaxConcurrent, currentConcurrent)
 }
 
 type LicenseServiceScope struct {
@@ -1,6 +1,9 @@
 package entity
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 //
 // @Author yfy2001
@@ -21,6 +24,10 @@ type Node struct {
 	DeviceCode string  // 设备唯一识别码，用于区分不同设备
 	Status     int     // 是否被封禁，0 = 未封禁，1 = 已封禁
 	Metadata   *string // 设备元信息，包含操作系统、版本等信息
+
+	CredentialHash      string     // 节点密钥摘要，空表示尚未签发
+	CredentialRevokedAt *time.Time // 节点密钥吊销时间，操作员重新签发前不允许注册
+	ReservedLicenseID   *uint      // 预留给指定 License，只能注册到该 License
 }
 
 // NewNode 工厂方法
@@ -42,3 +49,18 @@ func NewNode(deviceCode string, metaInfo *string) (*Node, error) {
 func (n *Node) IsValid() bool {
 	return n.Status == NodeStatusNormal
 }
+
+// ReservedForOther 节点是否预留给其他 License
+func (n *Node) ReservedForOther(licenseID uint) bool {
+	return n.ReservedLicenseID != nil && *n.ReservedLicenseID != licenseID
+}
+
+// HasCredential 节点是否已签发密钥
+func (n *Node) HasCredential() bool {
+	return n.CredentialHash != ""
+}
+
+// CredentialRevoked 节点密钥是否已被吊销且尚未重新签发
+func (n *Node) CredentialRevoked() bool {
+	return n.CredentialRevokedAt != nil
+}
@@ -10,8 +10,10 @@ import "fmt"
 type BindingStatus int
 
 const (
-	BindingStatusUnbound BindingStatus = iota // 0 未绑定
-	BindingStatusBound                        // 1 绑定
+	BindingStatusUnbound  BindingStatus = iota // 0 未绑定
+	BindingStatusBound                         // 1 绑定
+	BindingStatusPending                       // 2 待审批，不占用节点名额
+	BindingStatusRejected                      // 3 审批被拒绝
 )
 
 // NodeLicenseBinding 定义节点与许可证之间的绑定关系
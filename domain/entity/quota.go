@@ -0,0 +1,46 @@
+package entity
+
+import "time"
+
+// QuotaPeriod 用量配额的重置周期
+type QuotaPeriod string
+
+const (
+	QuotaPeriodDay   QuotaPeriod = "day"   // 每天零点重置
+	QuotaPeriodMonth QuotaPeriod = "month" // 每月一日重置
+	QuotaPeriodTotal QuotaPeriod = "total" // 不重置，按总量计
+)
+
+// IsValid 判断重置周期是否受支持
+func (p QuotaPeriod) IsValid() bool {
+	switch p {
+	case QuotaPeriodDay, QuotaPeriodMonth, QuotaPeriodTotal:
+		return true
+	}
+	return false
+}
+
+// Start 返回 now 所在周期的开始时间，total 周期返回零值
+func (p QuotaPeriod) Start(now time.Time) time.Time {
+	switch p {
+	case QuotaPeriodDay:
+		return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	case QuotaPeriodMonth:
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	}
+	return time.Time{}
+}
+
+// ResetsAt 返回从 start 开始的周期的重置时间，total 周期不重置返回 nil
+func (p QuotaPeriod) ResetsAt(start time.Time) *time.Time {
+	var next time.Time
+	switch p {
+	case QuotaPeriodDay:
+		next = start.AddDate(0, 0, 1)
+	case QuotaPeriodMonth:
+		next = start.AddDate(0, 1, 0)
+	default:
+		return nil
+	}
+	return &next
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"nexus-core/domain/entity"
+	"nexus-core/global"
+	"nexus-core/licensefile"
+	"nexus-core/persistence/repository"
+
+	"gorm.io/gorm"
+)
+
+// issueLease 为注册或心跳成功的节点签发租约令牌
+func issueLease(ctx context.Context, db *gorm.DB, nodeID uint, license *entity.License, productID uint, now time.Time) (*LeaseData, error) {
+	services, err := licenseServiceIdentifiers(ctx, db, license.ID)
+	if err != nil {
+		return nil, err
+	}
+	leaseCfg := global.GetConfig().Lease
+	expiresAt := now.Add(time.Duration(leaseCfg.TTLSeconds) * time.Second)
+	// 租约不能超过许可证本身的有效期（含过期后的宽限期）和计划吊销时间
+	serviceEndsAt := license.AccessEndsAt()
+	if serviceEndsAt != nil && expiresAt.After(*serviceEndsAt) {
+		expiresAt = *serviceEndsAt
+	}
+	graceUntil := expiresAt.Add(time.Duration(leaseCfg.OfflineGraceSeconds) * time.Second)
+	if serviceEndsAt != nil && graceUntil.After(*serviceEndsAt) {
+		graceUntil = *serviceEndsAt
+	}
+
+	claims := licensefile.LeaseClaims{
+		NodeID:     nodeID,
+		LicenseID:  license.ID,
+		LicenseKey: license.LicenseKey,
+		ProductID:  productID,
+		Services:   services,
+		IssuedAt:   now,
+		ExpiresAt:  expiresAt,
+		GraceUntil: graceUntil,
+	}
+	envelope, err := signEnvelope(ctx, db, licensefile.FormatLease, claims)
+	if err != nil {
+		return nil, err
+	}
+	token, err := licensefile.EncodeToken(envelope)
+	if err != nil {
+		return nil, WrapInternal("encode lease token failed", err)
+	}
+	return &LeaseData{
+		LeaseToken:      token,
+		LeaseExpiresAt:  expiresAt,
+		LeaseGraceUntil: graceUntil,
+	}, nil
+}
+
+// VerifyLease 在线校验租约令牌
+// 除签名与有效期外，还会拒绝许可证吊销前签发的租约以及已被封禁或强制下线节点的租约
+func (s *AccessService) VerifyLease(ctx context.Context, token string) (*VerifyLeaseResult, error) {
+	if token == "" {
+		return nil, ErrBadRequest("lease_token is required")
+	}
+	db := global.DB.WithContext(ctx)
+	keys, err := trustedKeySet(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	claims, err := licensefile.VerifyLease(token, keys, now)
+	if errors.Is(err, licensefile.ErrLeaseExpired) {
+		return nil, ErrForbidden("lease expired")
+	}
+	if err != nil {
+		return nil, ErrForbidden("invalid lease token")
+	}
+
+	license, err := GetLicenseEntityByID(ctx, db, claims.LicenseID)
+	if err != nil {
+		return nil, WrapInternal("get license failed", err)
+	}
+	if license == nil || license.KeyDigest != repository.LicenseKeyDigest(claims.LicenseKey) {
+		return nil, ErrForbidden("invalid license")
+	}
+	if license.Status == entity.StatusRevoked || !license.AcceptsLeaseIssuedAt(claims.IssuedAt) {
+		return nil, ErrForbidden("lease revoked")
+	}
+
+	node, err := GetNodeEntityByID(ctx, db, claims.NodeID)
+	if err != nil {
+		return nil, WrapInternal("get node failed", err)
+	}
+	if node == nil || node.Status == entity.NodeStatusBanned || node.Status == entity.NodeStatusForcedOffline {
+		return nil, ErrForbidden("invalid node")
+	}
+
+	return &VerifyLeaseResult{
+		NodeID:     claims.NodeID,
+		LicenseID:  claims.LicenseID,
+		LicenseKey: claims.LicenseKey,
+		ProductID:  claims.ProductID,
+		Services:   claims.Services,
+		IssuedAt:   claims.IssuedAt,
+		ExpiresAt:  claims.ExpiresAt,
+		GraceUntil: claims.GraceUntil,
+		InGrace:    claims.InGrace(now),
+	}, nil
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLeaseTokenIssuedAndRejectedAfterRevoke(t *testing.T) {
+	fixture := newFlowFixture(t, 1, 0, 24)
+
+	registerResult := fixture.register(t, "device-a")
+	if registerResult.LeaseToken == "" {
+		t.Fatal("register should return lease token")
+	}
+	if !registerResult.LeaseGraceUntil.After(registerResult.LeaseExpiresAt) {
+		t.Fatalf("grace deadline should be after lease expiry: %v <= %v", registerResult.LeaseGraceUntil, registerResult.LeaseExpiresAt)
+	}
+
+	verified, err := fixture.accessService.VerifyLease(fixture.ctx, registerResult.LeaseToken)
+	if err != nil {
+		t.Fatalf("verify register lease: %v", err)
+	}
+	if verified.NodeID != registerResult.NodeID || verified.ProductID != fixture.product.ID || verified.InGrace {
+		t.Fatalf("unexpected lease result: %#v", verified)
+	}
+	if verified.Services != nil {
+		t.Fatalf("license without service scopes should not restrict services: %#v", verified.Services)
+	}
+
+	_, err = fixture.accessService.VerifyLease(fixture.ctx, registerResult.LeaseToken+"tampered")
+	assertAppErrorKind(t, err, ErrorKindForbidden)
+
+	if err := fixture.licenseService.RevokeLicense(fixture.ctx, fixture.license.ID); err != nil {
+		t.Fatalf("revoke license: %v", err)
+	}
+	_, err = fixture.accessService.VerifyLease(fixture.ctx, registerResult.LeaseToken)
+	assertAppErrorKind(t, err, ErrorKindForbidden)
+
+	time.Sleep(10 * time.Millisecond)
+	if _, err := fixture.licenseService.RestoreLicense(fixture.ctx, RestoreLicenseCommand{ID: fixture.license.ID}); err != nil {
+		t.Fatalf("restore license: %v", err)
+	}
+	_, err = fixture.accessService.VerifyLease(fixture.ctx, registerResult.LeaseToken)
+	assertAppErrorKind(t, err, ErrorKindForbidden)
+
+	heartbeatResult, err := fixture.heartbeat("device-a")
+	if err != nil {
+		t.Fatalf("heartbeat after restore: %v", err)
+	}
+	if _, err := fixture.accessService.VerifyLease(fixture.ctx, heartbeatResult.LeaseToken); err != nil {
+		t.Fatalf("lease issued after restore should be accepted: %v", err)
+	}
+}
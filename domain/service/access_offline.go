@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"nexus-core/global"
+	"nexus-core/licensefile"
+
+	"gorm.io/gorm"
+)
+
+// ActivateOffline 处理无法联网节点的离线激活
+// 管理员上传节点生成的激活请求，服务端执行与 Register 相同的校验与绑定，并返回签名的激活响应文件
+// 激活请求由管理员上传，视为已审批
+func (s *AccessService) ActivateOffline(ctx context.Context, cmd OfflineActivationCommand) (*OfflineActivationData, error) {
+	request, err := licensefile.DecodeActivationRequest(cmd.Request)
+	if err != nil {
+		return nil, ErrBadRequest("invalid activation request")
+	}
+
+	var data *OfflineActivationData
+	if err := global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		outcome, err := s.registerInTx(ctx, tx, AccessCommand{
+			DeviceCode:  request.DeviceCode,
+			LicenseKey:  request.LicenseKey,
+			ProductID:   request.ProductID,
+			VersionCode: request.VersionCode,
+		}, true)
+		if err != nil {
+			return err
+		}
+		node, license := outcome.node, outcome.license
+		// 与 Register 一致，密钥被吊销的节点需由操作员重新签发后才能激活
+		if node.CredentialRevoked() {
+			return ErrForbidden("node credential revoked")
+		}
+
+		services, err := licenseServiceIdentifiers(ctx, tx, license.ID)
+		if err != nil {
+			return err
+		}
+		envelope, err := signEnvelope(ctx, tx, licensefile.FormatActivation, licensefile.ActivationClaims{
+			Nonce:         request.Nonce,
+			NodeID:        node.ID,
+			DeviceCode:    node.DeviceCode,
+			LicenseID:     license.ID,
+			LicenseKey:    license.LicenseKey,
+			ProductID:     request.ProductID,
+			VersionCode:   request.VersionCode,
+			Services:      services,
+			MaxNodes:      license.MaxNodes,
+			MaxConcurrent: license.MaxConcurrent,
+			ActivatedAt:   license.ActivatedAt,
+			ExpiredAt:     license.ExpiredAt,
+			IssuedAt:      time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+		content, err := envelope.Marshal()
+		if err != nil {
+			return WrapInternal("marshal activation response failed", err)
+		}
+
+		recordAuditLog(ctx, tx, "node", node.ID, "offline_activate", map[string]interface{}{
+			"license_id": license.ID,
+			"product_id": request.ProductID,
+			"nonce":      request.Nonce,
+		})
+		data = &OfflineActivationData{
+			NodeID:             node.ID,
+			LicenseID:          license.ID,
+			BindingEstablished: outcome.bound,
+			KeyID:              envelope.KeyID,
+			FileName:           fmt.Sprintf("activation-%s.lic", node.DeviceCode),
+			Content:            content,
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"nexus-core/domain/entity"
+	"nexus-core/licensefile"
+)
+
+func TestOfflineActivationFlow(t *testing.T) {
+	fixture := newFlowFixture(t, 1, 0, 24)
+
+	request, err := licensefile.NewActivationRequest("offline-a", fixture.product.ID, "1.0.0", fixture.license.LicenseKey)
+	if err != nil {
+		t.Fatalf("new activation request: %v", err)
+	}
+	blob, err := request.Encode()
+	if err != nil {
+		t.Fatalf("encode request: %v", err)
+	}
+
+	data, err := fixture.accessService.ActivateOffline(fixture.ctx, OfflineActivationCommand{Request: blob})
+	if err != nil {
+		t.Fatalf("offline activate: %v", err)
+	}
+	if data.NodeID == 0 || !data.BindingEstablished {
+		t.Fatalf("offline activation should bind node: %#v", data)
+	}
+
+	keySet, err := trustedKeySet(fixture.ctx, fixture.db)
+	if err != nil {
+		t.Fatalf("trusted key set: %v", err)
+	}
+	claims, err := licensefile.VerifyActivation(data.Content, keySet, request, time.Now())
+	if err != nil {
+		t.Fatalf("verify activation response: %v", err)
+	}
+	if claims.NodeID != data.NodeID || claims.ActivatedAt == nil {
+		t.Fatalf("unexpected activation claims: %#v", claims)
+	}
+
+	license, err := fixture.licenseService.GetLicenseDataByID(fixture.ctx, fixture.license.ID)
+	if err != nil {
+		t.Fatalf("get license: %v", err)
+	}
+	if license.Status != int(entity.StatusActive) {
+		t.Fatalf("offline activation should activate license, status=%d", license.Status)
+	}
+
+	second, err := licensefile.NewActivationRequest("offline-b", fixture.product.ID, "1.0.0", fixture.license.LicenseKey)
+	if err != nil {
+		t.Fatalf("new second request: %v", err)
+	}
+	secondBlob, _ := second.Encode()
+	_, err = fixture.accessService.ActivateOffline(fixture.ctx, OfflineActivationCommand{Request: secondBlob})
+	assertAppErrorKind(t, err, ErrorKindConflict)
+
+	unsupported, _ := licensefile.NewActivationRequest("offline-c", fixture.product.ID, "9.9.9", fixture.license.LicenseKey)
+	unsupportedBlob, _ := unsupported.Encode()
+	_, err = fixture.accessService.ActivateOffline(fixture.ctx, OfflineActivationCommand{Request: unsupportedBlob})
+	assertAppErrorKind(t, err, ErrorKindBadRequest)
+
+	_, err = fixture.accessService.ActivateOffline(fixture.ctx, OfflineActivationCommand{Request: "not-a-request"})
+	assertAppErrorKind(t, err, ErrorKindBadRequest)
+
+	// 密钥被吊销的节点不能通过离线激活重新获取激活响应
+	if err := fixture.nodeService.RevokeNodeCredential(fixture.ctx, data.NodeID); err != nil {
+		t.Fatalf("revoke credential: %v", err)
+	}
+	_, err = fixture.accessService.ActivateOffline(fixture.ctx, OfflineActivationCommand{Request: blob})
+	assertAppErrorKind(t, err, ErrorKindForbidden)
+}
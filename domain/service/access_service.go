@@ -3,7 +3,6 @@ package service
 import (
 	"context"
 	"errors"
-	"fmt"
 	"nexus-core/domain/entity"
 	"nexus-core/global"
 	"nexus-core/monitor"
@@ -32,102 +31,83 @@ type AutoBindResult struct {
 
 // HeartbeatResult 返回心跳结果（简化）
 type HeartbeatResult struct {
-	Online         bool                   `json:"online"`
-	PendingControl *PendingControlSummary `json:"pending_control,omitempty"`
+	Online             bool                   `json:"online"`
+	LicenseStatus      int                    `json:"license_status"`
+	BindingStatus      string                 `json:"binding_status"`                 // bound 或 pending_approval
+	GraceEndsAt        *time.Time             `json:"grace_ends_at,omitempty"`        // 宽限期结束时间，仅宽限期内返回
+	GraceDaysRemaining int                    `json:"grace_days_remaining,omitempty"` // 宽限期剩余天数
+	PendingControl     *PendingControlSummary `json:"pending_control,omitempty"`
+	Entitlements       []EntitlementData      `json:"entitlements"` // 当前产品已生效的功能
+	Quotas             []FeatureQuotaData     `json:"quotas"`       // 当前产品功能的用量配额与剩余量
+	LeaseData
 }
 
 // Register 执行自动节点绑定注册逻辑
 // 检查许可证，产品，版本之前的支持情况
 // 绑定成功后激活许可证
 func (s *AccessService) Register(ctx context.Context, cmd AccessCommand) (*RegisterResult, error) {
-	deviceCode, licenseKey, productID, versionCode := cmd.DeviceCode, cmd.LicenseKey, cmd.ProductID, cmd.VersionCode
 	var result *RegisterResult
 	if err := global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		//验证许可证产品支持
-		license, err := GetLicenseEntityByKey(ctx, tx, licenseKey)
+		outcome, err := s.registerInTx(ctx, tx, cmd, false)
 		if err != nil {
-			return WrapInternal("get license failed", err)
-		}
-		if license == nil {
-			return ErrBadRequest("invalid license")
-		}
-		if license.ProductID != productID {
-			return Forbiddenf("license does not support product id %d", productID)
+			return err
 		}
+		node, license, productID := outcome.node, outcome.license, cmd.ProductID
 
-		//验证产品版本支持
-		product, err := GetProductEntityByID(ctx, tx, productID)
-		if err != nil {
-			return WrapInternal("get product failed", err)
-		}
-		if product == nil {
-			return ErrBadRequest("invalid product")
+		// 已签发密钥的节点必须携带密钥注册，防止他人冒用设备码；未签发时在注册时签发
+		// 密钥被吊销的节点需由操作员重新签发，不能通过注册重新获取
+		nodeSecret := ""
+		if node.CredentialRevoked() {
+			return ErrForbidden("node credential revoked")
 		}
-		if !product.CheckVersionSupportedByCode(versionCode) {
-			return ErrBadRequest("version not supported")
+		if node.HasCredential() {
+			if err := verifyNodeCredential(node, cmd.NodeSecret); err != nil {
+				return err
+			}
+		} else {
+			nodeSecret, _, err = issueNodeCredential(ctx, tx, node.ID)
+			if err != nil {
+				return err
+			}
 		}
 
-		// 检查许可证状态
-		toActivate := false
-		currentStatus := license.CalculateStatus(time.Now())
-		switch currentStatus {
-		case entity.StatusInactive:
-			//尝试激活许可证
-			if !license.Activate(time.Now()) || !license.IsActive() {
-				return ErrConflict("license activation failed")
+		// 等待审批的节点不签发租约，审批通过后重新注册或心跳即可使用
+		if outcome.pending {
+			result = &RegisterResult{
+				NodeID:            node.ID,
+				LicenseID:         license.ID,
+				ProductID:         productID,
+				LicenseKey:        license.LicenseKey,
+				LicenseStatus:     int(license.Status),
+				MaxNodes:          license.MaxNodes,
+				CurrentNodeCount:  license.CurrentNodeCount,
+				MaxConcurrent:     license.MaxConcurrent,
+				HeartbeatInterval: 60,
+				BindingStatus:     BindingStatePendingApproval,
+				NodeSecret:        nodeSecret,
+				Entitlements:      []EntitlementData{},
+				Quotas:            []FeatureQuotaData{},
 			}
-			toActivate = true
-		case entity.StatusActive:
-		case entity.StatusExpired, entity.StatusRevoked:
-			return ErrConflict("license not available")
+			recordAuditLog(ctx, tx, "node", node.ID, "register", map[string]interface{}{
+				"license_id":       license.ID,
+				"product_id":       productID,
+				"pending_approval": true,
+			})
+			return nil
 		}
 
-		// 检查当前绑定数量是否超过 MaxNodes
-		// 检查 Node 是否存在
-		node, err := GetNodeEntityByCode(ctx, tx, deviceCode)
+		now := time.Now()
+		lease, err := issueLease(ctx, tx, node.ID, license, productID, now)
 		if err != nil {
-			return WrapInternal("get node failed", err)
-		}
-		if node == nil {
-			//创建节点
-			newNode := &model.Node{
-				DeviceCode: cmd.DeviceCode,
-				Status:     entity.NodeStatusNormal,
-			}
-			err = nodeRepo.Create(ctx, tx, newNode)
-			if err != nil {
-				return WrapInternal("create node failed", err)
-			}
-			metadata := string(newNode.Metadata)
-			node = &entity.Node{
-				ID:         newNode.ID,
-				DeviceCode: newNode.DeviceCode,
-				Status:     0,
-				Metadata:   &metadata,
-			}
-		} else if node.Status == entity.NodeStatusForcedOffline {
-			if err := restoreNodeOnline(ctx, tx, node.ID, nil, "register_restore_online"); err != nil {
-				return err
-			}
-			node.Status = entity.NodeStatusNormal
-		} else if !node.IsValid() {
-			return ErrForbidden("invalid node")
+			return err
 		}
-
-		bound, err := bindNodeToLicense(ctx, tx, node.ID, license, productID)
+		entitlements, err := resolveEntitlements(ctx, tx, license.ID, productID, now)
 		if err != nil {
 			return err
 		}
-
-		if toActivate {
-			if err := tx.Model(&model.License{}).Where("id = ?", license.ID).
-				Updates(map[string]interface{}{
-					"activated_at": license.ActivatedAt,
-					"expired_at":   license.ExpiredAt,
-					"status":       int(license.Status),
-				}).Error; err != nil {
-				return WrapInternal("update license activation failed", err)
-			}
+		quotas, err := resolveFeatureQuotas(ctx, tx, license.ID, productID, now)
+		if err != nil {
+			return err
 		}
 
 		result = &RegisterResult{
@@ -141,7 +121,12 @@ func (s *AccessService) Register(ctx context.Context, cmd AccessCommand) (*Regis
 			CurrentNodeCount:   license.CurrentNodeCount,
 			MaxConcurrent:      license.MaxConcurrent,
 			HeartbeatInterval:  60,
-			BindingEstablished: bound,
+			BindingEstablished: outcome.bound,
+			BindingStatus:      BindingStateBound,
+			NodeSecret:         nodeSecret,
+			Entitlements:       entitlements,
+			Quotas:             quotas,
+			LeaseData:          *lease,
 		}
 		recordAuditLog(ctx, tx, "node", node.ID, "register", map[string]interface{}{
 			"license_id": license.ID,
@@ -155,9 +140,34 @@ func (s *AccessService) Register(ctx context.Context, cmd AccessCommand) (*Regis
 	return result, nil
 }
 
-// Heartbeat 处理心跳逻辑
-func (s *AccessService) Heartbeat(ctx context.Context, deviceCode string, productID uint, versionCode string, licenseKey string) (*HeartbeatResult, error) {
-	product, err := GetProductEntityByID(ctx, global.DB.WithContext(ctx), productID)
+// registerOutcome 注册校验与绑定的结果
+type registerOutcome struct {
+	node    *entity.Node
+	license *entity.License
+	bound   bool
+	pending bool // 新设备绑定等待审批
+}
+
+// registerInTx 在事务内完成注册的校验、节点创建、绑定与激活
+// 在线注册与离线激活共用该流程，保证两者的校验规则一致
+// operatorApproved 表示由管理员发起，需要审批的 License 也直接绑定
+func (s *AccessService) registerInTx(ctx context.Context, tx *gorm.DB, cmd AccessCommand, operatorApproved bool) (*registerOutcome, error) {
+	deviceCode, licenseKey, productID, versionCode := cmd.DeviceCode, cmd.LicenseKey, cmd.ProductID, cmd.VersionCode
+
+	//验证许可证产品支持
+	license, err := GetLicenseEntityByKey(ctx, tx, licenseKey)
+	if err != nil {
+		return nil, WrapInternal("get license failed", err)
+	}
+	if license == nil {
+		return nil, ErrBadRequest("invalid license")
+	}
+	if _, err := authorizeLicenseProduct(ctx, tx, license, productID); err != nil {
+		return nil, err
+	}
+
+	//验证产品版本支持
+	product, err := GetProductEntityByID(ctx, tx, productID)
 	if err != nil {
 		return nil, WrapInternal("get product failed", err)
 	}
@@ -165,68 +175,165 @@ func (s *AccessService) Heartbeat(ctx context.Context, deviceCode string, produc
 		return nil, ErrBadRequest("invalid product")
 	}
 	if !product.CheckVersionSupportedByCode(versionCode) {
-		return nil, ErrBadRequest("product version not supported")
-	}
-
-	license, err := GetLicenseEntityByKey(ctx, global.DB.WithContext(ctx), licenseKey)
-	if err != nil {
-		return nil, WrapInternal("get license failed", err)
+		return nil, ErrBadRequest("version not supported")
 	}
-	if license == nil {
-		return nil, ErrBadRequest("invalid license")
+	if err := checkVersionEntitlement(license, product, versionCode); err != nil {
+		return nil, err
 	}
-
-	if license.ProductID != productID {
-		return nil, ErrForbidden("product not supported")
+	if err := checkLicenseSchedule(license, time.Now()); err != nil {
+		return nil, err
 	}
 
+	// 检查许可证状态
 	currentStatus := license.CalculateStatus(time.Now())
 	switch currentStatus {
-	case entity.StatusInactive:
-		return nil, ErrConflict("license not active")
-	case entity.StatusActive:
-	case entity.StatusExpired:
-		return nil, ErrConflict("license expired")
-	case entity.StatusRevoked:
-		return nil, ErrForbidden("invalid license")
+	case entity.StatusInactive, entity.StatusActive, entity.StatusGrace:
+	case entity.StatusExpired, entity.StatusRevoked:
+		return nil, ErrConflict("license not available")
 	}
 
-	node, err := GetNodeEntityByCode(ctx, global.DB.WithContext(ctx), deviceCode)
+	// 检查当前绑定数量是否超过 MaxNodes
+	// 检查 Node 是否存在
+	node, err := GetNodeEntityByCode(ctx, tx, deviceCode)
 	if err != nil {
 		return nil, WrapInternal("get node failed", err)
 	}
 	if node == nil {
-		return nil, ErrNotFound("node not found")
+		//创建节点
+		newNode := &model.Node{
+			DeviceCode: cmd.DeviceCode,
+			Status:     entity.NodeStatusNormal,
+		}
+		err = nodeRepo.Create(ctx, tx, newNode)
+		if err != nil {
+			return nil, WrapInternal("create node failed", err)
+		}
+		metadata := string(newNode.Metadata)
+		node = &entity.Node{
+			ID:         newNode.ID,
+			DeviceCode: newNode.DeviceCode,
+			Status:     0,
+			Metadata:   &metadata,
+		}
+	} else if node.Status == entity.NodeStatusForcedOffline {
+		if err := restoreNodeOnline(ctx, tx, node.ID, nil, "register_restore_online"); err != nil {
+			return nil, err
+		}
+		node.Status = entity.NodeStatusNormal
+	} else if !node.IsValid() {
+		return nil, ErrForbidden("invalid node")
 	}
-	if node.Status == entity.NodeStatusForcedOffline {
-		return nil, ErrForbidden("node forced offline")
+
+	// 已绑定的设备移出白名单或预留给其他 License 后同样不能注册
+	if err := checkNodeBindable(ctx, tx, node, license.ID); err != nil {
+		return nil, err
 	}
-	if !node.IsValid() {
-		return nil, ErrForbidden("invalid node")
+
+	// 需要审批的 License，新设备先提交待审批的绑定，审批通过后才占用节点名额
+	pending := false
+	if license.ApprovalRequired && !operatorApproved {
+		pending, err = requestBindingApproval(ctx, tx, node.ID, license.ID, productID, time.Now())
+		if err != nil {
+			return nil, err
+		}
+	}
+	bound := false
+	if !pending {
+		bound, err = bindNodeToLicense(ctx, tx, node, license, productID)
+		if err != nil {
+			return nil, err
+		}
+		if err := touchBinding(ctx, tx, node.ID, license.ID, productID, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+	// 宽限期内只允许已绑定的节点重新注册
+	if (bound || pending) && currentStatus == entity.StatusGrace {
+		return nil, ErrConflict("license in grace period")
+	}
+	// 待审批的设备不激活 License，也不消耗试用，审批通过后再处理
+	if !pending {
+		if err := startLicenseUse(ctx, tx, license, deviceCode, time.Now()); err != nil {
+			return nil, err
+		}
 	}
 
-	var binding model.NodeLicenseBinding
-	err = global.DB.WithContext(ctx).Where("node_id = ? AND license_id = ?", node.ID, license.ID).First(&binding).Error
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		return nil, ErrNotFound("binding not found")
+	return &registerOutcome{node: node, license: license, bound: bound, pending: pending}, nil
+}
+
+// startLicenseUse 设备开始使用 License 时激活未激活的 License，并为试用 License 记录设备的试用
+// 注册绑定与审批通过共用，保证待审批的设备不会提前开始计算有效期或消耗试用
+func startLicenseUse(ctx context.Context, tx *gorm.DB, license *entity.License, deviceCode string, now time.Time) error {
+	if license.CalculateStatus(now) == entity.StatusInactive {
+		before := licenseStateOf(license)
+		if !license.Activate(now) || !license.IsActive() {
+			return ErrConflict("license activation failed")
+		}
+		if err := tx.WithContext(ctx).Model(&model.License{}).Where("id = ?", license.ID).
+			Updates(map[string]interface{}{
+				"activated_at": license.ActivatedAt,
+				"expired_at":   license.ExpiredAt,
+				"status":       int(license.Status),
+			}).Error; err != nil {
+			return WrapInternal("update license activation failed", err)
+		}
+		if err := recordLicenseHistory(ctx, tx, licenseChange{
+			LicenseID:  license.ID,
+			Event:      LicenseEventActivate,
+			Before:     before,
+			After:      licenseStateOf(license),
+			OccurredAt: *license.ActivatedAt,
+		}); err != nil {
+			return err
+		}
 	}
+	// 同一设备在同一产品下只能使用一次试用
+	if license.IsTrial() {
+		return consumeTrial(ctx, tx, license, deviceCode)
+	}
+	return nil
+}
+
+// Heartbeat 处理心跳逻辑
+// 节点必须携带注册时签发的节点密钥，可同时上报功能用量增量
+func (s *AccessService) Heartbeat(ctx context.Context, cmd AccessCommand) (*HeartbeatResult, error) {
+	for identifier, increment := range cmd.Usage {
+		if increment < 0 {
+			return nil, BadRequestf("usage increment for %q must not be negative", identifier)
+		}
+	}
+	access, err := authorizeNodeAccess(ctx, cmd)
 	if err != nil {
-		return nil, WrapInternal("check binding failed", err)
+		return nil, err
 	}
-	if binding.Status != int(entity.BindingStatusBound) {
-		return nil, ErrConflict("binding not bound")
+	license, scope, node, currentStatus, productID := access.license, access.scope, access.node, access.status, cmd.ProductID
+	if err := requireBoundBinding(ctx, node.ID, license.ID, productID); err != nil {
+		// 等待审批的节点正常返回审批状态，客户端据此提示用户并继续轮询
+		if ErrorCodeOf(err) != CodeBindingPending {
+			return nil, err
+		}
+		return &HeartbeatResult{
+			LicenseStatus: int(currentStatus),
+			BindingStatus: BindingStatePendingApproval,
+			Entitlements:  []EntitlementData{},
+			Quotas:        []FeatureQuotaData{},
+		}, nil
 	}
 
-	onlineKey := fmt.Sprintf("%d|%s|%s", productID, node.DeviceCode, license.LicenseKey)
+	onlineKey := monitor.NewOnlineNodeKey(license.TenantID, productID, node.DeviceCode, license.KeyDigest).Key()
 
-	// 并发检查，同一个节点刷新心跳不占用新的并发名额。
-	totalConcurrent := monitor.GlobalStat.GetConcurrentByLicenseForProduct(license.LicenseKey, productID)
-	if monitor.GlobalStat.HasOnlineNode(onlineKey) {
-		totalConcurrent--
+	// 并发检查，同一个节点刷新心跳或已持有席位时不占用新的并发名额。
+	// 在线节点与浮动席位共用并发名额；License 并发限制按所有产品合计，产品单独设置的限制只统计该产品
+	totalConcurrent, productConcurrent, err := concurrentUsage(ctx, global.DB, license, productID, node, time.Now())
+	if err != nil {
+		return nil, err
 	}
 	if !license.ValidateConcurrentLimit(totalConcurrent) {
 		return nil, ErrConflict("maximum concurrent exceeded")
 	}
+	if scope != nil && !scope.ValidateConcurrentLimit(productConcurrent) {
+		return nil, Conflictf("maximum concurrent exceeded for product %d", productID)
+	}
 
 	monitor.GlobalMonitor.HeartBeat(onlineKey, time.Second*60)
 	monitor.GlobalStat.AddOnlineNode(onlineKey)
@@ -239,13 +346,157 @@ func (s *AccessService) Heartbeat(ctx context.Context, deviceCode string, produc
 		}).Error; err != nil {
 		return nil, WrapInternal("update node heartbeat failed", err)
 	}
+	if err := touchBinding(ctx, global.DB, node.ID, license.ID, productID, now); err != nil {
+		return nil, err
+	}
 
 	pendingControl, err := getPendingControlSummary(ctx, node.ID)
 	if err != nil {
 		return nil, err
 	}
 
-	return &HeartbeatResult{Online: true, PendingControl: pendingControl}, nil
+	lease, err := issueLease(ctx, global.DB.WithContext(ctx), node.ID, license, productID, now)
+	if err != nil {
+		return nil, err
+	}
+	if err := recordFeatureUsage(ctx, global.DB, license.ID, node.ID, productID, cmd.Usage, now); err != nil {
+		return nil, err
+	}
+	entitlements, err := resolveEntitlements(ctx, global.DB, license.ID, productID, now)
+	if err != nil {
+		return nil, err
+	}
+	quotas, err := resolveFeatureQuotas(ctx, global.DB, license.ID, productID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &HeartbeatResult{
+		Online:         true,
+		LicenseStatus:  int(currentStatus),
+		BindingStatus:  BindingStateBound,
+		PendingControl: pendingControl,
+		Entitlements:   entitlements,
+		Quotas:         quotas,
+		LeaseData:      *lease,
+	}
+	if currentStatus == entity.StatusGrace {
+		result.GraceEndsAt = license.GraceEndsAt()
+		result.GraceDaysRemaining = license.GraceDaysRemaining(now)
+	}
+	return result, nil
+}
+
+// boundNodeAccess 节点访问接口校验通过后的 License、产品范围与节点
+type boundNodeAccess struct {
+	license *entity.License
+	scope   *entity.LicenseProductScope
+	node    *entity.Node
+	status  entity.LicenseStatus
+}
+
+// authorizeBoundNode 校验产品版本、License 状态、节点密钥以及节点与 License 的绑定
+// 心跳与浮动席位接口共用
+func authorizeBoundNode(ctx context.Context, cmd AccessCommand) (*boundNodeAccess, error) {
+	access, err := authorizeNodeAccess(ctx, cmd)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireBoundBinding(ctx, access.node.ID, access.license.ID, cmd.ProductID); err != nil {
+		return nil, err
+	}
+	return access, nil
+}
+
+// authorizeNodeAccess 校验产品版本、License 状态与节点密钥，不检查绑定
+func authorizeNodeAccess(ctx context.Context, cmd AccessCommand) (*boundNodeAccess, error) {
+	deviceCode, licenseKey, productID, versionCode := cmd.DeviceCode, cmd.LicenseKey, cmd.ProductID, cmd.VersionCode
+	product, err := GetProductEntityByID(ctx, global.DB.WithContext(ctx), productID)
+	if err != nil {
+		return nil, WrapInternal("get product failed", err)
+	}
+	if product == nil {
+		return nil, ErrBadRequest("invalid product")
+	}
+	if !product.CheckVersionSupportedByCode(versionCode) {
+		return nil, ErrBadRequest("product version not supported")
+	}
+
+	license, err := GetLicenseEntityByKey(ctx, global.DB.WithContext(ctx), licenseKey)
+	if err != nil {
+		return nil, WrapInternal("get license failed", err)
+	}
+	if license == nil {
+		return nil, ErrBadRequest("invalid license")
+	}
+
+	scope, err := authorizeLicenseProduct(ctx, global.DB.WithContext(ctx), license, productID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersionEntitlement(license, product, versionCode); err != nil {
+		return nil, err
+	}
+
+	if err := checkLicenseSchedule(license, time.Now()); err != nil {
+		return nil, err
+	}
+	currentStatus := license.CalculateStatus(time.Now())
+	switch currentStatus {
+	case entity.StatusInactive, entity.StatusActive, entity.StatusGrace:
+	case entity.StatusExpired:
+		return nil, ErrConflict("license expired")
+	case entity.StatusRevoked:
+		return nil, ErrForbidden("invalid license")
+	}
+
+	node, err := GetNodeEntityByCode(ctx, global.DB.WithContext(ctx), deviceCode)
+	if err != nil {
+		return nil, WrapInternal("get node failed", err)
+	}
+	if node == nil {
+		return nil, ErrNotFound("node not found")
+	}
+	if err := verifyNodeCredential(node, cmd.NodeSecret); err != nil {
+		return nil, err
+	}
+	if node.Status == entity.NodeStatusForcedOffline {
+		return nil, ErrForbidden("node forced offline")
+	}
+	if !node.IsValid() {
+		return nil, ErrForbidden("invalid node")
+	}
+	// License 在首个设备审批通过时才激活，未激活时只允许待审批的设备查询审批状态
+	if currentStatus == entity.StatusInactive &&
+		ErrorCodeOf(requireBoundBinding(ctx, node.ID, license.ID, productID)) != CodeBindingPending {
+		return nil, ErrConflict("license not active")
+	}
+
+	return &boundNodeAccess{license: license, scope: scope, node: node, status: currentStatus}, nil
+}
+
+// requireBoundBinding 检查节点在该产品下已绑定到 License，等待审批时返回 CodeBindingPending
+func requireBoundBinding(ctx context.Context, nodeID uint, licenseID uint, productID uint) error {
+	var binding model.NodeLicenseBinding
+	err := global.DB.WithContext(ctx).
+		Where("node_id = ? AND license_id = ? AND product_id = ?", nodeID, licenseID, productID).
+		First(&binding).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound("binding not found")
+	}
+	if err != nil {
+		return WrapInternal("check binding failed", err)
+	}
+	switch entity.BindingStatus(binding.Status) {
+	case entity.BindingStatusBound:
+		return nil
+	case entity.BindingStatusPending:
+		return ConflictWithCodef(CodeBindingPending, "binding pending approval")
+	case entity.BindingStatusRejected:
+		return ErrForbidden("binding rejected")
+	default:
+		return ErrConflict("binding not bound")
+	}
 }
 
 func getPendingControlSummary(ctx context.Context, nodeID uint) (*PendingControlSummary, error) {
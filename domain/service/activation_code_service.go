@@ -0,0 +1,357 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"nexus-core/domain/entity"
+	"nexus-core/global"
+	"nexus-core/licensekey"
+	"nexus-core/persistence/model"
+	"nexus-core/persistence/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	ActivationCodeStatusUnused   = 0
+	ActivationCodeStatusRedeemed = 1
+	ActivationCodeStatusDisabled = 2
+)
+
+// maxActivationCodeBatch 单次最多生成的兑换码数量
+const maxActivationCodeBatch = 1000
+
+// activationCodeFormat 兑换码格式：5 组 4 位，带校验位，输错一位可以在查询数据库前发现
+var activationCodeFormat = licensekey.Format{Groups: 5, GroupSize: 4, CheckDigit: true}
+
+// ActivationCodeService 管理经销商售卖的续期兑换码
+// 每个兑换码只能使用一次，兑换时为 License 增加有效时长或提高节点数、并发数
+type ActivationCodeService struct{}
+
+func NewActivationCodeService() *ActivationCodeService {
+	return &ActivationCodeService{}
+}
+
+// GenerateActivationCodes 批量生成兑换码，完整兑换码只在本次返回
+func (s *ActivationCodeService) GenerateActivationCodes(ctx context.Context, cmd GenerateActivationCodesCommand) (*ActivationCodeBatchData, error) {
+	if cmd.ExtraHours < 0 || cmd.ExtraNodes < 0 || cmd.ExtraConcurrent < 0 {
+		return nil, ErrBadRequest("extra_hours, extra_nodes and extra_concurrent must be greater than or equal to 0")
+	}
+	if cmd.ExtraHours == 0 && cmd.ExtraNodes == 0 && cmd.ExtraConcurrent == 0 {
+		return nil, ErrBadRequest("activation code must carry extra_hours, extra_nodes or extra_concurrent")
+	}
+	if cmd.Count <= 0 {
+		return nil, ErrBadRequest("count must be greater than 0")
+	}
+	if cmd.Count > maxActivationCodeBatch {
+		return nil, BadRequestf("count must be less than or equal to %d", maxActivationCodeBatch)
+	}
+	if cmd.ExpiresAt != nil && !cmd.ExpiresAt.After(time.Now()) {
+		return nil, ErrBadRequest("expires_at must be in the future")
+	}
+
+	batch := uuid.NewString()
+	codes := make([]string, 0, cmd.Count)
+	rows := make([]model.ActivationCode, 0, cmd.Count)
+	for i := 0; i < cmd.Count; i++ {
+		code, err := activationCodeFormat.Generate()
+		if err != nil {
+			return nil, WrapInternal("generate activation code failed", err)
+		}
+		codes = append(codes, code)
+		rows = append(rows, model.ActivationCode{
+			CodeDigest:      repository.LicenseKeyDigest(code),
+			CodePrefix:      licensekey.DisplayPrefix(code),
+			Batch:           batch,
+			ProductID:       cmd.ProductID,
+			ExtraHours:      cmd.ExtraHours,
+			ExtraNodes:      cmd.ExtraNodes,
+			ExtraConcurrent: cmd.ExtraConcurrent,
+			ExpiresAt:       cmd.ExpiresAt,
+			Status:          ActivationCodeStatusUnused,
+			Remark:          cmd.Remark,
+		})
+	}
+
+	err := global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if cmd.ProductID != nil {
+			var product model.Product
+			if err := tx.Where("id = ?", *cmd.ProductID).First(&product).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return ErrNotFound("product not found")
+				}
+				return WrapInternal("get product failed", err)
+			}
+		}
+		if err := tx.Create(&rows).Error; err != nil {
+			return WrapInternal("create activation codes failed", err)
+		}
+		recordAuditLog(ctx, tx, "activation_code", 0, "generate", map[string]interface{}{
+			"batch":            batch,
+			"count":            cmd.Count,
+			"product_id":       cmd.ProductID,
+			"extra_hours":      cmd.ExtraHours,
+			"extra_nodes":      cmd.ExtraNodes,
+			"extra_concurrent": cmd.ExtraConcurrent,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	data := &ActivationCodeBatchData{Batch: batch, Codes: make([]ActivationCodeData, 0, len(rows))}
+	for i := range rows {
+		item := toActivationCodeData(&rows[i])
+		item.Code = codes[i]
+		data.Codes = append(data.Codes, item)
+	}
+	return data, nil
+}
+
+func (s *ActivationCodeService) ListActivationCodes(ctx context.Context, cmd ListActivationCodesCommand) ([]ActivationCodeData, error) {
+	query := global.DB.WithContext(ctx).Model(&model.ActivationCode{}).Order("id DESC")
+	if cmd.Batch != nil {
+		query = query.Where("batch = ?", *cmd.Batch)
+	}
+	if cmd.Status != nil {
+		query = query.Where("status = ?", *cmd.Status)
+	}
+	if cmd.LicenseID != nil {
+		query = query.Where("redeemed_license_id = ?", *cmd.LicenseID)
+	}
+	if cmd.Limit > 0 {
+		query = query.Limit(cmd.Limit)
+	}
+	if cmd.Offset > 0 {
+		query = query.Offset(cmd.Offset)
+	}
+	var codes []model.ActivationCode
+	if err := query.Find(&codes).Error; err != nil {
+		return nil, WrapInternal("list activation codes failed", err)
+	}
+	data := make([]ActivationCodeData, 0, len(codes))
+	for i := range codes {
+		data = append(data, toActivationCodeData(&codes[i]))
+	}
+	return data, nil
+}
+
+// DisableActivationCode 作废未使用的兑换码
+func (s *ActivationCodeService) DisableActivationCode(ctx context.Context, id uint) (*ActivationCodeData, error) {
+	if id == 0 {
+		return nil, ErrBadRequest("id is required")
+	}
+	var code model.ActivationCode
+	err := global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ?", id).First(&code).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotFound("activation code not found")
+			}
+			return WrapInternal("get activation code failed", err)
+		}
+		result := tx.Model(&code).Where("status = ?", ActivationCodeStatusUnused).
+			Update("status", ActivationCodeStatusDisabled)
+		if result.Error != nil {
+			return WrapInternal("disable activation code failed", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrConflict("only unused activation codes can be disabled")
+		}
+		code.Status = ActivationCodeStatusDisabled
+		recordAuditLog(ctx, tx, "activation_code", code.ID, "disable", map[string]interface{}{
+			"batch": code.Batch,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	data := toActivationCodeData(&code)
+	return &data, nil
+}
+
+// RedeemActivationCode 使用注册码兑换兑换码
+// 有效时长通过 License.Renew 增加，规则与手动续期一致；节点数、并发数不限制（为 0）时保持不限制
+// 同一客户端 IP 或注册码连续兑换失败过多时暂时拒绝兑换
+func (s *ActivationCodeService) RedeemActivationCode(ctx context.Context, cmd RedeemActivationCodeCommand) (*RedeemActivationCodeData, error) {
+	keys := redeemThrottleKeys(cmd)
+	if redeemThrottle.Blocked(time.Now(), keys...) {
+		return nil, ErrTooManyRequests("too many failed redeem attempts, try again later")
+	}
+	data, err := s.redeemActivationCode(ctx, cmd)
+	if isRedeemGuessFailure(err) {
+		redeemThrottle.Fail(time.Now(), keys...)
+	}
+	return data, err
+}
+
+func (s *ActivationCodeService) redeemActivationCode(ctx context.Context, cmd RedeemActivationCodeCommand) (*RedeemActivationCodeData, error) {
+	if licensekey.CheckSyntax(cmd.LicenseKey) != nil {
+		return nil, ErrBadRequest("invalid license")
+	}
+	input := activationCodeFormat.Normalize(cmd.Code)
+	if activationCodeFormat.Check(input) != nil {
+		return nil, ErrBadRequest("malformed activation code")
+	}
+	redeemedBy := model.OperatorFromContext(ctx)
+	if redeemedBy == model.SystemOperator && cmd.ClientIP != "" {
+		redeemedBy = cmd.ClientIP
+	}
+
+	var (
+		code      model.ActivationCode
+		licenseID uint
+	)
+	err := global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		found, err := GetLicenseEntityByKey(ctx, tx, cmd.LicenseKey)
+		if err != nil {
+			return WrapInternal("get license failed", err)
+		}
+		if found == nil {
+			return ErrBadRequest("invalid license")
+		}
+		licenseID = found.ID
+		// 锁定 License 后重新读取，并发兑换不同兑换码时按顺序累加，不会覆盖彼此的结果
+		var pLicense model.License
+		if err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+			Where("id = ?", licenseID).First(&pLicense).Error; err != nil {
+			return WrapInternal("get license failed", err)
+		}
+		license, err := hydrateLicenseEntity(ctx, tx, &pLicense)
+		if err != nil {
+			return WrapInternal("get license failed", err)
+		}
+		license.LicenseKey = cmd.LicenseKey
+
+		err = tx.Where("code_digest = ?", repository.LicenseKeyDigest(input)).First(&code).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotFound("activation code not found")
+		}
+		if err != nil {
+			return WrapInternal("get activation code failed", err)
+		}
+		now := time.Now()
+		if err := checkActivationCodeRedeemable(ctx, tx, &code, license, now); err != nil {
+			return err
+		}
+
+		// 先占用兑换码，并发兑换同一个码时只有一方成功
+		result := tx.Model(&code).Where("status = ?", ActivationCodeStatusUnused).Updates(map[string]interface{}{
+			"status":              ActivationCodeStatusRedeemed,
+			"redeemed_license_id": license.ID,
+			"redeemed_at":         now,
+			"redeemed_by":         redeemedBy,
+		})
+		if result.Error != nil {
+			return WrapInternal("redeem activation code failed", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrConflict("activation code already redeemed")
+		}
+		code.Status, code.RedeemedLicenseID, code.RedeemedAt, code.RedeemedBy = ActivationCodeStatusRedeemed, &licenseID, &now, redeemedBy
+
+		before := licenseStateOf(license)
+		if code.ExtraHours > 0 {
+			license.Renew(now, code.ExtraHours)
+		}
+		if license.MaxNodes > 0 {
+			license.MaxNodes += code.ExtraNodes
+		}
+		if license.MaxConcurrent > 0 {
+			license.MaxConcurrent += code.ExtraConcurrent
+		}
+		if err := tx.Model(&model.License{}).Where("id = ?", license.ID).Updates(map[string]interface{}{
+			"validity_hours": license.ValidityHours,
+			"expired_at":     license.ExpiredAt,
+			"status":         int(license.Status),
+			"max_nodes":      license.MaxNodes,
+			"max_concurrent": license.MaxConcurrent,
+		}).Error; err != nil {
+			return WrapInternal("apply activation code failed", err)
+		}
+		recordAuditLog(ctx, tx, "license", license.ID, "redeem_activation_code", map[string]interface{}{
+			"activation_code_id": code.ID,
+			"batch":              code.Batch,
+			"extra_hours":        code.ExtraHours,
+			"extra_nodes":        code.ExtraNodes,
+			"extra_concurrent":   code.ExtraConcurrent,
+			"validity_hours":     license.ValidityHours,
+			"redeemed_by":        redeemedBy,
+		})
+		return recordLicenseHistory(ctx, tx, licenseChange{
+			LicenseID:  license.ID,
+			Event:      LicenseEventRedeem,
+			Before:     before,
+			After:      licenseStateOf(license),
+			ExtraHours: code.ExtraHours,
+			OccurredAt: now,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	license, err := NewLicenseService().GetLicenseDataByID(ctx, licenseID)
+	if err != nil {
+		return nil, err
+	}
+	return &RedeemActivationCodeData{Code: toActivationCodeData(&code), License: *license}, nil
+}
+
+// checkActivationCodeRedeemable 检查兑换码状态、适用产品以及 License 是否允许续期
+// 限定产品的兑换码适用于 License 的主产品和启用的附加产品
+func checkActivationCodeRedeemable(ctx context.Context, tx *gorm.DB, code *model.ActivationCode, license *entity.License, now time.Time) error {
+	switch code.Status {
+	case ActivationCodeStatusRedeemed:
+		return ErrConflict("activation code already redeemed")
+	case ActivationCodeStatusDisabled:
+		return ErrForbidden("activation code disabled")
+	}
+	if code.ExpiresAt != nil && now.After(*code.ExpiresAt) {
+		return ErrConflict("activation code expired")
+	}
+	if code.ProductID != nil {
+		if _, err := authorizeLicenseProduct(ctx, tx, license, *code.ProductID); err != nil {
+			if ErrorKindOf(err) == ErrorKindForbidden {
+				return ErrForbidden("activation code does not apply to this product")
+			}
+			return err
+		}
+	}
+	if license.Status == entity.StatusRevoked {
+		return ErrForbidden("revoked license must be restored before redeem")
+	}
+	if code.ExtraHours > 0 {
+		if license.Perpetual {
+			return ErrConflict("perpetual license cannot be renewed")
+		}
+		if !license.AllowsRenew(code.ExtraHours) {
+			return Conflictf("trial license cannot be renewed beyond %d hours", license.TrialMaxHours)
+		}
+	}
+	return nil
+}
+
+func toActivationCodeData(code *model.ActivationCode) ActivationCodeData {
+	return ActivationCodeData{
+		ID:                code.ID,
+		Code:              licensekey.Mask(code.CodePrefix),
+		Batch:             code.Batch,
+		ProductID:         code.ProductID,
+		ExtraHours:        code.ExtraHours,
+		ExtraNodes:        code.ExtraNodes,
+		ExtraConcurrent:   code.ExtraConcurrent,
+		ExpiresAt:         code.ExpiresAt,
+		Status:            code.Status,
+		RedeemedLicenseID: code.RedeemedLicenseID,
+		RedeemedAt:        code.RedeemedAt,
+		RedeemedBy:        code.RedeemedBy,
+		Remark:            code.Remark,
+		CreatedAt:         code.CreatedAt,
+	}
+}
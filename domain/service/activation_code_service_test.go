@@ -0,0 +1,256 @@
+package service
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"nexus-core/domain/entity"
+	"nexus-core/persistence/model"
+)
+
+func TestActivationCodeRedeem(t *testing.T) {
+	f := newFlowFixture(t, 2, 1, 24)
+	codeService := NewActivationCodeService()
+	licenseID := f.license.ID
+	f.register(t, "device-a")
+
+	_, err := codeService.GenerateActivationCodes(f.ctx, GenerateActivationCodesCommand{Count: 1})
+	assertAppErrorKind(t, err, ErrorKindBadRequest)
+	_, err = codeService.GenerateActivationCodes(f.ctx, GenerateActivationCodesCommand{ExtraHours: 24, Count: maxActivationCodeBatch + 1})
+	assertAppErrorKind(t, err, ErrorKindBadRequest)
+	missingProduct := f.product.ID + 100
+	_, err = codeService.GenerateActivationCodes(f.ctx, GenerateActivationCodesCommand{ProductID: &missingProduct, ExtraHours: 24, Count: 1})
+	assertAppErrorKind(t, err, ErrorKindNotFound)
+
+	batch, err := codeService.GenerateActivationCodes(f.ctx, GenerateActivationCodesCommand{
+		ProductID:  &f.product.ID,
+		ExtraHours: 48,
+		ExtraNodes: 1,
+		Count:      3,
+	})
+	if err != nil || len(batch.Codes) != 3 {
+		t.Fatalf("generate codes: %#v %v", batch, err)
+	}
+	code := batch.Codes[0].Code
+	if strings.Contains(code, "*") {
+		t.Fatalf("generated code should be returned in full: %s", code)
+	}
+	var stored model.ActivationCode
+	if err := f.db.First(&stored, batch.Codes[0].ID).Error; err != nil || strings.Contains(stored.CodeDigest, code) {
+		t.Fatalf("code should be stored as digest: %#v %v", stored, err)
+	}
+
+	_, err = codeService.RedeemActivationCode(f.ctx, RedeemActivationCodeCommand{LicenseKey: f.license.LicenseKey, Code: "not-a-code"})
+	assertAppErrorKind(t, err, ErrorKindBadRequest)
+
+	// 兑换码不区分大小写和分隔符
+	redeemed, err := codeService.RedeemActivationCode(f.ctx, RedeemActivationCodeCommand{
+		LicenseKey: f.license.LicenseKey,
+		Code:       strings.ToLower(strings.ReplaceAll(code, "-", "")),
+		ClientIP:   "203.0.113.7",
+	})
+	if err != nil {
+		t.Fatalf("redeem: %v", err)
+	}
+	if redeemed.Code.Status != ActivationCodeStatusRedeemed || redeemed.Code.RedeemedLicenseID == nil || *redeemed.Code.RedeemedLicenseID != licenseID ||
+		redeemed.Code.RedeemedBy != "203.0.113.7" || redeemed.Code.RedeemedAt == nil {
+		t.Fatalf("unexpected redeemed code: %#v", redeemed.Code)
+	}
+	if redeemed.License.MaxNodes != 3 || redeemed.License.MaxConcurrent != 1 || redeemed.License.ValidityHours != 72 {
+		t.Fatalf("unexpected license after redeem: %#v", redeemed.License)
+	}
+
+	_, err = codeService.RedeemActivationCode(f.ctx, RedeemActivationCodeCommand{LicenseKey: f.license.LicenseKey, Code: code})
+	assertAppErrorKind(t, err, ErrorKindConflict)
+
+	var audits int64
+	f.db.Model(&model.AuditLog{}).Where("resource_id = ? AND action = ?", licenseID, "redeem_activation_code").Count(&audits)
+	if audits != 1 {
+		t.Fatalf("expected one redeem audit entry, got %d", audits)
+	}
+	history, err := f.licenseService.ListLicenseHistory(f.ctx, licenseID)
+	if err != nil {
+		t.Fatalf("list history: %v", err)
+	}
+	last := history[len(history)-1]
+	if last.Event != LicenseEventRedeem || last.ExtraHours != 48 || last.After.MaxNodes != 3 ||
+		last.After.ExpiredAt.Sub(*last.Before.ExpiredAt) != 48*time.Hour {
+		t.Fatalf("redeem should be recorded in history: %#v", last)
+	}
+
+	list, err := codeService.ListActivationCodes(f.ctx, ListActivationCodesCommand{LicenseID: &licenseID})
+	if err != nil || len(list) != 1 || list[0].ID != batch.Codes[0].ID || !strings.Contains(list[0].Code, "*") {
+		t.Fatalf("list redeemed codes: %#v %v", list, err)
+	}
+
+	// 作废的兑换码不能再兑换，已兑换的不能作废
+	if _, err := codeService.DisableActivationCode(f.ctx, batch.Codes[1].ID); err != nil {
+		t.Fatalf("disable: %v", err)
+	}
+	_, err = codeService.RedeemActivationCode(f.ctx, RedeemActivationCodeCommand{LicenseKey: f.license.LicenseKey, Code: batch.Codes[1].Code})
+	assertAppErrorKind(t, err, ErrorKindForbidden)
+	_, err = codeService.DisableActivationCode(f.ctx, batch.Codes[0].ID)
+	assertAppErrorKind(t, err, ErrorKindConflict)
+
+	if err := f.licenseService.RevokeLicense(f.ctx, licenseID); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	_, err = codeService.RedeemActivationCode(f.ctx, RedeemActivationCodeCommand{LicenseKey: f.license.LicenseKey, Code: batch.Codes[2].Code})
+	assertAppErrorKind(t, err, ErrorKindForbidden)
+	var unused model.ActivationCode
+	if err := f.db.First(&unused, batch.Codes[2].ID).Error; err != nil || unused.Status != ActivationCodeStatusUnused {
+		t.Fatalf("rejected redeem should not consume the code: %#v %v", unused, err)
+	}
+}
+
+func TestActivationCodeRestrictions(t *testing.T) {
+	f := newFlowFixture(t, 0, 2, 24)
+	codeService := NewActivationCodeService()
+
+	otherProduct := model.Product{Name: "other-product"}
+	if err := f.db.Create(&otherProduct).Error; err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+	past := time.Now().Add(-time.Hour)
+	if _, err := codeService.GenerateActivationCodes(f.ctx, GenerateActivationCodesCommand{ExtraHours: 24, ExpiresAt: &past, Count: 1}); err == nil {
+		t.Fatalf("expires_at in the past should be rejected")
+	}
+
+	scoped, err := codeService.GenerateActivationCodes(f.ctx, GenerateActivationCodesCommand{ProductID: &otherProduct.ID, ExtraHours: 24, Count: 1})
+	if err != nil {
+		t.Fatalf("generate scoped code: %v", err)
+	}
+	_, err = codeService.RedeemActivationCode(f.ctx, RedeemActivationCodeCommand{LicenseKey: f.license.LicenseKey, Code: scoped.Codes[0].Code})
+	assertAppErrorKind(t, err, ErrorKindForbidden)
+
+	expiring, err := codeService.GenerateActivationCodes(f.ctx, GenerateActivationCodesCommand{ExtraHours: 24, Count: 1})
+	if err != nil {
+		t.Fatalf("generate code: %v", err)
+	}
+	if err := f.db.Model(&model.ActivationCode{}).Where("id = ?", expiring.Codes[0].ID).Update("expires_at", past).Error; err != nil {
+		t.Fatalf("expire code: %v", err)
+	}
+	_, err = codeService.RedeemActivationCode(f.ctx, RedeemActivationCodeCommand{LicenseKey: f.license.LicenseKey, Code: expiring.Codes[0].Code})
+	assertAppErrorKind(t, err, ErrorKindConflict)
+
+	// 不限制节点数的 License 兑换后仍不限制，未激活的 License 只增加有效时长
+	limits, err := codeService.GenerateActivationCodes(f.ctx, GenerateActivationCodesCommand{ExtraHours: 24, ExtraNodes: 2, ExtraConcurrent: 1, Count: 1})
+	if err != nil {
+		t.Fatalf("generate code: %v", err)
+	}
+	redeemed, err := codeService.RedeemActivationCode(f.ctx, RedeemActivationCodeCommand{LicenseKey: f.license.LicenseKey, Code: limits.Codes[0].Code})
+	if err != nil {
+		t.Fatalf("redeem: %v", err)
+	}
+	if redeemed.License.MaxNodes != 0 || redeemed.License.MaxConcurrent != 3 || redeemed.License.ValidityHours != 48 ||
+		redeemed.License.Status != int(entity.StatusInactive) {
+		t.Fatalf("unexpected license after redeem: %#v", redeemed.License)
+	}
+}
+
+func TestActivationCodeConcurrentRedeem(t *testing.T) {
+	f := newFlowFixture(t, 2, 1, 24)
+	codeService := NewActivationCodeService()
+	batch, err := codeService.GenerateActivationCodes(f.ctx, GenerateActivationCodesCommand{ExtraHours: 24, Count: 1})
+	if err != nil {
+		t.Fatalf("generate code: %v", err)
+	}
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		successes int
+	)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := codeService.RedeemActivationCode(f.ctx, RedeemActivationCodeCommand{LicenseKey: f.license.LicenseKey, Code: batch.Codes[0].Code})
+			if err == nil {
+				mu.Lock()
+				successes++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if successes != 1 {
+		t.Fatalf("code should be redeemed exactly once, got %d", successes)
+	}
+	license, err := f.licenseService.GetLicenseDataByID(f.ctx, f.license.ID)
+	if err != nil || license.ValidityHours != 48 {
+		t.Fatalf("license should be renewed once: %#v %v", license, err)
+	}
+}
+
+func TestActivationCodeForExtraProductScope(t *testing.T) {
+	f := newFlowFixture(t, 2, 1, 24)
+	codeService := NewActivationCodeService()
+
+	extra := model.Product{Name: "extra-product"}
+	if err := f.db.Create(&extra).Error; err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+	batch, err := codeService.GenerateActivationCodes(f.ctx, GenerateActivationCodesCommand{ProductID: &extra.ID, ExtraHours: 24, Count: 1})
+	if err != nil {
+		t.Fatalf("generate scoped code: %v", err)
+	}
+	_, err = codeService.RedeemActivationCode(f.ctx, RedeemActivationCodeCommand{LicenseKey: f.license.LicenseKey, Code: batch.Codes[0].Code})
+	assertAppErrorKind(t, err, ErrorKindForbidden)
+
+	// 启用的附加产品同样可以兑换限定该产品的兑换码，禁用后不能兑换
+	disabled := int(entity.ScopeStatusDisabled)
+	if _, err := f.licenseService.SetLicenseProductScope(f.ctx, SetLicenseProductScopeCommand{LicenseID: f.license.ID, ProductID: extra.ID, Status: disabled}); err != nil {
+		t.Fatalf("add disabled product scope: %v", err)
+	}
+	_, err = codeService.RedeemActivationCode(f.ctx, RedeemActivationCodeCommand{LicenseKey: f.license.LicenseKey, Code: batch.Codes[0].Code})
+	assertAppErrorKind(t, err, ErrorKindForbidden)
+	if _, err := f.licenseService.SetLicenseProductScope(f.ctx, SetLicenseProductScopeCommand{LicenseID: f.license.ID, ProductID: extra.ID}); err != nil {
+		t.Fatalf("enable product scope: %v", err)
+	}
+	redeemed, err := codeService.RedeemActivationCode(f.ctx, RedeemActivationCodeCommand{LicenseKey: f.license.LicenseKey, Code: batch.Codes[0].Code})
+	if err != nil {
+		t.Fatalf("redeem for extra product: %v", err)
+	}
+	if redeemed.License.ValidityHours != 48 {
+		t.Fatalf("unexpected license after redeem: %#v", redeemed.License)
+	}
+}
+
+func TestActivationCodeRedeemThrottle(t *testing.T) {
+	f := newFlowFixture(t, 2, 1, 24)
+	codeService := NewActivationCodeService()
+	oldThrottle := redeemThrottle
+	redeemThrottle = newAttemptLimiter(3, time.Minute)
+	t.Cleanup(func() { redeemThrottle = oldThrottle })
+
+	batch, err := codeService.GenerateActivationCodes(f.ctx, GenerateActivationCodesCommand{ExtraHours: 24, Count: 1})
+	if err != nil {
+		t.Fatalf("generate code: %v", err)
+	}
+	wrong := strings.Repeat("A", len(batch.Codes[0].Code))
+	for i := 0; i < 3; i++ {
+		_, err = codeService.RedeemActivationCode(f.ctx, RedeemActivationCodeCommand{LicenseKey: f.license.LicenseKey, Code: wrong, ClientIP: "203.0.113.7"})
+		if kind := ErrorKindOf(err); kind != ErrorKindBadRequest && kind != ErrorKindNotFound {
+			t.Fatalf("wrong code should fail, got %v", err)
+		}
+	}
+
+	// 失败次数达到上限后，同一 IP 或同一注册码即使使用正确的兑换码也被拒绝
+	_, err = codeService.RedeemActivationCode(f.ctx, RedeemActivationCodeCommand{LicenseKey: f.license.LicenseKey, Code: batch.Codes[0].Code, ClientIP: "203.0.113.7"})
+	assertAppErrorKind(t, err, ErrorKindTooManyRequests)
+	_, err = codeService.RedeemActivationCode(f.ctx, RedeemActivationCodeCommand{LicenseKey: f.license.LicenseKey, Code: batch.Codes[0].Code, ClientIP: "203.0.113.8"})
+	assertAppErrorKind(t, err, ErrorKindTooManyRequests)
+	var code model.ActivationCode
+	if err := f.db.First(&code, batch.Codes[0].ID).Error; err != nil || code.Status != ActivationCodeStatusUnused {
+		t.Fatalf("throttled redeem should not consume the code: %#v %v", code, err)
+	}
+
+	// 窗口结束后恢复
+	keys := redeemThrottleKeys(RedeemActivationCodeCommand{LicenseKey: f.license.LicenseKey, ClientIP: "203.0.113.7"})
+	if redeemThrottle.Blocked(time.Now().Add(time.Minute), keys...) {
+		t.Fatal("throttle should reset after the window")
+	}
+}
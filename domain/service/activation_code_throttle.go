@@ -0,0 +1,103 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"nexus-core/persistence/repository"
+)
+
+const (
+	redeemFailureLimit  = 10               // 统计窗口内允许的兑换失败次数
+	redeemFailureWindow = 15 * time.Minute // 兑换失败次数的统计窗口
+	redeemSweepSize     = 10000            // 记录数超过该值时清理过期记录
+)
+
+// redeemThrottle 兑换接口不要求 API Key，按客户端 IP 和注册码分别限制失败次数，防止穷举兑换码
+var redeemThrottle = newAttemptLimiter(redeemFailureLimit, redeemFailureWindow)
+
+// attemptLimiter 进程内的固定窗口失败计数
+type attemptLimiter struct {
+	mu       sync.Mutex
+	limit    int
+	window   time.Duration
+	failures map[string]*attemptWindow
+}
+
+type attemptWindow struct {
+	count   int
+	resetAt time.Time
+}
+
+func newAttemptLimiter(limit int, window time.Duration) *attemptLimiter {
+	return &attemptLimiter{
+		limit:    limit,
+		window:   window,
+		failures: make(map[string]*attemptWindow),
+	}
+}
+
+// Blocked 任一标识在窗口内的失败次数达到上限时返回 true，空标识忽略
+func (l *attemptLimiter) Blocked(now time.Time, keys ...string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		item, ok := l.failures[key]
+		if !ok {
+			continue
+		}
+		if !now.Before(item.resetAt) {
+			delete(l.failures, key)
+			continue
+		}
+		if item.count >= l.limit {
+			return true
+		}
+	}
+	return false
+}
+
+// Fail 为每个标识记录一次失败，空标识忽略
+func (l *attemptLimiter) Fail(now time.Time, keys ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.failures) > redeemSweepSize {
+		for key, item := range l.failures {
+			if !now.Before(item.resetAt) {
+				delete(l.failures, key)
+			}
+		}
+	}
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		item, ok := l.failures[key]
+		if !ok || !now.Before(item.resetAt) {
+			item = &attemptWindow{resetAt: now.Add(l.window)}
+			l.failures[key] = item
+		}
+		item.count++
+	}
+}
+
+// redeemThrottleKeys 兑换限流的标识，注册码只保存摘要
+func redeemThrottleKeys(cmd RedeemActivationCodeCommand) []string {
+	keys := []string{"license:" + repository.LicenseKeyDigest(cmd.LicenseKey)}
+	if cmd.ClientIP != "" {
+		keys = append(keys, "ip:"+cmd.ClientIP)
+	}
+	return keys
+}
+
+// isRedeemGuessFailure 注册码或兑换码不存在、格式错误时计为一次失败
+func isRedeemGuessFailure(err error) bool {
+	switch ErrorKindOf(err) {
+	case ErrorKindBadRequest, ErrorKindNotFound:
+		return true
+	}
+	return false
+}
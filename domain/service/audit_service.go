@@ -26,6 +26,7 @@ type ListAuditLogsCommand struct {
 	ResourceType *string
 	ResourceID   *uint
 	Action       *string
+	Operator     *string
 	Limit        int
 	Offset       int
 }
@@ -55,6 +56,9 @@ func (s *AuditService) ListAuditLogs(ctx context.Context, cmd ListAuditLogsComma
 	if cmd.Action != nil && *cmd.Action != "" {
 		query = query.Where("action = ?", *cmd.Action)
 	}
+	if cmd.Operator != nil && *cmd.Operator != "" {
+		query = query.Where("operator = ?", *cmd.Operator)
+	}
 
 	var logs []model.AuditLog
 	if err := query.Find(&logs).Error; err != nil {
@@ -84,7 +88,7 @@ func recordAuditLog(ctx context.Context, db *gorm.DB, resourceType string, resou
 		ResourceType: resourceType,
 		ResourceID:   resourceID,
 		Action:       action,
-		Operator:     "system",
+		Operator:     model.OperatorFromContext(ctx),
 		Data:         payload,
 	}
 	_ = db.WithContext(ctx).Create(&log).Error
@@ -0,0 +1,262 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"nexus-core/domain/entity"
+	"nexus-core/global"
+	"nexus-core/persistence/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SetLicenseApprovalRequired 设置新设备绑定是否需要审批，关闭后已提交的申请仍需审批或拒绝
+func (s *LicenseService) SetLicenseApprovalRequired(ctx context.Context, cmd SetLicenseApprovalCommand) (*LicenseData, error) {
+	if cmd.LicenseID == 0 {
+		return nil, ErrBadRequest("id is required")
+	}
+	result := global.DB.WithContext(ctx).Model(&model.License{}).Where("id = ?", cmd.LicenseID).
+		Update("approval_required", cmd.ApprovalRequired)
+	if result.Error != nil {
+		return nil, WrapInternal("update license approval policy failed", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrNotFound("license not found")
+	}
+	recordAuditLog(ctx, global.DB.WithContext(ctx), "license", cmd.LicenseID, "set_approval_required", map[string]interface{}{
+		"approval_required": cmd.ApprovalRequired,
+	})
+	return s.GetLicenseDataByID(ctx, cmd.LicenseID)
+}
+
+// ListBindingRequests 查询 License 待审批与已拒绝的设备绑定申请
+func (s *LicenseService) ListBindingRequests(ctx context.Context, licenseID uint) ([]BindingRequestData, error) {
+	if licenseID == 0 {
+		return nil, ErrBadRequest("id is required")
+	}
+	db := global.DB.WithContext(ctx)
+	var count int64
+	if err := db.Model(&model.License{}).Where("id = ?", licenseID).Count(&count).Error; err != nil {
+		return nil, WrapInternal("get license failed", err)
+	}
+	if count == 0 {
+		return nil, ErrNotFound("license not found")
+	}
+	var bindings []model.NodeLicenseBinding
+	if err := db.Where("license_id = ? AND status IN ?", licenseID,
+		[]entity.BindingStatus{entity.BindingStatusPending, entity.BindingStatusRejected}).
+		Order("id ASC").Find(&bindings).Error; err != nil {
+		return nil, WrapInternal("list binding requests failed", err)
+	}
+	return toBindingRequestList(ctx, db, bindings)
+}
+
+// ApproveBinding 审批通过设备绑定申请，通过后占用节点名额，并受节点数与换绑限制约束
+func (s *LicenseService) ApproveBinding(ctx context.Context, cmd BindingDecisionCommand) (*BindingRequestData, error) {
+	if cmd.LicenseID == 0 || cmd.BindingID == 0 {
+		return nil, ErrBadRequest("license_id and binding_id are required")
+	}
+	var binding model.NodeLicenseBinding
+	err := global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var pLicense model.License
+		if err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+			Where("id = ?", cmd.LicenseID).First(&pLicense).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotFound("license not found")
+			}
+			return WrapInternal("get license failed", err)
+		}
+		license := ToEntityLicense(&pLicense)
+		if err := fillGracePolicies(ctx, tx, license); err != nil {
+			return WrapInternal("get grace policy failed", err)
+		}
+		now := time.Now()
+		switch license.CalculateStatus(now) {
+		case entity.StatusInactive:
+			if err := checkLicenseSchedule(license, now); err != nil {
+				return err
+			}
+		case entity.StatusActive:
+		case entity.StatusGrace:
+			return ErrConflict("license in grace period")
+		default:
+			return ErrConflict("license not available")
+		}
+
+		if err := getPendingBinding(ctx, tx, cmd, &binding); err != nil {
+			return err
+		}
+		node, err := GetNodeEntityByID(ctx, tx, binding.NodeID)
+		if err != nil {
+			return WrapInternal("get node failed", err)
+		}
+		if node == nil {
+			return ErrNotFound("node not found")
+		}
+		// 申请提交后节点可能被禁用、产品授权可能被停用或移除，批准时重新校验
+		if !node.IsValid() {
+			return ErrForbidden("invalid node")
+		}
+		if _, err := authorizeLicenseProduct(ctx, tx, license, binding.ProductID); err != nil {
+			return err
+		}
+		if _, err := bindNodeToLicense(ctx, tx, node, license, binding.ProductID); err != nil {
+			return err
+		}
+		// 审批通过才开始使用 License：首个设备通过时激活，试用 License 记录该设备的试用
+		if err := startLicenseUse(ctx, tx, license, node.DeviceCode, now); err != nil {
+			return err
+		}
+		recordAuditLog(ctx, tx, "node", binding.NodeID, "approve_binding", map[string]interface{}{
+			"license_id": cmd.LicenseID,
+			"product_id": binding.ProductID,
+		})
+		return tx.Where("id = ?", binding.ID).First(&binding).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toBindingRequestData(ctx, global.DB.WithContext(ctx), &binding)
+}
+
+// RejectBinding 拒绝设备绑定申请，被拒绝的设备再次注册会返回 403，解绑后可以重新申请
+func (s *LicenseService) RejectBinding(ctx context.Context, cmd BindingDecisionCommand) (*BindingRequestData, error) {
+	if cmd.LicenseID == 0 || cmd.BindingID == 0 {
+		return nil, ErrBadRequest("license_id and binding_id are required")
+	}
+	var binding model.NodeLicenseBinding
+	err := global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := getPendingBinding(ctx, tx, cmd, &binding); err != nil {
+			return err
+		}
+		if err := tx.Model(&binding).Update("status", entity.BindingStatusRejected).Error; err != nil {
+			return WrapInternal("update binding failed", err)
+		}
+		recordAuditLog(ctx, tx, "node", binding.NodeID, "reject_binding", map[string]interface{}{
+			"license_id": cmd.LicenseID,
+			"product_id": binding.ProductID,
+			"reason":     normalizeOptionalReason(cmd.Reason),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toBindingRequestData(ctx, global.DB.WithContext(ctx), &binding)
+}
+
+// requestBindingApproval 为需要审批的 License 提交新设备的绑定申请
+// 节点已绑定时返回 false 按正常流程处理；已提交过申请时保持待审批；已被拒绝时返回 403
+func requestBindingApproval(ctx context.Context, tx *gorm.DB, nodeID uint, licenseID uint, productID uint, now time.Time) (bool, error) {
+	var binding model.NodeLicenseBinding
+	err := tx.WithContext(ctx).
+		Where("node_id = ? AND license_id = ? AND product_id = ?", nodeID, licenseID, productID).
+		First(&binding).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, WrapInternal("get binding failed", err)
+	}
+	if err == nil {
+		switch entity.BindingStatus(binding.Status) {
+		case entity.BindingStatusBound:
+			return false, nil
+		case entity.BindingStatusPending:
+			return true, nil
+		case entity.BindingStatusRejected:
+			return false, ErrForbidden("binding rejected")
+		}
+		if err := tx.WithContext(ctx).Model(&binding).Updates(map[string]interface{}{
+			"status":       entity.BindingStatusPending,
+			"requested_at": now,
+		}).Error; err != nil {
+			return false, WrapInternal("update binding failed", err)
+		}
+	} else {
+		binding = model.NodeLicenseBinding{
+			NodeID:      nodeID,
+			LicenseID:   licenseID,
+			ProductID:   productID,
+			Status:      int(entity.BindingStatusPending),
+			RequestedAt: &now,
+		}
+		if err := tx.WithContext(ctx).Create(&binding).Error; err != nil {
+			return false, WrapInternal("create binding failed", err)
+		}
+	}
+	recordAuditLog(ctx, tx, "node", nodeID, "request_binding_approval", map[string]interface{}{
+		"license_id": licenseID,
+		"product_id": productID,
+	})
+	return true, nil
+}
+
+// getPendingBinding 绑定表没有租户字段，先按租户确认 License 存在，防止跨租户处理申请
+func getPendingBinding(ctx context.Context, tx *gorm.DB, cmd BindingDecisionCommand, binding *model.NodeLicenseBinding) error {
+	if err := ensureLicenseExists(ctx, tx, cmd.LicenseID); err != nil {
+		return err
+	}
+	if err := tx.WithContext(ctx).Where("id = ? AND license_id = ?", cmd.BindingID, cmd.LicenseID).
+		First(binding).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotFound("binding request not found")
+		}
+		return WrapInternal("get binding failed", err)
+	}
+	if binding.Status != int(entity.BindingStatusPending) {
+		return ErrConflict("binding is not pending approval")
+	}
+	return nil
+}
+
+func toBindingRequestList(ctx context.Context, db *gorm.DB, bindings []model.NodeLicenseBinding) ([]BindingRequestData, error) {
+	data := make([]BindingRequestData, 0, len(bindings))
+	if len(bindings) == 0 {
+		return data, nil
+	}
+	nodeIDs := make([]uint, 0, len(bindings))
+	for i := range bindings {
+		nodeIDs = append(nodeIDs, bindings[i].NodeID)
+	}
+	var nodes []model.Node
+	if err := db.WithContext(ctx).Where("id IN ?", nodeIDs).Find(&nodes).Error; err != nil {
+		return nil, WrapInternal("list binding request nodes failed", err)
+	}
+	deviceCodes := make(map[uint]string, len(nodes))
+	for i := range nodes {
+		deviceCodes[nodes[i].ID] = nodes[i].DeviceCode
+	}
+	for i := range bindings {
+		data = append(data, newBindingRequestData(&bindings[i], deviceCodes[bindings[i].NodeID]))
+	}
+	return data, nil
+}
+
+func toBindingRequestData(ctx context.Context, db *gorm.DB, binding *model.NodeLicenseBinding) (*BindingRequestData, error) {
+	list, err := toBindingRequestList(ctx, db, []model.NodeLicenseBinding{*binding})
+	if err != nil {
+		return nil, err
+	}
+	return &list[0], nil
+}
+
+func newBindingRequestData(binding *model.NodeLicenseBinding, deviceCode string) BindingRequestData {
+	status := BindingStatePendingApproval
+	switch entity.BindingStatus(binding.Status) {
+	case entity.BindingStatusBound:
+		status = BindingStateBound
+	case entity.BindingStatusRejected:
+		status = BindingStateRejected
+	}
+	return BindingRequestData{
+		ID:          binding.ID,
+		NodeID:      binding.NodeID,
+		DeviceCode:  deviceCode,
+		LicenseID:   binding.LicenseID,
+		ProductID:   binding.ProductID,
+		Status:      status,
+		RequestedAt: binding.RequestedAt,
+		BoundAt:     binding.BoundAt,
+	}
+}
@@ -0,0 +1,278 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"nexus-core/domain/entity"
+	"nexus-core/persistence/model"
+)
+
+func TestBindingApproval(t *testing.T) {
+	fixture := newFlowFixture(t, 1, 0, 24)
+	license, err := fixture.licenseService.SetLicenseApprovalRequired(fixture.ctx, SetLicenseApprovalCommand{
+		LicenseID: fixture.license.ID, ApprovalRequired: true,
+	})
+	if err != nil || !license.ApprovalRequired {
+		t.Fatalf("enable approval: %#v %v", license, err)
+	}
+	nodeCount := func() int {
+		t.Helper()
+		var current model.License
+		if err := fixture.db.Where("id = ?", fixture.license.ID).First(&current).Error; err != nil {
+			t.Fatalf("get license: %v", err)
+		}
+		return current.CurrentNodeCount
+	}
+
+	// 新设备注册后等待审批，不占用节点名额也不签发租约
+	registered := fixture.register(t, "device-a")
+	if registered.BindingStatus != BindingStatePendingApproval || registered.NodeSecret == "" || registered.LeaseToken != "" {
+		t.Fatalf("register should be pending approval: %#v", registered)
+	}
+	fixture.register(t, "device-b")
+	if count := nodeCount(); count != 0 {
+		t.Fatalf("pending bindings must not count against max nodes, got %d", count)
+	}
+	activatedAt := func() *time.Time {
+		t.Helper()
+		var current model.License
+		if err := fixture.db.Where("id = ?", fixture.license.ID).First(&current).Error; err != nil {
+			t.Fatalf("get license: %v", err)
+		}
+		return current.ActivatedAt
+	}
+	if activatedAt() != nil {
+		t.Fatalf("pending bindings must not activate the license")
+	}
+	heartbeat, err := fixture.heartbeat("device-a")
+	if err != nil || heartbeat.Online || heartbeat.BindingStatus != BindingStatePendingApproval {
+		t.Fatalf("heartbeat should report pending approval: %#v %v", heartbeat, err)
+	}
+	_, err = fixture.accessService.CheckoutSeat(fixture.ctx, SeatCheckoutCommand{AccessCommand: AccessCommand{
+		DeviceCode:  "device-a",
+		LicenseKey:  fixture.license.LicenseKey,
+		ProductID:   fixture.product.ID,
+		VersionCode: "1.0.0",
+		NodeSecret:  fixture.secrets["device-a"],
+	}})
+	if ErrorCodeOf(err) != CodeBindingPending {
+		t.Fatalf("pending node should not check out a seat: %v", err)
+	}
+
+	requests, err := fixture.licenseService.ListBindingRequests(fixture.ctx, fixture.license.ID)
+	if err != nil || len(requests) != 2 || requests[0].DeviceCode != "device-a" || requests[0].RequestedAt == nil {
+		t.Fatalf("list binding requests: %#v %v", requests, err)
+	}
+	decision := func(index int) BindingDecisionCommand {
+		return BindingDecisionCommand{LicenseID: fixture.license.ID, BindingID: requests[index].ID}
+	}
+
+	approved, err := fixture.licenseService.ApproveBinding(fixture.ctx, decision(0))
+	if err != nil || approved.Status != BindingStateBound || nodeCount() != 1 {
+		t.Fatalf("approve binding: %#v %v", approved, err)
+	}
+	if activatedAt() == nil {
+		t.Fatalf("approving the first binding should activate the license")
+	}
+	heartbeat, err = fixture.heartbeat("device-a")
+	if err != nil || !heartbeat.Online || heartbeat.BindingStatus != BindingStateBound {
+		t.Fatalf("approved node should be online: %#v %v", heartbeat, err)
+	}
+	_, err = fixture.licenseService.ApproveBinding(fixture.ctx, decision(0))
+	assertAppErrorKind(t, err, ErrorKindConflict)
+	_, err = fixture.licenseService.ApproveBinding(fixture.ctx, decision(1))
+	assertAppErrorKind(t, err, ErrorKindConflict)
+
+	// 被拒绝的设备不能注册，撤销申请后可以重新申请
+	reason := "unknown device"
+	rejected, err := fixture.licenseService.RejectBinding(fixture.ctx, BindingDecisionCommand{
+		LicenseID: fixture.license.ID, BindingID: requests[1].ID, Reason: &reason,
+	})
+	if err != nil || rejected.Status != BindingStateRejected {
+		t.Fatalf("reject binding: %#v %v", rejected, err)
+	}
+	_, err = fixture.accessService.Register(fixture.ctx, AccessCommand{
+		DeviceCode:  "device-b",
+		LicenseKey:  fixture.license.LicenseKey,
+		ProductID:   fixture.product.ID,
+		VersionCode: "1.0.0",
+		NodeSecret:  fixture.secrets["device-b"],
+	})
+	assertAppErrorKind(t, err, ErrorKindForbidden)
+	_, err = fixture.heartbeat("device-b")
+	assertAppErrorKind(t, err, ErrorKindForbidden)
+
+	if err := fixture.nodeService.UnbindByID(fixture.ctx, UnbindCommand{NodeID: rejected.NodeID, LicenseID: fixture.license.ID}); err != nil {
+		t.Fatalf("clear rejected request: %v", err)
+	}
+	if count := nodeCount(); count != 1 {
+		t.Fatalf("clearing a request must not release node seats, got %d", count)
+	}
+	if again := fixture.register(t, "device-b"); again.BindingStatus != BindingStatePendingApproval {
+		t.Fatalf("device should be able to request again: %#v", again)
+	}
+}
+
+func TestBindingApprovalDefersTrialConsumption(t *testing.T) {
+	fixture := newFlowFixture(t, 1, 0, 24)
+	trial, err := fixture.licenseService.CreateLicense(fixture.ctx, CreateLicenseCommand{
+		ProductID:     fixture.product.ID,
+		Type:          int(entity.LicenseTypeTrial),
+		ValidityHours: 48,
+	})
+	if err != nil {
+		t.Fatalf("create trial: %v", err)
+	}
+	if _, err := fixture.licenseService.SetLicenseApprovalRequired(fixture.ctx, SetLicenseApprovalCommand{
+		LicenseID: trial.ID, ApprovalRequired: true,
+	}); err != nil {
+		t.Fatalf("enable approval: %v", err)
+	}
+	fixture.license = trial
+	trials := func() int64 {
+		t.Helper()
+		var count int64
+		if err := fixture.db.Model(&model.TrialConsumption{}).Where("license_id = ?", trial.ID).Count(&count).Error; err != nil {
+			t.Fatalf("count trial consumption: %v", err)
+		}
+		return count
+	}
+
+	// 待审批和被拒绝的设备不消耗试用
+	fixture.register(t, "device-a")
+	if count := trials(); count != 0 {
+		t.Fatalf("pending device must not consume the trial, got %d", count)
+	}
+	requests, err := fixture.licenseService.ListBindingRequests(fixture.ctx, trial.ID)
+	if err != nil || len(requests) != 1 {
+		t.Fatalf("list binding requests: %#v %v", requests, err)
+	}
+	if _, err := fixture.licenseService.RejectBinding(fixture.ctx, BindingDecisionCommand{
+		LicenseID: trial.ID, BindingID: requests[0].ID,
+	}); err != nil {
+		t.Fatalf("reject binding: %v", err)
+	}
+	if count := trials(); count != 0 {
+		t.Fatalf("rejected device must not consume the trial, got %d", count)
+	}
+
+	fixture.register(t, "device-b")
+	requests, err = fixture.licenseService.ListBindingRequests(fixture.ctx, trial.ID)
+	if err != nil || len(requests) != 2 {
+		t.Fatalf("list binding requests: %#v %v", requests, err)
+	}
+	if _, err := fixture.licenseService.ApproveBinding(fixture.ctx, BindingDecisionCommand{
+		LicenseID: trial.ID, BindingID: requests[1].ID,
+	}); err != nil {
+		t.Fatalf("approve binding: %v", err)
+	}
+	if count := trials(); count != 1 {
+		t.Fatalf("approved device should consume the trial, got %d", count)
+	}
+}
+
+func TestCleanUnboundNodeKeepsPendingRequests(t *testing.T) {
+	fixture := newFlowFixture(t, 1, 0, 24)
+	if _, err := fixture.licenseService.SetLicenseApprovalRequired(fixture.ctx, SetLicenseApprovalCommand{
+		LicenseID: fixture.license.ID, ApprovalRequired: true,
+	}); err != nil {
+		t.Fatalf("enable approval: %v", err)
+	}
+	fixture.register(t, "device-a")
+
+	// 待审批申请的节点没有已绑定记录，清理后仍可批准
+	if err := fixture.nodeService.CleanUnboundNode(fixture.ctx); err != nil {
+		t.Fatalf("clean unbound nodes: %v", err)
+	}
+	requests, err := fixture.licenseService.ListBindingRequests(fixture.ctx, fixture.license.ID)
+	if err != nil || len(requests) != 1 {
+		t.Fatalf("list binding requests: %#v %v", requests, err)
+	}
+	approved, err := fixture.licenseService.ApproveBinding(fixture.ctx, BindingDecisionCommand{
+		LicenseID: fixture.license.ID, BindingID: requests[0].ID,
+	})
+	if err != nil || approved.Status != BindingStateBound {
+		t.Fatalf("approve after cleanup: %#v %v", approved, err)
+	}
+}
+
+func TestApproveBindingRechecksNodeAndProduct(t *testing.T) {
+	fixture := newFlowFixture(t, 0, 0, 24)
+	if _, err := fixture.licenseService.SetLicenseApprovalRequired(fixture.ctx, SetLicenseApprovalCommand{
+		LicenseID: fixture.license.ID, ApprovalRequired: true,
+	}); err != nil {
+		t.Fatalf("enable approval: %v", err)
+	}
+	addon, err := fixture.productService.CreateProduct(fixture.ctx, CreateProductCommand{Name: "addon-product"})
+	if err != nil {
+		t.Fatalf("create addon product: %v", err)
+	}
+	if _, err := fixture.productService.CreateProductVersion(fixture.ctx, CreateProductVersionCommand{
+		ProductID: addon.ID, VersionCode: "1.0.0", Method: ReleaseImmediate,
+	}); err != nil {
+		t.Fatalf("create addon version: %v", err)
+	}
+	setScope := func(status int) {
+		t.Helper()
+		if _, err := fixture.licenseService.SetLicenseProductScope(fixture.ctx, SetLicenseProductScopeCommand{
+			LicenseID: fixture.license.ID, ProductID: addon.ID, Status: status,
+		}); err != nil {
+			t.Fatalf("set product scope: %v", err)
+		}
+	}
+	requestAddon := func(device string) {
+		t.Helper()
+		result, err := fixture.accessService.Register(fixture.ctx, AccessCommand{
+			DeviceCode: device, LicenseKey: fixture.license.LicenseKey, ProductID: addon.ID, VersionCode: "1.0.0",
+		})
+		if err != nil || result.BindingStatus != BindingStatePendingApproval {
+			t.Fatalf("register addon product: %#v %v", result, err)
+		}
+	}
+	pending := func() []BindingRequestData {
+		t.Helper()
+		requests, err := fixture.licenseService.ListBindingRequests(fixture.ctx, fixture.license.ID)
+		if err != nil {
+			t.Fatalf("list binding requests: %v", err)
+		}
+		return requests
+	}
+	approve := func(request BindingRequestData) error {
+		_, err := fixture.licenseService.ApproveBinding(fixture.ctx, BindingDecisionCommand{
+			LicenseID: fixture.license.ID, BindingID: request.ID,
+		})
+		return err
+	}
+
+	// 申请后产品授权被停用，批准时拒绝
+	setScope(1)
+	requestAddon("device-a")
+	setScope(2)
+	requests := pending()
+	if len(requests) != 1 {
+		t.Fatalf("expected one request: %#v", requests)
+	}
+	assertAppErrorKind(t, approve(requests[0]), ErrorKindForbidden)
+
+	// 申请后节点被禁用，批准时拒绝
+	registered := fixture.register(t, "device-b")
+	if err := fixture.nodeService.BanNode(fixture.ctx, UpdateNodeStatusCommand{NodeID: registered.NodeID}); err != nil {
+		t.Fatalf("ban node: %v", err)
+	}
+	requests = pending()
+	if len(requests) != 2 || requests[1].NodeID != registered.NodeID {
+		t.Fatalf("expected banned node request: %#v", requests)
+	}
+	assertAppErrorKind(t, approve(requests[1]), ErrorKindForbidden)
+
+	// 移除产品授权时撤销该产品的待审批申请
+	if err := fixture.licenseService.RemoveLicenseProductScope(fixture.ctx, RemoveLicenseProductScopeCommand{
+		LicenseID: fixture.license.ID, ProductID: addon.ID,
+	}); err != nil {
+		t.Fatalf("remove product scope: %v", err)
+	}
+	if requests = pending(); len(requests) != 1 || requests[0].NodeID != registered.NodeID {
+		t.Fatalf("addon request should be withdrawn: %#v", requests)
+	}
+}
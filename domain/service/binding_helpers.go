@@ -10,10 +10,13 @@ import (
 	"gorm.io/gorm"
 )
 
-func bindNodeToLicense(ctx context.Context, tx *gorm.DB, nodeID uint, license *entity.License, productID uint) (bool, error) {
+// bindNodeToLicense 将节点绑定到 License 的指定产品，已绑定时返回 false
+// 每个产品单独占用一个节点名额，同时受 License 总数、该产品限制、换绑限制、设备白名单与设备预留约束
+func bindNodeToLicense(ctx context.Context, tx *gorm.DB, node *entity.Node, license *entity.License, productID uint) (bool, error) {
+	nodeID := node.ID
 	var binding model.NodeLicenseBinding
 	err := tx.WithContext(ctx).
-		Where("node_id = ? AND license_id = ?", nodeID, license.ID).
+		Where("node_id = ? AND license_id = ? AND product_id = ?", nodeID, license.ID, productID).
 		First(&binding).Error
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		return false, WrapInternal("get binding failed", err)
@@ -22,6 +25,15 @@ func bindNodeToLicense(ctx context.Context, tx *gorm.DB, nodeID uint, license *e
 		return false, nil
 	}
 
+	if err := checkNodeBindable(ctx, tx, node, license.ID); err != nil {
+		return false, err
+	}
+	if err := checkTransferPolicy(ctx, tx, nodeID, license, time.Now()); err != nil {
+		return false, err
+	}
+	if err := checkProductNodeLimit(ctx, tx, license.ID, productID); err != nil {
+		return false, err
+	}
 	if err := incrementLicenseNodeCount(ctx, tx, license.ID); err != nil {
 		return false, err
 	}
@@ -41,7 +53,6 @@ func bindNodeToLicense(ctx context.Context, tx *gorm.DB, nodeID uint, license *e
 		}
 	} else {
 		if err := tx.WithContext(ctx).Model(&binding).Updates(map[string]interface{}{
-			"product_id": productID,
 			"status":     entity.BindingStatusBound,
 			"is_bound":   true,
 			"bound_at":   &now,
@@ -56,6 +67,28 @@ func bindNodeToLicense(ctx context.Context, tx *gorm.DB, nodeID uint, license *e
 	return true, nil
 }
 
+// checkNodeBindable 校验设备在 License 的白名单内，且没有预留给其他 License
+// 所有绑定入口（注册、绑定申请、审批、管理员绑定）都经过该检查
+func checkNodeBindable(ctx context.Context, tx *gorm.DB, node *entity.Node, licenseID uint) error {
+	if err := checkDeviceAllowed(ctx, tx, licenseID, node.DeviceCode); err != nil {
+		return err
+	}
+	if node.ReservedForOther(licenseID) {
+		return ErrForbidden("device reserved for another license")
+	}
+	return nil
+}
+
+// touchBinding 记录绑定最近一次注册或心跳的时间，不活跃节点按此自动解绑
+func touchBinding(ctx context.Context, tx *gorm.DB, nodeID uint, licenseID uint, productID uint, now time.Time) error {
+	if err := tx.WithContext(ctx).Model(&model.NodeLicenseBinding{}).
+		Where("node_id = ? AND license_id = ? AND product_id = ?", nodeID, licenseID, productID).
+		Update("last_active_at", now).Error; err != nil {
+		return WrapInternal("update binding activity failed", err)
+	}
+	return nil
+}
+
 func incrementLicenseNodeCount(ctx context.Context, tx *gorm.DB, licenseID uint) error {
 	result := tx.WithContext(ctx).Model(&model.License{}).
 		Where("id = ? AND (max_nodes = 0 OR current_node_count < max_nodes)", licenseID).
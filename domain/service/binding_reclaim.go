@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"nexus-core/domain/entity"
+	"nexus-core/global"
+	"nexus-core/monitor"
+	"nexus-core/persistence/model"
+
+	"gorm.io/gorm"
+)
+
+// inactiveBindingCandidate 开启了自动解绑策略的已绑定记录
+type inactiveBindingCandidate struct {
+	ID           uint
+	NodeID       uint
+	LicenseID    uint
+	ProductID    uint
+	TenantID     uint
+	BoundAt      *time.Time
+	LastActiveAt *time.Time
+	LicenseDays  *int
+	ProductDays  *int
+}
+
+// inactiveDays 生效的自动解绑天数，License 单独设置优先于产品设置
+func (c *inactiveBindingCandidate) inactiveDays() int {
+	if c.LicenseDays != nil {
+		return *c.LicenseDays
+	}
+	if c.ProductDays != nil {
+		return *c.ProductDays
+	}
+	return 0
+}
+
+// ReclaimInactiveBindings 解绑超过策略天数没有注册或心跳的节点，释放 License 的节点名额
+// 策略取 License 的 inactive_unbind_days，未设置时取绑定产品的设置，返回解绑数量
+func (s *NodeService) ReclaimInactiveBindings(ctx context.Context, now time.Time) (int, error) {
+	var candidates []inactiveBindingCandidate
+	if err := global.DB.WithContext(ctx).Table("node_license_binding AS b").
+		Select("b.id, b.node_id, b.license_id, b.product_id, l.tenant_id, b.bound_at, b.last_active_at, "+
+			"l.inactive_unbind_days AS license_days, p.inactive_unbind_days AS product_days").
+		Joins("JOIN license AS l ON l.id = b.license_id AND l.deleted_at IS NULL").
+		Joins("LEFT JOIN product AS p ON p.id = b.product_id AND p.deleted_at IS NULL").
+		Where("b.status = ? AND b.deleted_at IS NULL", entity.BindingStatusBound).
+		Where("l.inactive_unbind_days > 0 OR (l.inactive_unbind_days IS NULL AND p.inactive_unbind_days > 0)").
+		Order("b.id ASC").
+		Scan(&candidates).Error; err != nil {
+		return 0, WrapInternal("list inactive bindings failed", err)
+	}
+
+	reclaimed := 0
+	for i := range candidates {
+		candidate := &candidates[i]
+		lastActive := candidate.LastActiveAt
+		if lastActive == nil {
+			lastActive = candidate.BoundAt
+		}
+		days := candidate.inactiveDays()
+		if lastActive == nil || days <= 0 || now.Sub(*lastActive) < time.Duration(days)*24*time.Hour {
+			continue
+		}
+		// 单条解绑失败只记录日志，不影响其余记录，下次执行时重试
+		unbound, err := unbindInactiveBinding(ctx, candidate, *lastActive, days, now)
+		if err != nil {
+			fmt.Printf("reclaim inactive binding %d failed: %v\n", candidate.ID, err)
+			continue
+		}
+		if unbound {
+			reclaimed++
+		}
+	}
+	return reclaimed, nil
+}
+
+func unbindInactiveBinding(ctx context.Context, candidate *inactiveBindingCandidate, lastActive time.Time, days int, now time.Time) (bool, error) {
+	// 后台任务不带租户，按 License 所属租户写入审计
+	ctx = model.WithTenant(ctx, candidate.TenantID)
+	unbound := false
+	onlineKey := ""
+	err := global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 只解绑仍处于绑定状态的记录，期间节点重新心跳或已被手动解绑时跳过
+		result := tx.Model(&model.NodeLicenseBinding{}).
+			Where("id = ? AND status = ?", candidate.ID, entity.BindingStatusBound).
+			Where("last_active_at IS NULL OR last_active_at <= ?", lastActive).
+			Updates(map[string]interface{}{
+				"status":     entity.BindingStatusUnbound,
+				"is_bound":   false,
+				"unbound_at": now,
+			})
+		if result.Error != nil {
+			return WrapInternal("update binding failed", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		if err := decrementLicenseNodeCount(ctx, tx, candidate.LicenseID); err != nil {
+			return WrapInternal("update license node count failed", err)
+		}
+		key, err := releaseBindingUsage(ctx, tx, candidate.NodeID, candidate.LicenseID, candidate.ProductID)
+		if err != nil {
+			return err
+		}
+		onlineKey = key
+		recordAuditLog(ctx, tx, "node", candidate.NodeID, "auto_unbind_inactive", map[string]interface{}{
+			"license_id":     candidate.LicenseID,
+			"product_id":     candidate.ProductID,
+			"last_active_at": lastActive,
+			"inactive_days":  days,
+		})
+		unbound = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	if onlineKey != "" {
+		monitor.GlobalStat.RemoveOnlineNode(onlineKey)
+	}
+	return unbound, nil
+}
+
+// releaseBindingUsage 释放节点在 License 某产品上持有的浮动席位和排队记录，返回节点在该产品上的在线统计标识
+// 在线统计不在事务内，由调用方在事务提交后移除
+func releaseBindingUsage(ctx context.Context, tx *gorm.DB, nodeID uint, licenseID uint, productID uint) (string, error) {
+	const query = "license_id = ? AND product_id = ? AND node_id = ?"
+	if err := tx.WithContext(ctx).Unscoped().Where(query, licenseID, productID, nodeID).
+		Delete(&model.SeatLease{}).Error; err != nil {
+		return "", WrapInternal("release seat lease failed", err)
+	}
+	if err := tx.WithContext(ctx).Unscoped().Where(query, licenseID, productID, nodeID).
+		Delete(&model.SeatWaiter{}).Error; err != nil {
+		return "", WrapInternal("delete seat waiter failed", err)
+	}
+	var license model.License
+	if err := tx.WithContext(ctx).Select("id", "tenant_id", "key_digest").
+		Where("id = ?", licenseID).Take(&license).Error; err != nil {
+		return "", WrapInternal("get license failed", err)
+	}
+	var node model.Node
+	if err := tx.WithContext(ctx).Select("id", "device_code").
+		Where("id = ?", nodeID).Take(&node).Error; err != nil {
+		return "", WrapInternal("get node failed", err)
+	}
+	return monitor.NewOnlineNodeKey(license.TenantID, productID, node.DeviceCode, license.KeyDigest).Key(), nil
+}
+
+// StartInactiveBindingReclaimWorker 定期解绑不活跃的节点
+func (s *NodeService) StartInactiveBindingReclaimWorker(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		if _, err := s.ReclaimInactiveBindings(ctx, time.Now()); err != nil {
+			fmt.Printf("reclaim inactive bindings failed: %v\n", err)
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := s.ReclaimInactiveBindings(ctx, time.Now()); err != nil {
+					fmt.Printf("reclaim inactive bindings failed: %v\n", err)
+				}
+			}
+		}
+	}()
+}
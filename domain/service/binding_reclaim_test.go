@@ -0,0 +1,112 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"nexus-core/monitor"
+	"nexus-core/persistence/model"
+)
+
+func TestReclaimInactiveBindings(t *testing.T) {
+	fixture := newFlowFixture(t, 2, 0, 24*365)
+	fixture.register(t, "device-a")
+	fixture.register(t, "device-b")
+
+	days := 30
+	if _, err := fixture.productService.UpdateProduct(fixture.ctx, UpdateProductCommand{
+		ID:                 fixture.product.ID,
+		InactiveUnbindDays: &days,
+	}); err != nil {
+		t.Fatalf("set product inactive unbind policy: %v", err)
+	}
+	deviceBinding := func(device string) model.NodeLicenseBinding {
+		t.Helper()
+		var binding model.NodeLicenseBinding
+		if err := fixture.db.Joins("JOIN node ON node.id = node_license_binding.node_id").
+			Where("node.device_code = ?", device).First(&binding).Error; err != nil {
+			t.Fatalf("get binding for %s: %v", device, err)
+		}
+		return binding
+	}
+	if deviceBinding("device-a").LastActiveAt == nil {
+		t.Fatalf("register should record binding activity")
+	}
+	idleSince := func(device string, age time.Duration) {
+		t.Helper()
+		if err := fixture.db.Model(&model.NodeLicenseBinding{}).Where("id = ?", deviceBinding(device).ID).
+			Update("last_active_at", time.Now().Add(-age)).Error; err != nil {
+			t.Fatalf("age binding: %v", err)
+		}
+	}
+
+	if _, err := fixture.heartbeat("device-a"); err != nil {
+		t.Fatalf("heartbeat device-a: %v", err)
+	}
+	if _, err := fixture.accessService.CheckoutSeat(fixture.ctx, SeatCheckoutCommand{AccessCommand: AccessCommand{
+		DeviceCode:  "device-a",
+		LicenseKey:  fixture.license.LicenseKey,
+		ProductID:   fixture.product.ID,
+		VersionCode: "1.0.0",
+		NodeSecret:  fixture.secrets["device-a"],
+	}}); err != nil {
+		t.Fatalf("checkout device-a: %v", err)
+	}
+
+	idleSince("device-a", 31*24*time.Hour)
+	idleSince("device-b", 29*24*time.Hour)
+	reclaimed, err := fixture.nodeService.ReclaimInactiveBindings(fixture.ctx, time.Now())
+	if err != nil || reclaimed != 1 {
+		t.Fatalf("expected one reclaimed binding, got %d %v", reclaimed, err)
+	}
+	var current model.License
+	if err := fixture.db.Where("id = ?", fixture.license.ID).First(&current).Error; err != nil || current.CurrentNodeCount != 1 {
+		t.Fatalf("node count should be released: %d %v", current.CurrentNodeCount, err)
+	}
+	// 解绑同时释放席位和在线名额
+	var seats int64
+	if err := fixture.db.Model(&model.SeatLease{}).Where("license_id = ?", fixture.license.ID).Count(&seats).Error; err != nil || seats != 0 {
+		t.Fatalf("seat lease should be released: %d %v", seats, err)
+	}
+	for _, key := range monitor.GlobalStat.Snapshot() {
+		if key.DeviceCode == "device-a" {
+			t.Fatalf("reclaimed node should leave the online stat: %#v", key)
+		}
+	}
+	var audits int64
+	fixture.db.Model(&model.AuditLog{}).Where("action = ?", "auto_unbind_inactive").Count(&audits)
+	if audits != 1 {
+		t.Fatalf("expected auto unbind audit log, got %d", audits)
+	}
+	_, err = fixture.heartbeat("device-a")
+	assertAppErrorKind(t, err, ErrorKindConflict)
+
+	// 心跳会刷新活跃时间
+	idleSince("device-b", 40*24*time.Hour)
+	if _, err := fixture.heartbeat("device-b"); err != nil {
+		t.Fatalf("heartbeat device-b: %v", err)
+	}
+	if reclaimed, err := fixture.nodeService.ReclaimInactiveBindings(fixture.ctx, time.Now()); err != nil || reclaimed != 0 {
+		t.Fatalf("active binding should be kept, got %d %v", reclaimed, err)
+	}
+
+	// License 单独设置 0 时关闭自动解绑
+	disabled := 0
+	data, err := fixture.licenseService.SetLicenseInactiveUnbind(fixture.ctx, SetLicenseInactiveUnbindCommand{
+		LicenseID:          fixture.license.ID,
+		InactiveUnbindDays: &disabled,
+	})
+	if err != nil || data.InactiveUnbindDays == nil || *data.InactiveUnbindDays != 0 {
+		t.Fatalf("set license inactive unbind: %#v %v", data, err)
+	}
+	idleSince("device-b", 40*24*time.Hour)
+	if reclaimed, err := fixture.nodeService.ReclaimInactiveBindings(fixture.ctx, time.Now()); err != nil || reclaimed != 0 {
+		t.Fatalf("disabled policy should keep bindings, got %d %v", reclaimed, err)
+	}
+	negative := -1
+	_, err = fixture.licenseService.SetLicenseInactiveUnbind(fixture.ctx, SetLicenseInactiveUnbindCommand{
+		LicenseID:          fixture.license.ID,
+		InactiveUnbindDays: &negative,
+	})
+	assertAppErrorKind(t, err, ErrorKindBadRequest)
+}
@@ -229,6 +229,10 @@ func (s *ControlService) CompleteControlCommand(ctx context.Context, cmd Complet
 	if err != nil {
 		return nil, WrapInternal("get control command failed", err)
 	}
+	// 只有指令所属节点可以回报执行结果
+	if err := authenticateNode(ctx, global.DB.WithContext(ctx), command.NodeID, cmd.NodeSecret); err != nil {
+		return nil, err
+	}
 
 	response := ControlCommandResponse{
 		CommandID:    cmd.CommandID,
@@ -300,6 +304,9 @@ func validateNodeCapabilityCommand(ctx context.Context, cmd ReportNodeCapability
 	if node == nil {
 		return ErrNotFound("node not found")
 	}
+	if err := verifyNodeCredential(node, cmd.NodeSecret); err != nil {
+		return err
+	}
 	if !node.IsValid() {
 		return ErrForbidden("invalid node")
 	}
@@ -337,12 +344,22 @@ func validateNodeHasValidBindingForControl(ctx context.Context, nodeID uint, ser
 		return WrapInternal("get node bindings failed", err)
 	}
 
+	graceRestricted := false
 	for _, binding := range bindings {
 		license, err := GetLicenseEntityByID(ctx, global.DB.WithContext(ctx), binding.LicenseID)
 		if err != nil {
 			return WrapInternal("get license failed", err)
 		}
-		if license != nil && license.CalculateStatus(time.Now()) == entity.StatusActive {
+		if license == nil {
+			continue
+		}
+		status := license.CalculateStatus(time.Now())
+		// 宽限期内是否允许控制命令由产品设置决定
+		if status == entity.StatusGrace && !license.Grace.AllowControl {
+			graceRestricted = true
+			continue
+		}
+		if status == entity.StatusActive || status == entity.StatusGrace {
 			if err := validateLicenseControlServiceScope(ctx, license.ID, serviceDef.Identifier); err != nil {
 				return err
 			}
@@ -350,6 +367,9 @@ func validateNodeHasValidBindingForControl(ctx context.Context, nodeID uint, ser
 		}
 	}
 
+	if graceRestricted {
+		return ErrForbidden("license in grace period, control commands are restricted")
+	}
 	return ErrForbidden("node has no valid license for control service")
 }
 
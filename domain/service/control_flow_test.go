@@ -43,6 +43,9 @@ func setupControlFlowTest(t *testing.T) context.Context {
 
 	base.AutoMigrate(db)
 	global.DB = db
+	if err := NewSigningService().EnsureActiveKey(context.Background()); err != nil {
+		t.Fatalf("ensure signing key: %v", err)
+	}
 	monitor.GlobalStat = monitor.NewOnlineStat()
 	monitor.GlobalMonitor = monitor.NewMonitor(monitor.GlobalStat)
 	return context.Background()
@@ -85,7 +88,13 @@ func TestControlFlowHTTPDispatch(t *testing.T) {
 	if err != nil {
 		t.Fatalf("register: %v", err)
 	}
-	if _, err := accessService.Heartbeat(ctx, "control-node", product.ID, "1.0.0", license.LicenseKey); err != nil {
+	if _, err := accessService.Heartbeat(ctx, AccessCommand{
+		DeviceCode:  "control-node",
+		LicenseKey:  license.LicenseKey,
+		ProductID:   product.ID,
+		VersionCode: "1.0.0",
+		NodeSecret:  register.NodeSecret,
+	}); err != nil {
 		t.Fatalf("heartbeat: %v", err)
 	}
 
@@ -115,6 +124,7 @@ func TestControlFlowHTTPDispatch(t *testing.T) {
 
 	_, err = controlService.ReportNodeCapability(ctx, ReportNodeCapabilityCommand{
 		NodeID:            register.NodeID,
+		NodeSecret:        register.NodeSecret,
 		ServiceIdentifier: "restart_process",
 		Protocol:          "http",
 		Endpoint:          &nodeServer.URL,
@@ -158,7 +168,7 @@ func TestControlFlowHTTPDispatch(t *testing.T) {
 
 func TestControlFlowMQTTDispatch(t *testing.T) {
 	ctx := setupControlFlowTest(t)
-	_, nodeID := prepareControlFlowTarget(t, ctx)
+	_, nodeID, nodeSecret := prepareControlFlowTarget(t, ctx)
 
 	fakePublisher := &fakeMQTTPublisher{}
 	oldPublisher := DefaultMQTTPublisher
@@ -169,6 +179,7 @@ func TestControlFlowMQTTDispatch(t *testing.T) {
 	topic := "nodes/control-node/restart"
 	_, err := controlService.ReportNodeCapability(ctx, ReportNodeCapabilityCommand{
 		NodeID:            nodeID,
+		NodeSecret:        nodeSecret,
 		ServiceIdentifier: "restart_process",
 		Protocol:          "mqtt",
 		Endpoint:          &topic,
@@ -215,7 +226,7 @@ func TestControlFlowMQTTDispatch(t *testing.T) {
 
 func TestControlFlowWebSocketDispatch(t *testing.T) {
 	ctx := setupControlFlowTest(t)
-	_, nodeID := prepareControlFlowTarget(t, ctx)
+	_, nodeID, nodeSecret := prepareControlFlowTarget(t, ctx)
 
 	hub := NewControlWebSocketHub()
 	oldHub := DefaultControlWebSocketHub
@@ -223,7 +234,7 @@ func TestControlFlowWebSocketDispatch(t *testing.T) {
 	t.Cleanup(func() { DefaultControlWebSocketHub = oldHub })
 
 	wsServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if err := hub.ServeHTTP(w, r, nodeID); err != nil {
+		if err := hub.ServeHTTP(w, r, nodeID, nodeSecret); err != nil {
 			t.Logf("serve websocket: %v", err)
 		}
 	}))
@@ -253,6 +264,7 @@ func TestControlFlowWebSocketDispatch(t *testing.T) {
 	controlService := NewControlService()
 	_, err = controlService.ReportNodeCapability(ctx, ReportNodeCapabilityCommand{
 		NodeID:            nodeID,
+		NodeSecret:        nodeSecret,
 		ServiceIdentifier: "restart_process",
 		Protocol:          "websocket",
 		Schema: json.RawMessage(`{
@@ -283,7 +295,7 @@ func TestControlFlowWebSocketDispatch(t *testing.T) {
 
 func TestControlServiceManagementLifecycle(t *testing.T) {
 	ctx := setupControlFlowTest(t)
-	productID, nodeID := prepareControlFlowTarget(t, ctx)
+	productID, nodeID, nodeSecret := prepareControlFlowTarget(t, ctx)
 	controlService := NewControlService()
 
 	services, err := controlService.ListControlServices(ctx, &productID)
@@ -325,6 +337,7 @@ func TestControlServiceManagementLifecycle(t *testing.T) {
 	topic := "nodes/control-node/service-management"
 	_, err = controlService.ReportNodeCapability(ctx, ReportNodeCapabilityCommand{
 		NodeID:            nodeID,
+		NodeSecret:        nodeSecret,
 		ServiceIdentifier: "restart_process",
 		Protocol:          "mqtt",
 		Endpoint:          &topic,
@@ -341,6 +354,7 @@ func TestControlServiceManagementLifecycle(t *testing.T) {
 
 	if _, err := controlService.ReportNodeCapability(ctx, ReportNodeCapabilityCommand{
 		NodeID:            nodeID,
+		NodeSecret:        nodeSecret,
 		ServiceIdentifier: "restart_process",
 		Protocol:          "mqtt",
 		Endpoint:          &topic,
@@ -450,6 +464,7 @@ func TestControlCommandRequiresOnlineNode(t *testing.T) {
 	topic := "nodes/offline-control-node/restart"
 	if _, err := controlService.ReportNodeCapability(ctx, ReportNodeCapabilityCommand{
 		NodeID:            register.NodeID,
+		NodeSecret:        register.NodeSecret,
 		ServiceIdentifier: "restart_process",
 		Protocol:          "mqtt",
 		Endpoint:          &topic,
@@ -468,7 +483,7 @@ func TestControlCommandRequiresOnlineNode(t *testing.T) {
 
 func TestControlCommandLicenseServiceScope(t *testing.T) {
 	ctx := setupControlFlowTest(t)
-	_, nodeID := prepareControlFlowTarget(t, ctx)
+	_, nodeID, nodeSecret := prepareControlFlowTarget(t, ctx)
 	controlService := NewControlService()
 
 	var binding model.NodeLicenseBinding
@@ -486,6 +501,7 @@ func TestControlCommandLicenseServiceScope(t *testing.T) {
 	topic := "nodes/control-node/scope"
 	if _, err := controlService.ReportNodeCapability(ctx, ReportNodeCapabilityCommand{
 		NodeID:            nodeID,
+		NodeSecret:        nodeSecret,
 		ServiceIdentifier: "restart_process",
 		Protocol:          "mqtt",
 		Endpoint:          &topic,
@@ -528,7 +544,7 @@ func TestControlCommandLicenseServiceScope(t *testing.T) {
 
 func TestCompleteControlCommandAndHeartbeatPendingSummary(t *testing.T) {
 	ctx := setupControlFlowTest(t)
-	productID, nodeID := prepareControlFlowTarget(t, ctx)
+	productID, nodeID, nodeSecret, licenseKey := prepareControlFlowTargetWithKey(t, ctx)
 	controlService := NewControlService()
 
 	services, err := controlService.ListControlServices(ctx, &productID)
@@ -552,6 +568,7 @@ func TestCompleteControlCommandAndHeartbeatPendingSummary(t *testing.T) {
 	topic := "nodes/control-node/async"
 	if _, err := controlService.ReportNodeCapability(ctx, ReportNodeCapabilityCommand{
 		NodeID:            nodeID,
+		NodeSecret:        nodeSecret,
 		ServiceIdentifier: "restart_process",
 		Protocol:          "mqtt",
 		Endpoint:          &topic,
@@ -581,16 +598,14 @@ func TestCompleteControlCommandAndHeartbeatPendingSummary(t *testing.T) {
 	if err := global.DB.WithContext(ctx).Where("id = ?", nodeID).First(&node).Error; err != nil {
 		t.Fatalf("get node: %v", err)
 	}
-	var binding model.NodeLicenseBinding
-	if err := global.DB.WithContext(ctx).Where("node_id = ?", nodeID).First(&binding).Error; err != nil {
-		t.Fatalf("get binding: %v", err)
-	}
-	var license model.License
-	if err := global.DB.WithContext(ctx).Where("id = ?", binding.LicenseID).First(&license).Error; err != nil {
-		t.Fatalf("get license: %v", err)
-	}
 	pending, err := NewAccessService(NewLicenseService(), NewNodeService(), NewProductService()).
-		Heartbeat(ctx, node.DeviceCode, productID, "1.0.0", license.LicenseKey)
+		Heartbeat(ctx, AccessCommand{
+			DeviceCode:  node.DeviceCode,
+			LicenseKey:  licenseKey,
+			ProductID:   productID,
+			VersionCode: "1.0.0",
+			NodeSecret:  nodeSecret,
+		})
 	if err != nil {
 		t.Fatalf("heartbeat pending summary: %v", err)
 	}
@@ -599,9 +614,10 @@ func TestCompleteControlCommandAndHeartbeatPendingSummary(t *testing.T) {
 	}
 
 	completed, err := controlService.CompleteControlCommand(ctx, CompleteControlCommandCommand{
-		CommandID: command.ID,
-		Status:    "success",
-		Result:    json.RawMessage(`{"applied":true}`),
+		CommandID:  command.ID,
+		NodeSecret: nodeSecret,
+		Status:     "success",
+		Result:     json.RawMessage(`{"applied":true}`),
 	})
 	if err != nil {
 		t.Fatalf("complete command: %v", err)
@@ -652,7 +668,14 @@ func (f *fakeMQTTPublisher) Publish(ctx context.Context, topic string, payload [
 	return nil
 }
 
-func prepareControlFlowTarget(t *testing.T, ctx context.Context) (uint, uint) {
+func prepareControlFlowTarget(t *testing.T, ctx context.Context) (uint, uint, string) {
+	t.Helper()
+	productID, nodeID, nodeSecret, _ := prepareControlFlowTargetWithKey(t, ctx)
+	return productID, nodeID, nodeSecret
+}
+
+// prepareControlFlowTargetWithKey 同时返回注册码，数据库中只保存注册码摘要
+func prepareControlFlowTargetWithKey(t *testing.T, ctx context.Context) (uint, uint, string, string) {
 	t.Helper()
 
 	productService := NewProductService()
@@ -689,7 +712,13 @@ func prepareControlFlowTarget(t *testing.T, ctx context.Context) (uint, uint) {
 	if err != nil {
 		t.Fatalf("register: %v", err)
 	}
-	if _, err := accessService.Heartbeat(ctx, "control-node-shared", product.ID, "1.0.0", license.LicenseKey); err != nil {
+	if _, err := accessService.Heartbeat(ctx, AccessCommand{
+		DeviceCode:  "control-node-shared",
+		LicenseKey:  license.LicenseKey,
+		ProductID:   product.ID,
+		VersionCode: "1.0.0",
+		NodeSecret:  register.NodeSecret,
+	}); err != nil {
 		t.Fatalf("heartbeat: %v", err)
 	}
 
@@ -705,5 +734,5 @@ func prepareControlFlowTarget(t *testing.T, ctx context.Context) (uint, uint) {
 		t.Fatalf("create control service: %v", err)
 	}
 
-	return product.ID, register.NodeID
+	return product.ID, register.NodeID, register.NodeSecret, license.LicenseKey
 }
@@ -35,6 +35,9 @@ func setupControlServiceTestDB(t *testing.T) context.Context {
 
 	base.AutoMigrate(db)
 	global.DB = db
+	if err := NewSigningService().EnsureActiveKey(context.Background()); err != nil {
+		t.Fatalf("ensure signing key: %v", err)
+	}
 	return context.Background()
 }
 
@@ -50,10 +50,14 @@ func NewControlWebSocketHub() *ControlWebSocketHub {
 	}
 }
 
-func (h *ControlWebSocketHub) ServeHTTP(w http.ResponseWriter, r *http.Request, nodeID uint) error {
+// ServeHTTP 接受节点发起的控制通道连接，升级前校验节点密钥
+func (h *ControlWebSocketHub) ServeHTTP(w http.ResponseWriter, r *http.Request, nodeID uint, nodeSecret string) error {
 	if nodeID == 0 {
 		return ErrBadRequest("node_id is required")
 	}
+	if err := authenticateNode(r.Context(), global.DB.WithContext(r.Context()), nodeID, nodeSecret); err != nil {
+		return err
+	}
 
 	wsConn, err := h.upgrader.Upgrade(w, r, nil)
 	if err != nil {
@@ -78,7 +82,7 @@ func (h *ControlWebSocketHub) ServeHTTP(w http.ResponseWriter, r *http.Request,
 			continue
 		}
 		if !conn.deliver(response) {
-			_ = recordControlCommandResponse(context.Background(), response)
+			_ = recordControlCommandResponse(context.Background(), nodeID, response)
 		}
 	}
 }
@@ -141,6 +145,15 @@ func (h *ControlWebSocketHub) unregister(nodeID uint, conn *controlWebSocketConn
 	}
 }
 
+// Disconnect 关闭节点当前的控制通道连接，用于节点密钥轮换或吊销后强制重连
+func (h *ControlWebSocketHub) Disconnect(nodeID uint) {
+	conn := h.get(nodeID)
+	if conn == nil {
+		return
+	}
+	_ = conn.conn.Close()
+}
+
 func (h *ControlWebSocketHub) get(nodeID uint) *controlWebSocketConnection {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
@@ -182,9 +195,9 @@ func (c *controlWebSocketConnection) write(payload []byte) error {
 	return c.conn.WriteMessage(websocket.TextMessage, payload)
 }
 
-func recordControlCommandResponse(ctx context.Context, response ControlCommandResponse) error {
+func recordControlCommandResponse(ctx context.Context, nodeID uint, response ControlCommandResponse) error {
 	var command model.ControlCommand
-	err := global.DB.WithContext(ctx).Where("id = ?", response.CommandID).First(&command).Error
+	err := global.DB.WithContext(ctx).Where("id = ? AND node_id = ?", response.CommandID, nodeID).First(&command).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil
 	}
@@ -0,0 +1,400 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"nexus-core/domain/entity"
+	"nexus-core/global"
+	"nexus-core/persistence/model"
+
+	"gorm.io/gorm"
+)
+
+const (
+	CustomerStatusActive   = 1
+	CustomerStatusDisabled = 2
+)
+
+const customerPortalKeyPrefix = "cpk_"
+
+// CustomerService 管理客户账号以及客户自助门户
+type CustomerService struct {
+	nodeService *NodeService
+}
+
+func NewCustomerService(nodeService *NodeService) *CustomerService {
+	return &CustomerService{
+		nodeService: nodeService,
+	}
+}
+
+func (s *CustomerService) CreateCustomer(ctx context.Context, cmd CreateCustomerCommand) (*CustomerData, error) {
+	name := strings.TrimSpace(cmd.Name)
+	if name == "" {
+		return nil, ErrBadRequest("name is required")
+	}
+	customer := &model.Customer{
+		Name:        name,
+		Company:     strings.TrimSpace(cmd.Company),
+		ContactName: strings.TrimSpace(cmd.ContactName),
+		Email:       strings.TrimSpace(cmd.Email),
+		Phone:       strings.TrimSpace(cmd.Phone),
+		Status:      CustomerStatusActive,
+	}
+	err := global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(customer).Error; err != nil {
+			return WrapInternal("create customer failed", err)
+		}
+		recordAuditLog(ctx, tx, "customer", customer.ID, "create", map[string]interface{}{
+			"name": name,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toCustomerData(customer), nil
+}
+
+func (s *CustomerService) GetCustomer(ctx context.Context, id uint) (*CustomerData, error) {
+	customer, err := getCustomer(ctx, global.DB, id)
+	if err != nil {
+		return nil, err
+	}
+	return toCustomerData(customer), nil
+}
+
+func (s *CustomerService) ListCustomers(ctx context.Context, cmd ListCustomersCommand) ([]CustomerData, error) {
+	query := global.DB.WithContext(ctx).Model(&model.Customer{}).Order("id DESC")
+	if cmd.Name != nil && strings.TrimSpace(*cmd.Name) != "" {
+		query = query.Where("name LIKE ?", "%"+strings.TrimSpace(*cmd.Name)+"%")
+	}
+	if cmd.Email != nil && strings.TrimSpace(*cmd.Email) != "" {
+		query = query.Where("email = ?", strings.TrimSpace(*cmd.Email))
+	}
+	if cmd.Status != nil {
+		query = query.Where("status = ?", *cmd.Status)
+	}
+	if cmd.Limit > 0 {
+		query = query.Limit(cmd.Limit)
+	}
+	if cmd.Offset > 0 {
+		query = query.Offset(cmd.Offset)
+	}
+	var customers []model.Customer
+	if err := query.Find(&customers).Error; err != nil {
+		return nil, WrapInternal("list customers failed", err)
+	}
+	data := make([]CustomerData, 0, len(customers))
+	for i := range customers {
+		data = append(data, *toCustomerData(&customers[i]))
+	}
+	return data, nil
+}
+
+func (s *CustomerService) UpdateCustomer(ctx context.Context, cmd UpdateCustomerCommand) (*CustomerData, error) {
+	if cmd.Status != nil && *cmd.Status != CustomerStatusActive && *cmd.Status != CustomerStatusDisabled {
+		return nil, ErrBadRequest("invalid status")
+	}
+	updates := map[string]interface{}{}
+	if cmd.Name != nil {
+		name := strings.TrimSpace(*cmd.Name)
+		if name == "" {
+			return nil, ErrBadRequest("name must not be empty")
+		}
+		updates["name"] = name
+	}
+	if cmd.Company != nil {
+		updates["company"] = strings.TrimSpace(*cmd.Company)
+	}
+	if cmd.ContactName != nil {
+		updates["contact_name"] = strings.TrimSpace(*cmd.ContactName)
+	}
+	if cmd.Email != nil {
+		updates["email"] = strings.TrimSpace(*cmd.Email)
+	}
+	if cmd.Phone != nil {
+		updates["phone"] = strings.TrimSpace(*cmd.Phone)
+	}
+	if cmd.Status != nil {
+		updates["status"] = *cmd.Status
+	}
+	var customer *model.Customer
+	err := global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		customer, err = getCustomer(ctx, tx, cmd.ID)
+		if err != nil {
+			return err
+		}
+		if len(updates) == 0 {
+			return nil
+		}
+		if err := tx.Model(customer).Updates(updates).Error; err != nil {
+			return WrapInternal("update customer failed", err)
+		}
+		recordAuditLog(ctx, tx, "customer", customer.ID, "update", updates)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetCustomer(ctx, customer.ID)
+}
+
+// RotatePortalKey 为客户签发门户访问密钥，旧密钥立即失效，明文只在本次返回
+func (s *CustomerService) RotatePortalKey(ctx context.Context, customerID uint) (*CustomerPortalKeyData, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, WrapInternal("generate portal key failed", err)
+	}
+	key := customerPortalKeyPrefix + hex.EncodeToString(raw)
+	now := time.Now()
+	err := global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		customer, err := getCustomer(ctx, tx, customerID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(customer).Updates(map[string]interface{}{
+			"portal_key_hash":      hashSecret(key),
+			"portal_key_issued_at": now,
+		}).Error; err != nil {
+			return WrapInternal("save portal key failed", err)
+		}
+		recordAuditLog(ctx, tx, "customer", customer.ID, "rotate_portal_key", nil)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &CustomerPortalKeyData{CustomerID: customerID, PortalKey: key, IssuedAt: now}, nil
+}
+
+// RevokePortalKey 关闭客户的门户访问
+func (s *CustomerService) RevokePortalKey(ctx context.Context, customerID uint) error {
+	result := global.DB.WithContext(ctx).Model(&model.Customer{}).Where("id = ?", customerID).
+		Updates(map[string]interface{}{
+			"portal_key_hash":      "",
+			"portal_key_issued_at": nil,
+		})
+	if result.Error != nil {
+		return WrapInternal("revoke portal key failed", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound("customer not found")
+	}
+	recordAuditLog(ctx, global.DB.WithContext(ctx), "customer", customerID, "revoke_portal_key", nil)
+	return nil
+}
+
+// Authenticate 校验门户密钥并返回客户身份，查找时不限定租户
+func (s *CustomerService) Authenticate(ctx context.Context, rawKey string) (*CustomerIdentity, error) {
+	rawKey = strings.TrimSpace(rawKey)
+	if rawKey == "" {
+		return nil, ErrUnauthorized("portal key is required")
+	}
+	hash := hashSecret(rawKey)
+	var customer model.Customer
+	if err := global.DB.WithContext(model.WithoutTenantScope(ctx)).
+		Where("portal_key_hash = ?", hash).First(&customer).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUnauthorized("invalid portal key")
+		}
+		return nil, WrapInternal("get customer failed", err)
+	}
+	if customer.Status != CustomerStatusActive {
+		return nil, ErrUnauthorized("customer disabled")
+	}
+	return &CustomerIdentity{
+		CustomerID: customer.ID,
+		TenantID:   customer.TenantID,
+		Name:       customer.Name,
+	}, nil
+}
+
+// ListCustomerLicenses 查询客户的全部 License 以及各 License 已绑定的节点
+func (s *CustomerService) ListCustomerLicenses(ctx context.Context, customerID uint) ([]CustomerLicenseData, error) {
+	db := global.DB.WithContext(ctx)
+	if _, err := getCustomer(ctx, db, customerID); err != nil {
+		return nil, err
+	}
+	var licenses []model.License
+	if err := db.Where("customer_id = ?", customerID).Order("id DESC").Find(&licenses).Error; err != nil {
+		return nil, WrapInternal("list customer licenses failed", err)
+	}
+	if len(licenses) == 0 {
+		return []CustomerLicenseData{}, nil
+	}
+	licenseIDs := make([]uint, 0, len(licenses))
+	for i := range licenses {
+		licenseIDs = append(licenseIDs, licenses[i].ID)
+	}
+	nodes, err := boundNodesByLicense(ctx, db, licenseIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	entityLicenses := make([]*entity.License, 0, len(licenses))
+	for i := range licenses {
+		entityLicenses = append(entityLicenses, ToEntityLicense(&licenses[i]))
+	}
+	if err := fillGracePolicies(ctx, db, entityLicenses...); err != nil {
+		return nil, WrapInternal("get grace policies failed", err)
+	}
+
+	now := time.Now()
+	data := make([]CustomerLicenseData, 0, len(licenses))
+	for i := range licenses {
+		license := entityLicenses[i]
+		item := CustomerLicenseData{
+			LicenseData: *toLicenseData(&licenses[i]),
+			ExpiredAt:   licenses[i].ExpiredAt,
+			Nodes:       nodes[licenses[i].ID],
+		}
+		item.Status = int(license.CalculateStatus(now))
+		item.GraceEndsAt = license.GraceEndsAt()
+		if item.Nodes == nil {
+			item.Nodes = []BoundNodeData{}
+		}
+		data = append(data, item)
+	}
+	return data, nil
+}
+
+// UnbindCustomerNode 客户自助解绑丢失的设备，只能操作属于自己的 License
+func (s *CustomerService) UnbindCustomerNode(ctx context.Context, cmd CustomerUnbindCommand) error {
+	if cmd.LicenseID == 0 || cmd.NodeID == 0 {
+		return ErrBadRequest("license_id and node_id are required")
+	}
+	if err := ensureCustomerLicense(ctx, cmd.CustomerID, cmd.LicenseID); err != nil {
+		return err
+	}
+	return s.nodeService.UnbindByID(ctx, UnbindCommand{NodeID: cmd.NodeID, LicenseID: cmd.LicenseID})
+}
+
+// ListCustomerBindingRequests 客户查询自己 License 的设备绑定申请
+func (s *CustomerService) ListCustomerBindingRequests(ctx context.Context, customerID uint, licenseID uint) ([]BindingRequestData, error) {
+	if err := ensureCustomerLicense(ctx, customerID, licenseID); err != nil {
+		return nil, err
+	}
+	return NewLicenseService().ListBindingRequests(ctx, licenseID)
+}
+
+// ApproveCustomerBinding 客户审批通过自己 License 的设备绑定申请
+func (s *CustomerService) ApproveCustomerBinding(ctx context.Context, customerID uint, cmd BindingDecisionCommand) (*BindingRequestData, error) {
+	if err := ensureCustomerLicense(ctx, customerID, cmd.LicenseID); err != nil {
+		return nil, err
+	}
+	return NewLicenseService().ApproveBinding(ctx, cmd)
+}
+
+// RejectCustomerBinding 客户拒绝自己 License 的设备绑定申请
+func (s *CustomerService) RejectCustomerBinding(ctx context.Context, customerID uint, cmd BindingDecisionCommand) (*BindingRequestData, error) {
+	if err := ensureCustomerLicense(ctx, customerID, cmd.LicenseID); err != nil {
+		return nil, err
+	}
+	return NewLicenseService().RejectBinding(ctx, cmd)
+}
+
+// ensureCustomerLicense 检查 License 归属该客户，不属于时按不存在处理
+func ensureCustomerLicense(ctx context.Context, customerID uint, licenseID uint) error {
+	var count int64
+	if err := global.DB.WithContext(ctx).Model(&model.License{}).
+		Where("id = ? AND customer_id = ?", licenseID, customerID).
+		Count(&count).Error; err != nil {
+		return WrapInternal("get customer license failed", err)
+	}
+	if count == 0 {
+		return ErrNotFound("license not found")
+	}
+	return nil
+}
+
+// boundNodesByLicense 按 License 分组查询已绑定的节点
+func boundNodesByLicense(ctx context.Context, db *gorm.DB, licenseIDs []uint) (map[uint][]BoundNodeData, error) {
+	var bindings []model.NodeLicenseBinding
+	if err := db.WithContext(ctx).
+		Where("license_id IN ? AND status = ?", licenseIDs, entity.BindingStatusBound).
+		Order("id ASC").Find(&bindings).Error; err != nil {
+		return nil, WrapInternal("list license bindings failed", err)
+	}
+	if len(bindings) == 0 {
+		return map[uint][]BoundNodeData{}, nil
+	}
+	nodeIDs := make([]uint, 0, len(bindings))
+	for i := range bindings {
+		nodeIDs = append(nodeIDs, bindings[i].NodeID)
+	}
+	var nodes []model.Node
+	if err := db.WithContext(ctx).Where("id IN ?", nodeIDs).Find(&nodes).Error; err != nil {
+		return nil, WrapInternal("list bound nodes failed", err)
+	}
+	nodeByID := make(map[uint]*model.Node, len(nodes))
+	for i := range nodes {
+		nodeByID[nodes[i].ID] = &nodes[i]
+	}
+
+	result := make(map[uint][]BoundNodeData, len(licenseIDs))
+	for i := range bindings {
+		node, ok := nodeByID[bindings[i].NodeID]
+		if !ok {
+			continue
+		}
+		result[bindings[i].LicenseID] = append(result[bindings[i].LicenseID], BoundNodeData{
+			NodeID:     node.ID,
+			ProductID:  bindings[i].ProductID,
+			DeviceCode: node.DeviceCode,
+			Status:     node.Status,
+			LastSeenAt: node.LastSeenAt,
+			BoundAt:    bindings[i].BoundAt,
+		})
+	}
+	return result, nil
+}
+
+func getCustomer(ctx context.Context, db *gorm.DB, id uint) (*model.Customer, error) {
+	if id == 0 {
+		return nil, ErrBadRequest("customer_id is required")
+	}
+	var customer model.Customer
+	if err := db.WithContext(ctx).Where("id = ?", id).First(&customer).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound("customer not found")
+		}
+		return nil, WrapInternal("get customer failed", err)
+	}
+	return &customer, nil
+}
+
+// ensureCustomerExists 校验 License 归属的客户存在，customerID 为空时跳过
+func ensureCustomerExists(ctx context.Context, db *gorm.DB, customerID *uint) error {
+	if customerID == nil {
+		return nil
+	}
+	if _, err := getCustomer(ctx, db, *customerID); err != nil {
+		if ErrorKindOf(err) == ErrorKindNotFound {
+			return BadRequestf("customer %d not found", *customerID)
+		}
+		return err
+	}
+	return nil
+}
+
+func toCustomerData(m *model.Customer) *CustomerData {
+	return &CustomerData{
+		ID:                m.ID,
+		Name:              m.Name,
+		Company:           m.Company,
+		ContactName:       m.ContactName,
+		Email:             m.Email,
+		Phone:             m.Phone,
+		Status:            m.Status,
+		PortalEnabled:     m.PortalKeyHash != "",
+		PortalKeyIssuedAt: m.PortalKeyIssuedAt,
+		CreatedAt:         m.CreatedAt,
+	}
+}
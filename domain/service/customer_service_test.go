@@ -0,0 +1,118 @@
+package service
+
+import "testing"
+
+func TestCustomerLicensesAndSelfServiceUnbind(t *testing.T) {
+	fixture := newFlowFixture(t, 2, 0, 24)
+	customerService := NewCustomerService(fixture.nodeService)
+
+	customer, err := customerService.CreateCustomer(fixture.ctx, CreateCustomerCommand{
+		Name:  "Acme",
+		Email: "ops@acme.test",
+	})
+	if err != nil {
+		t.Fatalf("create customer: %v", err)
+	}
+	other, err := customerService.CreateCustomer(fixture.ctx, CreateCustomerCommand{Name: "Other"})
+	if err != nil {
+		t.Fatalf("create other customer: %v", err)
+	}
+
+	missing := uint(9999)
+	_, err = fixture.licenseService.AssignCustomer(fixture.ctx, AssignLicenseCustomerCommand{LicenseID: fixture.license.ID, CustomerID: &missing})
+	assertAppErrorKind(t, err, ErrorKindBadRequest)
+	assigned, err := fixture.licenseService.AssignCustomer(fixture.ctx, AssignLicenseCustomerCommand{LicenseID: fixture.license.ID, CustomerID: &customer.ID})
+	if err != nil {
+		t.Fatalf("assign customer: %v", err)
+	}
+	if assigned.CustomerID == nil || *assigned.CustomerID != customer.ID {
+		t.Fatalf("license should belong to customer: %#v", assigned)
+	}
+	if _, err := fixture.licenseService.CreateLicense(fixture.ctx, CreateLicenseCommand{
+		ProductID:     fixture.product.ID,
+		CustomerID:    &other.ID,
+		ValidityHours: 24,
+	}); err != nil {
+		t.Fatalf("create license for other customer: %v", err)
+	}
+	filtered, err := fixture.licenseService.ListLicenses(fixture.ctx, ListLicensesCommand{CustomerID: &customer.ID})
+	if err != nil || len(filtered) != 1 || filtered[0].ID != fixture.license.ID {
+		t.Fatalf("list licenses by customer: %#v err=%v", filtered, err)
+	}
+
+	first := fixture.register(t, "device-a")
+	fixture.register(t, "device-b")
+
+	licenses, err := customerService.ListCustomerLicenses(fixture.ctx, customer.ID)
+	if err != nil {
+		t.Fatalf("list customer licenses: %v", err)
+	}
+	if len(licenses) != 1 || len(licenses[0].Nodes) != 2 {
+		t.Fatalf("customer should see one license with two nodes: %#v", licenses)
+	}
+
+	err = customerService.UnbindCustomerNode(fixture.ctx, CustomerUnbindCommand{
+		CustomerID: other.ID,
+		LicenseID:  fixture.license.ID,
+		NodeID:     first.NodeID,
+	})
+	assertAppErrorKind(t, err, ErrorKindNotFound)
+
+	if err := customerService.UnbindCustomerNode(fixture.ctx, CustomerUnbindCommand{
+		CustomerID: customer.ID,
+		LicenseID:  fixture.license.ID,
+		NodeID:     first.NodeID,
+	}); err != nil {
+		t.Fatalf("customer unbind: %v", err)
+	}
+	licenses, err = customerService.ListCustomerLicenses(fixture.ctx, customer.ID)
+	if err != nil {
+		t.Fatalf("list customer licenses after unbind: %v", err)
+	}
+	if len(licenses[0].Nodes) != 1 || licenses[0].Nodes[0].DeviceCode != "device-b" {
+		t.Fatalf("unbound device should disappear: %#v", licenses[0].Nodes)
+	}
+}
+
+func TestCustomerPortalKey(t *testing.T) {
+	fixture := newFlowFixture(t, 1, 0, 24)
+	customerService := NewCustomerService(fixture.nodeService)
+	customer, err := customerService.CreateCustomer(fixture.ctx, CreateCustomerCommand{Name: "Acme"})
+	if err != nil {
+		t.Fatalf("create customer: %v", err)
+	}
+	if customer.PortalEnabled {
+		t.Fatal("portal should be disabled until a key is issued")
+	}
+
+	issued, err := customerService.RotatePortalKey(fixture.ctx, customer.ID)
+	if err != nil {
+		t.Fatalf("rotate portal key: %v", err)
+	}
+	identity, err := customerService.Authenticate(fixture.ctx, issued.PortalKey)
+	if err != nil || identity.CustomerID != customer.ID {
+		t.Fatalf("authenticate portal key: %#v err=%v", identity, err)
+	}
+
+	rotated, err := customerService.RotatePortalKey(fixture.ctx, customer.ID)
+	if err != nil {
+		t.Fatalf("rotate again: %v", err)
+	}
+	_, err = customerService.Authenticate(fixture.ctx, issued.PortalKey)
+	assertAppErrorKind(t, err, ErrorKindUnauthorized)
+
+	disabled := CustomerStatusDisabled
+	if _, err := customerService.UpdateCustomer(fixture.ctx, UpdateCustomerCommand{ID: customer.ID, Status: &disabled}); err != nil {
+		t.Fatalf("disable customer: %v", err)
+	}
+	_, err = customerService.Authenticate(fixture.ctx, rotated.PortalKey)
+	assertAppErrorKind(t, err, ErrorKindUnauthorized)
+
+	if err := customerService.RevokePortalKey(fixture.ctx, customer.ID); err != nil {
+		t.Fatalf("revoke portal key: %v", err)
+	}
+	data, err := customerService.GetCustomer(fixture.ctx, customer.ID)
+	if err != nil || data.PortalEnabled {
+		t.Fatalf("portal should be disabled after revoke: %#v err=%v", data, err)
+	}
+}
@@ -8,15 +8,27 @@ import (
 type ErrorKind string
 
 const (
-	ErrorKindBadRequest ErrorKind = "bad_request"
-	ErrorKindNotFound   ErrorKind = "not_found"
-	ErrorKindConflict   ErrorKind = "conflict"
-	ErrorKindForbidden  ErrorKind = "forbidden"
-	ErrorKindInternal   ErrorKind = "internal"
+	ErrorKindBadRequest   ErrorKind = "bad_request"
+	ErrorKindUnauthorized ErrorKind = "unauthorized"
+	ErrorKindNotFound     ErrorKind = "not_found"
+	ErrorKindConflict     ErrorKind = "conflict"
+	ErrorKindForbidden    ErrorKind = "forbidden"
+	ErrorKindInternal     ErrorKind = "internal"
+	// ErrorKindTooManyRequests 请求过于频繁，稍后重试
+	ErrorKindTooManyRequests ErrorKind = "too_many_requests"
+)
+
+// 业务错误码，客户端据此区分同一类错误的具体原因
+const (
+	CodeRebindCooldown        = 40901 // 解绑释放的节点名额仍在冷却期
+	CodeTransferLimitExceeded = 40902 // 统计窗口内绑定的不同设备数已达上限
+	CodeBindingPending        = 40903 // 新设备绑定等待审批
+	CodeLicenseNotYetValid    = 40904 // 未到 License 的合同开始时间
 )
 
 type AppError struct {
 	Kind    ErrorKind
+	Code    int // 业务错误码，为 0 时按 Kind 映射
 	Message string
 	Err     error
 }
@@ -49,10 +61,23 @@ func ErrorKindOf(err error) ErrorKind {
 	return ErrorKindInternal
 }
 
+// ErrorCodeOf 返回错误携带的业务错误码，未设置时返回 0
+func ErrorCodeOf(err error) int {
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		return appErr.Code
+	}
+	return 0
+}
+
 func ErrBadRequest(message string) error {
 	return &AppError{Kind: ErrorKindBadRequest, Message: message}
 }
 
+func ErrUnauthorized(message string) error {
+	return &AppError{Kind: ErrorKindUnauthorized, Message: message}
+}
+
 func ErrNotFound(message string) error {
 	return &AppError{Kind: ErrorKindNotFound, Message: message}
 }
@@ -65,6 +90,10 @@ func ErrForbidden(message string) error {
 	return &AppError{Kind: ErrorKindForbidden, Message: message}
 }
 
+func ErrTooManyRequests(message string) error {
+	return &AppError{Kind: ErrorKindTooManyRequests, Message: message}
+}
+
 func ErrInternal(message string) error {
 	return &AppError{Kind: ErrorKindInternal, Message: message}
 }
@@ -84,6 +113,11 @@ func Conflictf(format string, args ...interface{}) error {
 	return ErrConflict(fmt.Sprintf(format, args...))
 }
 
+// ConflictWithCodef 返回携带业务错误码的状态冲突错误
+func ConflictWithCodef(code int, format string, args ...interface{}) error {
+	return &AppError{Kind: ErrorKindConflict, Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
 func Forbiddenf(format string, args ...interface{}) error {
 	return ErrForbidden(fmt.Sprintf(format, args...))
 }
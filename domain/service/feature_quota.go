@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"nexus-core/domain/entity"
+	"nexus-core/global"
+	"nexus-core/persistence/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ListLicenseFeatureQuotas 查询 License 的功能用量配额及当前周期用量
+func (s *FeatureService) ListLicenseFeatureQuotas(ctx context.Context, licenseID uint) ([]FeatureQuotaData, error) {
+	db := global.DB.WithContext(ctx)
+	if _, err := getLicenseProducts(ctx, db, licenseID); err != nil {
+		return nil, err
+	}
+	var quotas []model.LicenseFeatureQuota
+	if err := db.Where("license_id = ?", licenseID).Order("id ASC").Find(&quotas).Error; err != nil {
+		return nil, WrapInternal("list license feature quotas failed", err)
+	}
+	return toFeatureQuotaList(ctx, db, quotas, time.Now())
+}
+
+// SetLicenseFeatureQuota 设置 License 的功能用量配额，已存在时更新
+// 修改重置周期或指定 ResetUsage 时从新周期重新计量
+func (s *FeatureService) SetLicenseFeatureQuota(ctx context.Context, cmd SetLicenseFeatureQuotaCommand) (*FeatureQuotaData, error) {
+	if cmd.LicenseID == 0 || cmd.FeatureID == 0 {
+		return nil, ErrBadRequest("license_id and feature_id are required")
+	}
+	if cmd.Quota <= 0 {
+		return nil, ErrBadRequest("quota must be greater than 0")
+	}
+	period := entity.QuotaPeriod(strings.TrimSpace(cmd.Period))
+	if period == "" {
+		period = entity.QuotaPeriodMonth
+	}
+	if !period.IsValid() {
+		return nil, BadRequestf("invalid quota period %q", cmd.Period)
+	}
+
+	now := time.Now()
+	var quota model.LicenseFeatureQuota
+	var feature *model.CommonFeature
+	err := global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		feature, err = getLicenseOfferedFeature(ctx, tx, cmd.LicenseID, cmd.FeatureID)
+		if err != nil {
+			return err
+		}
+		err = tx.Where("license_id = ? AND feature_id = ?", cmd.LicenseID, cmd.FeatureID).First(&quota).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return WrapInternal("get license feature quota failed", err)
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			quota = model.LicenseFeatureQuota{
+				LicenseID:   cmd.LicenseID,
+				FeatureID:   cmd.FeatureID,
+				Quota:       cmd.Quota,
+				Period:      string(period),
+				PeriodStart: period.Start(now),
+			}
+			if err := tx.Create(&quota).Error; err != nil {
+				return WrapInternal("create license feature quota failed", err)
+			}
+		} else {
+			updates := map[string]interface{}{
+				"quota":  cmd.Quota,
+				"period": string(period),
+			}
+			if cmd.ResetUsage || quota.Period != string(period) {
+				updates["used"] = int64(0)
+				updates["period_start"] = period.Start(now)
+			}
+			if err := tx.Model(&quota).Updates(updates).Error; err != nil {
+				return WrapInternal("update license feature quota failed", err)
+			}
+		}
+		recordAuditLog(ctx, tx, "license", cmd.LicenseID, "set_feature_quota", map[string]interface{}{
+			"feature_id":  cmd.FeatureID,
+			"quota":       cmd.Quota,
+			"period":      period,
+			"reset_usage": cmd.ResetUsage,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	data := toFeatureQuotaData(&quota, feature, now)
+	return &data, nil
+}
+
+// RemoveLicenseFeatureQuota 删除 License 的功能用量配额，删除后该功能不再计量
+func (s *FeatureService) RemoveLicenseFeatureQuota(ctx context.Context, cmd RemoveLicenseFeatureQuotaCommand) error {
+	if cmd.LicenseID == 0 || cmd.FeatureID == 0 {
+		return ErrBadRequest("license_id and feature_id are required")
+	}
+	return global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := getLicenseProducts(ctx, tx, cmd.LicenseID); err != nil {
+			return err
+		}
+		result := tx.Unscoped().Where("license_id = ? AND feature_id = ?", cmd.LicenseID, cmd.FeatureID).Delete(&model.LicenseFeatureQuota{})
+		if result.Error != nil {
+			return WrapInternal("delete license feature quota failed", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound("license feature quota not found")
+		}
+		recordAuditLog(ctx, tx, "license", cmd.LicenseID, "remove_feature_quota", map[string]interface{}{
+			"feature_id": cmd.FeatureID,
+		})
+		return nil
+	})
+}
+
+// recordFeatureUsage 累加心跳上报的功能用量
+// 未设置配额的功能不计量；累加后超出配额时记录 quota_exceeded 审计日志
+func recordFeatureUsage(ctx context.Context, db *gorm.DB, licenseID uint, nodeID uint, productID uint, usage map[string]int64, now time.Time) error {
+	if len(usage) == 0 {
+		return nil
+	}
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var quotas []model.LicenseFeatureQuota
+		if err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+			Where("license_id = ?", licenseID).Find(&quotas).Error; err != nil {
+			return WrapInternal("list license feature quotas failed", err)
+		}
+		features, err := featuresByID(ctx, tx, quotas, func(item model.LicenseFeatureQuota) uint { return item.FeatureID })
+		if err != nil {
+			return err
+		}
+		quotaByIdentifier := make(map[string]*model.LicenseFeatureQuota, len(quotas))
+		for i := range quotas {
+			if feature, ok := features[quotas[i].FeatureID]; ok {
+				quotaByIdentifier[feature.Identifier] = &quotas[i]
+			}
+		}
+
+		identifiers := make([]string, 0, len(usage))
+		for identifier := range usage {
+			identifiers = append(identifiers, identifier)
+		}
+		sort.Strings(identifiers)
+		for _, identifier := range identifiers {
+			increment := usage[identifier]
+			quota, ok := quotaByIdentifier[identifier]
+			if !ok || increment == 0 {
+				continue
+			}
+			used, periodStart := currentQuotaUsage(quota, now)
+			used += increment
+			if err := tx.Model(quota).Updates(map[string]interface{}{
+				"used":         used,
+				"period_start": periodStart,
+			}).Error; err != nil {
+				return WrapInternal("update feature usage failed", err)
+			}
+			if used > quota.Quota {
+				recordAuditLog(ctx, tx, "license", licenseID, "quota_exceeded", map[string]interface{}{
+					"feature":    identifier,
+					"node_id":    nodeID,
+					"product_id": productID,
+					"reported":   increment,
+					"used":       used,
+					"quota":      quota.Quota,
+					"period":     quota.Period,
+				})
+			}
+		}
+		return nil
+	})
+}
+
+// resolveFeatureQuotas 查询 License 在指定产品提供的功能上设置的用量配额
+func resolveFeatureQuotas(ctx context.Context, db *gorm.DB, licenseID uint, productID uint, now time.Time) ([]FeatureQuotaData, error) {
+	db = db.WithContext(ctx)
+	var quotas []model.LicenseFeatureQuota
+	if err := db.Where("license_id = ? AND feature_id IN (?)", licenseID,
+		db.Model(&model.ProductFeature{}).Select("feature_id").Where("product_id = ?", productID)).
+		Order("id ASC").Find(&quotas).Error; err != nil {
+		return nil, WrapInternal("list license feature quotas failed", err)
+	}
+	return toFeatureQuotaList(ctx, db, quotas, now)
+}
+
+// exhaustedFeatures 返回当前周期用量已达到配额的功能
+func exhaustedFeatures(ctx context.Context, db *gorm.DB, licenseID uint, featureIDs []uint, now time.Time) (map[uint]bool, error) {
+	var quotas []model.LicenseFeatureQuota
+	if err := db.WithContext(ctx).Where("license_id = ? AND feature_id IN ?", licenseID, featureIDs).
+		Find(&quotas).Error; err != nil {
+		return nil, WrapInternal("list license feature quotas failed", err)
+	}
+	exhausted := make(map[uint]bool, len(quotas))
+	for i := range quotas {
+		if used, _ := currentQuotaUsage(&quotas[i], now); used >= quotas[i].Quota {
+			exhausted[quotas[i].FeatureID] = true
+		}
+	}
+	return exhausted, nil
+}
+
+// currentQuotaUsage 返回 now 所在周期的已用量与周期开始时间，已进入新周期时用量归零
+func currentQuotaUsage(quota *model.LicenseFeatureQuota, now time.Time) (int64, time.Time) {
+	period := entity.QuotaPeriod(quota.Period)
+	if period == entity.QuotaPeriodTotal {
+		return quota.Used, quota.PeriodStart
+	}
+	start := period.Start(now)
+	if quota.PeriodStart.Before(start) {
+		return 0, start
+	}
+	return quota.Used, quota.PeriodStart
+}
+
+func toFeatureQuotaList(ctx context.Context, db *gorm.DB, quotas []model.LicenseFeatureQuota, now time.Time) ([]FeatureQuotaData, error) {
+	features, err := featuresByID(ctx, db, quotas, func(item model.LicenseFeatureQuota) uint { return item.FeatureID })
+	if err != nil {
+		return nil, err
+	}
+	data := make([]FeatureQuotaData, 0, len(quotas))
+	for i := range quotas {
+		feature, ok := features[quotas[i].FeatureID]
+		if !ok {
+			continue
+		}
+		data = append(data, toFeatureQuotaData(&quotas[i], feature, now))
+	}
+	return data, nil
+}
+
+func toFeatureQuotaData(quota *model.LicenseFeatureQuota, feature *model.CommonFeature, now time.Time) FeatureQuotaData {
+	used, start := currentQuotaUsage(quota, now)
+	data := FeatureQuotaData{
+		FeatureID:  quota.FeatureID,
+		Identifier: feature.Identifier,
+		Period:     quota.Period,
+		Quota:      quota.Quota,
+		Used:       used,
+		Remaining:  max(quota.Quota-used, 0),
+	}
+	if period := entity.QuotaPeriod(quota.Period); period != entity.QuotaPeriodTotal {
+		data.PeriodStart = &start
+		data.ResetsAt = period.ResetsAt(start)
+	}
+	return data
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"nexus-core/domain/entity"
+	"nexus-core/persistence/model"
+)
+
+func TestFeatureUsageQuota(t *testing.T) {
+	fixture := newFlowFixture(t, 2, 0, 24)
+	featureService := NewFeatureService()
+
+	export, err := featureService.CreateFeature(fixture.ctx, CreateFeatureCommand{Identifier: "export", Name: "Export"})
+	if err != nil {
+		t.Fatalf("create feature: %v", err)
+	}
+	if _, err := featureService.SetProductFeature(fixture.ctx, SetProductFeatureCommand{
+		ProductID: fixture.product.ID,
+		FeatureID: export.ID,
+		Default:   true,
+	}); err != nil {
+		t.Fatalf("set product feature: %v", err)
+	}
+
+	_, err = featureService.SetLicenseFeatureQuota(fixture.ctx, SetLicenseFeatureQuotaCommand{
+		LicenseID: fixture.license.ID, FeatureID: export.ID, Quota: 10, Period: "week",
+	})
+	assertAppErrorKind(t, err, ErrorKindBadRequest)
+	quota, err := featureService.SetLicenseFeatureQuota(fixture.ctx, SetLicenseFeatureQuotaCommand{
+		LicenseID: fixture.license.ID, FeatureID: export.ID, Quota: 10,
+	})
+	if err != nil {
+		t.Fatalf("set quota: %v", err)
+	}
+	if quota.Period != string(entity.QuotaPeriodMonth) || quota.Remaining != 10 || quota.ResetsAt == nil {
+		t.Fatalf("unexpected quota: %#v", quota)
+	}
+
+	registered := fixture.register(t, "device-a")
+	if len(registered.Quotas) != 1 || registered.Quotas[0].Remaining != 10 {
+		t.Fatalf("register should return quotas: %#v", registered.Quotas)
+	}
+
+	report := func(usage map[string]int64) *HeartbeatResult {
+		t.Helper()
+		result, err := fixture.accessService.Heartbeat(fixture.ctx, AccessCommand{
+			DeviceCode:  "device-a",
+			LicenseKey:  fixture.license.LicenseKey,
+			ProductID:   fixture.product.ID,
+			VersionCode: "1.0.0",
+			NodeSecret:  fixture.secrets["device-a"],
+			Usage:       usage,
+		})
+		if err != nil {
+			t.Fatalf("heartbeat with usage: %v", err)
+		}
+		return result
+	}
+
+	heartbeat := report(map[string]int64{"export": 4, "unmetered": 100})
+	if heartbeat.Quotas[0].Used != 4 || heartbeat.Quotas[0].Remaining != 6 {
+		t.Fatalf("usage should be persisted: %#v", heartbeat.Quotas)
+	}
+	assertEntitlements(t, heartbeat.Entitlements, "export")
+
+	_, err = fixture.accessService.Heartbeat(fixture.ctx, AccessCommand{
+		DeviceCode:  "device-a",
+		LicenseKey:  fixture.license.LicenseKey,
+		ProductID:   fixture.product.ID,
+		VersionCode: "1.0.0",
+		NodeSecret:  fixture.secrets["device-a"],
+		Usage:       map[string]int64{"export": -1},
+	})
+	assertAppErrorKind(t, err, ErrorKindBadRequest)
+
+	// 超出配额后功能被拒绝，并记录超额审计
+	heartbeat = report(map[string]int64{"export": 7})
+	if heartbeat.Quotas[0].Used != 11 || heartbeat.Quotas[0].Remaining != 0 {
+		t.Fatalf("quota should be exhausted: %#v", heartbeat.Quotas)
+	}
+	assertEntitlements(t, heartbeat.Entitlements)
+	var overages int64
+	if err := fixture.db.Model(&model.AuditLog{}).
+		Where("resource_type = ? AND resource_id = ? AND action = ?", "license", fixture.license.ID, "quota_exceeded").
+		Count(&overages).Error; err != nil {
+		t.Fatalf("count audit logs: %v", err)
+	}
+	if overages != 1 {
+		t.Fatalf("expected one quota_exceeded audit log, got %d", overages)
+	}
+
+	// 进入新周期后用量归零
+	if err := fixture.db.Model(&model.LicenseFeatureQuota{}).Where("feature_id = ?", export.ID).
+		Update("period_start", time.Now().AddDate(0, -1, 0)).Error; err != nil {
+		t.Fatalf("move period start: %v", err)
+	}
+	heartbeat = report(nil)
+	if heartbeat.Quotas[0].Used != 0 || heartbeat.Quotas[0].Remaining != 10 {
+		t.Fatalf("quota should reset in a new period: %#v", heartbeat.Quotas)
+	}
+	assertEntitlements(t, heartbeat.Entitlements, "export")
+
+	err = featureService.DeleteFeature(fixture.ctx, export.ID)
+	assertAppErrorKind(t, err, ErrorKindConflict)
+	if err := featureService.RemoveLicenseFeatureQuota(fixture.ctx, RemoveLicenseFeatureQuotaCommand{
+		LicenseID: fixture.license.ID, FeatureID: export.ID,
+	}); err != nil {
+		t.Fatalf("remove quota: %v", err)
+	}
+	if quotas, err := featureService.ListLicenseFeatureQuotas(fixture.ctx, fixture.license.ID); err != nil || len(quotas) != 0 {
+		t.Fatalf("list quotas after removal: %#v %v", quotas, err)
+	}
+}
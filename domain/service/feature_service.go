@@ -0,0 +1,528 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"nexus-core/domain/entity"
+	"nexus-core/global"
+	"nexus-core/persistence/model"
+
+	"gorm.io/gorm"
+)
+
+const (
+	FeatureStatusEnabled  = 1
+	FeatureStatusDisabled = 2
+)
+
+// FeatureService 管理功能目录、产品提供的功能以及 License 单独授予的功能
+type FeatureService struct{}
+
+func NewFeatureService() *FeatureService {
+	return &FeatureService{}
+}
+
+func (s *FeatureService) CreateFeature(ctx context.Context, cmd CreateFeatureCommand) (*FeatureData, error) {
+	identifier := strings.TrimSpace(cmd.Identifier)
+	name := strings.TrimSpace(cmd.Name)
+	if identifier == "" || name == "" {
+		return nil, ErrBadRequest("identifier and name are required")
+	}
+	featureType := strings.TrimSpace(cmd.Type)
+	if featureType == "" {
+		featureType = model.FeatureTypeAbility
+	}
+	if featureType != model.FeatureTypeAbility && featureType != model.FeatureTypeCommand {
+		return nil, ErrBadRequest("invalid feature type")
+	}
+	feature := &model.CommonFeature{
+		Feature: model.Feature{
+			Identifier:  identifier,
+			Type:        featureType,
+			Name:        name,
+			Description: cmd.Description,
+			Data:        cmd.Data,
+		},
+		Status: FeatureStatusEnabled,
+	}
+	err := global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&model.CommonFeature{}).Where("identifier = ?", identifier).Count(&count).Error; err != nil {
+			return WrapInternal("check feature identifier failed", err)
+		}
+		if count > 0 {
+			return ErrConflict("feature identifier already exists")
+		}
+		if err := tx.Create(feature).Error; err != nil {
+			return WrapInternal("create feature failed", err)
+		}
+		recordAuditLog(ctx, tx, "feature", feature.ID, "create", map[string]interface{}{
+			"identifier": identifier,
+			"type":       featureType,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toFeatureData(feature), nil
+}
+
+func (s *FeatureService) GetFeature(ctx context.Context, id uint) (*FeatureData, error) {
+	feature, err := getFeature(ctx, global.DB, id)
+	if err != nil {
+		return nil, err
+	}
+	return toFeatureData(feature), nil
+}
+
+func (s *FeatureService) ListFeatures(ctx context.Context, cmd ListFeaturesCommand) ([]FeatureData, error) {
+	query := global.DB.WithContext(ctx).Model(&model.CommonFeature{}).Order("id DESC")
+	if cmd.Type != nil && strings.TrimSpace(*cmd.Type) != "" {
+		query = query.Where("type = ?", strings.TrimSpace(*cmd.Type))
+	}
+	if cmd.Status != nil {
+		query = query.Where("status = ?", *cmd.Status)
+	}
+	if cmd.Limit > 0 {
+		query = query.Limit(cmd.Limit)
+	}
+	if cmd.Offset > 0 {
+		query = query.Offset(cmd.Offset)
+	}
+	var features []model.CommonFeature
+	if err := query.Find(&features).Error; err != nil {
+		return nil, WrapInternal("list features failed", err)
+	}
+	data := make([]FeatureData, 0, len(features))
+	for i := range features {
+		data = append(data, *toFeatureData(&features[i]))
+	}
+	return data, nil
+}
+
+// UpdateFeature 修改功能信息，标识符和类型创建后不可修改
+// 禁用后该功能不再出现在任何节点的功能列表中
+func (s *FeatureService) UpdateFeature(ctx context.Context, cmd UpdateFeatureCommand) (*FeatureData, error) {
+	if cmd.Status != nil && *cmd.Status != FeatureStatusEnabled && *cmd.Status != FeatureStatusDisabled {
+		return nil, ErrBadRequest("invalid status")
+	}
+	updates := map[string]interface{}{}
+	if cmd.Name != nil {
+		name := strings.TrimSpace(*cmd.Name)
+		if name == "" {
+			return nil, ErrBadRequest("name is required")
+		}
+		updates["name"] = name
+	}
+	if cmd.Description != nil {
+		updates["description"] = cmd.Description
+	}
+	if cmd.Data != nil {
+		updates["data"] = cmd.Data
+	}
+	if cmd.Status != nil {
+		updates["status"] = *cmd.Status
+	}
+	err := global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		feature, err := getFeature(ctx, tx, cmd.ID)
+		if err != nil {
+			return err
+		}
+		if len(updates) == 0 {
+			return nil
+		}
+		if err := tx.Model(feature).Updates(updates).Error; err != nil {
+			return WrapInternal("update feature failed", err)
+		}
+		recordAuditLog(ctx, tx, "feature", feature.ID, "update", updates)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetFeature(ctx, cmd.ID)
+}
+
+// DeleteFeature 删除功能，仍被产品、License 授予或用量配额引用时拒绝删除
+func (s *FeatureService) DeleteFeature(ctx context.Context, id uint) error {
+	return global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		feature, err := getFeature(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		var productCount, licenseCount, quotaCount int64
+		if err := tx.Model(&model.ProductFeature{}).Where("feature_id = ?", id).Count(&productCount).Error; err != nil {
+			return WrapInternal("count product features failed", err)
+		}
+		if err := tx.Model(&model.LicenseFeature{}).Where("feature_id = ?", id).Count(&licenseCount).Error; err != nil {
+			return WrapInternal("count license features failed", err)
+		}
+		if err := tx.Model(&model.LicenseFeatureQuota{}).Where("feature_id = ?", id).Count(&quotaCount).Error; err != nil {
+			return WrapInternal("count license feature quotas failed", err)
+		}
+		if productCount > 0 || licenseCount > 0 || quotaCount > 0 {
+			return ErrConflict("feature is in use")
+		}
+		if err := tx.Delete(feature).Error; err != nil {
+			return WrapInternal("delete feature failed", err)
+		}
+		recordAuditLog(ctx, tx, "feature", id, "delete", nil)
+		return nil
+	})
+}
+
+func (s *FeatureService) ListProductFeatures(ctx context.Context, productID uint) ([]ProductFeatureData, error) {
+	db := global.DB.WithContext(ctx)
+	if err := ensureProduct(ctx, db, productID); err != nil {
+		return nil, err
+	}
+	var items []model.ProductFeature
+	if err := db.Where("product_id = ?", productID).Order("id ASC").Find(&items).Error; err != nil {
+		return nil, WrapInternal("list product features failed", err)
+	}
+	features, err := featuresByID(ctx, db, items, func(item model.ProductFeature) uint { return item.FeatureID })
+	if err != nil {
+		return nil, err
+	}
+	data := make([]ProductFeatureData, 0, len(items))
+	for _, item := range items {
+		feature, ok := features[item.FeatureID]
+		if !ok {
+			continue
+		}
+		data = append(data, ProductFeatureData{
+			ProductID:   item.ProductID,
+			Default:     item.IsDefault,
+			FeatureData: *toFeatureData(feature),
+		})
+	}
+	return data, nil
+}
+
+// SetProductFeature 将功能加入产品，或修改该功能是否为产品默认功能
+func (s *FeatureService) SetProductFeature(ctx context.Context, cmd SetProductFeatureCommand) (*ProductFeatureData, error) {
+	if cmd.ProductID == 0 || cmd.FeatureID == 0 {
+		return nil, ErrBadRequest("product_id and feature_id are required")
+	}
+	var feature *model.CommonFeature
+	err := global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := ensureProduct(ctx, tx, cmd.ProductID); err != nil {
+			return err
+		}
+		var err error
+		feature, err = getFeature(ctx, tx, cmd.FeatureID)
+		if err != nil {
+			return err
+		}
+		var item model.ProductFeature
+		err = tx.Where("product_id = ? AND feature_id = ?", cmd.ProductID, cmd.FeatureID).First(&item).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return WrapInternal("get product feature failed", err)
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			item = model.ProductFeature{ProductID: cmd.ProductID, FeatureID: cmd.FeatureID, IsDefault: cmd.Default}
+			if err := tx.Create(&item).Error; err != nil {
+				return WrapInternal("create product feature failed", err)
+			}
+		} else if err := tx.Model(&item).Update("is_default", cmd.Default).Error; err != nil {
+			return WrapInternal("update product feature failed", err)
+		}
+		recordAuditLog(ctx, tx, "product", cmd.ProductID, "set_feature", map[string]interface{}{
+			"feature_id": cmd.FeatureID,
+			"default":    cmd.Default,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ProductFeatureData{
+		ProductID:   cmd.ProductID,
+		Default:     cmd.Default,
+		FeatureData: *toFeatureData(feature),
+	}, nil
+}
+
+// RemoveProductFeature 将功能移出产品，已授予 License 的记录保留但不再生效
+func (s *FeatureService) RemoveProductFeature(ctx context.Context, cmd RemoveProductFeatureCommand) error {
+	if cmd.ProductID == 0 || cmd.FeatureID == 0 {
+		return ErrBadRequest("product_id and feature_id are required")
+	}
+	return global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := ensureProduct(ctx, tx, cmd.ProductID); err != nil {
+			return err
+		}
+		result := tx.Unscoped().Where("product_id = ? AND feature_id = ?", cmd.ProductID, cmd.FeatureID).Delete(&model.ProductFeature{})
+		if result.Error != nil {
+			return WrapInternal("delete product feature failed", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound("product feature not found")
+		}
+		recordAuditLog(ctx, tx, "product", cmd.ProductID, "remove_feature", map[string]interface{}{
+			"feature_id": cmd.FeatureID,
+		})
+		return nil
+	})
+}
+
+func (s *FeatureService) ListLicenseFeatures(ctx context.Context, licenseID uint) ([]LicenseFeatureData, error) {
+	db := global.DB.WithContext(ctx)
+	if _, err := getLicenseProducts(ctx, db, licenseID); err != nil {
+		return nil, err
+	}
+	var grants []model.LicenseFeature
+	if err := db.Where("license_id = ?", licenseID).Order("id ASC").Find(&grants).Error; err != nil {
+		return nil, WrapInternal("list license features failed", err)
+	}
+	features, err := featuresByID(ctx, db, grants, func(item model.LicenseFeature) uint { return item.FeatureID })
+	if err != nil {
+		return nil, err
+	}
+	data := make([]LicenseFeatureData, 0, len(grants))
+	for _, grant := range grants {
+		feature, ok := features[grant.FeatureID]
+		if !ok {
+			continue
+		}
+		data = append(data, LicenseFeatureData{
+			LicenseID:   grant.LicenseID,
+			ExpiresAt:   grant.ExpiresAt,
+			FeatureData: *toFeatureData(feature),
+		})
+	}
+	return data, nil
+}
+
+// GrantLicenseFeature 为 License 单独授予功能，已授予时更新到期时间
+// 功能必须由 License 授权的某个产品提供
+func (s *FeatureService) GrantLicenseFeature(ctx context.Context, cmd GrantLicenseFeatureCommand) (*LicenseFeatureData, error) {
+	if cmd.LicenseID == 0 || cmd.FeatureID == 0 {
+		return nil, ErrBadRequest("license_id and feature_id are required")
+	}
+	if cmd.ExpiresAt != nil && !cmd.ExpiresAt.After(time.Now()) {
+		return nil, ErrBadRequest("expires_at must be in the future")
+	}
+	var feature *model.CommonFeature
+	err := global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		feature, err = getLicenseOfferedFeature(ctx, tx, cmd.LicenseID, cmd.FeatureID)
+		if err != nil {
+			return err
+		}
+
+		var grant model.LicenseFeature
+		err = tx.Where("license_id = ? AND feature_id = ?", cmd.LicenseID, cmd.FeatureID).First(&grant).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return WrapInternal("get license feature failed", err)
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			grant = model.LicenseFeature{LicenseID: cmd.LicenseID, FeatureID: cmd.FeatureID, ExpiresAt: cmd.ExpiresAt}
+			if err := tx.Create(&grant).Error; err != nil {
+				return WrapInternal("create license feature failed", err)
+			}
+		} else if err := tx.Model(&grant).Update("expires_at", cmd.ExpiresAt).Error; err != nil {
+			return WrapInternal("update license feature failed", err)
+		}
+		recordAuditLog(ctx, tx, "license", cmd.LicenseID, "grant_feature", map[string]interface{}{
+			"feature_id": cmd.FeatureID,
+			"expires_at": cmd.ExpiresAt,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &LicenseFeatureData{
+		LicenseID:   cmd.LicenseID,
+		ExpiresAt:   cmd.ExpiresAt,
+		FeatureData: *toFeatureData(feature),
+	}, nil
+}
+
+// RevokeLicenseFeature 收回 License 单独授予的功能，不影响产品默认功能
+func (s *FeatureService) RevokeLicenseFeature(ctx context.Context, cmd RevokeLicenseFeatureCommand) error {
+	if cmd.LicenseID == 0 || cmd.FeatureID == 0 {
+		return ErrBadRequest("license_id and feature_id are required")
+	}
+	return global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := getLicenseProducts(ctx, tx, cmd.LicenseID); err != nil {
+			return err
+		}
+		result := tx.Unscoped().Where("license_id = ? AND feature_id = ?", cmd.LicenseID, cmd.FeatureID).Delete(&model.LicenseFeature{})
+		if result.Error != nil {
+			return WrapInternal("delete license feature failed", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound("license feature not found")
+		}
+		recordAuditLog(ctx, tx, "license", cmd.LicenseID, "revoke_feature", map[string]interface{}{
+			"feature_id": cmd.FeatureID,
+		})
+		return nil
+	})
+}
+
+// resolveEntitlements 计算 License 在指定产品下当前生效的功能
+// 产品提供且已启用的功能中，默认功能直接生效，其余功能需 License 单独授予且未到期
+// 设置了用量配额且当前周期已用尽的功能不再生效
+func resolveEntitlements(ctx context.Context, db *gorm.DB, licenseID uint, productID uint, now time.Time) ([]EntitlementData, error) {
+	db = db.WithContext(ctx)
+	var items []model.ProductFeature
+	if err := db.Where("product_id = ?", productID).Find(&items).Error; err != nil {
+		return nil, WrapInternal("list product features failed", err)
+	}
+	entitlements := []EntitlementData{}
+	if len(items) == 0 {
+		return entitlements, nil
+	}
+	featureIDs := make([]uint, 0, len(items))
+	for _, item := range items {
+		featureIDs = append(featureIDs, item.FeatureID)
+	}
+	var features []model.CommonFeature
+	if err := db.Where("id IN ? AND status = ?", featureIDs, FeatureStatusEnabled).
+		Order("identifier ASC").Find(&features).Error; err != nil {
+		return nil, WrapInternal("list features failed", err)
+	}
+	var grants []model.LicenseFeature
+	if err := db.Where("license_id = ? AND feature_id IN ?", licenseID, featureIDs).Find(&grants).Error; err != nil {
+		return nil, WrapInternal("list license features failed", err)
+	}
+	exhausted, err := exhaustedFeatures(ctx, db, licenseID, featureIDs, now)
+	if err != nil {
+		return nil, err
+	}
+	defaults := make(map[uint]bool, len(items))
+	for _, item := range items {
+		defaults[item.FeatureID] = item.IsDefault
+	}
+	grantByFeature := make(map[uint]*model.LicenseFeature, len(grants))
+	for i := range grants {
+		grantByFeature[grants[i].FeatureID] = &grants[i]
+	}
+
+	for i := range features {
+		feature := &features[i]
+		if exhausted[feature.ID] {
+			continue
+		}
+		entitlement := EntitlementData{
+			Identifier: feature.Identifier,
+			Type:       feature.Type,
+			Name:       feature.Name,
+			Data:       feature.Data,
+		}
+		if !defaults[feature.ID] {
+			grant, ok := grantByFeature[feature.ID]
+			if !ok || (grant.ExpiresAt != nil && !grant.ExpiresAt.After(now)) {
+				continue
+			}
+			entitlement.ExpiresAt = grant.ExpiresAt
+		}
+		entitlements = append(entitlements, entitlement)
+	}
+	return entitlements, nil
+}
+
+func getFeature(ctx context.Context, db *gorm.DB, id uint) (*model.CommonFeature, error) {
+	if id == 0 {
+		return nil, ErrBadRequest("feature_id is required")
+	}
+	var feature model.CommonFeature
+	if err := db.WithContext(ctx).Where("id = ?", id).First(&feature).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound("feature not found")
+		}
+		return nil, WrapInternal("get feature failed", err)
+	}
+	return &feature, nil
+}
+
+// featuresByID 按关联记录中的功能 ID 批量查询功能
+func featuresByID[T any](ctx context.Context, db *gorm.DB, items []T, featureID func(T) uint) (map[uint]*model.CommonFeature, error) {
+	result := make(map[uint]*model.CommonFeature, len(items))
+	if len(items) == 0 {
+		return result, nil
+	}
+	ids := make([]uint, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, featureID(item))
+	}
+	var features []model.CommonFeature
+	if err := db.WithContext(ctx).Where("id IN ?", ids).Find(&features).Error; err != nil {
+		return nil, WrapInternal("list features failed", err)
+	}
+	for i := range features {
+		result[features[i].ID] = &features[i]
+	}
+	return result, nil
+}
+
+// getLicenseOfferedFeature 查询功能并确认其由 License 授权的某个产品提供
+func getLicenseOfferedFeature(ctx context.Context, db *gorm.DB, licenseID uint, featureID uint) (*model.CommonFeature, error) {
+	productIDs, err := getLicenseProducts(ctx, db, licenseID)
+	if err != nil {
+		return nil, err
+	}
+	feature, err := getFeature(ctx, db, featureID)
+	if err != nil {
+		return nil, err
+	}
+	var offered int64
+	if err := db.WithContext(ctx).Model(&model.ProductFeature{}).
+		Where("feature_id = ? AND product_id IN ?", featureID, productIDs).
+		Count(&offered).Error; err != nil {
+		return nil, WrapInternal("check product feature failed", err)
+	}
+	if offered == 0 {
+		return nil, ErrBadRequest("feature is not offered by license products")
+	}
+	return feature, nil
+}
+
+func ensureProduct(ctx context.Context, db *gorm.DB, productID uint) error {
+	var count int64
+	if err := db.WithContext(ctx).Model(&model.Product{}).Where("id = ?", productID).Count(&count).Error; err != nil {
+		return WrapInternal("get product failed", err)
+	}
+	if count == 0 {
+		return ErrNotFound("product not found")
+	}
+	return nil
+}
+
+// getLicenseProducts 返回 License 授权的全部产品：主产品与启用的产品范围
+func getLicenseProducts(ctx context.Context, db *gorm.DB, licenseID uint) ([]uint, error) {
+	var license model.License
+	if err := db.WithContext(ctx).Select("id", "product_id").Where("id = ?", licenseID).First(&license).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound("license not found")
+		}
+		return nil, WrapInternal("get license failed", err)
+	}
+	var scoped []uint
+	if err := db.WithContext(ctx).Model(&model.LicenseProductScope{}).
+		Where("license_id = ? AND status = ?", licenseID, entity.ScopeStatusEnabled).
+		Pluck("product_id", &scoped).Error; err != nil {
+		return nil, WrapInternal("list license product scopes failed", err)
+	}
+	return append([]uint{license.ProductID}, scoped...), nil
+}
+
+func toFeatureData(feature *model.CommonFeature) *FeatureData {
+	return &FeatureData{
+		ID:          feature.ID,
+		Identifier:  feature.Identifier,
+		Type:        feature.Type,
+		Name:        feature.Name,
+		Description: feature.Description,
+		Data:        feature.Data,
+		Status:      feature.Status,
+		CreatedAt:   feature.CreatedAt,
+	}
+}
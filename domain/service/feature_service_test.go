@@ -0,0 +1,131 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"nexus-core/persistence/model"
+)
+
+func TestFeatureEntitlements(t *testing.T) {
+	fixture := newFlowFixture(t, 2, 0, 24)
+	featureService := NewFeatureService()
+
+	create := func(identifier string) *FeatureData {
+		t.Helper()
+		feature, err := featureService.CreateFeature(fixture.ctx, CreateFeatureCommand{Identifier: identifier, Name: identifier})
+		if err != nil {
+			t.Fatalf("create feature %s: %v", identifier, err)
+		}
+		return feature
+	}
+	basic := create("basic-report")
+	export := create("export")
+	audit := create("audit")
+	create("unused")
+
+	_, err := featureService.CreateFeature(fixture.ctx, CreateFeatureCommand{Identifier: "export", Name: "dup"})
+	assertAppErrorKind(t, err, ErrorKindConflict)
+	_, err = featureService.CreateFeature(fixture.ctx, CreateFeatureCommand{Identifier: "bad", Name: "bad", Type: "plugin"})
+	assertAppErrorKind(t, err, ErrorKindBadRequest)
+
+	for _, item := range []struct {
+		feature *FeatureData
+		def     bool
+	}{{basic, true}, {export, false}, {audit, false}} {
+		if _, err := featureService.SetProductFeature(fixture.ctx, SetProductFeatureCommand{
+			ProductID: fixture.product.ID,
+			FeatureID: item.feature.ID,
+			Default:   item.def,
+		}); err != nil {
+			t.Fatalf("set product feature: %v", err)
+		}
+	}
+
+	registered := fixture.register(t, "device-a")
+	assertEntitlements(t, registered.Entitlements, "basic-report")
+
+	expiresAt := time.Now().Add(24 * time.Hour)
+	if _, err := featureService.GrantLicenseFeature(fixture.ctx, GrantLicenseFeatureCommand{
+		LicenseID: fixture.license.ID,
+		FeatureID: export.ID,
+		ExpiresAt: &expiresAt,
+	}); err != nil {
+		t.Fatalf("grant export: %v", err)
+	}
+	if _, err := featureService.GrantLicenseFeature(fixture.ctx, GrantLicenseFeatureCommand{
+		LicenseID: fixture.license.ID,
+		FeatureID: audit.ID,
+	}); err != nil {
+		t.Fatalf("grant audit: %v", err)
+	}
+	heartbeat, err := fixture.heartbeat("device-a")
+	if err != nil {
+		t.Fatalf("heartbeat: %v", err)
+	}
+	assertEntitlements(t, heartbeat.Entitlements, "audit", "basic-report", "export")
+
+	// 到期的授予与禁用的功能不再下发
+	if err := fixture.db.Model(&model.LicenseFeature{}).Where("feature_id = ?", export.ID).
+		Update("expires_at", time.Now().Add(-time.Minute)).Error; err != nil {
+		t.Fatalf("expire grant: %v", err)
+	}
+	disabled := FeatureStatusDisabled
+	if _, err := featureService.UpdateFeature(fixture.ctx, UpdateFeatureCommand{ID: audit.ID, Status: &disabled}); err != nil {
+		t.Fatalf("disable feature: %v", err)
+	}
+	heartbeat, err = fixture.heartbeat("device-a")
+	if err != nil {
+		t.Fatalf("heartbeat after expiry: %v", err)
+	}
+	assertEntitlements(t, heartbeat.Entitlements, "basic-report")
+
+	other, err := fixture.productService.CreateProduct(fixture.ctx, CreateProductCommand{Name: "other-product"})
+	if err != nil {
+		t.Fatalf("create other product: %v", err)
+	}
+	otherFeature := create("other-only")
+	if _, err := featureService.SetProductFeature(fixture.ctx, SetProductFeatureCommand{ProductID: other.ID, FeatureID: otherFeature.ID}); err != nil {
+		t.Fatalf("set other product feature: %v", err)
+	}
+	_, err = featureService.GrantLicenseFeature(fixture.ctx, GrantLicenseFeatureCommand{LicenseID: fixture.license.ID, FeatureID: otherFeature.ID})
+	assertAppErrorKind(t, err, ErrorKindBadRequest)
+
+	err = featureService.DeleteFeature(fixture.ctx, export.ID)
+	assertAppErrorKind(t, err, ErrorKindConflict)
+	if err := featureService.RevokeLicenseFeature(fixture.ctx, RevokeLicenseFeatureCommand{LicenseID: fixture.license.ID, FeatureID: export.ID}); err != nil {
+		t.Fatalf("revoke export: %v", err)
+	}
+	// 撤销后可以重新授予
+	if _, err := featureService.GrantLicenseFeature(fixture.ctx, GrantLicenseFeatureCommand{LicenseID: fixture.license.ID, FeatureID: export.ID}); err != nil {
+		t.Fatalf("re-grant export: %v", err)
+	}
+	if err := featureService.RevokeLicenseFeature(fixture.ctx, RevokeLicenseFeatureCommand{LicenseID: fixture.license.ID, FeatureID: export.ID}); err != nil {
+		t.Fatalf("revoke export again: %v", err)
+	}
+	if err := featureService.RemoveProductFeature(fixture.ctx, RemoveProductFeatureCommand{ProductID: fixture.product.ID, FeatureID: export.ID}); err != nil {
+		t.Fatalf("remove product feature: %v", err)
+	}
+	// 移除后可以重新加入
+	if _, err := featureService.SetProductFeature(fixture.ctx, SetProductFeatureCommand{ProductID: fixture.product.ID, FeatureID: export.ID}); err != nil {
+		t.Fatalf("re-add product feature: %v", err)
+	}
+	if err := featureService.RemoveProductFeature(fixture.ctx, RemoveProductFeatureCommand{ProductID: fixture.product.ID, FeatureID: export.ID}); err != nil {
+		t.Fatalf("remove product feature again: %v", err)
+	}
+	if err := featureService.DeleteFeature(fixture.ctx, export.ID); err != nil {
+		t.Fatalf("delete feature: %v", err)
+	}
+}
+
+func assertEntitlements(t *testing.T, entitlements []EntitlementData, identifiers ...string) {
+	t.Helper()
+	if len(entitlements) != len(identifiers) {
+		t.Fatalf("expected entitlements %v, got %#v", identifiers, entitlements)
+	}
+	for i, identifier := range identifiers {
+		if entitlements[i].Identifier != identifier {
+			t.Fatalf("expected entitlements %v, got %#v", identifiers, entitlements)
+		}
+	}
+}
@@ -0,0 +1,302 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strings"
+
+	"nexus-core/domain/entity"
+	"nexus-core/global"
+	"nexus-core/persistence/model"
+
+	"gorm.io/gorm"
+)
+
+// maxAllowedDeviceImport 单次导入的设备白名单条数上限
+const maxAllowedDeviceImport = 10000
+
+// ListAllowedDevices 查询 License 的设备白名单及各设备的预留状态
+func (s *LicenseService) ListAllowedDevices(ctx context.Context, licenseID uint) ([]AllowedDeviceData, error) {
+	db := global.DB.WithContext(ctx)
+	if err := ensureLicenseExists(ctx, db, licenseID); err != nil {
+		return nil, err
+	}
+	var devices []model.LicenseAllowedDevice
+	if err := db.Where("license_id = ?", licenseID).Order("id ASC").Find(&devices).Error; err != nil {
+		return nil, WrapInternal("list allowed devices failed", err)
+	}
+	return toAllowedDeviceList(ctx, db, licenseID, devices)
+}
+
+// AddAllowedDevices 向 License 的设备白名单添加设备，已在名单内的设备更新备注
+// Reserve 为 true 时同时预先创建节点并预留给该 License，预留的节点不能注册到其他 License
+func (s *LicenseService) AddAllowedDevices(ctx context.Context, cmd AddAllowedDevicesCommand) (*AllowedDeviceImportData, error) {
+	if cmd.LicenseID == 0 {
+		return nil, ErrBadRequest("id is required")
+	}
+	devices := make([]AllowedDeviceEntry, 0, len(cmd.Devices))
+	seen := make(map[string]bool, len(cmd.Devices))
+	for _, device := range cmd.Devices {
+		device.DeviceCode = strings.TrimSpace(device.DeviceCode)
+		if device.DeviceCode == "" {
+			return nil, ErrBadRequest("device_code is required")
+		}
+		if len(device.DeviceCode) > 100 {
+			return nil, BadRequestf("device_code %q is too long", device.DeviceCode)
+		}
+		if seen[device.DeviceCode] {
+			continue
+		}
+		seen[device.DeviceCode] = true
+		devices = append(devices, device)
+	}
+	if len(devices) == 0 {
+		return nil, ErrBadRequest("devices are required")
+	}
+	if len(devices) > maxAllowedDeviceImport {
+		return nil, BadRequestf("at most %d devices can be imported at once", maxAllowedDeviceImport)
+	}
+
+	result := &AllowedDeviceImportData{}
+	err := global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := ensureLicenseExists(ctx, tx, cmd.LicenseID); err != nil {
+			return err
+		}
+		for _, device := range devices {
+			added, err := upsertAllowedDevice(ctx, tx, cmd.LicenseID, device)
+			if err != nil {
+				return err
+			}
+			if added {
+				result.Added++
+			} else {
+				result.Updated++
+			}
+			if cmd.Reserve {
+				if err := reserveNode(ctx, tx, cmd.LicenseID, device.DeviceCode); err != nil {
+					return err
+				}
+				result.Reserved++
+			}
+		}
+		recordAuditLog(ctx, tx, "license", cmd.LicenseID, "add_allowed_devices", map[string]interface{}{
+			"added":    result.Added,
+			"updated":  result.Updated,
+			"reserved": result.Reserved,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ImportAllowedDevices 从 CSV 批量导入设备白名单
+// 每行为 device_code[,remark]，首行为 device_code 表头时跳过，空行忽略
+func (s *LicenseService) ImportAllowedDevices(ctx context.Context, cmd ImportAllowedDevicesCommand) (*AllowedDeviceImportData, error) {
+	if cmd.Content == nil {
+		return nil, ErrBadRequest("csv content is required")
+	}
+	reader := csv.NewReader(cmd.Content)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	var devices []AllowedDeviceEntry
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, BadRequestf("invalid csv: %v", err)
+		}
+		deviceCode := strings.TrimSpace(record[0])
+		if line == 1 && strings.EqualFold(deviceCode, "device_code") {
+			continue
+		}
+		if deviceCode == "" {
+			continue
+		}
+		entry := AllowedDeviceEntry{DeviceCode: deviceCode}
+		if len(record) > 1 {
+			if remark := strings.TrimSpace(record[1]); remark != "" {
+				entry.Remark = &remark
+			}
+		}
+		devices = append(devices, entry)
+		if len(devices) > maxAllowedDeviceImport {
+			return nil, BadRequestf("at most %d devices can be imported at once", maxAllowedDeviceImport)
+		}
+	}
+	return s.AddAllowedDevices(ctx, AddAllowedDevicesCommand{
+		LicenseID: cmd.LicenseID,
+		Devices:   devices,
+		Reserve:   cmd.Reserve,
+	})
+}
+
+// RemoveAllowedDevice 从设备白名单移除设备，并取消该设备为此 License 的预留
+// 已绑定的节点不会被解绑；移除最后一个设备后不再限制注册设备
+func (s *LicenseService) RemoveAllowedDevice(ctx context.Context, licenseID uint, deviceCode string) error {
+	if licenseID == 0 || strings.TrimSpace(deviceCode) == "" {
+		return ErrBadRequest("id and device_code are required")
+	}
+	return global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := ensureLicenseExists(ctx, tx, licenseID); err != nil {
+			return err
+		}
+		result := tx.Unscoped().Where("license_id = ? AND device_code = ?", licenseID, deviceCode).
+			Delete(&model.LicenseAllowedDevice{})
+		if result.Error != nil {
+			return WrapInternal("delete allowed device failed", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound("allowed device not found")
+		}
+		if err := tx.Model(&model.Node{}).Where("device_code = ? AND reserved_license_id = ?", deviceCode, licenseID).
+			Update("reserved_license_id", nil).Error; err != nil {
+			return WrapInternal("release node reservation failed", err)
+		}
+		recordAuditLog(ctx, tx, "license", licenseID, "remove_allowed_device", map[string]interface{}{
+			"device_code": deviceCode,
+		})
+		return nil
+	})
+}
+
+// checkDeviceAllowed 设置了设备白名单的 License 只允许名单内的设备注册
+func checkDeviceAllowed(ctx context.Context, tx *gorm.DB, licenseID uint, deviceCode string) error {
+	var total int64
+	if err := tx.WithContext(ctx).Model(&model.LicenseAllowedDevice{}).Where("license_id = ?", licenseID).Count(&total).Error; err != nil {
+		return WrapInternal("check allowed devices failed", err)
+	}
+	if total == 0 {
+		return nil
+	}
+	var allowed int64
+	if err := tx.WithContext(ctx).Model(&model.LicenseAllowedDevice{}).
+		Where("license_id = ? AND device_code = ?", licenseID, deviceCode).Count(&allowed).Error; err != nil {
+		return WrapInternal("check allowed devices failed", err)
+	}
+	if allowed == 0 {
+		return ErrForbidden("device not allowed for this license")
+	}
+	return nil
+}
+
+func upsertAllowedDevice(ctx context.Context, tx *gorm.DB, licenseID uint, device AllowedDeviceEntry) (bool, error) {
+	var existing model.LicenseAllowedDevice
+	err := tx.WithContext(ctx).Where("license_id = ? AND device_code = ?", licenseID, device.DeviceCode).
+		First(&existing).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, WrapInternal("get allowed device failed", err)
+	}
+	if err == nil {
+		if device.Remark != nil {
+			if err := tx.Model(&existing).Update("remark", device.Remark).Error; err != nil {
+				return false, WrapInternal("update allowed device failed", err)
+			}
+		}
+		return false, nil
+	}
+	if err := tx.WithContext(ctx).Create(&model.LicenseAllowedDevice{
+		LicenseID:  licenseID,
+		DeviceCode: device.DeviceCode,
+		Remark:     device.Remark,
+	}).Error; err != nil {
+		return false, WrapInternal("create allowed device failed", err)
+	}
+	return true, nil
+}
+
+// reserveNode 预先创建节点并预留给 License，节点已预留给其他 License 时返回冲突
+func reserveNode(ctx context.Context, tx *gorm.DB, licenseID uint, deviceCode string) error {
+	node, err := nodeRepo.GetByDeviceCode(ctx, tx, deviceCode)
+	if err != nil {
+		return WrapInternal("get node failed", err)
+	}
+	if node == nil {
+		if err := nodeRepo.Create(ctx, tx, &model.Node{
+			DeviceCode:        deviceCode,
+			Status:            entity.NodeStatusNormal,
+			ReservedLicenseID: &licenseID,
+		}); err != nil {
+			return WrapInternal("create reserved node failed", err)
+		}
+		return nil
+	}
+	if node.ReservedLicenseID != nil {
+		if *node.ReservedLicenseID == licenseID {
+			return nil
+		}
+		return Conflictf("device %s is reserved for license %d", deviceCode, *node.ReservedLicenseID)
+	}
+	if err := tx.WithContext(ctx).Model(&model.Node{}).Where("id = ?", node.ID).
+		Update("reserved_license_id", licenseID).Error; err != nil {
+		return WrapInternal("reserve node failed", err)
+	}
+	return nil
+}
+
+// releaseLicenseAllowlist 删除 License 时清理设备白名单并取消节点预留
+func releaseLicenseAllowlist(ctx context.Context, tx *gorm.DB, licenseIDs ...uint) error {
+	if err := tx.WithContext(ctx).Unscoped().Where("license_id IN ?", licenseIDs).
+		Delete(&model.LicenseAllowedDevice{}).Error; err != nil {
+		return WrapInternal("delete allowed devices failed", err)
+	}
+	if err := tx.WithContext(ctx).Model(&model.Node{}).Where("reserved_license_id IN ?", licenseIDs).
+		Update("reserved_license_id", nil).Error; err != nil {
+		return WrapInternal("release node reservations failed", err)
+	}
+	return nil
+}
+
+func ensureLicenseExists(ctx context.Context, db *gorm.DB, licenseID uint) error {
+	if licenseID == 0 {
+		return ErrBadRequest("id is required")
+	}
+	var count int64
+	if err := db.WithContext(ctx).Model(&model.License{}).Where("id = ?", licenseID).Count(&count).Error; err != nil {
+		return WrapInternal("get license failed", err)
+	}
+	if count == 0 {
+		return ErrNotFound("license not found")
+	}
+	return nil
+}
+
+func toAllowedDeviceList(ctx context.Context, db *gorm.DB, licenseID uint, devices []model.LicenseAllowedDevice) ([]AllowedDeviceData, error) {
+	data := make([]AllowedDeviceData, 0, len(devices))
+	if len(devices) == 0 {
+		return data, nil
+	}
+	codes := make([]string, 0, len(devices))
+	for i := range devices {
+		codes = append(codes, devices[i].DeviceCode)
+	}
+	var nodes []model.Node
+	if err := db.WithContext(ctx).Where("device_code IN ?", codes).Find(&nodes).Error; err != nil {
+		return nil, WrapInternal("list allowed device nodes failed", err)
+	}
+	nodeByCode := make(map[string]*model.Node, len(nodes))
+	for i := range nodes {
+		nodeByCode[nodes[i].DeviceCode] = &nodes[i]
+	}
+	for i := range devices {
+		item := AllowedDeviceData{
+			ID:         devices[i].ID,
+			LicenseID:  devices[i].LicenseID,
+			DeviceCode: devices[i].DeviceCode,
+			Remark:     devices[i].Remark,
+			CreatedAt:  devices[i].CreatedAt,
+		}
+		if node, ok := nodeByCode[devices[i].DeviceCode]; ok {
+			item.NodeID = &node.ID
+			item.Reserved = node.ReservedLicenseID != nil && *node.ReservedLicenseID == licenseID
+		}
+		data = append(data, item)
+	}
+	return data, nil
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"nexus-core/persistence/model"
+)
+
+func TestLicenseDeviceAllowlist(t *testing.T) {
+	fixture := newFlowFixture(t, 5, 0, 24)
+	registerWith := func(licenseKey string, device string) error {
+		_, err := fixture.accessService.Register(fixture.ctx, AccessCommand{
+			DeviceCode:  device,
+			LicenseKey:  licenseKey,
+			ProductID:   fixture.product.ID,
+			VersionCode: "1.0.0",
+		})
+		return err
+	}
+
+	imported, err := fixture.licenseService.ImportAllowedDevices(fixture.ctx, ImportAllowedDevicesCommand{
+		LicenseID: fixture.license.ID,
+		Content:   strings.NewReader("device_code,remark\ndevice-a,lab\n\ndevice-b\ndevice-a\n"),
+	})
+	if err != nil || imported.Added != 2 || imported.Reserved != 0 {
+		t.Fatalf("import allowlist: %#v %v", imported, err)
+	}
+	fixture.register(t, "device-a")
+	assertAppErrorKind(t, registerWith(fixture.license.LicenseKey, "device-c"), ErrorKindForbidden)
+
+	// 预留的节点在设备首次连接前就已存在，只能注册到预留的 License
+	other, err := fixture.licenseService.CreateLicense(fixture.ctx, CreateLicenseCommand{
+		ProductID:     fixture.product.ID,
+		ValidityHours: 24,
+	})
+	if err != nil {
+		t.Fatalf("create other license: %v", err)
+	}
+	reserved, err := fixture.licenseService.AddAllowedDevices(fixture.ctx, AddAllowedDevicesCommand{
+		LicenseID: fixture.license.ID,
+		Devices:   []AllowedDeviceEntry{{DeviceCode: "device-r"}},
+		Reserve:   true,
+	})
+	if err != nil || reserved.Added != 1 || reserved.Reserved != 1 {
+		t.Fatalf("reserve device: %#v %v", reserved, err)
+	}
+	node, err := fixture.nodeService.GetByDeviceCode(fixture.ctx, "device-r")
+	if err != nil || node == nil {
+		t.Fatalf("reserved node should be pre-created: %#v %v", node, err)
+	}
+	assertAppErrorKind(t, registerWith(other.LicenseKey, "device-r"), ErrorKindForbidden)
+
+	// 管理员绑定与自动绑定同样受白名单和预留约束
+	if err := registerWith(other.LicenseKey, "device-x"); err != nil {
+		t.Fatalf("activate other license: %v", err)
+	}
+	err = fixture.nodeService.AddBinding(fixture.ctx, AddBindingCommand{NodeID: node.ID, LicenseID: other.ID})
+	assertAppErrorKind(t, err, ErrorKindForbidden)
+	err = fixture.nodeService.AutoBind(fixture.ctx, AutoBindCommand{DeviceCode: "device-c", LicenseID: fixture.license.ID})
+	assertAppErrorKind(t, err, ErrorKindForbidden)
+	_, err = fixture.licenseService.AddAllowedDevices(fixture.ctx, AddAllowedDevicesCommand{
+		LicenseID: other.ID,
+		Devices:   []AllowedDeviceEntry{{DeviceCode: "device-r"}},
+		Reserve:   true,
+	})
+	assertAppErrorKind(t, err, ErrorKindConflict)
+	if registered := fixture.register(t, "device-r"); registered.NodeID != node.ID {
+		t.Fatalf("reserved node should be reused: %#v", registered)
+	}
+
+	devices, err := fixture.licenseService.ListAllowedDevices(fixture.ctx, fixture.license.ID)
+	if err != nil || len(devices) != 3 || devices[0].Remark == nil || !devices[2].Reserved || devices[2].NodeID == nil {
+		t.Fatalf("list allowlist: %#v %v", devices, err)
+	}
+
+	// 移除全部设备后不再限制注册设备，同时取消预留
+	for _, device := range devices {
+		if err := fixture.licenseService.RemoveAllowedDevice(fixture.ctx, fixture.license.ID, device.DeviceCode); err != nil {
+			t.Fatalf("remove %s: %v", device.DeviceCode, err)
+		}
+	}
+	assertAppErrorKind(t, fixture.licenseService.RemoveAllowedDevice(fixture.ctx, fixture.license.ID, "device-a"), ErrorKindNotFound)
+	if err := registerWith(fixture.license.LicenseKey, "device-c"); err != nil {
+		t.Fatalf("license without allowlist should accept any device: %v", err)
+	}
+	var released model.Node
+	if err := fixture.db.Where("id = ?", node.ID).First(&released).Error; err != nil || released.ReservedLicenseID != nil {
+		t.Fatalf("node reservation should be released: %#v %v", released.ReservedLicenseID, err)
+	}
+}
+
+func TestCleanUnboundNodeKeepsReservedNodes(t *testing.T) {
+	fixture := newFlowFixture(t, 5, 0, 24)
+	if _, err := fixture.licenseService.AddAllowedDevices(fixture.ctx, AddAllowedDevicesCommand{
+		LicenseID: fixture.license.ID,
+		Devices:   []AllowedDeviceEntry{{DeviceCode: "device-r"}},
+		Reserve:   true,
+	}); err != nil {
+		t.Fatalf("reserve device: %v", err)
+	}
+
+	// 预留的节点尚未注册，清理未绑定节点时不能丢失预留
+	if err := fixture.nodeService.CleanUnboundNode(fixture.ctx); err != nil {
+		t.Fatalf("clean unbound nodes: %v", err)
+	}
+	node, err := fixture.nodeService.GetByDeviceCode(fixture.ctx, "device-r")
+	if err != nil || node == nil {
+		t.Fatalf("reserved node should survive cleanup: %#v %v", node, err)
+	}
+	if registered := fixture.register(t, "device-r"); registered.NodeID != node.ID {
+		t.Fatalf("reserved node should be reused: %#v", registered)
+	}
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"nexus-core/domain/entity"
+	"nexus-core/global"
+	"nexus-core/persistence/model"
+
+	"gorm.io/gorm"
+)
+
+// SetLicenseVersionEntitlement 设置许可证可使用的版本范围
+// 截止时间与版本范围可以同时设置，三项均为空表示不限制版本
+func (s *LicenseService) SetLicenseVersionEntitlement(ctx context.Context, cmd SetLicenseVersionEntitlementCommand) (*LicenseData, error) {
+	if cmd.LicenseID == 0 {
+		return nil, ErrBadRequest("id is required")
+	}
+	err := global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var license model.License
+		if err := tx.Select("id", "product_id").Where("id = ?", cmd.LicenseID).First(&license).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotFound("license not found")
+			}
+			return WrapInternal("get license failed", err)
+		}
+		if err := validateVersionEntitlement(ctx, tx, license.ProductID, cmd.MinVersionID, cmd.MaxVersionID); err != nil {
+			return err
+		}
+		if err := tx.Model(&model.License{}).Where("id = ?", license.ID).Updates(map[string]interface{}{
+			"version_cutoff_at": cmd.VersionCutoffAt,
+			"min_version_id":    cmd.MinVersionID,
+			"max_version_id":    cmd.MaxVersionID,
+		}).Error; err != nil {
+			return WrapInternal("update license version entitlement failed", err)
+		}
+		recordAuditLog(ctx, tx, "license", license.ID, "set_version_entitlement", map[string]interface{}{
+			"version_cutoff_at": cmd.VersionCutoffAt,
+			"min_version_id":    cmd.MinVersionID,
+			"max_version_id":    cmd.MaxVersionID,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetLicenseDataByID(ctx, cmd.LicenseID)
+}
+
+// validateVersionEntitlement 校验版本范围的边界属于该产品，且最低版本不晚于最高版本
+func validateVersionEntitlement(ctx context.Context, db *gorm.DB, productID uint, minVersionID *uint, maxVersionID *uint) error {
+	minVersion, err := getEntitlementVersion(ctx, db, productID, minVersionID, "min_version_id")
+	if err != nil {
+		return err
+	}
+	maxVersion, err := getEntitlementVersion(ctx, db, productID, maxVersionID, "max_version_id")
+	if err != nil {
+		return err
+	}
+	if minVersion != nil && maxVersion != nil && minVersion.ReleaseDate != nil && maxVersion.ReleaseDate != nil &&
+		minVersion.ReleaseDate.After(*maxVersion.ReleaseDate) {
+		return ErrBadRequest("min_version_id must not be released after max_version_id")
+	}
+	return nil
+}
+
+func getEntitlementVersion(ctx context.Context, db *gorm.DB, productID uint, versionID *uint, field string) (*model.ProductVersion, error) {
+	if versionID == nil {
+		return nil, nil
+	}
+	version, err := productVersionRepo.GetByID(ctx, db.WithContext(ctx), *versionID)
+	if err != nil {
+		return nil, WrapInternal("get product version failed", err)
+	}
+	if version == nil || version.ProductID != productID {
+		return nil, BadRequestf("%s does not belong to product", field)
+	}
+	return version, nil
+}
+
+// checkVersionEntitlement 校验客户端版本在许可证的版本授权范围内
+func checkVersionEntitlement(license *entity.License, product *entity.Product, versionCode string) error {
+	if !license.HasVersionEntitlement() {
+		return nil
+	}
+	version, err := product.GetVersionByCode(versionCode)
+	if err != nil || !license.CoversVersion(product, *version) {
+		return Forbiddenf("upgrade not covered by your license: version %s", versionCode)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"nexus-core/domain/entity"
+	"nexus-core/persistence/model"
+)
+
+func TestPerpetualLicense(t *testing.T) {
+	fixture := newFlowFixture(t, 1, 0, 24)
+	perpetual, err := fixture.licenseService.CreateLicense(fixture.ctx, CreateLicenseCommand{
+		ProductID: fixture.product.ID,
+		Perpetual: true,
+		MaxNodes:  1,
+	})
+	if err != nil {
+		t.Fatalf("create perpetual license: %v", err)
+	}
+	if !perpetual.Perpetual || perpetual.ValidityHours != 0 {
+		t.Fatalf("unexpected perpetual license: %#v", perpetual)
+	}
+	fixture.license = perpetual
+	fixture.register(t, "device-a")
+
+	license, err := GetLicenseEntityByID(fixture.ctx, fixture.db, perpetual.ID)
+	if err != nil {
+		t.Fatalf("get license: %v", err)
+	}
+	if license.ActivatedAt == nil || license.ExpiredAt != nil || license.CalculateStatus(time.Now()) != entity.StatusActive {
+		t.Fatalf("perpetual license should activate without expiry: %#v", license)
+	}
+
+	err = fixture.licenseService.RenewLicense(fixture.ctx, RenewLicenseCommand{ID: perpetual.ID, ExtraHours: 24})
+	assertAppErrorKind(t, err, ErrorKindConflict)
+
+	_, err = fixture.licenseService.CreateLicense(fixture.ctx, CreateLicenseCommand{
+		ProductID: fixture.product.ID,
+		Type:      int(entity.LicenseTypeTrial),
+		Perpetual: true,
+	})
+	assertAppErrorKind(t, err, ErrorKindBadRequest)
+}
+
+func TestLicenseVersionEntitlement(t *testing.T) {
+	fixture := newFlowFixture(t, 2, 0, 24)
+	released := time.Now().Add(-48 * time.Hour)
+	if err := fixture.db.Model(&model.ProductVersion{}).
+		Where("product_id = ? AND version_code = ?", fixture.product.ID, "1.0.0").
+		Update("release_date", released).Error; err != nil {
+		t.Fatalf("backdate version: %v", err)
+	}
+	v2, err := fixture.productService.CreateProductVersion(fixture.ctx, CreateProductVersionCommand{
+		ProductID:   fixture.product.ID,
+		VersionCode: "2.0.0",
+		Method:      ReleaseImmediate,
+	})
+	if err != nil {
+		t.Fatalf("create version: %v", err)
+	}
+
+	cutoff := time.Now().Add(-24 * time.Hour)
+	data, err := fixture.licenseService.SetLicenseVersionEntitlement(fixture.ctx, SetLicenseVersionEntitlementCommand{
+		LicenseID:       fixture.license.ID,
+		VersionCutoffAt: &cutoff,
+	})
+	if err != nil {
+		t.Fatalf("set version cutoff: %v", err)
+	}
+	if data.VersionCutoffAt == nil {
+		t.Fatalf("license should expose version cutoff: %#v", data)
+	}
+
+	fixture.register(t, "device-a")
+	access := AccessCommand{
+		DeviceCode:  "device-a",
+		LicenseKey:  fixture.license.LicenseKey,
+		ProductID:   fixture.product.ID,
+		VersionCode: "2.0.0",
+		NodeSecret:  fixture.secrets["device-a"],
+	}
+	_, err = fixture.accessService.Heartbeat(fixture.ctx, access)
+	assertAppErrorKind(t, err, ErrorKindForbidden)
+
+	_, err = fixture.accessService.Register(fixture.ctx, AccessCommand{
+		DeviceCode:  "device-b",
+		LicenseKey:  fixture.license.LicenseKey,
+		ProductID:   fixture.product.ID,
+		VersionCode: "2.0.0",
+	})
+	assertAppErrorKind(t, err, ErrorKindForbidden)
+
+	// 改为版本范围后，范围内的新版本可以正常心跳
+	if _, err := fixture.licenseService.SetLicenseVersionEntitlement(fixture.ctx, SetLicenseVersionEntitlementCommand{
+		LicenseID:    fixture.license.ID,
+		MaxVersionID: &v2.ID,
+	}); err != nil {
+		t.Fatalf("set version range: %v", err)
+	}
+	if _, err := fixture.accessService.Heartbeat(fixture.ctx, access); err != nil {
+		t.Fatalf("heartbeat within range: %v", err)
+	}
+
+	if _, err := fixture.licenseService.SetLicenseVersionEntitlement(fixture.ctx, SetLicenseVersionEntitlementCommand{
+		LicenseID:    fixture.license.ID,
+		MinVersionID: &v2.ID,
+	}); err != nil {
+		t.Fatalf("set min version: %v", err)
+	}
+	_, err = fixture.heartbeat("device-a")
+	assertAppErrorKind(t, err, ErrorKindForbidden)
+
+	other, err := fixture.productService.CreateProduct(fixture.ctx, CreateProductCommand{Name: "other-product"})
+	if err != nil {
+		t.Fatalf("create other product: %v", err)
+	}
+	otherVersion, err := fixture.productService.CreateProductVersion(fixture.ctx, CreateProductVersionCommand{
+		ProductID:   other.ID,
+		VersionCode: "1.0.0",
+		Method:      ReleaseImmediate,
+	})
+	if err != nil {
+		t.Fatalf("create other version: %v", err)
+	}
+	_, err = fixture.licenseService.SetLicenseVersionEntitlement(fixture.ctx, SetLicenseVersionEntitlementCommand{
+		LicenseID:    fixture.license.ID,
+		MaxVersionID: &otherVersion.ID,
+	})
+	assertAppErrorKind(t, err, ErrorKindBadRequest)
+
+	// 版本范围只约束主产品，附加产品的任意版本不受影响
+	if _, err := fixture.licenseService.SetLicenseProductScope(fixture.ctx, SetLicenseProductScopeCommand{
+		LicenseID: fixture.license.ID,
+		ProductID: other.ID,
+	}); err != nil {
+		t.Fatalf("add product scope: %v", err)
+	}
+	if _, err := fixture.accessService.Register(fixture.ctx, AccessCommand{
+		DeviceCode:  "device-a",
+		LicenseKey:  fixture.license.LicenseKey,
+		ProductID:   other.ID,
+		VersionCode: "1.0.0",
+		NodeSecret:  fixture.secrets["device-a"],
+	}); err != nil {
+		t.Fatalf("register scoped product outside primary range: %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"nexus-core/domain/entity"
+	"nexus-core/licensefile"
+	"nexus-core/licensekey"
+	"nexus-core/persistence/model"
+)
+
+func TestExportLicenseFileVerifiesOffline(t *testing.T) {
+	fixture := newFlowFixture(t, 2, 0, 24)
+	if err := fixture.db.Create(&model.LicenseServiceScope{
+		LicenseID:         fixture.license.ID,
+		ServiceIdentifier: "device.reboot",
+		Status:            int(entity.ScopeStatusEnabled),
+	}).Error; err != nil {
+		t.Fatalf("create service scope: %v", err)
+	}
+
+	file, err := fixture.licenseService.ExportLicenseFile(fixture.ctx, fixture.license.ID)
+	if err != nil {
+		t.Fatalf("export license file: %v", err)
+	}
+
+	signingService := NewSigningService()
+	keys, err := signingService.ListKeys(fixture.ctx)
+	if err != nil {
+		t.Fatalf("list signing keys: %v", err)
+	}
+	keySet := licensefile.KeySet{}
+	for _, key := range keys {
+		if err := keySet.Add(key.KeyID, key.PublicKey); err != nil {
+			t.Fatalf("add key: %v", err)
+		}
+	}
+
+	claims, err := licensefile.VerifyLicense(file.Content, keySet, fixture.product.ID, time.Now())
+	if err != nil {
+		t.Fatalf("verify license file: %v", err)
+	}
+	if claims.LicenseKey != licensekey.Mask(licensekey.DisplayPrefix(fixture.license.LicenseKey)) || claims.MaxNodes != 2 || claims.ExpiredAt == nil {
+		t.Fatalf("unexpected claims: %#v", claims)
+	}
+	if !claims.AllowsService("device.reboot") || claims.AllowsService("device.shutdown") {
+		t.Fatalf("unexpected service scopes: %#v", claims.ServiceScopes)
+	}
+	if _, err := licensefile.VerifyLicense(file.Content, keySet, fixture.product.ID+1, time.Now()); !errors.Is(err, licensefile.ErrProductNotAuthorized) {
+		t.Fatalf("expected product not authorized, got %v", err)
+	}
+
+	license, err := fixture.licenseService.GetLicenseDataByID(fixture.ctx, fixture.license.ID)
+	if err != nil {
+		t.Fatalf("get license: %v", err)
+	}
+	if license.Status != int(entity.StatusActive) {
+		t.Fatalf("export should activate license, status=%d", license.Status)
+	}
+
+	rotated, err := signingService.RotateKey(fixture.ctx)
+	if err != nil {
+		t.Fatalf("rotate key: %v", err)
+	}
+	if rotated.KeyID == file.KeyID {
+		t.Fatal("rotation should create a new key")
+	}
+	next, err := fixture.licenseService.ExportLicenseFile(fixture.ctx, fixture.license.ID)
+	if err != nil {
+		t.Fatalf("export after rotation: %v", err)
+	}
+	if next.KeyID != rotated.KeyID {
+		t.Fatalf("expected new key %s, got %s", rotated.KeyID, next.KeyID)
+	}
+
+	if err := fixture.licenseService.RevokeLicense(fixture.ctx, fixture.license.ID); err != nil {
+		t.Fatalf("revoke license: %v", err)
+	}
+	_, err = fixture.licenseService.ExportLicenseFile(fixture.ctx, fixture.license.ID)
+	assertAppErrorKind(t, err, ErrorKindForbidden)
+}
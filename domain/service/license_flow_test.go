@@ -26,6 +26,7 @@ type flowFixture struct {
 	accessService  *AccessService
 	product        *ProductData
 	license        *LicenseData
+	secrets        map[string]string
 }
 
 func newFlowFixture(t *testing.T, maxNodes int, maxConcurrent int, validityHours int) *flowFixture {
@@ -54,6 +55,9 @@ func newFlowFixture(t *testing.T, maxNodes int, maxConcurrent int, validityHours
 
 	base.AutoMigrate(db)
 	global.DB = db
+	if err := NewSigningService().EnsureActiveKey(context.Background()); err != nil {
+		t.Fatalf("ensure signing key: %v", err)
+	}
 	monitor.GlobalStat = monitor.NewOnlineStat()
 	monitor.GlobalMonitor = monitor.NewMonitor(monitor.GlobalStat)
 
@@ -98,6 +102,7 @@ func newFlowFixture(t *testing.T, maxNodes int, maxConcurrent int, validityHours
 		accessService:  accessService,
 		product:        product,
 		license:        license,
+		secrets:        map[string]string{},
 	}
 }
 
@@ -108,13 +113,27 @@ func (f *flowFixture) register(t *testing.T, deviceCode string) *RegisterResult
 		LicenseKey:  f.license.LicenseKey,
 		ProductID:   f.product.ID,
 		VersionCode: "1.0.0",
+		NodeSecret:  f.secrets[deviceCode],
 	})
 	if err != nil {
 		t.Fatalf("register %s: %v", deviceCode, err)
 	}
+	if result.NodeSecret != "" {
+		f.secrets[deviceCode] = result.NodeSecret
+	}
 	return result
 }
 
+func (f *flowFixture) heartbeat(deviceCode string) (*HeartbeatResult, error) {
+	return f.accessService.Heartbeat(f.ctx, AccessCommand{
+		DeviceCode:  deviceCode,
+		LicenseKey:  f.license.LicenseKey,
+		ProductID:   f.product.ID,
+		VersionCode: "1.0.0",
+		NodeSecret:  f.secrets[deviceCode],
+	})
+}
+
 func assertAppErrorKind(t *testing.T, err error, kind ErrorKind) {
 	t.Helper()
 	if err == nil {
@@ -151,7 +170,7 @@ func TestLicenseMainFlow(t *testing.T) {
 		t.Fatalf("stored current node count should be 1, got %d", storedLicense.CurrentNodeCount)
 	}
 
-	heartbeatResult, err := fixture.accessService.Heartbeat(fixture.ctx, "device-a", fixture.product.ID, "1.0.0", fixture.license.LicenseKey)
+	heartbeatResult, err := fixture.heartbeat("device-a")
 	if err != nil {
 		t.Fatalf("heartbeat: %v", err)
 	}
@@ -159,7 +178,7 @@ func TestLicenseMainFlow(t *testing.T) {
 		t.Fatal("heartbeat should mark node online")
 	}
 
-	heartbeatResult, err = fixture.accessService.Heartbeat(fixture.ctx, "device-a", fixture.product.ID, "1.0.0", fixture.license.LicenseKey)
+	heartbeatResult, err = fixture.heartbeat("device-a")
 	if err != nil {
 		t.Fatalf("repeated heartbeat from same node should refresh online state: %v", err)
 	}
@@ -207,10 +226,10 @@ func TestConcurrentLimit(t *testing.T) {
 	fixture.register(t, "device-a")
 	fixture.register(t, "device-b")
 
-	if _, err := fixture.accessService.Heartbeat(fixture.ctx, "device-a", fixture.product.ID, "1.0.0", fixture.license.LicenseKey); err != nil {
+	if _, err := fixture.heartbeat("device-a"); err != nil {
 		t.Fatalf("first heartbeat: %v", err)
 	}
-	_, err := fixture.accessService.Heartbeat(fixture.ctx, "device-b", fixture.product.ID, "1.0.0", fixture.license.LicenseKey)
+	_, err := fixture.heartbeat("device-b")
 	assertAppErrorKind(t, err, ErrorKindConflict)
 }
 
@@ -225,7 +244,7 @@ func TestLicenseExpiredAndRevokedBlockHeartbeat(t *testing.T) {
 	}).Error; err != nil {
 		t.Fatalf("expire license: %v", err)
 	}
-	_, err := fixture.accessService.Heartbeat(fixture.ctx, "device-a", fixture.product.ID, "1.0.0", fixture.license.LicenseKey)
+	_, err := fixture.heartbeat("device-a")
 	assertAppErrorKind(t, err, ErrorKindConflict)
 
 	if err := fixture.db.Model(&model.License{}).Where("id = ?", fixture.license.ID).Updates(map[string]interface{}{
@@ -237,7 +256,7 @@ func TestLicenseExpiredAndRevokedBlockHeartbeat(t *testing.T) {
 	if err := fixture.licenseService.RevokeLicense(fixture.ctx, fixture.license.ID); err != nil {
 		t.Fatalf("revoke license: %v", err)
 	}
-	_, err = fixture.accessService.Heartbeat(fixture.ctx, "device-a", fixture.product.ID, "1.0.0", fixture.license.LicenseKey)
+	_, err = fixture.heartbeat("device-a")
 	assertAppErrorKind(t, err, ErrorKindForbidden)
 }
 
@@ -249,7 +268,7 @@ func TestBannedNodeBlocksRegisterBindingAndHeartbeat(t *testing.T) {
 		t.Fatalf("ban node: %v", err)
 	}
 
-	_, err := fixture.accessService.Heartbeat(fixture.ctx, "device-a", fixture.product.ID, "1.0.0", fixture.license.LicenseKey)
+	_, err := fixture.heartbeat("device-a")
 	assertAppErrorKind(t, err, ErrorKindForbidden)
 
 	_, err = fixture.accessService.Register(fixture.ctx, AccessCommand{
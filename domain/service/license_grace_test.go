@@ -0,0 +1,117 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"nexus-core/domain/entity"
+	"nexus-core/persistence/model"
+)
+
+func TestLicenseGracePeriod(t *testing.T) {
+	fixture := newFlowFixture(t, 2, 0, 24)
+	first := fixture.register(t, "device-a")
+
+	graceHours := 48
+	if _, err := fixture.productService.UpdateProduct(fixture.ctx, UpdateProductCommand{
+		ID:               fixture.product.ID,
+		GracePeriodHours: &graceHours,
+	}); err != nil {
+		t.Fatalf("set product grace period: %v", err)
+	}
+	controlService := NewControlService()
+	serviceDef, err := controlService.CreateControlService(fixture.ctx, CreateControlServiceCommand{
+		ProductID:    &fixture.product.ID,
+		Identifier:   "restart_process",
+		Name:         "Restart Process",
+		ServiceType:  "command",
+		InputSchema:  json.RawMessage(`{"type":"object"}`),
+		OutputSchema: json.RawMessage(`{"type":"object"}`),
+	})
+	if err != nil {
+		t.Fatalf("create control service: %v", err)
+	}
+	restart := &model.ControlService{ProductID: &fixture.product.ID, Identifier: serviceDef.Identifier}
+
+	expiredAt := time.Now().Add(-2 * time.Hour)
+	if err := fixture.db.Model(&model.License{}).Where("id = ?", fixture.license.ID).
+		Update("expired_at", expiredAt).Error; err != nil {
+		t.Fatalf("expire license: %v", err)
+	}
+
+	heartbeat, err := fixture.heartbeat("device-a")
+	if err != nil {
+		t.Fatalf("heartbeat in grace period: %v", err)
+	}
+	if heartbeat.LicenseStatus != int(entity.StatusGrace) || heartbeat.GraceDaysRemaining != 2 || heartbeat.GraceEndsAt == nil {
+		t.Fatalf("heartbeat should report grace state: %#v", heartbeat)
+	}
+	if !heartbeat.LeaseExpiresAt.After(time.Now()) {
+		t.Fatalf("lease should extend into grace period: %v", heartbeat.LeaseExpiresAt)
+	}
+
+	license, err := fixture.licenseService.GetLicenseDataByID(fixture.ctx, fixture.license.ID)
+	if err != nil {
+		t.Fatalf("get license: %v", err)
+	}
+	if license.Status != int(entity.StatusGrace) || license.GraceEndsAt == nil {
+		t.Fatalf("license query should show grace state: %#v", license)
+	}
+
+	// 宽限期内不允许绑定新节点
+	_, err = fixture.accessService.Register(fixture.ctx, AccessCommand{
+		DeviceCode:  "device-b",
+		LicenseKey:  fixture.license.LicenseKey,
+		ProductID:   fixture.product.ID,
+		VersionCode: "1.0.0",
+	})
+	assertAppErrorKind(t, err, ErrorKindConflict)
+
+	summary, err := NewMonitorService().GetOnlineSummary(fixture.ctx)
+	if err != nil {
+		t.Fatalf("get online summary: %v", err)
+	}
+	if len(summary.GraceLicenses) != 1 || summary.GraceLicenses[0].LicenseID != fixture.license.ID || summary.GraceLicenses[0].Online != 1 {
+		t.Fatalf("monitor summary should list grace license: %#v", summary.GraceLicenses)
+	}
+
+	err = validateNodeHasValidBindingForControl(fixture.ctx, first.NodeID, restart)
+	assertAppErrorKind(t, err, ErrorKindForbidden)
+	allowControl := true
+	if _, err := fixture.productService.UpdateProduct(fixture.ctx, UpdateProductCommand{
+		ID:                fixture.product.ID,
+		GraceAllowControl: &allowControl,
+	}); err != nil {
+		t.Fatalf("allow control in grace: %v", err)
+	}
+	if err := validateNodeHasValidBindingForControl(fixture.ctx, first.NodeID, restart); err != nil {
+		t.Fatalf("control should be allowed in grace: %v", err)
+	}
+
+	if err := fixture.licenseService.CleanInvalidLicense(fixture.ctx); err != nil {
+		t.Fatalf("clean invalid licenses: %v", err)
+	}
+	if _, err := fixture.licenseService.GetLicenseDataByID(fixture.ctx, fixture.license.ID); err != nil {
+		t.Fatalf("license in grace should not be cleaned: %v", err)
+	}
+
+	// License 单独设置的宽限期优先于产品设置
+	shortGrace := 1
+	if _, err := fixture.licenseService.SetLicenseGracePeriod(fixture.ctx, SetLicenseGracePeriodCommand{
+		LicenseID:        fixture.license.ID,
+		GracePeriodHours: &shortGrace,
+	}); err != nil {
+		t.Fatalf("set license grace period: %v", err)
+	}
+	_, err = fixture.heartbeat("device-a")
+	assertAppErrorKind(t, err, ErrorKindConflict)
+
+	restored, err := fixture.licenseService.SetLicenseGracePeriod(fixture.ctx, SetLicenseGracePeriodCommand{LicenseID: fixture.license.ID})
+	if err != nil {
+		t.Fatalf("clear license grace period: %v", err)
+	}
+	if restored.GracePeriodHours != nil || restored.Status != int(entity.StatusGrace) {
+		t.Fatalf("license should fall back to product grace period: %#v", restored)
+	}
+}
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"nexus-core/domain/entity"
+	"nexus-core/global"
+	"nexus-core/persistence/model"
+
+	"gorm.io/gorm"
+)
+
+// License 状态历史事件
+const (
+	LicenseEventCreate       = "create"
+	LicenseEventActivate     = "activate"
+	LicenseEventRenew        = "renew"
+	LicenseEventRevoke       = "revoke"
+	LicenseEventRestore      = "restore"
+	LicenseEventExpire       = "expire"        // 到期进入宽限期或过期
+	LicenseEventStatusChange = "status_change" // 其他按时间自动校正的状态变化
+	LicenseEventLimitChange  = "limit_change"
+	LicenseEventConvertTrial = "convert_trial"
+	LicenseEventChangePlan   = "change_plan"
+	LicenseEventRedeem       = "redeem" // 兑换兑换码
+)
+
+// licenseState 历史记录中变更前或变更后的取值
+type licenseState struct {
+	Status        entity.LicenseStatus
+	ExpiredAt     *time.Time
+	MaxNodes      int
+	MaxConcurrent int
+}
+
+func licenseStateOf(license *entity.License) licenseState {
+	state := licenseState{
+		Status:        license.Status,
+		MaxNodes:      license.MaxNodes,
+		MaxConcurrent: license.MaxConcurrent,
+	}
+	if license.ExpiredAt != nil {
+		expiredAt := *license.ExpiredAt
+		state.ExpiredAt = &expiredAt
+	}
+	return state
+}
+
+func (s licenseState) equal(other licenseState) bool {
+	if s.Status != other.Status || s.MaxNodes != other.MaxNodes || s.MaxConcurrent != other.MaxConcurrent {
+		return false
+	}
+	if s.ExpiredAt == nil || other.ExpiredAt == nil {
+		return s.ExpiredAt == nil && other.ExpiredAt == nil
+	}
+	return s.ExpiredAt.Equal(*other.ExpiredAt)
+}
+
+func (s licenseState) data() LicenseStateData {
+	return LicenseStateData{
+		Status:        int(s.Status),
+		ExpiredAt:     s.ExpiredAt,
+		MaxNodes:      s.MaxNodes,
+		MaxConcurrent: s.MaxConcurrent,
+	}
+}
+
+// licenseChange 一次需要写入状态历史的 License 变更
+type licenseChange struct {
+	LicenseID  uint
+	Event      string
+	Before     licenseState
+	After      licenseState
+	ExtraHours int
+	OccurredAt time.Time
+}
+
+// recordLicenseHistory 写入 License 状态历史，需与变更本身在同一事务中调用
+func recordLicenseHistory(ctx context.Context, db *gorm.DB, change licenseChange) error {
+	if change.OccurredAt.IsZero() {
+		change.OccurredAt = time.Now()
+	}
+	history := model.LicenseStatusHistory{
+		LicenseID:         change.LicenseID,
+		Event:             change.Event,
+		FromStatus:        int(change.Before.Status),
+		ToStatus:          int(change.After.Status),
+		FromExpiredAt:     change.Before.ExpiredAt,
+		ToExpiredAt:       change.After.ExpiredAt,
+		FromMaxNodes:      change.Before.MaxNodes,
+		ToMaxNodes:        change.After.MaxNodes,
+		FromMaxConcurrent: change.Before.MaxConcurrent,
+		ToMaxConcurrent:   change.After.MaxConcurrent,
+		ExtraHours:        change.ExtraHours,
+		Operator:          model.OperatorFromContext(ctx),
+		OccurredAt:        change.OccurredAt,
+	}
+	if err := db.WithContext(ctx).Create(&history).Error; err != nil {
+		return WrapInternal("record license history failed", err)
+	}
+	return nil
+}
+
+// statusTransitionAt 按时间自动变化的状态实际发生的时刻，用于补记延迟发现的到期
+func statusTransitionAt(license *entity.License, status entity.LicenseStatus, now time.Time) time.Time {
+	switch status {
+	case entity.StatusGrace:
+		if license.ExpiredAt != nil {
+			return *license.ExpiredAt
+		}
+	case entity.StatusExpired:
+		if endsAt := license.GraceEndsAt(); license.Status == entity.StatusGrace && endsAt != nil {
+			return *endsAt
+		}
+		if license.ExpiredAt != nil {
+			return *license.ExpiredAt
+		}
+	}
+	return now
+}
+
+// ListLicenseHistory 按时间顺序列出 License 的状态历史，已删除的 License 仍可查询
+func (s *LicenseService) ListLicenseHistory(ctx context.Context, licenseID uint) ([]LicenseHistoryData, error) {
+	if licenseID == 0 {
+		return nil, ErrBadRequest("id is required")
+	}
+	db := global.DB.WithContext(ctx)
+	if _, err := getLicenseForHistory(ctx, db, licenseID); err != nil {
+		return nil, err
+	}
+	var histories []model.LicenseStatusHistory
+	if err := db.Where("license_id = ?", licenseID).Order("occurred_at ASC, id ASC").Find(&histories).Error; err != nil {
+		return nil, WrapInternal("list license history failed", err)
+	}
+	data := make([]LicenseHistoryData, 0, len(histories))
+	for i := range histories {
+		data = append(data, toLicenseHistoryData(&histories[i]))
+	}
+	return data, nil
+}
+
+// GetLicenseStatusAt 回溯 License 在指定时刻的状态、过期时间和限制
+// 取该时刻之前最近一次变更后的取值，过期和宽限期按时间推导，宽限时长使用当前设置
+func (s *LicenseService) GetLicenseStatusAt(ctx context.Context, licenseID uint, at time.Time) (*LicenseStatusAtData, error) {
+	if licenseID == 0 {
+		return nil, ErrBadRequest("id is required")
+	}
+	if at.IsZero() {
+		return nil, ErrBadRequest("at is required")
+	}
+	at = at.Local()
+	db := global.DB.WithContext(ctx)
+	license, err := getLicenseForHistory(ctx, db, licenseID)
+	if err != nil {
+		return nil, err
+	}
+	if at.Before(license.CreatedAt) {
+		return nil, ErrNotFound("license did not exist at the given time")
+	}
+
+	data := &LicenseStatusAtData{LicenseID: licenseID, At: at}
+	var last model.LicenseStatusHistory
+	err = db.Where("license_id = ? AND occurred_at <= ?", licenseID, at).
+		Order("occurred_at DESC, id DESC").First(&last).Error
+	switch {
+	case err == nil:
+		lastEvent := toLicenseHistoryData(&last)
+		data.LicenseStateData = lastEvent.After
+		data.LastEvent = &lastEvent
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		// 该时刻之前没有变更：取第一次变更前的取值，没有历史的旧 License 取当前值
+		var first model.LicenseStatusHistory
+		err = db.Where("license_id = ?", licenseID).Order("occurred_at ASC, id ASC").First(&first).Error
+		if err == nil {
+			data.LicenseStateData = toLicenseHistoryData(&first).Before
+		} else if errors.Is(err, gorm.ErrRecordNotFound) {
+			data.LicenseStateData = licenseStateOf(ToEntityLicense(license)).data()
+		} else {
+			return nil, WrapInternal("get license history failed", err)
+		}
+	default:
+		return nil, WrapInternal("get license history failed", err)
+	}
+
+	snapshot := ToEntityLicense(license)
+	snapshot.Status = entity.LicenseStatus(data.Status)
+	snapshot.ExpiredAt = data.ExpiredAt
+	if err := fillGracePolicies(ctx, db, snapshot); err != nil {
+		return nil, WrapInternal("get license grace policy failed", err)
+	}
+	data.Status = int(snapshot.CalculateStatus(at))
+	return data, nil
+}
+
+func getLicenseForHistory(ctx context.Context, db *gorm.DB, licenseID uint) (*model.License, error) {
+	var license model.License
+	err := db.WithContext(ctx).Unscoped().Where("id = ?", licenseID).First(&license).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound("license not found")
+	}
+	if err != nil {
+		return nil, WrapInternal("get license failed", err)
+	}
+	return &license, nil
+}
+
+func toLicenseHistoryData(history *model.LicenseStatusHistory) LicenseHistoryData {
+	return LicenseHistoryData{
+		ID:        history.ID,
+		LicenseID: history.LicenseID,
+		Event:     history.Event,
+		Before: LicenseStateData{
+			Status:        history.FromStatus,
+			ExpiredAt:     history.FromExpiredAt,
+			MaxNodes:      history.FromMaxNodes,
+			MaxConcurrent: history.FromMaxConcurrent,
+		},
+		After: LicenseStateData{
+			Status:        history.ToStatus,
+			ExpiredAt:     history.ToExpiredAt,
+			MaxNodes:      history.ToMaxNodes,
+			MaxConcurrent: history.ToMaxConcurrent,
+		},
+		ExtraHours: history.ExtraHours,
+		Operator:   history.Operator,
+		OccurredAt: history.OccurredAt,
+	}
+}
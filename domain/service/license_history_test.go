@@ -0,0 +1,133 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"nexus-core/domain/entity"
+	"nexus-core/persistence/model"
+)
+
+func TestLicenseStatusHistory(t *testing.T) {
+	f := newFlowFixture(t, 2, 1, 24)
+	licenseID := f.license.ID
+	f.register(t, "device-a")
+
+	if err := f.licenseService.UpdateLicense(f.ctx, UpdateLicenseCommand{ID: licenseID, MaxNodes: 3, MaxConcurrent: 1}); err != nil {
+		t.Fatalf("update license: %v", err)
+	}
+	// 限制没有变化时不记录历史
+	if err := f.licenseService.UpdateLicense(f.ctx, UpdateLicenseCommand{ID: licenseID, MaxNodes: 3, MaxConcurrent: 1}); err != nil {
+		t.Fatalf("update license again: %v", err)
+	}
+	if err := f.licenseService.RenewLicense(f.ctx, RenewLicenseCommand{ID: licenseID, ExtraHours: 48}); err != nil {
+		t.Fatalf("renew license: %v", err)
+	}
+	afterRenew := time.Now()
+	if err := f.licenseService.RevokeLicense(f.ctx, licenseID); err != nil {
+		t.Fatalf("revoke license: %v", err)
+	}
+	afterRevoke := time.Now()
+	if _, err := f.licenseService.RestoreLicense(f.ctx, RestoreLicenseCommand{ID: licenseID}); err != nil {
+		t.Fatalf("restore license: %v", err)
+	}
+
+	history, err := f.licenseService.ListLicenseHistory(f.ctx, licenseID)
+	if err != nil {
+		t.Fatalf("list history: %v", err)
+	}
+	events := make([]string, 0, len(history))
+	for _, item := range history {
+		events = append(events, item.Event)
+	}
+	want := []string{LicenseEventCreate, LicenseEventActivate, LicenseEventLimitChange, LicenseEventRenew, LicenseEventRevoke, LicenseEventRestore}
+	if len(events) != len(want) {
+		t.Fatalf("events = %v, want %v", events, want)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Fatalf("events = %v, want %v", events, want)
+		}
+	}
+	activate, limit, renew := history[1], history[2], history[3]
+	if activate.Before.Status != int(entity.StatusInactive) || activate.After.Status != int(entity.StatusActive) || activate.After.ExpiredAt == nil {
+		t.Fatalf("unexpected activate entry: %#v", activate)
+	}
+	if limit.Before.MaxNodes != 2 || limit.After.MaxNodes != 3 {
+		t.Fatalf("unexpected limit entry: %#v", limit)
+	}
+	if renew.ExtraHours != 48 || renew.After.ExpiredAt.Sub(*renew.Before.ExpiredAt) != 48*time.Hour {
+		t.Fatalf("unexpected renew entry: %#v", renew)
+	}
+	if history[4].After.Status != int(entity.StatusRevoked) || history[5].After.Status != int(entity.StatusActive) {
+		t.Fatalf("unexpected revoke/restore entries: %#v %#v", history[4], history[5])
+	}
+
+	at, err := f.licenseService.GetLicenseStatusAt(f.ctx, licenseID, afterRevoke)
+	if err != nil || at.Status != int(entity.StatusRevoked) || at.LastEvent == nil || at.LastEvent.Event != LicenseEventRevoke {
+		t.Fatalf("status after revoke: %#v %v", at, err)
+	}
+	at, err = f.licenseService.GetLicenseStatusAt(f.ctx, licenseID, afterRenew)
+	if err != nil || at.Status != int(entity.StatusActive) || !at.ExpiredAt.Equal(*renew.After.ExpiredAt) || at.MaxNodes != 3 {
+		t.Fatalf("status after renew: %#v %v", at, err)
+	}
+	_, err = f.licenseService.GetLicenseStatusAt(f.ctx, licenseID, time.Now().Add(-time.Hour))
+	assertAppErrorKind(t, err, ErrorKindNotFound)
+	_, err = f.licenseService.GetLicenseStatusAt(f.ctx, licenseID, time.Time{})
+	assertAppErrorKind(t, err, ErrorKindBadRequest)
+}
+
+func TestLicenseStatusAtDerivesExpiry(t *testing.T) {
+	f := newFlowFixture(t, 2, 1, 1)
+	licenseID := f.license.ID
+	f.register(t, "device-a")
+
+	// 把创建和激活挪到两小时前，模拟 License 在无人访问时到期
+	now := time.Now()
+	createdAt, activatedAt, expiredAt := now.Add(-2*time.Hour), now.Add(-90*time.Minute), now.Add(-30*time.Minute)
+	if err := f.db.Model(&model.License{}).Where("id = ?", licenseID).Updates(map[string]interface{}{
+		"created_at":   createdAt,
+		"activated_at": activatedAt,
+		"expired_at":   expiredAt,
+	}).Error; err != nil {
+		t.Fatalf("shift license: %v", err)
+	}
+	for event, occurredAt := range map[string]time.Time{LicenseEventCreate: createdAt, LicenseEventActivate: activatedAt} {
+		if err := f.db.Model(&model.LicenseStatusHistory{}).Where("license_id = ? AND event = ?", licenseID, event).
+			Updates(map[string]interface{}{"occurred_at": occurredAt, "to_expired_at": expiredAt}).Error; err != nil {
+			t.Fatalf("shift history: %v", err)
+		}
+	}
+
+	// 到期前没有任何请求，回溯仍按过期时间推导状态
+	at, err := f.licenseService.GetLicenseStatusAt(f.ctx, licenseID, now)
+	if err != nil || at.Status != int(entity.StatusExpired) || at.LastEvent.Event != LicenseEventActivate {
+		t.Fatalf("status before expiry is observed: %#v %v", at, err)
+	}
+
+	if _, err := f.licenseService.GetLicenseDataByID(f.ctx, licenseID); err != nil {
+		t.Fatalf("get license: %v", err)
+	}
+	history, err := f.licenseService.ListLicenseHistory(f.ctx, licenseID)
+	if err != nil || len(history) != 3 {
+		t.Fatalf("list history: %#v %v", history, err)
+	}
+	expire := history[2]
+	if expire.Event != LicenseEventExpire || expire.After.Status != int(entity.StatusExpired) || !expire.OccurredAt.Equal(expiredAt) {
+		t.Fatalf("expire should be recorded at the expiry time: %#v", expire)
+	}
+
+	for _, tc := range []struct {
+		at     time.Time
+		status entity.LicenseStatus
+	}{
+		{createdAt.Add(time.Minute), entity.StatusInactive},
+		{activatedAt.Add(time.Minute), entity.StatusActive},
+		{expiredAt.Add(time.Minute), entity.StatusExpired},
+	} {
+		at, err := f.licenseService.GetLicenseStatusAt(f.ctx, licenseID, tc.at)
+		if err != nil || at.Status != int(tc.status) {
+			t.Fatalf("status at %s = %#v %v, want %d", tc.at, at, err, tc.status)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"nexus-core/licensekey"
+	"nexus-core/persistence/model"
+	"nexus-core/persistence/repository"
+
+	"gorm.io/gorm"
+)
+
+// ImportLicenseKeys 按声明的格式导入外部生成的注册码，每个注册码创建一个 License
+// 注册码不符合格式、重复或已被占用时整体失败
+func (s *LicenseService) ImportLicenseKeys(ctx context.Context, cmd ImportLicenseKeysCommand) ([]LicenseData, error) {
+	if len(cmd.Keys) == 0 {
+		return nil, ErrBadRequest("keys are required")
+	}
+	if cmd.Format != nil {
+		if err := cmd.Format.Validate(); err != nil {
+			return nil, ErrBadRequest(err.Error())
+		}
+	}
+	cmd.Count = len(cmd.Keys)
+	return s.createLicenses(ctx, cmd.BatchCreateLicenseCommand, cmd.Keys, cmd.Format)
+}
+
+// productKeyFormat 产品配置的注册码格式，未配置时为 UUID 格式
+func productKeyFormat(product *model.Product) licensekey.Format {
+	return repository.ProductKeyFormat(product)
+}
+
+func generateLicenseKeys(format licensekey.Format, count int) ([]string, error) {
+	keys := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		key, err := format.Generate()
+		if err != nil {
+			return nil, WrapInternal("generate license key failed", err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// normalizeImportedKeys 规整并校验导入的注册码
+func normalizeImportedKeys(format licensekey.Format, keys []string) ([]string, error) {
+	normalized := make([]string, 0, len(keys))
+	seen := make(map[string]struct{}, len(keys))
+	for _, raw := range keys {
+		key := format.Normalize(raw)
+		if err := format.Check(key); err != nil {
+			return nil, BadRequestf("invalid license key %q: %v", strings.TrimSpace(raw), err)
+		}
+		if _, ok := seen[key]; ok {
+			return nil, BadRequestf("duplicate license key %s", key)
+		}
+		seen[key] = struct{}{}
+		normalized = append(normalized, key)
+	}
+	return normalized, nil
+}
+
+// ensureLicenseKeysAvailable 检查注册码未被使用，已删除的 License 仍占用唯一索引
+func ensureLicenseKeysAvailable(ctx context.Context, tx *gorm.DB, keys []string) error {
+	keyByDigest := make(map[string]string, len(keys))
+	for _, key := range keys {
+		keyByDigest[repository.LicenseKeyDigest(key)] = key
+	}
+	digests := make([]string, 0, len(keys))
+	for digest := range keyByDigest {
+		digests = append(digests, digest)
+	}
+	var existing []string
+	if err := tx.WithContext(ctx).Unscoped().Model(&model.License{}).
+		Where("key_digest IN ?", digests).Limit(1).Pluck("key_digest", &existing).Error; err != nil {
+		return WrapInternal("check license keys failed", err)
+	}
+	if len(existing) > 0 {
+		return Conflictf("license key %s already exists", keyByDigest[existing[0]])
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"nexus-core/licensekey"
+	"nexus-core/monitor"
+	"nexus-core/persistence/base"
+	"nexus-core/persistence/model"
+	"nexus-core/persistence/repository"
+)
+
+func TestLicenseKeyFormat(t *testing.T) {
+	fixture := newFlowFixture(t, 2, 0, 24)
+
+	_, err := fixture.productService.UpdateProduct(fixture.ctx, UpdateProductCommand{
+		ID:        fixture.product.ID,
+		KeyFormat: &licensekey.Format{Prefix: "FLOW", Groups: 2, GroupSize: 4},
+	})
+	assertAppErrorKind(t, err, ErrorKindBadRequest)
+	format := licensekey.Format{Prefix: "FLOW", Groups: 4, GroupSize: 5, CheckDigit: true}
+	product, err := fixture.productService.UpdateProduct(fixture.ctx, UpdateProductCommand{
+		ID:        fixture.product.ID,
+		KeyFormat: &format,
+	})
+	if err != nil || product.KeyFormat != format {
+		t.Fatalf("update key format: %#v %v", product, err)
+	}
+
+	license, err := fixture.licenseService.CreateLicense(fixture.ctx, CreateLicenseCommand{
+		ProductID:     fixture.product.ID,
+		ValidityHours: 24,
+		MaxNodes:      1,
+	})
+	if err != nil {
+		t.Fatalf("create license: %v", err)
+	}
+	if err := format.Check(license.LicenseKey); err != nil {
+		t.Fatalf("generated key %s should match the product format: %v", license.LicenseKey, err)
+	}
+	batch, err := fixture.licenseService.BatchCreateLicenses(fixture.ctx, BatchCreateLicenseCommand{
+		ProductID:     fixture.product.ID,
+		ValidityHours: 24,
+		Count:         2,
+	})
+	if err != nil || len(batch) != 2 || format.Check(batch[1].LicenseKey) != nil {
+		t.Fatalf("batch create: %#v %v", batch, err)
+	}
+	if found, err := fixture.licenseService.GetLicenseDataByKey(fixture.ctx, license.LicenseKey); err != nil || found.ID != license.ID {
+		t.Fatalf("get by key: %#v %v", found, err)
+	}
+	_, err = fixture.licenseService.GetLicenseDataByKey(fixture.ctx, "FLOW--??")
+	assertAppErrorKind(t, err, ErrorKindBadRequest)
+
+	// 前缀对应产品格式时按格式和校验位校验，错误的注册码不会查询到 License
+	last := license.LicenseKey[len(license.LicenseKey)-1]
+	tampered := license.LicenseKey[:len(license.LicenseKey)-1] + string(licensekey.Alphabet[(strings.IndexByte(licensekey.Alphabet, last)+1)%len(licensekey.Alphabet)])
+	_, err = fixture.licenseService.GetLicenseDataByKey(fixture.ctx, tampered)
+	assertAppErrorKind(t, err, ErrorKindBadRequest)
+	_, err = fixture.licenseService.GetLicenseDataByKey(fixture.ctx, "FLOW-ABCDE")
+	assertAppErrorKind(t, err, ErrorKindBadRequest)
+	_, err = fixture.accessService.Register(fixture.ctx, AccessCommand{
+		DeviceCode:  "device-tampered",
+		LicenseKey:  tampered,
+		ProductID:   fixture.product.ID,
+		VersionCode: "1.0.0",
+	})
+	assertAppErrorKind(t, err, ErrorKindBadRequest)
+
+	// 导入外部生成的注册码，按声明的格式规整和校验
+	external := licensekey.Format{Prefix: "EXT", Groups: 3, GroupSize: 4}
+	importCommand := func(keys ...string) ImportLicenseKeysCommand {
+		return ImportLicenseKeysCommand{
+			BatchCreateLicenseCommand: BatchCreateLicenseCommand{ProductID: fixture.product.ID, ValidityHours: 24},
+			Format:                    &external,
+			Keys:                      keys,
+		}
+	}
+	_, err = fixture.licenseService.ImportLicenseKeys(fixture.ctx, importCommand("EXT-AAAA-BBBB-CCCC", "EXT-AAAA-BBBB"))
+	assertAppErrorKind(t, err, ErrorKindBadRequest)
+	_, err = fixture.licenseService.ImportLicenseKeys(fixture.ctx, importCommand("EXT-AAAA-BBBB-CCCC", "ext-aaaa-bbbb-cccc"))
+	assertAppErrorKind(t, err, ErrorKindBadRequest)
+	imported, err := fixture.licenseService.ImportLicenseKeys(fixture.ctx, importCommand("ext-aaaa-bbbb-cccc", "EXT-0000 1111 2222"))
+	if err != nil || len(imported) != 2 {
+		t.Fatalf("import keys: %#v %v", imported, err)
+	}
+	if imported[0].LicenseKey != "EXT-AAAA-BBBB-CCCC" || imported[1].LicenseKey != "EXT-0000-1111-2222" {
+		t.Fatalf("imported keys should be normalized: %s %s", imported[0].LicenseKey, imported[1].LicenseKey)
+	}
+	if err := fixture.licenseService.DeleteLicense(fixture.ctx, imported[0].ID); err != nil {
+		t.Fatalf("delete license: %v", err)
+	}
+	_, err = fixture.licenseService.ImportLicenseKeys(fixture.ctx, importCommand("EXT-AAAA-BBBB-CCCC"))
+	assertAppErrorKind(t, err, ErrorKindConflict)
+
+	// 未声明格式时按产品格式校验，校验位不对的注册码被拒绝
+	_, err = fixture.licenseService.ImportLicenseKeys(fixture.ctx, ImportLicenseKeysCommand{
+		BatchCreateLicenseCommand: BatchCreateLicenseCommand{ProductID: fixture.product.ID, ValidityHours: 24},
+		Keys:                      []string{"FLOW-AAAAA-BBBBB-CCCCC-DDDDD"},
+	})
+	assertAppErrorKind(t, err, ErrorKindBadRequest)
+}
+
+func TestLicenseKeyStoredAsDigest(t *testing.T) {
+	fixture := newFlowFixture(t, 2, 0, 24)
+	key := fixture.license.LicenseKey
+
+	var stored model.License
+	if err := fixture.db.Where("id = ?", fixture.license.ID).First(&stored).Error; err != nil {
+		t.Fatalf("get license: %v", err)
+	}
+	if stored.LicenseKey != "" || stored.KeyDigest != repository.LicenseKeyDigest(key) || stored.KeyPrefix != licensekey.DisplayPrefix(key) {
+		t.Fatalf("license key should be stored as digest: %#v", stored)
+	}
+
+	masked := licensekey.Mask(licensekey.DisplayPrefix(key))
+	byKey, err := fixture.licenseService.GetLicenseDataByKey(fixture.ctx, key)
+	if err != nil || byKey.ID != fixture.license.ID || byKey.LicenseKey != masked {
+		t.Fatalf("get by key should return masked key: %#v %v", byKey, err)
+	}
+	for _, filter := range []string{key, licensekey.DisplayPrefix(key)[:4]} {
+		licenses, err := fixture.licenseService.ListLicenses(fixture.ctx, ListLicensesCommand{LicenseKey: &filter})
+		if err != nil || len(licenses) != 1 || licenses[0].LicenseKey != masked {
+			t.Fatalf("list by %q: %#v %v", filter, licenses, err)
+		}
+	}
+
+	fixture.register(t, "device-a")
+	if _, err := fixture.heartbeat("device-a"); err != nil {
+		t.Fatalf("heartbeat: %v", err)
+	}
+	summary, err := NewMonitorService().GetOnlineSummary(fixture.ctx)
+	if err != nil || summary.TotalOnline != 1 || summary.Nodes[0].LicenseKey != masked ||
+		summary.ByLicense[0].Key != masked || summary.ByLicense[0].LicenseID != fixture.license.ID {
+		t.Fatalf("online summary should show masked keys: %#v %v", summary, err)
+	}
+	for _, item := range monitor.GlobalStat.Snapshot() {
+		if strings.Contains(item.Key(), key) {
+			t.Fatalf("online key should not contain the license key: %s", item.Key())
+		}
+	}
+
+	// 升级前明文保存的注册码在迁移时改为摘要
+	legacyKey := "0123456789abcdef0123456789abcdef"
+	if err := fixture.db.Exec("INSERT INTO license (product_id, license_key, validity_hours, created_at, updated_at) VALUES (?, ?, 24, ?, ?)",
+		fixture.product.ID, legacyKey, time.Now(), time.Now()).Error; err != nil {
+		t.Fatalf("insert legacy license: %v", err)
+	}
+	base.AutoMigrate(fixture.db)
+	legacy, err := fixture.licenseService.GetLicenseDataByKey(fixture.ctx, legacyKey)
+	if err != nil || legacy.LicenseKey != "0123456789****" {
+		t.Fatalf("legacy key should be migrated: %#v %v", legacy, err)
+	}
+	var plaintext int64
+	if err := fixture.db.Model(&model.License{}).Where("license_key <> ''").Count(&plaintext).Error; err != nil || plaintext != 0 {
+		t.Fatalf("plaintext keys should be cleared: %d %v", plaintext, err)
+	}
+}
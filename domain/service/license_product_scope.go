@@ -0,0 +1,263 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"nexus-core/domain/entity"
+	"nexus-core/global"
+	"nexus-core/monitor"
+	"nexus-core/persistence/model"
+
+	"gorm.io/gorm"
+)
+
+// ListLicenseProductScopes 查询 License 授权的产品及各产品的绑定、在线数量
+// 主产品始终排在第一位，未单独设置限制时限制为 0
+func (s *LicenseService) ListLicenseProductScopes(ctx context.Context, licenseID uint) ([]LicenseProductScopeData, error) {
+	if licenseID == 0 {
+		return nil, ErrBadRequest("id is required")
+	}
+	db := global.DB.WithContext(ctx)
+	var license model.License
+	if err := db.Select("id", "tenant_id", "product_id", "key_digest").Where("id = ?", licenseID).First(&license).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound("license not found")
+		}
+		return nil, WrapInternal("get license failed", err)
+	}
+	var scopes []model.LicenseProductScope
+	if err := db.Where("license_id = ?", licenseID).Order("product_id ASC").Find(&scopes).Error; err != nil {
+		return nil, WrapInternal("list license product scopes failed", err)
+	}
+	type boundCount struct {
+		ProductID uint
+		Count     int
+	}
+	var counts []boundCount
+	if err := db.Model(&model.NodeLicenseBinding{}).
+		Select("product_id, COUNT(*) AS count").
+		Where("license_id = ? AND status = ?", licenseID, entity.BindingStatusBound).
+		Group("product_id").
+		Scan(&counts).Error; err != nil {
+		return nil, WrapInternal("count license bindings failed", err)
+	}
+	boundByProduct := make(map[uint]int, len(counts))
+	for _, item := range counts {
+		boundByProduct[item.ProductID] = item.Count
+	}
+
+	primary := LicenseProductScopeData{
+		LicenseID: licenseID,
+		ProductID: license.ProductID,
+		Primary:   true,
+		Status:    int(entity.ScopeStatusEnabled),
+	}
+	data := []LicenseProductScopeData{primary}
+	for i := range scopes {
+		item := toLicenseProductScopeData(&scopes[i])
+		if item.ProductID == license.ProductID {
+			item.Primary = true
+			data[0] = item
+			continue
+		}
+		data = append(data, item)
+	}
+	for i := range data {
+		data[i].BoundNodes = boundByProduct[data[i].ProductID]
+		data[i].OnlineNodes = monitor.GlobalStat.GetConcurrentByLicenseForProduct(license.TenantID, license.KeyDigest, data[i].ProductID)
+	}
+	return data, nil
+}
+
+// SetLicenseProductScope 新增或更新 License 授权的产品及该产品的节点、并发限制
+// 主产品不能禁用，只能单独设置限制
+func (s *LicenseService) SetLicenseProductScope(ctx context.Context, cmd SetLicenseProductScopeCommand) (*LicenseProductScopeData, error) {
+	if cmd.LicenseID == 0 || cmd.ProductID == 0 {
+		return nil, ErrBadRequest("license_id and product_id are required")
+	}
+	if cmd.MaxNodes < 0 || cmd.MaxConcurrent < 0 {
+		return nil, ErrBadRequest("max_nodes and max_concurrent must be >= 0")
+	}
+	status := entity.ScopeStatus(cmd.Status)
+	if status == 0 {
+		status = entity.ScopeStatusEnabled
+	}
+	if status != entity.ScopeStatusEnabled && status != entity.ScopeStatusDisabled {
+		return nil, ErrBadRequest("invalid status")
+	}
+
+	var scope model.LicenseProductScope
+	primary := false
+	err := global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var license model.License
+		if err := tx.Select("id", "product_id").Where("id = ?", cmd.LicenseID).First(&license).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotFound("license not found")
+			}
+			return WrapInternal("get license failed", err)
+		}
+		primary = license.ProductID == cmd.ProductID
+		if primary && status == entity.ScopeStatusDisabled {
+			return ErrBadRequest("primary product cannot be disabled")
+		}
+		if err := ensureProduct(ctx, tx, cmd.ProductID); err != nil {
+			return err
+		}
+
+		err := tx.Where("license_id = ? AND product_id = ?", cmd.LicenseID, cmd.ProductID).First(&scope).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return WrapInternal("get license product scope failed", err)
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			scope = model.LicenseProductScope{
+				LicenseID:     cmd.LicenseID,
+				ProductID:     cmd.ProductID,
+				Status:        int(status),
+				MaxNodes:      cmd.MaxNodes,
+				MaxConcurrent: cmd.MaxConcurrent,
+			}
+			if err := tx.Create(&scope).Error; err != nil {
+				return WrapInternal("create license product scope failed", err)
+			}
+		} else {
+			scope.Status = int(status)
+			scope.MaxNodes = cmd.MaxNodes
+			scope.MaxConcurrent = cmd.MaxConcurrent
+			if err := tx.Model(&scope).Updates(map[string]interface{}{
+				"status":         scope.Status,
+				"max_nodes":      scope.MaxNodes,
+				"max_concurrent": scope.MaxConcurrent,
+			}).Error; err != nil {
+				return WrapInternal("update license product scope failed", err)
+			}
+		}
+		recordAuditLog(ctx, tx, "license", cmd.LicenseID, "set_product_scope", map[string]interface{}{
+			"product_id":     cmd.ProductID,
+			"status":         scope.Status,
+			"max_nodes":      scope.MaxNodes,
+			"max_concurrent": scope.MaxConcurrent,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	data := toLicenseProductScopeData(&scope)
+	data.Primary = primary
+	return &data, nil
+}
+
+// RemoveLicenseProductScope 移除 License 授权的产品
+// 该产品仍有绑定节点时拒绝移除，待审批的绑定申请随之撤销；移除主产品的记录只会清除其单独设置的限制
+func (s *LicenseService) RemoveLicenseProductScope(ctx context.Context, cmd RemoveLicenseProductScopeCommand) error {
+	if cmd.LicenseID == 0 || cmd.ProductID == 0 {
+		return ErrBadRequest("license_id and product_id are required")
+	}
+	return global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var withdrawn int64
+		var license model.License
+		if err := tx.Select("id", "product_id").Where("id = ?", cmd.LicenseID).First(&license).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotFound("license not found")
+			}
+			return WrapInternal("get license failed", err)
+		}
+		if license.ProductID != cmd.ProductID {
+			var bound int64
+			if err := tx.Model(&model.NodeLicenseBinding{}).
+				Where("license_id = ? AND product_id = ? AND status = ?", cmd.LicenseID, cmd.ProductID, entity.BindingStatusBound).
+				Count(&bound).Error; err != nil {
+				return WrapInternal("count license bindings failed", err)
+			}
+			if bound > 0 {
+				return ErrConflict("product scope has bound nodes")
+			}
+			result := tx.Model(&model.NodeLicenseBinding{}).
+				Where("license_id = ? AND product_id = ? AND status = ?", cmd.LicenseID, cmd.ProductID, entity.BindingStatusPending).
+				Update("status", entity.BindingStatusUnbound)
+			if result.Error != nil {
+				return WrapInternal("withdraw binding requests failed", result.Error)
+			}
+			withdrawn = result.RowsAffected
+		}
+		result := tx.Unscoped().Where("license_id = ? AND product_id = ?", cmd.LicenseID, cmd.ProductID).Delete(&model.LicenseProductScope{})
+		if result.Error != nil {
+			return WrapInternal("delete license product scope failed", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound("license product scope not found")
+		}
+		recordAuditLog(ctx, tx, "license", cmd.LicenseID, "remove_product_scope", map[string]interface{}{
+			"product_id":         cmd.ProductID,
+			"withdrawn_requests": withdrawn,
+		})
+		return nil
+	})
+}
+
+// authorizeLicenseProduct 校验 License 是否授权指定产品，返回该产品的范围设置
+// 主产品未单独设置时返回 nil，表示只受 License 总数限制
+func authorizeLicenseProduct(ctx context.Context, db *gorm.DB, license *entity.License, productID uint) (*entity.LicenseProductScope, error) {
+	scope, err := getLicenseProductScope(ctx, db, license.ID, productID)
+	if err != nil {
+		return nil, err
+	}
+	if scope == nil {
+		if license.ProductID == productID {
+			return nil, nil
+		}
+		return nil, Forbiddenf("license does not support product id %d", productID)
+	}
+	if !scope.IsEnabled() && license.ProductID != productID {
+		return nil, Forbiddenf("license does not support product id %d", productID)
+	}
+	return scope, nil
+}
+
+func getLicenseProductScope(ctx context.Context, db *gorm.DB, licenseID, productID uint) (*entity.LicenseProductScope, error) {
+	var scope model.LicenseProductScope
+	err := db.WithContext(ctx).Where("license_id = ? AND product_id = ?", licenseID, productID).First(&scope).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, WrapInternal("get license product scope failed", err)
+	}
+	return &entity.LicenseProductScope{
+		ID:            scope.ID,
+		LicenseID:     scope.LicenseID,
+		ProductID:     scope.ProductID,
+		Status:        entity.ScopeStatus(scope.Status),
+		MaxNodes:      scope.MaxNodes,
+		MaxConcurrent: scope.MaxConcurrent,
+	}, nil
+}
+
+// checkProductNodeLimit 绑定新节点前检查该产品单独设置的节点数限制
+func checkProductNodeLimit(ctx context.Context, tx *gorm.DB, licenseID, productID uint) error {
+	scope, err := getLicenseProductScope(ctx, tx, licenseID, productID)
+	if err != nil || scope == nil || scope.MaxNodes == 0 {
+		return err
+	}
+	var bound int64
+	if err := tx.WithContext(ctx).Model(&model.NodeLicenseBinding{}).
+		Where("license_id = ? AND product_id = ? AND status = ?", licenseID, productID, entity.BindingStatusBound).
+		Count(&bound).Error; err != nil {
+		return WrapInternal("count license bindings failed", err)
+	}
+	if !scope.ValidateNodeLimit(int(bound)) {
+		return Conflictf("license has reached max nodes for product %d", productID)
+	}
+	return nil
+}
+
+func toLicenseProductScopeData(scope *model.LicenseProductScope) LicenseProductScopeData {
+	return LicenseProductScopeData{
+		LicenseID:     scope.LicenseID,
+		ProductID:     scope.ProductID,
+		Status:        scope.Status,
+		MaxNodes:      scope.MaxNodes,
+		MaxConcurrent: scope.MaxConcurrent,
+	}
+}
@@ -0,0 +1,135 @@
+package service
+
+import "testing"
+
+func TestMultiProductLicense(t *testing.T) {
+	fixture := newFlowFixture(t, 0, 0, 24)
+	addon, err := fixture.productService.CreateProduct(fixture.ctx, CreateProductCommand{Name: "addon-product"})
+	if err != nil {
+		t.Fatalf("create addon product: %v", err)
+	}
+	if _, err := fixture.productService.CreateProductVersion(fixture.ctx, CreateProductVersionCommand{
+		ProductID:   addon.ID,
+		VersionCode: "1.0.0",
+		Method:      ReleaseImmediate,
+	}); err != nil {
+		t.Fatalf("create addon version: %v", err)
+	}
+	access := func(device string, productID uint) AccessCommand {
+		return AccessCommand{
+			DeviceCode:  device,
+			LicenseKey:  fixture.license.LicenseKey,
+			ProductID:   productID,
+			VersionCode: "1.0.0",
+			NodeSecret:  fixture.secrets[device],
+		}
+	}
+
+	_, err = fixture.accessService.Register(fixture.ctx, access("device-a", addon.ID))
+	assertAppErrorKind(t, err, ErrorKindForbidden)
+
+	if _, err := fixture.licenseService.SetLicenseProductScope(fixture.ctx, SetLicenseProductScopeCommand{
+		LicenseID:     fixture.license.ID,
+		ProductID:     addon.ID,
+		MaxNodes:      2,
+		MaxConcurrent: 1,
+	}); err != nil {
+		t.Fatalf("set product scope: %v", err)
+	}
+
+	fixture.register(t, "device-a")
+	result, err := fixture.accessService.Register(fixture.ctx, access("device-a", addon.ID))
+	if err != nil {
+		t.Fatalf("register addon product: %v", err)
+	}
+	if !result.BindingEstablished || result.ProductID != addon.ID || result.CurrentNodeCount != 2 {
+		t.Fatalf("same device should bind addon product separately: %#v", result)
+	}
+	if _, err := fixture.accessService.Register(fixture.ctx, access("device-b", addon.ID)); err != nil {
+		t.Fatalf("register second addon node: %v", err)
+	}
+	_, err = fixture.accessService.Register(fixture.ctx, access("device-c", addon.ID))
+	assertAppErrorKind(t, err, ErrorKindConflict)
+
+	if _, err := fixture.accessService.Heartbeat(fixture.ctx, access("device-a", addon.ID)); err != nil {
+		t.Fatalf("heartbeat addon product: %v", err)
+	}
+	if _, err := fixture.heartbeat("device-a"); err != nil {
+		t.Fatalf("heartbeat primary product: %v", err)
+	}
+
+	scopes, err := fixture.licenseService.ListLicenseProductScopes(fixture.ctx, fixture.license.ID)
+	if err != nil {
+		t.Fatalf("list product scopes: %v", err)
+	}
+	if len(scopes) != 2 || !scopes[0].Primary || scopes[0].ProductID != fixture.product.ID {
+		t.Fatalf("unexpected product scopes: %#v", scopes)
+	}
+	if scopes[0].BoundNodes != 1 || scopes[0].OnlineNodes != 1 || scopes[1].BoundNodes != 2 || scopes[1].OnlineNodes != 1 {
+		t.Fatalf("product scopes should track usage per product: %#v", scopes)
+	}
+
+	_, err = fixture.licenseService.SetLicenseProductScope(fixture.ctx, SetLicenseProductScopeCommand{
+		LicenseID: fixture.license.ID,
+		ProductID: fixture.product.ID,
+		Status:    2,
+	})
+	assertAppErrorKind(t, err, ErrorKindBadRequest)
+
+	err = fixture.licenseService.RemoveLicenseProductScope(fixture.ctx, RemoveLicenseProductScopeCommand{
+		LicenseID: fixture.license.ID,
+		ProductID: addon.ID,
+	})
+	assertAppErrorKind(t, err, ErrorKindConflict)
+	err = fixture.productService.DeleteProduct(fixture.ctx, addon.ID)
+	assertAppErrorKind(t, err, ErrorKindConflict)
+
+	for _, device := range []string{"device-a", "device-b"} {
+		node, err := fixture.nodeService.GetByDeviceCode(fixture.ctx, device)
+		if err != nil {
+			t.Fatalf("get node %s: %v", device, err)
+		}
+		if err := fixture.nodeService.UnbindByID(fixture.ctx, UnbindCommand{
+			NodeID:    node.ID,
+			LicenseID: fixture.license.ID,
+			ProductID: addon.ID,
+		}); err != nil {
+			t.Fatalf("unbind %s: %v", device, err)
+		}
+	}
+	if _, err := fixture.heartbeat("device-a"); err != nil {
+		t.Fatalf("primary binding should survive addon unbind: %v", err)
+	}
+	if err := fixture.licenseService.RemoveLicenseProductScope(fixture.ctx, RemoveLicenseProductScopeCommand{
+		LicenseID: fixture.license.ID,
+		ProductID: addon.ID,
+	}); err != nil {
+		t.Fatalf("remove product scope: %v", err)
+	}
+	_, err = fixture.accessService.Heartbeat(fixture.ctx, access("device-a", addon.ID))
+	assertAppErrorKind(t, err, ErrorKindForbidden)
+	if _, err := fixture.licenseService.SetLicenseProductScope(fixture.ctx, SetLicenseProductScopeCommand{
+		LicenseID: fixture.license.ID,
+		ProductID: addon.ID,
+	}); err != nil {
+		t.Fatalf("re-add removed product scope: %v", err)
+	}
+}
+
+func TestProductScopeConcurrentLimit(t *testing.T) {
+	fixture := newFlowFixture(t, 0, 0, 24)
+	if _, err := fixture.licenseService.SetLicenseProductScope(fixture.ctx, SetLicenseProductScopeCommand{
+		LicenseID:     fixture.license.ID,
+		ProductID:     fixture.product.ID,
+		MaxConcurrent: 1,
+	}); err != nil {
+		t.Fatalf("set primary product limit: %v", err)
+	}
+	fixture.register(t, "device-a")
+	fixture.register(t, "device-b")
+	if _, err := fixture.heartbeat("device-a"); err != nil {
+		t.Fatalf("heartbeat device-a: %v", err)
+	}
+	_, err := fixture.heartbeat("device-b")
+	assertAppErrorKind(t, err, ErrorKindConflict)
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"nexus-core/domain/entity"
+	"nexus-core/global"
+	"nexus-core/persistence/model"
+
+	"gorm.io/gorm"
+)
+
+// validateLicenseSchedule 计划吊销时间必须晚于合同开始时间
+func validateLicenseSchedule(notBefore *time.Time, scheduledRevokeAt *time.Time) error {
+	if notBefore != nil && scheduledRevokeAt != nil && !scheduledRevokeAt.After(*notBefore) {
+		return ErrBadRequest("scheduled_revoke_at must be later than not_before")
+	}
+	return nil
+}
+
+// checkLicenseSchedule 注册和心跳时检查合同起止时间
+// 计划吊销时间已到但后台任务尚未执行时同样拒绝
+func checkLicenseSchedule(license *entity.License, now time.Time) error {
+	if license.NotYetValid(now) {
+		return ConflictWithCodef(CodeLicenseNotYetValid, "license not valid before %s", license.NotBefore.Format(time.RFC3339))
+	}
+	if license.RevokeDue(now) {
+		return ErrForbidden("license revoked")
+	}
+	return nil
+}
+
+// SetLicenseSchedule 设置 License 的合同开始时间和计划吊销时间，为空表示取消
+func (s *LicenseService) SetLicenseSchedule(ctx context.Context, cmd SetLicenseScheduleCommand) (*LicenseData, error) {
+	if cmd.LicenseID == 0 {
+		return nil, ErrBadRequest("id is required")
+	}
+	if err := validateLicenseSchedule(cmd.NotBefore, cmd.ScheduledRevokeAt); err != nil {
+		return nil, err
+	}
+	db := global.DB.WithContext(ctx)
+	result := db.Model(&model.License{}).Where("id = ?", cmd.LicenseID).Updates(map[string]interface{}{
+		"not_before":          cmd.NotBefore,
+		"scheduled_revoke_at": cmd.ScheduledRevokeAt,
+	})
+	if result.Error != nil {
+		return nil, WrapInternal("update license schedule failed", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrNotFound("license not found")
+	}
+	recordAuditLog(ctx, db, "license", cmd.LicenseID, "set_schedule", map[string]interface{}{
+		"not_before":          cmd.NotBefore,
+		"scheduled_revoke_at": cmd.ScheduledRevokeAt,
+	})
+	return s.GetLicenseDataByID(ctx, cmd.LicenseID)
+}
+
+// RevokeScheduledLicenses 吊销计划吊销时间已到的 License，返回吊销数量
+// 吊销时间记为计划时间，之后签发的租约一并失效；执行后清空计划，恢复的 License 不会被再次吊销
+// 单个 License 失败时只记录日志并继续处理其余 License，下次执行时重试
+func (s *LicenseService) RevokeScheduledLicenses(ctx context.Context, now time.Time) (int, error) {
+	var licenses []model.License
+	if err := global.DB.WithContext(ctx).
+		Where("scheduled_revoke_at IS NOT NULL AND scheduled_revoke_at <= ? AND status <> ?", now, int(entity.StatusRevoked)).
+		Order("scheduled_revoke_at ASC, id ASC").
+		Find(&licenses).Error; err != nil {
+		return 0, WrapInternal("list scheduled revokes failed", err)
+	}
+
+	revoked := 0
+	for i := range licenses {
+		applied, err := revokeScheduledLicense(ctx, &licenses[i], now)
+		if err != nil {
+			fmt.Printf("revoke scheduled license %d failed: %v\n", licenses[i].ID, err)
+			continue
+		}
+		if applied {
+			revoked++
+		}
+	}
+	return revoked, nil
+}
+
+func revokeScheduledLicense(ctx context.Context, license *model.License, now time.Time) (bool, error) {
+	// 后台任务不带租户，按 License 所属租户写入审计和历史
+	ctx = model.WithTenant(ctx, license.TenantID)
+	scheduledAt := *license.ScheduledRevokeAt
+	applied := false
+	err := global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		current := ToEntityLicense(license)
+		before := licenseStateOf(current)
+		current.Revoke(scheduledAt)
+		// 期间计划被推迟、取消或 License 已被手动吊销时跳过
+		result := tx.Model(&model.License{}).
+			Where("id = ? AND status <> ? AND scheduled_revoke_at <= ?", license.ID, int(entity.StatusRevoked), now).
+			Updates(map[string]interface{}{
+				"status":              int(current.Status),
+				"revoked_at":          current.RevokedAt,
+				"scheduled_revoke_at": nil,
+			})
+		if result.Error != nil {
+			return WrapInternal("revoke scheduled license failed", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		recordAuditLog(ctx, tx, "license", license.ID, "scheduled_revoke", map[string]interface{}{
+			"scheduled_revoke_at": scheduledAt,
+		})
+		applied = true
+		return recordLicenseHistory(ctx, tx, licenseChange{
+			LicenseID:  license.ID,
+			Event:      LicenseEventRevoke,
+			Before:     before,
+			After:      licenseStateOf(current),
+			OccurredAt: scheduledAt,
+		})
+	})
+	return applied, err
+}
+
+// StartScheduledRevokeWorker 定期吊销到达计划吊销时间的 License
+func (s *LicenseService) StartScheduledRevokeWorker(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		if _, err := s.RevokeScheduledLicenses(ctx, time.Now()); err != nil {
+			fmt.Printf("revoke scheduled licenses failed: %v\n", err)
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := s.RevokeScheduledLicenses(ctx, time.Now()); err != nil {
+					fmt.Printf("revoke scheduled licenses failed: %v\n", err)
+				}
+			}
+		}
+	}()
+}
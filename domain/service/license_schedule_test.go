@@ -0,0 +1,190 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"nexus-core/domain/entity"
+	"nexus-core/persistence/model"
+
+	"gorm.io/gorm"
+)
+
+func TestLicenseNotBefore(t *testing.T) {
+	f := newFlowFixture(t, 2, 1, 24)
+	licenseID := f.license.ID
+	startsAt := time.Now().Add(24 * time.Hour)
+
+	_, err := f.licenseService.SetLicenseSchedule(f.ctx, SetLicenseScheduleCommand{
+		LicenseID:         licenseID,
+		NotBefore:         &startsAt,
+		ScheduledRevokeAt: &startsAt,
+	})
+	assertAppErrorKind(t, err, ErrorKindBadRequest)
+	data, err := f.licenseService.SetLicenseSchedule(f.ctx, SetLicenseScheduleCommand{LicenseID: licenseID, NotBefore: &startsAt})
+	if err != nil || data.NotBefore == nil || !data.NotBefore.Equal(startsAt) {
+		t.Fatalf("set schedule: %#v %v", data, err)
+	}
+
+	_, err = f.accessService.Register(f.ctx, AccessCommand{
+		DeviceCode:  "device-a",
+		LicenseKey:  f.license.LicenseKey,
+		ProductID:   f.product.ID,
+		VersionCode: "1.0.0",
+	})
+	if ErrorCodeOf(err) != CodeLicenseNotYetValid {
+		t.Fatalf("register before start should fail with not yet valid, got %v", err)
+	}
+	// 导出 License 文件同样不会提前激活
+	_, err = f.licenseService.ExportLicenseFile(f.ctx, licenseID)
+	if ErrorCodeOf(err) != CodeLicenseNotYetValid {
+		t.Fatalf("export before start should fail with not yet valid, got %v", err)
+	}
+	if data, _ := f.licenseService.GetLicenseDataByID(f.ctx, licenseID); data.Status != int(entity.StatusInactive) {
+		t.Fatalf("license should stay inactive: %#v", data)
+	}
+
+	started := time.Now().Add(-time.Minute)
+	if _, err := f.licenseService.SetLicenseSchedule(f.ctx, SetLicenseScheduleCommand{LicenseID: licenseID, NotBefore: &started}); err != nil {
+		t.Fatalf("move start date: %v", err)
+	}
+	f.register(t, "device-a")
+	if _, err := f.heartbeat("device-a"); err != nil {
+		t.Fatalf("heartbeat after start: %v", err)
+	}
+
+	// 已激活的 License 推迟开始时间后心跳同样被拒绝
+	if _, err := f.licenseService.SetLicenseSchedule(f.ctx, SetLicenseScheduleCommand{LicenseID: licenseID, NotBefore: &startsAt}); err != nil {
+		t.Fatalf("postpone start date: %v", err)
+	}
+	if _, err := f.heartbeat("device-a"); ErrorCodeOf(err) != CodeLicenseNotYetValid {
+		t.Fatalf("heartbeat before start should fail with not yet valid, got %v", err)
+	}
+}
+
+func TestScheduledRevoke(t *testing.T) {
+	f := newFlowFixture(t, 2, 1, 24)
+	licenseID := f.license.ID
+	f.register(t, "device-a")
+
+	future := time.Now().Add(time.Hour)
+	if _, err := f.licenseService.SetLicenseSchedule(f.ctx, SetLicenseScheduleCommand{LicenseID: licenseID, ScheduledRevokeAt: &future}); err != nil {
+		t.Fatalf("schedule revoke: %v", err)
+	}
+	if revoked, err := f.licenseService.RevokeScheduledLicenses(f.ctx, time.Now()); err != nil || revoked != 0 {
+		t.Fatalf("future revoke should not apply: %d %v", revoked, err)
+	}
+
+	due := time.Now().Add(-time.Minute)
+	if _, err := f.licenseService.SetLicenseSchedule(f.ctx, SetLicenseScheduleCommand{LicenseID: licenseID, ScheduledRevokeAt: &due}); err != nil {
+		t.Fatalf("schedule revoke: %v", err)
+	}
+	// 后台任务执行前心跳已被拒绝
+	_, err := f.heartbeat("device-a")
+	assertAppErrorKind(t, err, ErrorKindForbidden)
+
+	if revoked, err := f.licenseService.RevokeScheduledLicenses(f.ctx, time.Now()); err != nil || revoked != 1 {
+		t.Fatalf("due revoke should apply: %d %v", revoked, err)
+	}
+	var license model.License
+	if err := f.db.First(&license, licenseID).Error; err != nil {
+		t.Fatalf("load license: %v", err)
+	}
+	if license.Status != int(entity.StatusRevoked) || license.ScheduledRevokeAt != nil || license.RevokedAt == nil || !license.RevokedAt.Equal(due) {
+		t.Fatalf("unexpected revoked license: %#v", license)
+	}
+	var audits int64
+	f.db.Model(&model.AuditLog{}).Where("resource_id = ? AND action = ?", licenseID, "scheduled_revoke").Count(&audits)
+	if audits != 1 {
+		t.Fatalf("expected one scheduled_revoke audit entry, got %d", audits)
+	}
+	history, err := f.licenseService.ListLicenseHistory(f.ctx, licenseID)
+	if err != nil {
+		t.Fatalf("list history: %v", err)
+	}
+	recorded := false
+	for _, item := range history {
+		recorded = recorded || (item.Event == LicenseEventRevoke && item.OccurredAt.Equal(due))
+	}
+	if !recorded {
+		t.Fatalf("revoke should be recorded at the scheduled time: %#v", history)
+	}
+
+	// 执行后计划被清空，恢复的 License 不会被再次吊销
+	if _, err := f.licenseService.RestoreLicense(f.ctx, RestoreLicenseCommand{ID: licenseID}); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if revoked, err := f.licenseService.RevokeScheduledLicenses(f.ctx, time.Now()); err != nil || revoked != 0 {
+		t.Fatalf("restored license should not be revoked again: %d %v", revoked, err)
+	}
+}
+
+func TestScheduledRevokeCapsLeases(t *testing.T) {
+	f := newFlowFixture(t, 2, 1, 24)
+	f.register(t, "device-a")
+
+	// 租约和席位不能在计划吊销后继续有效
+	revokeAt := time.Now().Add(2 * time.Minute).Truncate(time.Second)
+	if _, err := f.licenseService.SetLicenseSchedule(f.ctx, SetLicenseScheduleCommand{LicenseID: f.license.ID, ScheduledRevokeAt: &revokeAt}); err != nil {
+		t.Fatalf("schedule revoke: %v", err)
+	}
+	heartbeat, err := f.heartbeat("device-a")
+	if err != nil {
+		t.Fatalf("heartbeat: %v", err)
+	}
+	if heartbeat.LeaseExpiresAt.After(revokeAt) || heartbeat.LeaseGraceUntil.After(revokeAt) {
+		t.Fatalf("lease should end by scheduled revoke %v: %v / %v", revokeAt, heartbeat.LeaseExpiresAt, heartbeat.LeaseGraceUntil)
+	}
+	seat, err := f.accessService.CheckoutSeat(f.ctx, SeatCheckoutCommand{AccessCommand: AccessCommand{
+		DeviceCode:  "device-a",
+		LicenseKey:  f.license.LicenseKey,
+		ProductID:   f.product.ID,
+		VersionCode: "1.0.0",
+		NodeSecret:  f.secrets["device-a"],
+	}})
+	if err != nil {
+		t.Fatalf("checkout seat: %v", err)
+	}
+	if seat.ExpiresAt.After(revokeAt) {
+		t.Fatalf("seat should end by scheduled revoke %v: %v", revokeAt, seat.ExpiresAt)
+	}
+}
+
+func TestScheduledRevokeContinuesAfterFailure(t *testing.T) {
+	f := newFlowFixture(t, 2, 1, 24)
+	other, err := f.licenseService.CreateLicense(f.ctx, CreateLicenseCommand{ProductID: f.product.ID, ValidityHours: 24})
+	if err != nil {
+		t.Fatalf("create license: %v", err)
+	}
+	failing := f.license.ID
+	for i, licenseID := range []uint{failing, other.ID} {
+		due := time.Now().Add(-time.Duration(2-i) * time.Minute)
+		if _, err := f.licenseService.SetLicenseSchedule(f.ctx, SetLicenseScheduleCommand{LicenseID: licenseID, ScheduledRevokeAt: &due}); err != nil {
+			t.Fatalf("schedule revoke: %v", err)
+		}
+	}
+	// 先处理的 License 写入历史失败
+	if err := f.db.Callback().Create().Before("gorm:create").Register("test:fail_history", func(db *gorm.DB) {
+		if history, ok := db.Statement.Dest.(*model.LicenseStatusHistory); ok && history.LicenseID == failing {
+			_ = db.AddError(errors.New("history unavailable"))
+		}
+	}); err != nil {
+		t.Fatalf("register callback: %v", err)
+	}
+
+	revoked, err := f.licenseService.RevokeScheduledLicenses(f.ctx, time.Now())
+	if err != nil || revoked != 1 {
+		t.Fatalf("one failure should not stop the rest: %d %v", revoked, err)
+	}
+	var licenses []model.License
+	if err := f.db.Where("id IN ?", []uint{failing, other.ID}).Order("id ASC").Find(&licenses).Error; err != nil {
+		t.Fatalf("load licenses: %v", err)
+	}
+	if licenses[0].Status == int(entity.StatusRevoked) || licenses[0].ScheduledRevokeAt == nil {
+		t.Fatalf("failed revoke should roll back and stay scheduled: %#v", licenses[0])
+	}
+	if licenses[1].Status != int(entity.StatusRevoked) {
+		t.Fatalf("later license should still be revoked: %#v", licenses[1])
+	}
+}
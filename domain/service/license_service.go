@@ -3,14 +3,17 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"nexus-core/global"
+	"nexus-core/licensefile"
+	"nexus-core/licensekey"
 	"nexus-core/persistence/model"
+	"nexus-core/persistence/repository"
 	"strings"
 	"time"
 
 	"nexus-core/domain/entity"
 
-	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
@@ -31,9 +34,21 @@ func NewLicenseService() *LicenseService {
 
 // CreateLicense 创建单个许可证
 func (s *LicenseService) CreateLicense(ctx context.Context, cmd CreateLicenseCommand) (*LicenseData, error) {
+	template, err := loadPlanTemplate(ctx, global.DB, cmd.PlanID, cmd.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	template.applyDefaults(&cmd.ValidityHours, &cmd.MaxNodes, &cmd.MaxConcurrent)
+	applyTrialDefaults(cmd.Type, &cmd.TrialMaxHours)
+	if cmd.Perpetual {
+		cmd.ValidityHours = 0
+	}
 	if err := validateCreateLicenseCommand(cmd); err != nil {
 		return nil, err
 	}
+	if err := validateVersionEntitlement(ctx, global.DB, cmd.ProductID, cmd.MinVersionID, cmd.MaxVersionID); err != nil {
+		return nil, err
+	}
 	var product model.Product
 	if err := global.DB.WithContext(ctx).Model(&model.Product{}).Where("id = ?", cmd.ProductID).First(&product).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -41,32 +56,89 @@ func (s *LicenseService) CreateLicense(ctx context.Context, cmd CreateLicenseCom
 		}
 		return nil, WrapInternal("get product failed", err)
 	}
-	license := &model.License{
-		ProductID:     product.ID,
-		LicenseKey:    strings.ReplaceAll(uuid.New().String(), "-", ""),
-		ValidityHours: cmd.ValidityHours,
-		ActivatedAt:   nil,
-		ExpiredAt:     nil,
-		Status:        int(entity.StatusInactive), //默认未激活
-		MaxNodes:      cmd.MaxNodes,
-		MaxConcurrent: cmd.MaxConcurrent,
-		FeatureMask:   "",
-		Remark:        cmd.Remark,
-	}
-	err := licenseRepo.Create(ctx, global.DB.WithContext(ctx), license)
+	if err := ensureCustomerExists(ctx, global.DB, cmd.CustomerID); err != nil {
+		return nil, err
+	}
+	licenseKey, err := productKeyFormat(&product).Generate()
 	if err != nil {
-		return nil, WrapInternal("create license failed", err)
+		return nil, WrapInternal("generate license key failed", err)
 	}
-	recordAuditLog(ctx, global.DB.WithContext(ctx), "license", license.ID, "create", map[string]interface{}{
-		"product_id": license.ProductID,
+	license := &model.License{
+		ProductID:         product.ID,
+		CustomerID:        cmd.CustomerID,
+		PlanID:            cmd.PlanID,
+		Type:              cmd.Type,
+		TrialMaxHours:     cmd.TrialMaxHours,
+		GracePeriodHours:  cmd.GracePeriodHours,
+		Perpetual:         cmd.Perpetual,
+		VersionCutoffAt:   cmd.VersionCutoffAt,
+		MinVersionID:      cmd.MinVersionID,
+		MaxVersionID:      cmd.MaxVersionID,
+		KeyDigest:         repository.LicenseKeyDigest(licenseKey),
+		KeyPrefix:         licensekey.DisplayPrefix(licenseKey),
+		ValidityHours:     cmd.ValidityHours,
+		ActivatedAt:       nil,
+		ExpiredAt:         nil,
+		NotBefore:         cmd.NotBefore,
+		ScheduledRevokeAt: cmd.ScheduledRevokeAt,
+		Status:            int(entity.StatusInactive), //默认未激活
+		MaxNodes:          cmd.MaxNodes,
+		MaxConcurrent:     cmd.MaxConcurrent,
+		FeatureMask:       "",
+		Remark:            cmd.Remark,
+	}
+	err = global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := licenseRepo.Create(ctx, tx, license); err != nil {
+			return WrapInternal("create license failed", err)
+		}
+		if template != nil {
+			if err := replaceLicenseServiceScopes(ctx, tx, license.ID, template.services); err != nil {
+				return err
+			}
+		}
+		recordAuditLog(ctx, tx, "license", license.ID, "create", map[string]interface{}{
+			"product_id": license.ProductID,
+			"plan_id":    license.PlanID,
+			"type":       license.Type,
+		})
+		state := licenseStateOf(ToEntityLicense(license))
+		return recordLicenseHistory(ctx, tx, licenseChange{
+			LicenseID: license.ID,
+			Event:     LicenseEventCreate,
+			Before:    state,
+			After:     state,
+		})
 	})
-	return toLicenseData(license), nil
+	if err != nil {
+		return nil, err
+	}
+	// 明文注册码只在创建时返回一次
+	data := toLicenseData(license)
+	data.LicenseKey = licenseKey
+	return data, nil
 }
 
 func (s *LicenseService) BatchCreateLicenses(ctx context.Context, cmd BatchCreateLicenseCommand) ([]LicenseData, error) {
+	return s.createLicenses(ctx, cmd, nil, nil)
+}
+
+// createLicenses 批量创建 License，keys 为空时按产品的注册码格式生成，否则使用导入的注册码
+func (s *LicenseService) createLicenses(ctx context.Context, cmd BatchCreateLicenseCommand, keys []string, format *licensekey.Format) ([]LicenseData, error) {
+	template, err := loadPlanTemplate(ctx, global.DB, cmd.PlanID, cmd.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	template.applyDefaults(&cmd.ValidityHours, &cmd.MaxNodes, &cmd.MaxConcurrent)
+	applyTrialDefaults(cmd.Type, &cmd.TrialMaxHours)
+	if cmd.Perpetual {
+		cmd.ValidityHours = 0
+	}
 	if err := validateBatchCreateLicenseCommand(cmd); err != nil {
 		return nil, err
 	}
+	if err := validateVersionEntitlement(ctx, global.DB, cmd.ProductID, cmd.MinVersionID, cmd.MaxVersionID); err != nil {
+		return nil, err
+	}
 
 	var product model.Product
 	if err := global.DB.WithContext(ctx).Model(&model.Product{}).Where("id = ?", cmd.ProductID).First(&product).Error; err != nil {
@@ -76,31 +148,93 @@ func (s *LicenseService) BatchCreateLicenses(ctx context.Context, cmd BatchCreat
 		return nil, WrapInternal("get product failed", err)
 	}
 
+	if err := ensureCustomerExists(ctx, global.DB, cmd.CustomerID); err != nil {
+		return nil, err
+	}
+
+	keyFormat := productKeyFormat(&product)
+	if format != nil {
+		keyFormat = *format
+	}
+	var licenseKeys []string
+	if keys == nil {
+		licenseKeys, err = generateLicenseKeys(keyFormat, cmd.Count)
+	} else {
+		licenseKeys, err = normalizeImportedKeys(keyFormat, keys)
+	}
+	if err != nil {
+		return nil, err
+	}
+
 	licenses := make([]model.License, 0, cmd.Count)
 	for i := 0; i < cmd.Count; i++ {
 		licenses = append(licenses, model.License{
-			ProductID:     product.ID,
-			LicenseKey:    strings.ReplaceAll(uuid.New().String(), "-", ""),
-			ValidityHours: cmd.ValidityHours,
-			Status:        int(entity.StatusInactive),
-			MaxNodes:      cmd.MaxNodes,
-			MaxConcurrent: cmd.MaxConcurrent,
-			FeatureMask:   "",
-			Remark:        cmd.Remark,
+			ProductID:         product.ID,
+			CustomerID:        cmd.CustomerID,
+			PlanID:            cmd.PlanID,
+			Type:              cmd.Type,
+			TrialMaxHours:     cmd.TrialMaxHours,
+			GracePeriodHours:  cmd.GracePeriodHours,
+			Perpetual:         cmd.Perpetual,
+			VersionCutoffAt:   cmd.VersionCutoffAt,
+			MinVersionID:      cmd.MinVersionID,
+			MaxVersionID:      cmd.MaxVersionID,
+			KeyDigest:         repository.LicenseKeyDigest(licenseKeys[i]),
+			KeyPrefix:         licensekey.DisplayPrefix(licenseKeys[i]),
+			ValidityHours:     cmd.ValidityHours,
+			NotBefore:         cmd.NotBefore,
+			ScheduledRevokeAt: cmd.ScheduledRevokeAt,
+			Status:            int(entity.StatusInactive),
+			MaxNodes:          cmd.MaxNodes,
+			MaxConcurrent:     cmd.MaxConcurrent,
+			FeatureMask:       "",
+			Remark:            cmd.Remark,
 		})
 	}
 
-	if err := global.DB.WithContext(ctx).Create(&licenses).Error; err != nil {
-		return nil, WrapInternal("batch create licenses failed", err)
+	err = global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if keys != nil {
+			if err := ensureLicenseKeysAvailable(ctx, tx, licenseKeys); err != nil {
+				return err
+			}
+		}
+		if err := tx.Create(&licenses).Error; err != nil {
+			return WrapInternal("batch create licenses failed", err)
+		}
+		for i := range licenses {
+			if template != nil {
+				if err := replaceLicenseServiceScopes(ctx, tx, licenses[i].ID, template.services); err != nil {
+					return err
+				}
+			}
+			recordAuditLog(ctx, tx, "license", licenses[i].ID, "create", map[string]interface{}{
+				"product_id":   licenses[i].ProductID,
+				"plan_id":      licenses[i].PlanID,
+				"type":         licenses[i].Type,
+				"batch_create": true,
+				"imported_key": keys != nil,
+			})
+			state := licenseStateOf(ToEntityLicense(&licenses[i]))
+			if err := recordLicenseHistory(ctx, tx, licenseChange{
+				LicenseID: licenses[i].ID,
+				Event:     LicenseEventCreate,
+				Before:    state,
+				After:     state,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	data := make([]LicenseData, 0, len(licenses))
 	for i := range licenses {
-		recordAuditLog(ctx, global.DB.WithContext(ctx), "license", licenses[i].ID, "create", map[string]interface{}{
-			"product_id":   licenses[i].ProductID,
-			"batch_create": true,
-		})
-		data = append(data, *toLicenseData(&licenses[i]))
+		item := toLicenseData(&licenses[i])
+		item.LicenseKey = licenseKeys[i]
+		data = append(data, *item)
 	}
 	return data, nil
 }
@@ -108,15 +242,33 @@ func (s *LicenseService) BatchCreateLicenses(ctx context.Context, cmd BatchCreat
 // RevokeLicense 吊销许可证
 // todo 后续可能需要强制下线？
 func (s *LicenseService) RevokeLicense(ctx context.Context, licenseID uint) error {
-	result := global.DB.WithContext(ctx).Model(&model.License{}).Where("id = ?", licenseID).Update("status", entity.StatusRevoked)
-	if result.Error != nil {
-		return WrapInternal("revoke license failed", result.Error)
-	}
-	if result.RowsAffected == 0 {
-		return ErrNotFound("license not found")
-	}
-	recordAuditLog(ctx, global.DB.WithContext(ctx), "license", licenseID, "revoke", nil)
-	return nil
+	return global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var license model.License
+		err := tx.Where("id = ?", licenseID).First(&license).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotFound("license not found")
+		}
+		if err != nil {
+			return WrapInternal("get license failed", err)
+		}
+		current := ToEntityLicense(&license)
+		before := licenseStateOf(current)
+		current.Revoke(time.Now())
+		if err := tx.Model(&license).Updates(map[string]interface{}{
+			"status":     int(current.Status),
+			"revoked_at": current.RevokedAt,
+		}).Error; err != nil {
+			return WrapInternal("revoke license failed", err)
+		}
+		recordAuditLog(ctx, tx, "license", licenseID, "revoke", nil)
+		return recordLicenseHistory(ctx, tx, licenseChange{
+			LicenseID:  licenseID,
+			Event:      LicenseEventRevoke,
+			Before:     before,
+			After:      licenseStateOf(current),
+			OccurredAt: *current.RevokedAt,
+		})
+	})
 }
 
 func (s *LicenseService) RestoreLicense(ctx context.Context, cmd RestoreLicenseCommand) (*LicenseData, error) {
@@ -144,13 +296,25 @@ func (s *LicenseService) RestoreLicense(ctx context.Context, cmd RestoreLicenseC
 		}
 	}
 
-	if err := global.DB.WithContext(ctx).Model(&license).Update("status", status).Error; err != nil {
-		return nil, WrapInternal("restore license failed", err)
-	}
-	license.Status = status
-	recordAuditLog(ctx, global.DB.WithContext(ctx), "license", license.ID, "restore", map[string]interface{}{
-		"status": status,
+	before := licenseStateOf(ToEntityLicense(&license))
+	err = global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&license).Update("status", status).Error; err != nil {
+			return WrapInternal("restore license failed", err)
+		}
+		license.Status = status
+		recordAuditLog(ctx, tx, "license", license.ID, "restore", map[string]interface{}{
+			"status": status,
+		})
+		return recordLicenseHistory(ctx, tx, licenseChange{
+			LicenseID: license.ID,
+			Event:     LicenseEventRestore,
+			Before:    before,
+			After:     licenseStateOf(ToEntityLicense(&license)),
+		})
 	})
+	if err != nil {
+		return nil, err
+	}
 	return toLicenseData(&license), nil
 }
 
@@ -172,19 +336,102 @@ func (s *LicenseService) UpdateLicense(ctx context.Context, cmd UpdateLicenseCom
 		"feature_mask":   cmd.FeatureMask,
 		"remark":         cmd.Remark,
 	}
-	result := global.DB.WithContext(ctx).Model(&model.License{}).Where("id = ?", id).Updates(updates)
+	return global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var license model.License
+		err := tx.Where("id = ?", id).First(&license).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotFound("license not found")
+		}
+		if err != nil {
+			return WrapInternal("get license failed", err)
+		}
+		before := licenseStateOf(ToEntityLicense(&license))
+		if err := tx.Model(&license).Updates(updates).Error; err != nil {
+			return WrapInternal("update license failed", err)
+		}
+		recordAuditLog(ctx, tx, "license", id, "update", map[string]interface{}{
+			"max_nodes":      cmd.MaxNodes,
+			"max_concurrent": cmd.MaxConcurrent,
+			"feature_mask":   cmd.FeatureMask,
+		})
+		after := before
+		after.MaxNodes, after.MaxConcurrent = cmd.MaxNodes, cmd.MaxConcurrent
+		if after.equal(before) {
+			return nil
+		}
+		return recordLicenseHistory(ctx, tx, licenseChange{
+			LicenseID: id,
+			Event:     LicenseEventLimitChange,
+			Before:    before,
+			After:     after,
+		})
+	})
+}
+
+// SetLicenseGracePeriod 单独设置许可证过期后的宽限期，为空时恢复使用产品设置
+func (s *LicenseService) SetLicenseGracePeriod(ctx context.Context, cmd SetLicenseGracePeriodCommand) (*LicenseData, error) {
+	if cmd.LicenseID == 0 {
+		return nil, ErrBadRequest("id is required")
+	}
+	if cmd.GracePeriodHours != nil && *cmd.GracePeriodHours < 0 {
+		return nil, ErrBadRequest("grace_period_hours must be greater than or equal to 0")
+	}
+	result := global.DB.WithContext(ctx).Model(&model.License{}).Where("id = ?", cmd.LicenseID).
+		Update("grace_period_hours", cmd.GracePeriodHours)
 	if result.Error != nil {
-		return WrapInternal("update license failed", result.Error)
+		return nil, WrapInternal("update license grace period failed", result.Error)
 	}
 	if result.RowsAffected == 0 {
-		return ErrNotFound("license not found")
+		return nil, ErrNotFound("license not found")
 	}
-	recordAuditLog(ctx, global.DB.WithContext(ctx), "license", id, "update", map[string]interface{}{
-		"max_nodes":      cmd.MaxNodes,
-		"max_concurrent": cmd.MaxConcurrent,
-		"feature_mask":   cmd.FeatureMask,
+	recordAuditLog(ctx, global.DB.WithContext(ctx), "license", cmd.LicenseID, "set_grace_period", map[string]interface{}{
+		"grace_period_hours": cmd.GracePeriodHours,
 	})
-	return nil
+	return s.GetLicenseDataByID(ctx, cmd.LicenseID)
+}
+
+// SetLicenseInactiveUnbind 单独设置不活跃节点的自动解绑天数，为空时恢复使用产品设置
+func (s *LicenseService) SetLicenseInactiveUnbind(ctx context.Context, cmd SetLicenseInactiveUnbindCommand) (*LicenseData, error) {
+	if cmd.LicenseID == 0 {
+		return nil, ErrBadRequest("id is required")
+	}
+	if cmd.InactiveUnbindDays != nil && *cmd.InactiveUnbindDays < 0 {
+		return nil, ErrBadRequest("inactive_unbind_days must be greater than or equal to 0")
+	}
+	result := global.DB.WithContext(ctx).Model(&model.License{}).Where("id = ?", cmd.LicenseID).
+		Update("inactive_unbind_days", cmd.InactiveUnbindDays)
+	if result.Error != nil {
+		return nil, WrapInternal("update license inactive unbind policy failed", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrNotFound("license not found")
+	}
+	recordAuditLog(ctx, global.DB.WithContext(ctx), "license", cmd.LicenseID, "set_inactive_unbind", map[string]interface{}{
+		"inactive_unbind_days": cmd.InactiveUnbindDays,
+	})
+	return s.GetLicenseDataByID(ctx, cmd.LicenseID)
+}
+
+// AssignCustomer 设置或解除 License 的归属客户
+func (s *LicenseService) AssignCustomer(ctx context.Context, cmd AssignLicenseCustomerCommand) (*LicenseData, error) {
+	if cmd.LicenseID == 0 {
+		return nil, ErrBadRequest("id is required")
+	}
+	if err := ensureCustomerExists(ctx, global.DB, cmd.CustomerID); err != nil {
+		return nil, err
+	}
+	result := global.DB.WithContext(ctx).Model(&model.License{}).Where("id = ?", cmd.LicenseID).
+		Update("customer_id", cmd.CustomerID)
+	if result.Error != nil {
+		return nil, WrapInternal("assign license customer failed", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrNotFound("license not found")
+	}
+	recordAuditLog(ctx, global.DB.WithContext(ctx), "license", cmd.LicenseID, "assign_customer", map[string]interface{}{
+		"customer_id": cmd.CustomerID,
+	})
+	return s.GetLicenseDataByID(ctx, cmd.LicenseID)
 }
 
 // RenewLicense 增加或减少许可证时间
@@ -196,30 +443,128 @@ func (s *LicenseService) RenewLicense(ctx context.Context, cmd RenewLicenseComma
 	if extraHours == 0 {
 		return ErrBadRequest("extra_hours must not be 0")
 	}
-	license, err := GetLicenseEntityByID(ctx, global.DB.WithContext(ctx), licenseID)
-	if err != nil {
-		return err
-	}
-	if license == nil {
-		return ErrNotFound("license not found")
-	}
-	if license.Status == entity.StatusRevoked {
-		return ErrForbidden("revoked license must be restored before renew")
-	}
-	license.Renew(time.Now(), extraHours)
-	if err := global.DB.WithContext(ctx).Model(&model.License{}).Where("id = ?", licenseID).
-		Updates(map[string]interface{}{
+	return global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		license, err := GetLicenseEntityByID(ctx, tx, licenseID)
+		if err != nil {
+			return err
+		}
+		if license == nil {
+			return ErrNotFound("license not found")
+		}
+		if license.Status == entity.StatusRevoked {
+			return ErrForbidden("revoked license must be restored before renew")
+		}
+		if license.Perpetual {
+			return ErrConflict("perpetual license cannot be renewed")
+		}
+		if !license.AllowsRenew(extraHours) {
+			return Conflictf("trial license cannot be renewed beyond %d hours", license.TrialMaxHours)
+		}
+		before := licenseStateOf(license)
+		license.Renew(time.Now(), extraHours)
+		if err := tx.Model(&model.License{}).Where("id = ?", licenseID).
+			Updates(map[string]interface{}{
+				"validity_hours": license.ValidityHours,
+				"expired_at":     license.ExpiredAt,
+				"status":         int(license.Status),
+			}).Error; err != nil {
+			return WrapInternal("renew license failed", err)
+		}
+		recordAuditLog(ctx, tx, "license", licenseID, "renew", map[string]interface{}{
+			"extra_hours":    extraHours,
 			"validity_hours": license.ValidityHours,
-			"expired_at":     license.ExpiredAt,
-			"status":         int(license.Status),
-		}).Error; err != nil {
-		return WrapInternal("renew license failed", err)
+		})
+		return recordLicenseHistory(ctx, tx, licenseChange{
+			LicenseID:  licenseID,
+			Event:      LicenseEventRenew,
+			Before:     before,
+			After:      licenseStateOf(license),
+			ExtraHours: extraHours,
+		})
+	})
+}
+
+// ExportLicenseFile 导出签名的离线 License 文件
+// 未激活的许可证在导出时激活，已吊销或已过期的许可证不允许导出
+func (s *LicenseService) ExportLicenseFile(ctx context.Context, licenseID uint) (*LicenseFileData, error) {
+	if licenseID == 0 {
+		return nil, ErrBadRequest("id is required")
 	}
-	recordAuditLog(ctx, global.DB.WithContext(ctx), "license", licenseID, "renew", map[string]interface{}{
-		"extra_hours":    extraHours,
-		"validity_hours": license.ValidityHours,
+	var data *LicenseFileData
+	err := global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		license, err := GetLicenseEntityByID(ctx, tx, licenseID)
+		if err != nil {
+			return WrapInternal("get license failed", err)
+		}
+		if license == nil {
+			return ErrNotFound("license not found")
+		}
+
+		now := time.Now()
+		switch license.Status {
+		case entity.StatusRevoked:
+			return ErrForbidden("license revoked")
+		case entity.StatusExpired, entity.StatusGrace:
+			return ErrForbidden("license expired")
+		case entity.StatusInactive:
+			// 未到合同开始时间不激活，避免提前消耗有效期
+			if license.NotYetValid(now) {
+				return ConflictWithCodef(CodeLicenseNotYetValid, "license not valid before %s", license.NotBefore.Format(time.RFC3339))
+			}
+			before := licenseStateOf(license)
+			if !license.Activate(now) {
+				return ErrForbidden("license cannot be activated")
+			}
+			if err := tx.Model(&model.License{}).Where("id = ?", license.ID).
+				Updates(map[string]interface{}{
+					"activated_at": license.ActivatedAt,
+					"expired_at":   license.ExpiredAt,
+					"status":       int(license.Status),
+				}).Error; err != nil {
+				return WrapInternal("activate license failed", err)
+			}
+			if err := recordLicenseHistory(ctx, tx, licenseChange{
+				LicenseID:  license.ID,
+				Event:      LicenseEventActivate,
+				Before:     before,
+				After:      licenseStateOf(license),
+				OccurredAt: now,
+			}); err != nil {
+				return err
+			}
+		}
+
+		pLicense, err := licenseRepo.GetByID(ctx, tx, license.ID)
+		if err != nil {
+			return WrapInternal("get license failed", err)
+		}
+		claims, err := licenseFileClaims(ctx, tx, pLicense, now)
+		if err != nil {
+			return err
+		}
+		envelope, err := signEnvelope(ctx, tx, licensefile.FormatLicense, claims)
+		if err != nil {
+			return err
+		}
+		content, err := envelope.Marshal()
+		if err != nil {
+			return WrapInternal("marshal license file failed", err)
+		}
+		recordAuditLog(ctx, tx, "license", license.ID, "export_file", map[string]interface{}{
+			"key_id": envelope.KeyID,
+		})
+		data = &LicenseFileData{
+			LicenseID: license.ID,
+			KeyID:     envelope.KeyID,
+			FileName:  fmt.Sprintf("license-%d.lic", license.ID),
+			Content:   content,
+		}
+		return nil
 	})
-	return nil
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
 }
 
 // RemoveBindings 移除许可证的所有绑定关系
@@ -247,6 +592,10 @@ func (s *LicenseService) RemoveBindings(ctx context.Context, id uint) error {
 // DeleteLicense 删除许可证
 func (s *LicenseService) DeleteLicense(ctx context.Context, id uint) error {
 	return global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 关联表没有租户字段，先按租户确认 License 存在
+		if err := ensureLicenseExists(ctx, tx, id); err != nil {
+			return err
+		}
 		if err := tx.Where("license_id = ?", id).Delete(&model.NodeLicenseBinding{}).Error; err != nil {
 			return WrapInternal("delete license bindings failed", err)
 		}
@@ -256,6 +605,21 @@ func (s *LicenseService) DeleteLicense(ctx context.Context, id uint) error {
 		if err := tx.Where("license_id = ?", id).Delete(&model.LicenseServiceScope{}).Error; err != nil {
 			return WrapInternal("delete license service scopes failed", err)
 		}
+		if err := tx.Where("license_id = ?", id).Delete(&model.LicenseFeature{}).Error; err != nil {
+			return WrapInternal("delete license features failed", err)
+		}
+		if err := tx.Where("license_id = ?", id).Delete(&model.LicenseFeatureQuota{}).Error; err != nil {
+			return WrapInternal("delete license feature quotas failed", err)
+		}
+		if err := tx.Unscoped().Where("license_id = ?", id).Delete(&model.SeatLease{}).Error; err != nil {
+			return WrapInternal("delete seat leases failed", err)
+		}
+		if err := tx.Unscoped().Where("license_id = ?", id).Delete(&model.SeatWaiter{}).Error; err != nil {
+			return WrapInternal("delete seat waiters failed", err)
+		}
+		if err := releaseLicenseAllowlist(ctx, tx, id); err != nil {
+			return err
+		}
 		result := tx.Where("id = ?", id).Delete(&model.License{})
 		if result.Error != nil {
 			return WrapInternal("delete license failed", result.Error)
@@ -280,18 +644,40 @@ func (s *LicenseService) GetLicenseDataByID(ctx context.Context, id uint) (*Lice
 	return &LicenseData{
 		ID:            license.ID,
 		ProductID:     license.ProductID,
-		LicenseKey:    license.LicenseKey,
+		CustomerID:    license.CustomerID,
+		PlanID:        license.PlanID,
+		Type:          int(license.Type),
+		TrialMaxHours: license.TrialMaxHours,
+		LicenseKey:    licensekey.Mask(license.KeyPrefix),
 		ValidityHours: license.ValidityHours,
 		Status:        int(license.Status),
 		Remark:        license.Remark,
 		MaxNodes:      license.MaxNodes,
 		MaxConcurrent: license.MaxConcurrent,
 		FeatureMask:   license.FeatureMask,
+
+		Perpetual:          license.Perpetual,
+		VersionCutoffAt:    license.VersionCutoffAt,
+		MinVersionID:       license.MinVersionID,
+		MaxVersionID:       license.MaxVersionID,
+		GracePeriodHours:   license.GracePeriodHours,
+		GraceEndsAt:        license.GraceEndsAt(),
+		InactiveUnbindDays: license.InactiveUnbindDays,
+		ApprovalRequired:   license.ApprovalRequired,
+		NotBefore:          license.NotBefore,
+		ScheduledRevokeAt:  license.ScheduledRevokeAt,
 	}, nil
 }
 
 // GetLicenseDataByKey 根据许可证密钥获取许可证
 func (s *LicenseService) GetLicenseDataByKey(ctx context.Context, key string) (*LicenseData, error) {
+	// 不符合格式或校验位错误的注册码直接拒绝，不查询 License
+	if err := licenseRepo.CheckKey(ctx, global.DB.WithContext(ctx), key); err != nil {
+		if repository.IsMalformedLicenseKey(err) {
+			return nil, ErrBadRequest("malformed license key")
+		}
+		return nil, WrapInternal("check license key failed", err)
+	}
 	license, err := GetLicenseEntityByKey(ctx, global.DB.WithContext(ctx), key)
 	if err != nil {
 		return nil, err
@@ -302,26 +688,52 @@ func (s *LicenseService) GetLicenseDataByKey(ctx context.Context, key string) (*
 	return &LicenseData{
 		ID:            license.ID,
 		ProductID:     license.ProductID,
-		LicenseKey:    license.LicenseKey,
+		CustomerID:    license.CustomerID,
+		PlanID:        license.PlanID,
+		Type:          int(license.Type),
+		TrialMaxHours: license.TrialMaxHours,
+		LicenseKey:    licensekey.Mask(license.KeyPrefix),
 		ValidityHours: license.ValidityHours,
 		Status:        int(license.Status),
 		Remark:        license.Remark,
 		MaxNodes:      license.MaxNodes,
 		MaxConcurrent: license.MaxConcurrent,
 		FeatureMask:   license.FeatureMask,
+
+		Perpetual:          license.Perpetual,
+		VersionCutoffAt:    license.VersionCutoffAt,
+		MinVersionID:       license.MinVersionID,
+		MaxVersionID:       license.MaxVersionID,
+		GracePeriodHours:   license.GracePeriodHours,
+		GraceEndsAt:        license.GraceEndsAt(),
+		InactiveUnbindDays: license.InactiveUnbindDays,
+		ApprovalRequired:   license.ApprovalRequired,
+		NotBefore:          license.NotBefore,
+		ScheduledRevokeAt:  license.ScheduledRevokeAt,
 	}, nil
 }
 
 func (s *LicenseService) ListLicenses(ctx context.Context, cmd ListLicensesCommand) ([]LicenseData, error) {
 	query := global.DB.WithContext(ctx).Model(&model.License{}).Order("id DESC")
 	if cmd.ProductID != nil {
-		query = query.Where("product_id = ?", *cmd.ProductID)
+		// 同时包含通过产品范围授权该产品的多产品 License
+		scoped := global.DB.WithContext(ctx).Model(&model.LicenseProductScope{}).
+			Select("license_id").Where("product_id = ?", *cmd.ProductID)
+		query = query.Where("product_id = ? OR id IN (?)", *cmd.ProductID, scoped)
+	}
+	if cmd.CustomerID != nil {
+		query = query.Where("customer_id = ?", *cmd.CustomerID)
+	}
+	if cmd.Type != nil {
+		query = query.Where("type = ?", *cmd.Type)
 	}
 	if cmd.Status != nil {
 		query = query.Where("status = ?", *cmd.Status)
 	}
 	if cmd.LicenseKey != nil && strings.TrimSpace(*cmd.LicenseKey) != "" {
-		query = query.Where("license_key LIKE ?", "%"+strings.TrimSpace(*cmd.LicenseKey)+"%")
+		// 只保存摘要和展示前缀：完整注册码按摘要精确匹配，部分输入按前缀模糊匹配
+		key := strings.TrimSpace(*cmd.LicenseKey)
+		query = query.Where("key_digest = ? OR key_prefix LIKE ?", repository.LicenseKeyDigest(key), "%"+key+"%")
 	}
 	if cmd.Limit > 0 {
 		query = query.Limit(cmd.Limit)
@@ -335,20 +747,44 @@ func (s *LicenseService) ListLicenses(ctx context.Context, cmd ListLicensesComma
 		return nil, WrapInternal("list licenses failed", err)
 	}
 
+	entityLicenses := make([]*entity.License, 0, len(licenses))
+	for i := range licenses {
+		entityLicenses = append(entityLicenses, ToEntityLicense(&licenses[i]))
+	}
+	if err := fillGracePolicies(ctx, global.DB, entityLicenses...); err != nil {
+		return nil, WrapInternal("get grace policies failed", err)
+	}
+
+	now := time.Now()
 	data := make([]LicenseData, 0, len(licenses))
 	for i := range licenses {
-		entityLicense := ToEntityLicense(&licenses[i])
-		status := int(entityLicense.CalculateStatus(time.Now()))
+		entityLicense := entityLicenses[i]
+		status := int(entityLicense.CalculateStatus(now))
 		data = append(data, LicenseData{
 			ID:            licenses[i].ID,
 			ProductID:     licenses[i].ProductID,
-			LicenseKey:    licenses[i].LicenseKey,
+			CustomerID:    licenses[i].CustomerID,
+			PlanID:        licenses[i].PlanID,
+			Type:          int(licenses[i].Type),
+			TrialMaxHours: licenses[i].TrialMaxHours,
+			LicenseKey:    licensekey.Mask(licenses[i].KeyPrefix),
 			ValidityHours: licenses[i].ValidityHours,
 			Status:        status,
 			Remark:        licenses[i].Remark,
 			MaxNodes:      licenses[i].MaxNodes,
 			MaxConcurrent: licenses[i].MaxConcurrent,
 			FeatureMask:   licenses[i].FeatureMask,
+
+			Perpetual:          licenses[i].Perpetual,
+			VersionCutoffAt:    licenses[i].VersionCutoffAt,
+			MinVersionID:       licenses[i].MinVersionID,
+			MaxVersionID:       licenses[i].MaxVersionID,
+			GracePeriodHours:   licenses[i].GracePeriodHours,
+			GraceEndsAt:        entityLicense.GraceEndsAt(),
+			InactiveUnbindDays: licenses[i].InactiveUnbindDays,
+			ApprovalRequired:   licenses[i].ApprovalRequired,
+			NotBefore:          licenses[i].NotBefore,
+			ScheduledRevokeAt:  licenses[i].ScheduledRevokeAt,
 		})
 	}
 	return data, nil
@@ -366,14 +802,21 @@ func (s *LicenseService) CleanInvalidLicense(ctx context.Context) error {
 			return err
 		}
 
-		if len(expiredLicenses) == 0 {
-			return nil // 没有无效的许可证
-		}
-
-		// 提取所有无效许可证的 ID
+		// 提取所有无效许可证的 ID，仍在宽限期内的许可证保留
 		var ids []uint
-		for _, lic := range expiredLicenses {
-			ids = append(ids, lic.ID)
+		now := time.Now()
+		for i := range expiredLicenses {
+			license := ToEntityLicense(&expiredLicenses[i])
+			if err := fillGracePolicies(ctx, tx, license); err != nil {
+				return err
+			}
+			if license.CalculateStatus(now) == entity.StatusGrace {
+				continue
+			}
+			ids = append(ids, license.ID)
+		}
+		if len(ids) == 0 {
+			return nil // 没有无效的许可证
 		}
 
 		// 删除节点绑定关系
@@ -386,6 +829,21 @@ func (s *LicenseService) CleanInvalidLicense(ctx context.Context) error {
 		if err := tx.Where("license_id IN ?", ids).Delete(&model.LicenseServiceScope{}).Error; err != nil {
 			return err
 		}
+		if err := tx.Where("license_id IN ?", ids).Delete(&model.LicenseFeature{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("license_id IN ?", ids).Delete(&model.LicenseFeatureQuota{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("license_id IN ?", ids).Delete(&model.SeatLease{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("license_id IN ?", ids).Delete(&model.SeatWaiter{}).Error; err != nil {
+			return err
+		}
+		if err := releaseLicenseAllowlist(ctx, tx, ids...); err != nil {
+			return err
+		}
 
 		// 删除许可证
 		if err := tx.Where("id IN ?", ids).Delete(&model.License{}).Error; err != nil {
@@ -403,25 +861,52 @@ func validateCreateLicenseCommand(cmd CreateLicenseCommand) error {
 	if cmd.ProductID == 0 {
 		return ErrBadRequest("product_id is required")
 	}
-	if cmd.ValidityHours <= 0 {
+	if !cmd.Perpetual && cmd.ValidityHours <= 0 {
 		return ErrBadRequest("validity_hours must be greater than 0")
 	}
+	if cmd.Perpetual && entity.LicenseType(cmd.Type) == entity.LicenseTypeTrial {
+		return ErrBadRequest("trial license cannot be perpetual")
+	}
 	if cmd.MaxNodes < 0 {
 		return ErrBadRequest("max_nodes must be greater than or equal to 0")
 	}
 	if cmd.MaxConcurrent < 0 {
 		return ErrBadRequest("max_concurrent must be greater than or equal to 0")
 	}
+	if cmd.GracePeriodHours != nil && *cmd.GracePeriodHours < 0 {
+		return ErrBadRequest("grace_period_hours must be greater than or equal to 0")
+	}
+	if err := validateLicenseSchedule(cmd.NotBefore, cmd.ScheduledRevokeAt); err != nil {
+		return err
+	}
+	switch entity.LicenseType(cmd.Type) {
+	case entity.LicenseTypeStandard:
+		if cmd.TrialMaxHours != 0 {
+			return ErrBadRequest("trial_max_hours only applies to trial licenses")
+		}
+	case entity.LicenseTypeTrial:
+		if cmd.ValidityHours > cmd.TrialMaxHours {
+			return BadRequestf("trial validity_hours must not exceed %d", cmd.TrialMaxHours)
+		}
+	default:
+		return ErrBadRequest("invalid license type")
+	}
 	return nil
 }
 
 func validateBatchCreateLicenseCommand(cmd BatchCreateLicenseCommand) error {
 	if err := validateCreateLicenseCommand(CreateLicenseCommand{
-		ProductID:     cmd.ProductID,
-		ValidityHours: cmd.ValidityHours,
-		MaxNodes:      cmd.MaxNodes,
-		MaxConcurrent: cmd.MaxConcurrent,
-		Remark:        cmd.Remark,
+		ProductID:         cmd.ProductID,
+		Type:              cmd.Type,
+		TrialMaxHours:     cmd.TrialMaxHours,
+		GracePeriodHours:  cmd.GracePeriodHours,
+		NotBefore:         cmd.NotBefore,
+		ScheduledRevokeAt: cmd.ScheduledRevokeAt,
+		Perpetual:         cmd.Perpetual,
+		ValidityHours:     cmd.ValidityHours,
+		MaxNodes:          cmd.MaxNodes,
+		MaxConcurrent:     cmd.MaxConcurrent,
+		Remark:            cmd.Remark,
 	}); err != nil {
 		return err
 	}
@@ -438,12 +923,26 @@ func toLicenseData(license *model.License) *LicenseData {
 	return &LicenseData{
 		ID:            license.ID,
 		ProductID:     license.ProductID,
-		LicenseKey:    license.LicenseKey,
+		CustomerID:    license.CustomerID,
+		PlanID:        license.PlanID,
+		Type:          int(license.Type),
+		TrialMaxHours: license.TrialMaxHours,
+		LicenseKey:    licensekey.Mask(license.KeyPrefix),
 		ValidityHours: license.ValidityHours,
 		Status:        license.Status,
 		Remark:        license.Remark,
 		MaxNodes:      license.MaxNodes,
 		MaxConcurrent: license.MaxConcurrent,
 		FeatureMask:   license.FeatureMask,
+
+		Perpetual:          license.Perpetual,
+		VersionCutoffAt:    license.VersionCutoffAt,
+		MinVersionID:       license.MinVersionID,
+		MaxVersionID:       license.MaxVersionID,
+		GracePeriodHours:   license.GracePeriodHours,
+		InactiveUnbindDays: license.InactiveUnbindDays,
+		ApprovalRequired:   license.ApprovalRequired,
+		NotBefore:          license.NotBefore,
+		ScheduledRevokeAt:  license.ScheduledRevokeAt,
 	}
 }
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"nexus-core/domain/entity"
+	"nexus-core/global"
+	"nexus-core/persistence/model"
+
+	"gorm.io/gorm"
+)
+
+// GetLicenseTransfer 查询 License 的换绑限制策略及当前计数
+func (s *LicenseService) GetLicenseTransfer(ctx context.Context, licenseID uint) (*LicenseTransferData, error) {
+	if licenseID == 0 {
+		return nil, ErrBadRequest("id is required")
+	}
+	return getLicenseTransferData(ctx, global.DB.WithContext(ctx), licenseID, time.Now())
+}
+
+// SetLicenseTransferPolicy 设置 License 的换绑限制策略
+func (s *LicenseService) SetLicenseTransferPolicy(ctx context.Context, cmd SetLicenseTransferPolicyCommand) (*LicenseTransferData, error) {
+	if cmd.LicenseID == 0 {
+		return nil, ErrBadRequest("id is required")
+	}
+	if cmd.TransferLimit < 0 || cmd.TransferWindowDays < 0 || cmd.RebindCooldownMinutes < 0 {
+		return nil, ErrBadRequest("transfer policy values must be greater than or equal to 0")
+	}
+	if cmd.TransferLimit > 0 && cmd.TransferWindowDays == 0 {
+		return nil, ErrBadRequest("transfer_window_days is required when transfer_limit is set")
+	}
+	db := global.DB.WithContext(ctx)
+	result := db.Model(&model.License{}).Where("id = ?", cmd.LicenseID).Updates(map[string]interface{}{
+		"transfer_limit":          cmd.TransferLimit,
+		"transfer_window_days":    cmd.TransferWindowDays,
+		"rebind_cooldown_minutes": cmd.RebindCooldownMinutes,
+	})
+	if result.Error != nil {
+		return nil, WrapInternal("update license transfer policy failed", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrNotFound("license not found")
+	}
+	recordAuditLog(ctx, db, "license", cmd.LicenseID, "set_transfer_policy", map[string]interface{}{
+		"transfer_limit":          cmd.TransferLimit,
+		"transfer_window_days":    cmd.TransferWindowDays,
+		"rebind_cooldown_minutes": cmd.RebindCooldownMinutes,
+	})
+	return getLicenseTransferData(ctx, db, cmd.LicenseID, time.Now())
+}
+
+// ResetLicenseTransfer 重置换绑计数，之前的绑定不再计入设备数，冷却中的名额立即可用
+func (s *LicenseService) ResetLicenseTransfer(ctx context.Context, licenseID uint) (*LicenseTransferData, error) {
+	if licenseID == 0 {
+		return nil, ErrBadRequest("id is required")
+	}
+	now := time.Now()
+	db := global.DB.WithContext(ctx)
+	result := db.Model(&model.License{}).Where("id = ?", licenseID).Update("transfer_reset_at", now)
+	if result.Error != nil {
+		return nil, WrapInternal("reset license transfer failed", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrNotFound("license not found")
+	}
+	recordAuditLog(ctx, db, "license", licenseID, "reset_transfer", nil)
+	return getLicenseTransferData(ctx, db, licenseID, now)
+}
+
+// checkTransferPolicy 检查节点新绑定到 License 是否违反换绑限制
+// 节点自己解绑后重新绑定不受冷却限制，统计窗口内已绑定过的节点也不重复计数
+func checkTransferPolicy(ctx context.Context, tx *gorm.DB, nodeID uint, license *entity.License, now time.Time) error {
+	policy := license.Transfer
+	if policy.CooldownMinutes > 0 && license.MaxNodes > 0 && license.CurrentNodeCount < license.MaxNodes {
+		cooling, err := listCoolingBindings(ctx, tx, license.ID, nodeID, policy.CooldownSince(now))
+		if err != nil {
+			return err
+		}
+		if len(cooling) > 0 && license.CurrentNodeCount+len(cooling) >= license.MaxNodes {
+			endsAt := cooling[0].UnboundAt.Add(time.Duration(policy.CooldownMinutes) * time.Minute)
+			return ConflictWithCodef(CodeRebindCooldown, "released node seat is cooling down until %s", endsAt.Format(time.RFC3339))
+		}
+	}
+	if policy.Limit > 0 {
+		devices, err := countTransferDevices(ctx, tx, license.ID, nodeID, policy.CountSince(now))
+		if err != nil {
+			return err
+		}
+		if devices >= int64(policy.Limit) {
+			return ConflictWithCodef(CodeTransferLimitExceeded,
+				"license has reached %d bound devices in %d days", policy.Limit, policy.WindowDays)
+		}
+	}
+	return nil
+}
+
+// countTransferDevices 统计 since 之后绑定过 License 的不同设备数，不含 excludeNodeID
+func countTransferDevices(ctx context.Context, db *gorm.DB, licenseID uint, excludeNodeID uint, since time.Time) (int64, error) {
+	var count int64
+	if err := db.WithContext(ctx).Model(&model.NodeLicenseBinding{}).
+		Where("license_id = ? AND node_id <> ? AND bound_at > ?", licenseID, excludeNodeID, since).
+		Distinct("node_id").Count(&count).Error; err != nil {
+		return 0, WrapInternal("count license transfers failed", err)
+	}
+	return count, nil
+}
+
+// listCoolingBindings 查询 since 之后解绑、名额仍在冷却期的绑定，按解绑时间升序
+func listCoolingBindings(ctx context.Context, db *gorm.DB, licenseID uint, excludeNodeID uint, since time.Time) ([]model.NodeLicenseBinding, error) {
+	var bindings []model.NodeLicenseBinding
+	if err := db.WithContext(ctx).
+		Where("license_id = ? AND node_id <> ? AND status = ? AND unbound_at > ?",
+			licenseID, excludeNodeID, entity.BindingStatusUnbound, since).
+		Order("unbound_at ASC").Find(&bindings).Error; err != nil {
+		return nil, WrapInternal("list cooling bindings failed", err)
+	}
+	return bindings, nil
+}
+
+func getLicenseTransferData(ctx context.Context, db *gorm.DB, licenseID uint, now time.Time) (*LicenseTransferData, error) {
+	var pLicense model.License
+	if err := db.WithContext(ctx).Where("id = ?", licenseID).First(&pLicense).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound("license not found")
+		}
+		return nil, WrapInternal("get license failed", err)
+	}
+	policy := ToEntityLicense(&pLicense).Transfer
+	data := &LicenseTransferData{
+		LicenseID:             licenseID,
+		TransferLimit:         policy.Limit,
+		TransferWindowDays:    policy.WindowDays,
+		RebindCooldownMinutes: policy.CooldownMinutes,
+		TransferResetAt:       policy.ResetAt,
+	}
+	if policy.Limit > 0 {
+		devices, err := countTransferDevices(ctx, db, licenseID, 0, policy.CountSince(now))
+		if err != nil {
+			return nil, err
+		}
+		data.DevicesBound = int(devices)
+	}
+	if policy.CooldownMinutes > 0 {
+		cooling, err := listCoolingBindings(ctx, db, licenseID, 0, policy.CooldownSince(now))
+		if err != nil {
+			return nil, err
+		}
+		data.CoolingSeats = len(cooling)
+		if len(cooling) > 0 {
+			endsAt := cooling[0].UnboundAt.Add(time.Duration(policy.CooldownMinutes) * time.Minute)
+			data.CooldownEndsAt = &endsAt
+		}
+	}
+	return data, nil
+}
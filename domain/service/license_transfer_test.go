@@ -0,0 +1,74 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"nexus-core/persistence/model"
+)
+
+func TestLicenseTransferPolicy(t *testing.T) {
+	fixture := newFlowFixture(t, 2, 0, 24)
+	_, err := fixture.licenseService.SetLicenseTransferPolicy(fixture.ctx, SetLicenseTransferPolicyCommand{
+		LicenseID: fixture.license.ID, TransferLimit: 3,
+	})
+	assertAppErrorKind(t, err, ErrorKindBadRequest)
+	if _, err := fixture.licenseService.SetLicenseTransferPolicy(fixture.ctx, SetLicenseTransferPolicyCommand{
+		LicenseID: fixture.license.ID, TransferLimit: 3, TransferWindowDays: 30, RebindCooldownMinutes: 60,
+	}); err != nil {
+		t.Fatalf("set transfer policy: %v", err)
+	}
+
+	registerWithCode := func(device string, code int) {
+		t.Helper()
+		_, err := fixture.accessService.Register(fixture.ctx, AccessCommand{
+			DeviceCode:  device,
+			LicenseKey:  fixture.license.LicenseKey,
+			ProductID:   fixture.product.ID,
+			VersionCode: "1.0.0",
+			NodeSecret:  fixture.secrets[device],
+		})
+		assertAppErrorKind(t, err, ErrorKindConflict)
+		if ErrorCodeOf(err) != code {
+			t.Fatalf("expected error code %d, got %d: %v", code, ErrorCodeOf(err), err)
+		}
+	}
+	unbind := func(nodeID uint, age time.Duration) {
+		t.Helper()
+		if err := fixture.nodeService.UnbindByID(fixture.ctx, UnbindCommand{NodeID: nodeID, LicenseID: fixture.license.ID}); err != nil {
+			t.Fatalf("unbind node %d: %v", nodeID, err)
+		}
+		if err := fixture.db.Model(&model.NodeLicenseBinding{}).Where("node_id = ?", nodeID).
+			Update("unbound_at", time.Now().Add(-age)).Error; err != nil {
+			t.Fatalf("age unbind: %v", err)
+		}
+	}
+
+	fixture.register(t, "device-a")
+	deviceB := fixture.register(t, "device-b")
+
+	// 解绑释放的名额在冷却期内不能被其他设备占用，原设备可以重新绑定
+	unbind(deviceB.NodeID, 0)
+	registerWithCode("device-c", CodeRebindCooldown)
+	fixture.register(t, "device-b")
+	unbind(deviceB.NodeID, 2*time.Hour)
+	deviceC := fixture.register(t, "device-c")
+
+	// 统计窗口内已绑定过 3 台不同设备，第 4 台被拒绝
+	unbind(deviceC.NodeID, 2*time.Hour)
+	registerWithCode("device-d", CodeTransferLimitExceeded)
+	err = fixture.nodeService.AutoBind(fixture.ctx, AutoBindCommand{DeviceCode: "device-d", LicenseID: fixture.license.ID})
+	if ErrorCodeOf(err) != CodeTransferLimitExceeded {
+		t.Fatalf("auto bind should enforce the transfer limit: %v", err)
+	}
+	transfer, err := fixture.licenseService.GetLicenseTransfer(fixture.ctx, fixture.license.ID)
+	if err != nil || transfer.DevicesBound != 3 || transfer.CoolingSeats != 0 {
+		t.Fatalf("unexpected transfer data: %#v %v", transfer, err)
+	}
+
+	transfer, err = fixture.licenseService.ResetLicenseTransfer(fixture.ctx, fixture.license.ID)
+	if err != nil || transfer.DevicesBound != 0 || transfer.TransferResetAt == nil {
+		t.Fatalf("reset transfer: %#v %v", transfer, err)
+	}
+	fixture.register(t, "device-d")
+}
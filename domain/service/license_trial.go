@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"nexus-core/domain/entity"
+	"nexus-core/global"
+	"nexus-core/persistence/model"
+
+	"gorm.io/gorm"
+)
+
+// DefaultTrialMaxHours 未指定上限时试用许可证的最长有效时长
+const DefaultTrialMaxHours = 30 * 24
+
+// applyTrialDefaults 试用许可证未指定上限时使用默认值
+func applyTrialDefaults(licenseType int, trialMaxHours *int) {
+	if entity.LicenseType(licenseType) == entity.LicenseTypeTrial && *trialMaxHours == 0 {
+		*trialMaxHours = DefaultTrialMaxHours
+	}
+}
+
+// ConvertTrialLicense 将试用许可证原地转为正式许可证
+// 已有的节点绑定和试用记录保持不变，正式有效期从转换时刻开始计算
+func (s *LicenseService) ConvertTrialLicense(ctx context.Context, cmd ConvertTrialLicenseCommand) (*LicenseData, error) {
+	if cmd.LicenseID == 0 {
+		return nil, ErrBadRequest("id is required")
+	}
+	err := global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		license, err := GetLicenseEntityByID(ctx, tx, cmd.LicenseID)
+		if err != nil {
+			return WrapInternal("get license failed", err)
+		}
+		if license == nil {
+			return ErrNotFound("license not found")
+		}
+		if !license.IsTrial() {
+			return ErrConflict("license is not a trial")
+		}
+		if license.Status == entity.StatusRevoked {
+			return ErrForbidden("revoked license must be restored before convert")
+		}
+		template, err := loadPlanTemplate(ctx, tx, cmd.PlanID, license.ProductID)
+		if err != nil {
+			return err
+		}
+
+		validityHours, maxNodes, maxConcurrent := cmd.ValidityHours, license.MaxNodes, license.MaxConcurrent
+		if template != nil {
+			maxNodes, maxConcurrent = template.plan.MaxNodes, template.plan.MaxConcurrent
+			if validityHours == 0 {
+				validityHours = template.plan.ValidityHours
+			}
+		}
+		if cmd.MaxNodes != nil {
+			maxNodes = *cmd.MaxNodes
+		}
+		if cmd.MaxConcurrent != nil {
+			maxConcurrent = *cmd.MaxConcurrent
+		}
+		if err := validatePlanLimits(validityHours, maxNodes, maxConcurrent); err != nil {
+			return err
+		}
+		if maxNodes > 0 && license.CurrentNodeCount > maxNodes {
+			return Conflictf("license has %d bound nodes, max_nodes is %d", license.CurrentNodeCount, maxNodes)
+		}
+
+		before := licenseStateOf(license)
+		license.ConvertToStandard(time.Now(), validityHours)
+		updates := map[string]interface{}{
+			"type":            int(license.Type),
+			"trial_max_hours": license.TrialMaxHours,
+			"converted_at":    license.ConvertedAt,
+			"validity_hours":  license.ValidityHours,
+			"expired_at":      license.ExpiredAt,
+			"status":          int(license.Status),
+			"max_nodes":       maxNodes,
+			"max_concurrent":  maxConcurrent,
+		}
+		if template != nil {
+			updates["plan_id"] = template.plan.ID
+		}
+		if err := tx.Model(&model.License{}).Where("id = ?", license.ID).Updates(updates).Error; err != nil {
+			return WrapInternal("convert trial license failed", err)
+		}
+		if template != nil {
+			if err := replaceLicenseServiceScopes(ctx, tx, license.ID, template.services); err != nil {
+				return err
+			}
+		}
+		recordAuditLog(ctx, tx, "license", license.ID, "convert_trial", map[string]interface{}{
+			"plan_id":        updates["plan_id"],
+			"validity_hours": validityHours,
+			"max_nodes":      maxNodes,
+			"max_concurrent": maxConcurrent,
+		})
+		after := licenseStateOf(license)
+		after.MaxNodes, after.MaxConcurrent = maxNodes, maxConcurrent
+		return recordLicenseHistory(ctx, tx, licenseChange{
+			LicenseID: license.ID,
+			Event:     LicenseEventConvertTrial,
+			Before:    before,
+			After:     after,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetLicenseDataByID(ctx, cmd.LicenseID)
+}
+
+// consumeTrial 记录设备使用了该产品的试用
+// 设备已使用过其他试用许可证时拒绝注册，同一试用许可证重复注册不受影响
+func consumeTrial(ctx context.Context, tx *gorm.DB, license *entity.License, deviceCode string) error {
+	var consumption model.TrialConsumption
+	err := tx.WithContext(ctx).
+		Where("product_id = ? AND device_code = ?", license.ProductID, deviceCode).
+		First(&consumption).Error
+	if err == nil {
+		if consumption.LicenseID != license.ID {
+			return ErrConflict("device has already used a trial for this product")
+		}
+		return nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return WrapInternal("get trial consumption failed", err)
+	}
+	consumption = model.TrialConsumption{
+		ProductID:  license.ProductID,
+		DeviceCode: deviceCode,
+		LicenseID:  license.ID,
+		ConsumedAt: time.Now(),
+	}
+	if err := tx.WithContext(ctx).Create(&consumption).Error; err != nil {
+		return WrapInternal("record trial consumption failed", err)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"testing"
+
+	"nexus-core/domain/entity"
+	"nexus-core/persistence/model"
+)
+
+func TestTrialLicenseOncePerDeviceAndConversion(t *testing.T) {
+	fixture := newFlowFixture(t, 1, 0, 24)
+
+	_, err := fixture.licenseService.CreateLicense(fixture.ctx, CreateLicenseCommand{
+		ProductID:     fixture.product.ID,
+		Type:          int(entity.LicenseTypeTrial),
+		ValidityHours: DefaultTrialMaxHours + 1,
+	})
+	assertAppErrorKind(t, err, ErrorKindBadRequest)
+
+	trial, err := fixture.licenseService.CreateLicense(fixture.ctx, CreateLicenseCommand{
+		ProductID:     fixture.product.ID,
+		Type:          int(entity.LicenseTypeTrial),
+		TrialMaxHours: 72,
+		ValidityHours: 48,
+		MaxNodes:      2,
+	})
+	if err != nil {
+		t.Fatalf("create trial: %v", err)
+	}
+	second, err := fixture.licenseService.CreateLicense(fixture.ctx, CreateLicenseCommand{
+		ProductID:     fixture.product.ID,
+		Type:          int(entity.LicenseTypeTrial),
+		ValidityHours: 48,
+	})
+	if err != nil {
+		t.Fatalf("create second trial: %v", err)
+	}
+
+	fixture.license = trial
+	first := fixture.register(t, "device-a")
+	// 同一试用许可证重复注册不受影响
+	fixture.register(t, "device-a")
+
+	fixture.license = second
+	_, err = fixture.accessService.Register(fixture.ctx, AccessCommand{
+		DeviceCode:  "device-a",
+		LicenseKey:  second.LicenseKey,
+		ProductID:   fixture.product.ID,
+		VersionCode: "1.0.0",
+		NodeSecret:  fixture.secrets["device-a"],
+	})
+	assertAppErrorKind(t, err, ErrorKindConflict)
+	fixture.register(t, "device-b")
+
+	err = fixture.licenseService.RenewLicense(fixture.ctx, RenewLicenseCommand{ID: trial.ID, ExtraHours: 48})
+	assertAppErrorKind(t, err, ErrorKindConflict)
+	if err := fixture.licenseService.RenewLicense(fixture.ctx, RenewLicenseCommand{ID: trial.ID, ExtraHours: 24}); err != nil {
+		t.Fatalf("renew within trial cap: %v", err)
+	}
+
+	converted, err := fixture.licenseService.ConvertTrialLicense(fixture.ctx, ConvertTrialLicenseCommand{
+		LicenseID:     trial.ID,
+		ValidityHours: 8760,
+	})
+	if err != nil {
+		t.Fatalf("convert trial: %v", err)
+	}
+	if converted.Type != int(entity.LicenseTypeStandard) || converted.ValidityHours != 8760 || converted.MaxNodes != 2 {
+		t.Fatalf("unexpected converted license: %#v", converted)
+	}
+	_, err = fixture.licenseService.ConvertTrialLicense(fixture.ctx, ConvertTrialLicenseCommand{LicenseID: trial.ID, ValidityHours: 24})
+	assertAppErrorKind(t, err, ErrorKindConflict)
+
+	var binding model.NodeLicenseBinding
+	if err := fixture.db.Where("node_id = ? AND license_id = ?", first.NodeID, trial.ID).First(&binding).Error; err != nil {
+		t.Fatalf("binding should survive conversion: %v", err)
+	}
+	if binding.Status != int(entity.BindingStatusBound) {
+		t.Fatalf("binding should stay bound, status=%d", binding.Status)
+	}
+	if err := fixture.licenseService.RenewLicense(fixture.ctx, RenewLicenseCommand{ID: trial.ID, ExtraHours: 1000}); err != nil {
+		t.Fatalf("converted license should renew freely: %v", err)
+	}
+
+	trialType := int(entity.LicenseTypeTrial)
+	trials, err := fixture.licenseService.ListLicenses(fixture.ctx, ListLicensesCommand{Type: &trialType})
+	if err != nil || len(trials) != 1 || trials[0].ID != second.ID {
+		t.Fatalf("list trials: %#v err=%v", trials, err)
+	}
+}
@@ -227,13 +227,13 @@ func TestP2VersionScheduleAndVersionGuards(t *testing.T) {
 	})
 	assertAppErrorKind(t, err, ErrorKindBadRequest)
 	bound := fixture.register(t, "deprecated-version-heartbeat-node")
-	_, err = fixture.accessService.Heartbeat(
-		fixture.ctx,
-		"deprecated-version-heartbeat-node",
-		fixture.product.ID,
-		scheduled.VersionCode,
-		fixture.license.LicenseKey,
-	)
+	_, err = fixture.accessService.Heartbeat(fixture.ctx, AccessCommand{
+		DeviceCode:  "deprecated-version-heartbeat-node",
+		LicenseKey:  fixture.license.LicenseKey,
+		ProductID:   fixture.product.ID,
+		VersionCode: scheduled.VersionCode,
+		NodeSecret:  bound.NodeSecret,
+	})
 	assertAppErrorKind(t, err, ErrorKindBadRequest)
 	if bound.NodeID == 0 {
 		t.Fatal("registered node should have id")
@@ -11,10 +11,10 @@ func TestP3MonitorSummaryAndNodeHeartbeat(t *testing.T) {
 	fixture.register(t, "monitor-node-a")
 	fixture.register(t, "monitor-node-b")
 
-	if _, err := fixture.accessService.Heartbeat(fixture.ctx, "monitor-node-a", fixture.product.ID, "1.0.0", fixture.license.LicenseKey); err != nil {
+	if _, err := fixture.heartbeat("monitor-node-a"); err != nil {
 		t.Fatalf("heartbeat node a: %v", err)
 	}
-	if _, err := fixture.accessService.Heartbeat(fixture.ctx, "monitor-node-b", fixture.product.ID, "1.0.0", fixture.license.LicenseKey); err != nil {
+	if _, err := fixture.heartbeat("monitor-node-b"); err != nil {
 		t.Fatalf("heartbeat node b: %v", err)
 	}
 
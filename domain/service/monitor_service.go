@@ -2,10 +2,13 @@ package service
 
 import (
 	"context"
+	"sort"
 	"strconv"
 	"time"
 
+	"nexus-core/domain/entity"
 	"nexus-core/global"
+	"nexus-core/licensekey"
 	"nexus-core/monitor"
 	"nexus-core/persistence/model"
 )
@@ -21,15 +24,25 @@ func uintToString(value uint) string {
 }
 
 type OnlineCountData struct {
-	Key   string `json:"key"`
-	Count int    `json:"count"`
+	Key       string `json:"key"`
+	LicenseID uint   `json:"license_id,omitempty"` // 按 License 统计时的 License ID，Key 为脱敏注册码
+	Count     int    `json:"count"`
 }
 
 type OnlineSummaryData struct {
-	TotalOnline int               `json:"total_online"`
-	Nodes       []OnlineNodeData  `json:"nodes"`
-	ByProduct   []OnlineCountData `json:"by_product"`
-	ByLicense   []OnlineCountData `json:"by_license"`
+	TotalOnline   int                `json:"total_online"`
+	Nodes         []OnlineNodeData   `json:"nodes"`
+	ByProduct     []OnlineCountData  `json:"by_product"`
+	ByLicense     []OnlineCountData  `json:"by_license"`
+	GraceLicenses []GraceLicenseData `json:"grace_licenses"` // 有节点在线且处于宽限期的 License
+}
+
+type GraceLicenseData struct {
+	LicenseID          uint       `json:"license_id"`
+	LicenseKey         string     `json:"license_key"`
+	GraceEndsAt        *time.Time `json:"grace_ends_at"`
+	GraceDaysRemaining int        `json:"grace_days_remaining"`
+	Online             int        `json:"online"`
 }
 
 type NodeHeartbeatData struct {
@@ -52,17 +65,32 @@ func (s *MonitorService) GetOnlineSummary(ctx context.Context) (*OnlineSummaryDa
 	byProduct := map[uint]int{}
 	byLicense := map[string]int{}
 
-	nodes := make([]OnlineNodeData, 0, len(snapshot))
+	// 在线统计是进程内全局数据，按当前租户过滤
+	tenantID, scoped := model.TenantFromContext(ctx)
+
+	online := make([]monitor.OnlineNodeKey, 0, len(snapshot))
 	for _, item := range snapshot {
+		if scoped && item.TenantID != tenantID {
+			continue
+		}
+		online = append(online, item)
+		byProduct[item.ProductID]++
+		byLicense[item.KeyDigest]++
+	}
+	licenses, err := onlineLicenses(ctx, byLicense)
+	if err != nil {
+		return nil, err
+	}
+
+	// 在线标识只包含注册码摘要，输出时换成脱敏的注册码
+	nodes := make([]OnlineNodeData, 0, len(online))
+	for _, item := range online {
 		nodes = append(nodes, OnlineNodeData{
 			ProductID:  item.ProductID,
 			DeviceCode: item.DeviceCode,
-			LicenseKey: item.LicenseKey,
+			LicenseKey: maskedOnlineKey(licenses[item.KeyDigest]),
 		})
-		byProduct[item.ProductID]++
-		byLicense[item.LicenseKey]++
 	}
-
 	productCounts := make([]OnlineCountData, 0, len(byProduct))
 	for productID, count := range byProduct {
 		productCounts = append(productCounts, OnlineCountData{
@@ -71,21 +99,92 @@ func (s *MonitorService) GetOnlineSummary(ctx context.Context) (*OnlineSummaryDa
 		})
 	}
 	licenseCounts := make([]OnlineCountData, 0, len(byLicense))
-	for licenseKey, count := range byLicense {
-		licenseCounts = append(licenseCounts, OnlineCountData{
-			Key:   licenseKey,
+	for digest, count := range byLicense {
+		item := OnlineCountData{
+			Key:   maskedOnlineKey(licenses[digest]),
 			Count: count,
-		})
+		}
+		if license := licenses[digest]; license != nil {
+			item.LicenseID = license.ID
+		}
+		licenseCounts = append(licenseCounts, item)
+	}
+
+	graceLicenses, err := onlineGraceLicenses(ctx, licenses, byLicense)
+	if err != nil {
+		return nil, err
 	}
 
 	return &OnlineSummaryData{
-		TotalOnline: len(snapshot),
-		Nodes:       nodes,
-		ByProduct:   productCounts,
-		ByLicense:   licenseCounts,
+		TotalOnline:   len(nodes),
+		Nodes:         nodes,
+		ByProduct:     productCounts,
+		ByLicense:     licenseCounts,
+		GraceLicenses: graceLicenses,
 	}, nil
 }
 
+// onlineLicenses 按注册码摘要查询有节点在线的 License
+func onlineLicenses(ctx context.Context, onlineByLicense map[string]int) (map[string]*model.License, error) {
+	licenses := make(map[string]*model.License, len(onlineByLicense))
+	if len(onlineByLicense) == 0 {
+		return licenses, nil
+	}
+	digests := make([]string, 0, len(onlineByLicense))
+	for digest := range onlineByLicense {
+		digests = append(digests, digest)
+	}
+	var rows []model.License
+	if err := global.DB.WithContext(ctx).Where("key_digest IN ?", digests).Order("id ASC").Find(&rows).Error; err != nil {
+		return nil, WrapInternal("list online licenses failed", err)
+	}
+	for i := range rows {
+		licenses[rows[i].KeyDigest] = &rows[i]
+	}
+	return licenses, nil
+}
+
+// maskedOnlineKey 在线 License 的脱敏注册码，License 已删除时为空
+func maskedOnlineKey(license *model.License) string {
+	if license == nil {
+		return ""
+	}
+	return licensekey.Mask(license.KeyPrefix)
+}
+
+// onlineGraceLicenses 找出有节点在线且已过期但仍在宽限期内的 License
+func onlineGraceLicenses(ctx context.Context, licenses map[string]*model.License, onlineByLicense map[string]int) ([]GraceLicenseData, error) {
+	data := []GraceLicenseData{}
+	now := time.Now()
+	entityLicenses := make([]*entity.License, 0, len(licenses))
+	for _, license := range licenses {
+		if license.ExpiredAt == nil || !license.ExpiredAt.Before(now) || license.Status == int(entity.StatusRevoked) {
+			continue
+		}
+		entityLicenses = append(entityLicenses, ToEntityLicense(license))
+	}
+	if len(entityLicenses) == 0 {
+		return data, nil
+	}
+	sort.Slice(entityLicenses, func(i, j int) bool { return entityLicenses[i].ID < entityLicenses[j].ID })
+	if err := fillGracePolicies(ctx, global.DB, entityLicenses...); err != nil {
+		return nil, WrapInternal("get grace policies failed", err)
+	}
+	for _, license := range entityLicenses {
+		if license.CalculateStatus(now) != entity.StatusGrace {
+			continue
+		}
+		data = append(data, GraceLicenseData{
+			LicenseID:          license.ID,
+			LicenseKey:         licensekey.Mask(license.KeyPrefix),
+			GraceEndsAt:        license.GraceEndsAt(),
+			GraceDaysRemaining: license.GraceDaysRemaining(now),
+			Online:             onlineByLicense[license.KeyDigest],
+		})
+	}
+	return data, nil
+}
+
 func (s *MonitorService) ListNodeHeartbeats(ctx context.Context, limit int) ([]NodeHeartbeatData, error) {
 	return s.ListNodeHeartbeatsPage(ctx, limit, 0)
 }
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"time"
+
+	"nexus-core/domain/entity"
+	"nexus-core/global"
+	"nexus-core/persistence/model"
+
+	"gorm.io/gorm"
+)
+
+const nodeSecretPrefix = "ns_"
+
+// RotateNodeCredential 为节点重新签发密钥，旧密钥立即失效
+// 新密钥只在本次响应中返回，服务端仅保存摘要
+func (s *NodeService) RotateNodeCredential(ctx context.Context, nodeID uint) (*NodeCredentialData, error) {
+	if nodeID == 0 {
+		return nil, ErrBadRequest("node_id is required")
+	}
+	var data *NodeCredentialData
+	err := global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		node, err := GetNodeEntityByID(ctx, tx, nodeID)
+		if err != nil {
+			return WrapInternal("get node failed", err)
+		}
+		if node == nil {
+			return ErrNotFound("node not found")
+		}
+		secret, issuedAt, err := issueNodeCredential(ctx, tx, nodeID)
+		if err != nil {
+			return err
+		}
+		recordAuditLog(ctx, tx, "node", nodeID, "rotate_credential", nil)
+		data = &NodeCredentialData{
+			NodeID:     nodeID,
+			NodeSecret: secret,
+			IssuedAt:   issuedAt,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	DefaultControlWebSocketHub.Disconnect(nodeID)
+	return data, nil
+}
+
+// RevokeNodeCredential 吊销节点密钥
+// 吊销后节点的注册、心跳、控制通道都会被拒绝，直到操作员通过 RotateNodeCredential 重新签发
+func (s *NodeService) RevokeNodeCredential(ctx context.Context, nodeID uint) error {
+	if nodeID == 0 {
+		return ErrBadRequest("node_id is required")
+	}
+	db := global.DB.WithContext(ctx)
+	node, err := GetNodeEntityByID(ctx, db, nodeID)
+	if err != nil {
+		return WrapInternal("get node failed", err)
+	}
+	if node == nil {
+		return ErrNotFound("node not found")
+	}
+	if err := db.Model(&model.Node{}).Where("id = ?", nodeID).
+		Updates(map[string]interface{}{
+			"credential_hash":       "",
+			"credential_issued_at":  nil,
+			"credential_revoked_at": time.Now(),
+		}).Error; err != nil {
+		return WrapInternal("revoke node credential failed", err)
+	}
+	recordAuditLog(ctx, db, "node", nodeID, "revoke_credential", nil)
+	DefaultControlWebSocketHub.Disconnect(nodeID)
+	return nil
+}
+
+// issueNodeCredential 生成节点密钥并保存摘要，同时解除吊销状态
+func issueNodeCredential(ctx context.Context, db *gorm.DB, nodeID uint) (string, time.Time, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", time.Time{}, WrapInternal("generate node secret failed", err)
+	}
+	secret := nodeSecretPrefix + hex.EncodeToString(raw)
+	now := time.Now()
+	if err := db.WithContext(ctx).Model(&model.Node{}).Where("id = ?", nodeID).
+		Updates(map[string]interface{}{
+			"credential_hash":       hashSecret(secret),
+			"credential_issued_at":  now,
+			"credential_revoked_at": nil,
+		}).Error; err != nil {
+		return "", time.Time{}, WrapInternal("save node credential failed", err)
+	}
+	return secret, now, nil
+}
+
+// hashSecret 计算密钥摘要，节点密钥与操作员 API Key 均只保存摘要
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// verifyNodeCredential 校验节点提交的密钥
+func verifyNodeCredential(node *entity.Node, secret string) error {
+	if node.CredentialRevoked() {
+		return ErrForbidden("node credential revoked")
+	}
+	if !node.HasCredential() {
+		return ErrForbidden("node credential not issued")
+	}
+	if secret == "" {
+		return ErrForbidden("node credential is required")
+	}
+	if subtle.ConstantTimeCompare([]byte(hashSecret(secret)), []byte(node.CredentialHash)) != 1 {
+		return ErrForbidden("invalid node credential")
+	}
+	return nil
+}
+
+// authenticateNode 根据节点 ID 校验节点密钥
+func authenticateNode(ctx context.Context, db *gorm.DB, nodeID uint, secret string) error {
+	if nodeID == 0 {
+		return ErrBadRequest("node_id is required")
+	}
+	node, err := GetNodeEntityByID(ctx, db, nodeID)
+	if err != nil {
+		return WrapInternal("get node failed", err)
+	}
+	if node == nil {
+		return ErrNotFound("node not found")
+	}
+	return verifyNodeCredential(node, secret)
+}
@@ -0,0 +1,88 @@
+package service
+
+import "testing"
+
+func TestNodeCredentialLifecycle(t *testing.T) {
+	fixture := newFlowFixture(t, 1, 0, 24)
+	first := fixture.register(t, "device-a")
+	if first.NodeSecret == "" {
+		t.Fatal("first register should issue node secret")
+	}
+
+	issued := fixture.secrets["device-a"]
+	fixture.secrets["device-a"] = ""
+	_, err := fixture.heartbeat("device-a")
+	assertAppErrorKind(t, err, ErrorKindForbidden)
+
+	fixture.secrets["device-a"] = "ns_wrong"
+	_, err = fixture.heartbeat("device-a")
+	assertAppErrorKind(t, err, ErrorKindForbidden)
+
+	// 已签发密钥的节点重新注册时必须携带密钥，不会重复下发
+	_, err = fixture.accessService.Register(fixture.ctx, AccessCommand{
+		DeviceCode:  "device-a",
+		LicenseKey:  fixture.license.LicenseKey,
+		ProductID:   fixture.product.ID,
+		VersionCode: "1.0.0",
+	})
+	assertAppErrorKind(t, err, ErrorKindForbidden)
+
+	fixture.secrets["device-a"] = issued
+	again := fixture.register(t, "device-a")
+	if again.NodeSecret != "" {
+		t.Fatal("re-register with valid secret should not issue a new one")
+	}
+
+	rotated, err := fixture.nodeService.RotateNodeCredential(fixture.ctx, first.NodeID)
+	if err != nil {
+		t.Fatalf("rotate credential: %v", err)
+	}
+	if rotated.NodeSecret == "" || rotated.NodeSecret == issued {
+		t.Fatalf("rotate should issue a fresh secret: %#v", rotated)
+	}
+	_, err = fixture.heartbeat("device-a")
+	assertAppErrorKind(t, err, ErrorKindForbidden)
+
+	fixture.secrets["device-a"] = rotated.NodeSecret
+	if _, err := fixture.heartbeat("device-a"); err != nil {
+		t.Fatalf("heartbeat with rotated secret: %v", err)
+	}
+
+	if err := fixture.nodeService.RevokeNodeCredential(fixture.ctx, first.NodeID); err != nil {
+		t.Fatalf("revoke credential: %v", err)
+	}
+	_, err = fixture.heartbeat("device-a")
+	assertAppErrorKind(t, err, ErrorKindForbidden)
+
+	// 吊销后不能通过注册重新获取密钥，需由操作员重新签发
+	fixture.secrets["device-a"] = ""
+	_, err = fixture.accessService.Register(fixture.ctx, AccessCommand{
+		DeviceCode:  "device-a",
+		LicenseKey:  fixture.license.LicenseKey,
+		ProductID:   fixture.product.ID,
+		VersionCode: "1.0.0",
+	})
+	assertAppErrorKind(t, err, ErrorKindForbidden)
+
+	reissued, err := fixture.nodeService.RotateNodeCredential(fixture.ctx, first.NodeID)
+	if err != nil || reissued.NodeSecret == "" || reissued.NodeSecret == rotated.NodeSecret {
+		t.Fatalf("rotate after revoke should issue a new secret: %#v %v", reissued, err)
+	}
+	fixture.secrets["device-a"] = reissued.NodeSecret
+	if again := fixture.register(t, "device-a"); again.NodeSecret != "" {
+		t.Fatal("register with reissued secret should not issue another one")
+	}
+	if _, err := fixture.heartbeat("device-a"); err != nil {
+		t.Fatalf("heartbeat with reissued secret: %v", err)
+	}
+	// 重复吊销同一节点不会误报不存在
+	if err := fixture.nodeService.RevokeNodeCredential(fixture.ctx, first.NodeID); err != nil {
+		t.Fatalf("revoke credential: %v", err)
+	}
+	if err := fixture.nodeService.RevokeNodeCredential(fixture.ctx, first.NodeID); err != nil {
+		t.Fatalf("revoke credential again: %v", err)
+	}
+
+	err = fixture.nodeService.RevokeNodeCredential(fixture.ctx, 9999)
+	assertAppErrorKind(t, err, ErrorKindNotFound)
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"nexus-core/domain/entity"
 	"nexus-core/global"
 	"nexus-core/monitor"
@@ -272,8 +271,14 @@ func (s *NodeService) updateNodeStatus(ctx context.Context, nodeID uint, status
 		}
 	}
 	if status == entity.NodeStatusNormal {
-		result := global.DB.WithContext(ctx).
-			Exec("UPDATE node SET status = ?, banned_at = NULL, ban_reason = NULL, forced_offline_at = NULL, forced_offline_reason = NULL WHERE id = ? AND deleted_at IS NULL", status, nodeID)
+		result := global.DB.WithContext(ctx).Model(&model.Node{}).Where("id = ?", nodeID).
+			Updates(map[string]interface{}{
+				"status":                status,
+				"banned_at":             nil,
+				"ban_reason":            nil,
+				"forced_offline_at":     nil,
+				"forced_offline_reason": nil,
+			})
 		if result.Error != nil {
 			return WrapInternal("update node status failed", result.Error)
 		}
@@ -322,12 +327,17 @@ func (s *NodeService) AddBinding(ctx context.Context, cmd AddBindingCommand) err
 			return WrapInternal("get license failed", err)
 		}
 		license := ToEntityLicense(&pLicense)
+		if err := fillGracePolicies(ctx, tx, license); err != nil {
+			return WrapInternal("get grace policy failed", err)
+		}
 		switch license.CalculateStatus(time.Now()) {
 		case entity.StatusActive:
 		case entity.StatusInactive:
 			return ErrConflict("license not active")
 		case entity.StatusExpired:
 			return ErrConflict("license expired")
+		case entity.StatusGrace:
+			return ErrConflict("license in grace period")
 		case entity.StatusRevoked:
 			return ErrForbidden("invalid license")
 		}
@@ -344,7 +354,7 @@ func (s *NodeService) AddBinding(ctx context.Context, cmd AddBindingCommand) err
 			return ErrForbidden("invalid node")
 		}
 
-		_, err = bindNodeToLicense(ctx, tx, nodeID, license, license.ProductID)
+		_, err = bindNodeToLicense(ctx, tx, n, license, license.ProductID)
 		if err != nil {
 			return err
 		}
@@ -401,7 +411,7 @@ func (s *NodeService) AutoBind(ctx context.Context, cmd AutoBindCommand) error {
 		} else if !node.IsValid() {
 			return ErrForbidden("invalid node")
 		}
-		_, err = bindNodeToLicense(ctx, tx, node.ID, license, license.ProductID)
+		_, err = bindNodeToLicense(ctx, tx, node, license, license.ProductID)
 		if err != nil {
 			return err
 		}
@@ -414,52 +424,87 @@ func (s *NodeService) AutoBind(ctx context.Context, cmd AutoBindCommand) error {
 
 }
 
+// UnbindByID 解除节点与 License 的绑定
+// 未指定产品时解除该 License 在节点上所有产品的绑定
 func (s *NodeService) UnbindByID(ctx context.Context, cmd UnbindCommand) error {
 	nodeID, licenseID := cmd.NodeID, cmd.LicenseID
-	return global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	onlineKeys := make([]string, 0)
+	err := global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 绑定表没有租户字段，先按租户确认 License 和节点存在，防止跨租户解绑
+		if err := ensureLicenseExists(ctx, tx, licenseID); err != nil {
+			return err
+		}
+		if err := ensureNodeExists(ctx, tx, nodeID); err != nil {
+			return err
+		}
 		// 查找是否已有绑定关系
-		var binding model.NodeLicenseBinding
-		err := tx.Where("node_id = ? AND license_id = ?", nodeID, licenseID).
-			First(&binding).Error
-		if err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				return ErrNotFound("binding not found")
-			}
-			return WrapInternal("get binding failed", err)
+		query := tx.Where("node_id = ? AND license_id = ?", nodeID, licenseID)
+		if cmd.ProductID != 0 {
+			query = query.Where("product_id = ?", cmd.ProductID)
 		}
-		if binding.Status == 0 {
-			return nil
+		var bindings []model.NodeLicenseBinding
+		if err := query.Find(&bindings).Error; err != nil {
+			return WrapInternal("get binding failed", err)
 		}
-		if err := tx.Model(&binding).Updates(map[string]interface{}{
-			"status":     entity.BindingStatusUnbound,
-			"is_bound":   false,
-			"unbound_at": time.Now(),
-		}).Error; err != nil {
-			return WrapInternal("update binding failed", err)
+		if len(bindings) == 0 {
+			return ErrNotFound("binding not found")
 		}
-		if err := decrementLicenseNodeCount(ctx, tx, licenseID); err != nil {
-			return WrapInternal("update license node count failed", err)
+		for i := range bindings {
+			binding := &bindings[i]
+			if binding.Status == int(entity.BindingStatusUnbound) {
+				continue
+			}
+			// 待审批与已拒绝的申请不占用节点名额，撤销后设备可以重新申请
+			if binding.Status != int(entity.BindingStatusBound) {
+				if err := tx.Model(binding).Update("status", entity.BindingStatusUnbound).Error; err != nil {
+					return WrapInternal("update binding failed", err)
+				}
+				continue
+			}
+			if err := tx.Model(binding).Updates(map[string]interface{}{
+				"status":     entity.BindingStatusUnbound,
+				"is_bound":   false,
+				"unbound_at": time.Now(),
+			}).Error; err != nil {
+				return WrapInternal("update binding failed", err)
+			}
+			if err := decrementLicenseNodeCount(ctx, tx, licenseID); err != nil {
+				return WrapInternal("update license node count failed", err)
+			}
+			key, err := releaseBindingUsage(ctx, tx, nodeID, licenseID, binding.ProductID)
+			if err != nil {
+				return err
+			}
+			onlineKeys = append(onlineKeys, key)
+			recordAuditLog(ctx, tx, "node", nodeID, "unbind_license", map[string]interface{}{
+				"license_id": licenseID,
+				"product_id": binding.ProductID,
+			})
 		}
-		recordAuditLog(ctx, tx, "node", nodeID, "unbind_license", map[string]interface{}{
-			"license_id": licenseID,
-		})
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+	for _, key := range onlineKeys {
+		monitor.GlobalStat.RemoveOnlineNode(key)
+	}
+	return nil
 }
 
 func (s *NodeService) CleanUnboundNode(ctx context.Context) error {
 	return global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		// 1. 查出所有已绑定的节点 ID（status=1）
+		// 1. 查出所有已绑定或待审批的节点 ID，待审批的申请批准时仍需要节点
 		var boundNodeIDs []uint
 		if err := tx.Model(&model.NodeLicenseBinding{}).
-			Where("status = ?", entity.BindingStatusBound).
+			Where("status IN ?", []entity.BindingStatus{entity.BindingStatusBound, entity.BindingStatusPending}).
 			Pluck("node_id", &boundNodeIDs).Error; err != nil {
 			return err
 		}
 
-		// 2. 如果没有任何绑定，则删除所有节点
+		// 2. 如果没有任何绑定，则删除所有节点；预留给 License 的节点在设备首次连接前没有绑定，需要保留
 		if len(boundNodeIDs) == 0 {
-			result := tx.Delete(&model.Node{})
+			result := tx.Where("reserved_license_id IS NULL").Delete(&model.Node{})
 			if result.Error != nil {
 				return result.Error
 			}
@@ -469,8 +514,8 @@ func (s *NodeService) CleanUnboundNode(ctx context.Context) error {
 			return nil
 		}
 
-		// 3. 删除未绑定的节点
-		result := tx.Where("id NOT IN ?", boundNodeIDs).Delete(&model.Node{})
+		// 3. 删除未绑定且未预留的节点
+		result := tx.Where("id NOT IN ? AND reserved_license_id IS NULL", boundNodeIDs).Delete(&model.Node{})
 		if result.Error != nil {
 			return result.Error
 		}
@@ -482,6 +527,21 @@ func (s *NodeService) CleanUnboundNode(ctx context.Context) error {
 	})
 }
 
+// ensureNodeExists 按上下文租户确认节点存在
+func ensureNodeExists(ctx context.Context, db *gorm.DB, nodeID uint) error {
+	if nodeID == 0 {
+		return ErrBadRequest("node_id is required")
+	}
+	var count int64
+	if err := db.WithContext(ctx).Model(&model.Node{}).Where("id = ?", nodeID).Count(&count).Error; err != nil {
+		return WrapInternal("get node failed", err)
+	}
+	if count == 0 {
+		return ErrNotFound("node not found")
+	}
+	return nil
+}
+
 func restoreNodeOnline(ctx context.Context, db *gorm.DB, nodeID uint, reason *string, action string) error {
 	normalizedReason := normalizeOptionalReason(reason)
 	updates := map[string]interface{}{
@@ -520,12 +580,13 @@ func normalizeOptionalReason(reason *string) *string {
 
 func onlineKeysForNode(ctx context.Context, db *gorm.DB, nodeID uint, deviceCode string) ([]string, error) {
 	type bindingKey struct {
-		ProductID  uint
-		LicenseKey string
+		TenantID  uint
+		ProductID uint
+		KeyDigest string
 	}
 	var rows []bindingKey
 	if err := db.WithContext(ctx).Table("node_license_binding AS b").
-		Select("b.product_id, l.license_key").
+		Select("l.tenant_id, b.product_id, l.key_digest").
 		Joins("JOIN license AS l ON l.id = b.license_id").
 		Where("b.node_id = ? AND b.status = ? AND b.deleted_at IS NULL AND l.deleted_at IS NULL", nodeID, entity.BindingStatusBound).
 		Scan(&rows).Error; err != nil {
@@ -534,7 +595,7 @@ func onlineKeysForNode(ctx context.Context, db *gorm.DB, nodeID uint, deviceCode
 
 	keys := make([]string, 0, len(rows))
 	for _, row := range rows {
-		keys = append(keys, fmt.Sprintf("%d|%s|%s", row.ProductID, deviceCode, row.LicenseKey))
+		keys = append(keys, monitor.NewOnlineNodeKey(row.TenantID, row.ProductID, deviceCode, row.KeyDigest).Key())
 	}
 	return keys, nil
 }
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"nexus-core/persistence/model"
+)
+
+func TestOperatorIdentityRecordedOnModelsAndAuditLogs(t *testing.T) {
+	fixture := newFlowFixture(t, 1, 0, 24)
+	ctx := WithOperator(fixture.ctx, &OperatorIdentity{OperatorID: 7, Username: "alice", Role: RoleLicenseAdmin})
+
+	license, err := fixture.licenseService.CreateLicense(ctx, CreateLicenseCommand{
+		ProductID:     fixture.product.ID,
+		ValidityHours: 24,
+		MaxNodes:      1,
+	})
+	if err != nil {
+		t.Fatalf("create license: %v", err)
+	}
+	var stored model.License
+	if err := fixture.db.Where("id = ?", license.ID).First(&stored).Error; err != nil {
+		t.Fatalf("get license: %v", err)
+	}
+	if stored.CreatedBy != "alice" || stored.UpdatedBy != "alice" {
+		t.Fatalf("license should be created by alice, got %q/%q", stored.CreatedBy, stored.UpdatedBy)
+	}
+
+	bobCtx := model.WithOperator(fixture.ctx, "bob")
+	if err := fixture.licenseService.RevokeLicense(bobCtx, license.ID); err != nil {
+		t.Fatalf("revoke license: %v", err)
+	}
+	if err := fixture.db.Where("id = ?", license.ID).First(&stored).Error; err != nil {
+		t.Fatalf("get revoked license: %v", err)
+	}
+	if stored.CreatedBy != "alice" || stored.UpdatedBy != "bob" {
+		t.Fatalf("revoke should be recorded as bob, got %q/%q", stored.CreatedBy, stored.UpdatedBy)
+	}
+
+	auditService := NewAuditService()
+	operator := "bob"
+	logs, err := auditService.ListAuditLogs(fixture.ctx, ListAuditLogsCommand{Operator: &operator})
+	if err != nil {
+		t.Fatalf("list audit logs: %v", err)
+	}
+	if len(logs) == 0 {
+		t.Fatal("expected audit logs for bob")
+	}
+	for _, log := range logs {
+		if log.Operator != "bob" {
+			t.Fatalf("operator filter mismatch: %#v", log)
+		}
+	}
+	if logs[0].ResourceType != "license" || logs[0].Action != "revoke" {
+		t.Fatalf("latest bob audit log should be license revoke: %#v", logs[0])
+	}
+
+	system := model.SystemOperator
+	systemLogs, err := auditService.ListAuditLogs(fixture.ctx, ListAuditLogsCommand{Operator: &system})
+	if err != nil {
+		t.Fatalf("list system audit logs: %v", err)
+	}
+	for _, log := range systemLogs {
+		if log.ResourceType == "license" && log.ResourceID == license.ID {
+			t.Fatalf("license operations should not be recorded as system: %#v", log)
+		}
+	}
+}
+
+func TestOperatorTruncatedAtRuneBoundary(t *testing.T) {
+	// 63 个 ASCII 字符后接一个三字节字符，按字节截断会留下半个字符
+	name := strings.Repeat("a", 63) + "操作员"
+	operator := model.OperatorFromContext(model.WithOperator(context.Background(), name))
+	if !utf8.ValidString(operator) || operator != strings.Repeat("a", 63) {
+		t.Fatalf("operator should be truncated at a rune boundary, got %q", operator)
+	}
+	operator = model.OperatorFromContext(model.WithOperator(context.Background(), strings.Repeat("操", 30)))
+	if !utf8.ValidString(operator) || len(operator) != 63 {
+		t.Fatalf("operator should keep whole runes within 64 bytes, got %q (%d bytes)", operator, len(operator))
+	}
+}
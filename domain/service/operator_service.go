@@ -0,0 +1,463 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"nexus-core/global"
+	"nexus-core/persistence/model"
+
+	"gorm.io/gorm"
+)
+
+const (
+	RoleViewer       = "viewer"
+	RoleLicenseAdmin = "license-admin"
+	RoleNodeOperator = "node-operator"
+	RoleSuperAdmin   = "super-admin"
+)
+
+const (
+	OperatorStatusActive   = 1
+	OperatorStatusDisabled = 2
+)
+
+// Permission 管理接口权限
+type Permission string
+
+const (
+	PermissionRead           Permission = "read"            // 查询所有管理数据
+	PermissionLicenseManage  Permission = "license:manage"  // 产品、版本、License 与离线激活
+	PermissionNodeManage     Permission = "node:manage"     // 节点、绑定、控制服务与控制指令
+	PermissionOperatorManage Permission = "operator:manage" // 操作员、API Key 与签名密钥
+)
+
+var rolePermissions = map[string][]Permission{
+	RoleViewer:       {PermissionRead},
+	RoleLicenseAdmin: {PermissionRead, PermissionLicenseManage},
+	RoleNodeOperator: {PermissionRead, PermissionNodeManage},
+	RoleSuperAdmin:   {PermissionRead, PermissionLicenseManage, PermissionNodeManage, PermissionOperatorManage},
+}
+
+const (
+	apiKeyPrefix       = "nxk_"
+	apiKeyPrefixLength = 12
+	bootstrapUsername  = "admin"
+)
+
+// RolePermissions 返回角色拥有的权限，未知角色返回 nil
+func RolePermissions(role string) []Permission {
+	return rolePermissions[role]
+}
+
+// ValidRole 判断角色是否受支持
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// Can 判断操作员是否拥有指定权限
+func (i *OperatorIdentity) Can(permission Permission) bool {
+	if i == nil {
+		return false
+	}
+	for _, p := range RolePermissions(i.Role) {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+type operatorContextKey struct{}
+
+// WithOperator 将操作员身份写入上下文，用户名同时作为数据修改人与审计操作人
+func WithOperator(ctx context.Context, identity *OperatorIdentity) context.Context {
+	if identity == nil {
+		return ctx
+	}
+	ctx = model.WithOperator(ctx, identity.Username)
+	return context.WithValue(ctx, operatorContextKey{}, identity)
+}
+
+// OperatorFromContext 读取上下文中的操作员身份，未认证时返回 nil
+func OperatorFromContext(ctx context.Context) *OperatorIdentity {
+	if ctx == nil {
+		return nil
+	}
+	identity, _ := ctx.Value(operatorContextKey{}).(*OperatorIdentity)
+	return identity
+}
+
+// OperatorService 管理操作员账号与 API Key
+type OperatorService struct{}
+
+func NewOperatorService() *OperatorService {
+	return &OperatorService{}
+}
+
+func (s *OperatorService) CreateOperator(ctx context.Context, cmd CreateOperatorCommand) (*OperatorData, error) {
+	username := strings.TrimSpace(cmd.Username)
+	if username == "" {
+		return nil, ErrBadRequest("username is required")
+	}
+	if !ValidRole(cmd.Role) {
+		return nil, BadRequestf("invalid role %s", cmd.Role)
+	}
+	// 默认租户的操作员可以为任意租户创建操作员，其他租户只能创建本租户操作员
+	tenantID, _ := model.TenantFromContext(ctx)
+	if cmd.TenantID != model.DefaultTenantID && cmd.TenantID != tenantID {
+		if !isDefaultTenantContext(ctx) {
+			return nil, ErrForbidden("cannot create operator for another tenant")
+		}
+		tenantID = cmd.TenantID
+	}
+	operator := &model.Operator{
+		Username:    username,
+		DisplayName: cmd.DisplayName,
+		Role:        cmd.Role,
+		Status:      OperatorStatusActive,
+	}
+	err := global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if tenantID != model.DefaultTenantID {
+			if _, err := getTenant(ctx, tx, "id = ?", tenantID); err != nil {
+				return err
+			}
+		}
+		// 用户名全局唯一，作为操作人记录在各租户的数据中
+		var count int64
+		if err := tx.WithContext(model.WithoutTenantScope(ctx)).Model(&model.Operator{}).
+			Where("username = ?", username).Count(&count).Error; err != nil {
+			return WrapInternal("check operator username failed", err)
+		}
+		if count > 0 {
+			return ErrConflict("username already exists")
+		}
+		if err := tx.WithContext(model.WithTenant(ctx, tenantID)).Create(operator).Error; err != nil {
+			return WrapInternal("create operator failed", err)
+		}
+		recordAuditLog(ctx, tx, "operator", operator.ID, "create", map[string]interface{}{
+			"username": username,
+			"role":     cmd.Role,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	data := toOperatorData(operator)
+	return &data, nil
+}
+
+func (s *OperatorService) ListOperators(ctx context.Context) ([]OperatorData, error) {
+	var operators []model.Operator
+	if err := global.DB.WithContext(operatorScope(ctx)).Order("id ASC").Find(&operators).Error; err != nil {
+		return nil, WrapInternal("list operators failed", err)
+	}
+	data := make([]OperatorData, 0, len(operators))
+	for i := range operators {
+		data = append(data, toOperatorData(&operators[i]))
+	}
+	return data, nil
+}
+
+// UpdateOperator 修改操作员角色或状态
+// 不允许停用或降级租户内最后一个启用的超级管理员，避免管理后台被锁死
+func (s *OperatorService) UpdateOperator(ctx context.Context, cmd UpdateOperatorCommand) (*OperatorData, error) {
+	if cmd.Role != nil && !ValidRole(*cmd.Role) {
+		return nil, BadRequestf("invalid role %s", *cmd.Role)
+	}
+	if cmd.Status != nil && *cmd.Status != OperatorStatusActive && *cmd.Status != OperatorStatusDisabled {
+		return nil, ErrBadRequest("invalid status")
+	}
+	var operator model.Operator
+	err := global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		found, err := getOperator(ctx, tx, cmd.ID)
+		if err != nil {
+			return err
+		}
+		operator = *found
+		demoted := cmd.Role != nil && *cmd.Role != RoleSuperAdmin
+		disabled := cmd.Status != nil && *cmd.Status == OperatorStatusDisabled
+		if operator.Role == RoleSuperAdmin && operator.Status == OperatorStatusActive && (demoted || disabled) {
+			var others int64
+			if err := tx.WithContext(operatorScope(ctx)).Model(&model.Operator{}).
+				Where("tenant_id = ? AND role = ? AND status = ? AND id <> ?", operator.TenantID, RoleSuperAdmin, OperatorStatusActive, operator.ID).
+				Count(&others).Error; err != nil {
+				return WrapInternal("count super admins failed", err)
+			}
+			if others == 0 {
+				return ErrConflict("cannot demote or disable the last super admin")
+			}
+		}
+
+		updates := map[string]interface{}{}
+		if cmd.DisplayName != nil {
+			updates["display_name"] = *cmd.DisplayName
+		}
+		if cmd.Role != nil {
+			updates["role"] = *cmd.Role
+		}
+		if cmd.Status != nil {
+			updates["status"] = *cmd.Status
+		}
+		if len(updates) == 0 {
+			return nil
+		}
+		if err := tx.WithContext(operatorScope(ctx)).Model(&operator).Updates(updates).Error; err != nil {
+			return WrapInternal("update operator failed", err)
+		}
+		recordAuditLog(ctx, tx, "operator", operator.ID, "update", updates)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	data := toOperatorData(&operator)
+	return &data, nil
+}
+
+// CreateAPIKey 为操作员签发 API Key，明文只在本次返回
+func (s *OperatorService) CreateAPIKey(ctx context.Context, cmd CreateOperatorAPIKeyCommand) (*OperatorAPIKeyData, error) {
+	if cmd.ExpiresAt != nil && !cmd.ExpiresAt.After(time.Now()) {
+		return nil, ErrBadRequest("expires_at must be in the future")
+	}
+	var data *OperatorAPIKeyData
+	err := global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		operator, err := getOperator(ctx, tx, cmd.OperatorID)
+		if err != nil {
+			return err
+		}
+		key, raw, err := createOperatorAPIKey(ctx, tx, operator.ID, cmd.Name, cmd.ExpiresAt, "")
+		if err != nil {
+			return err
+		}
+		recordAuditLog(ctx, tx, "operator", operator.ID, "create_api_key", map[string]interface{}{
+			"api_key_id": key.ID,
+			"key_prefix": key.KeyPrefix,
+		})
+		keyData := toOperatorAPIKeyData(key)
+		keyData.Key = raw
+		data = &keyData
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (s *OperatorService) ListAPIKeys(ctx context.Context, operatorID uint) ([]OperatorAPIKeyData, error) {
+	if _, err := getOperator(ctx, global.DB, operatorID); err != nil {
+		return nil, err
+	}
+	var keys []model.OperatorAPIKey
+	if err := global.DB.WithContext(ctx).Where("operator_id = ?", operatorID).Order("id DESC").Find(&keys).Error; err != nil {
+		return nil, WrapInternal("list api keys failed", err)
+	}
+	data := make([]OperatorAPIKeyData, 0, len(keys))
+	for i := range keys {
+		data = append(data, toOperatorAPIKeyData(&keys[i]))
+	}
+	return data, nil
+}
+
+// RevokeAPIKey 吊销 API Key，吊销后立即无法认证
+func (s *OperatorService) RevokeAPIKey(ctx context.Context, keyID uint) error {
+	// API Key 不带租户，通过所属操作员校验租户
+	var key model.OperatorAPIKey
+	if err := global.DB.WithContext(ctx).Where("id = ?", keyID).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotFound("api key not found or already revoked")
+		}
+		return WrapInternal("get api key failed", err)
+	}
+	if _, err := getOperator(ctx, global.DB, key.OperatorID); err != nil {
+		if ErrorKindOf(err) == ErrorKindNotFound {
+			return ErrNotFound("api key not found or already revoked")
+		}
+		return err
+	}
+	now := time.Now()
+	result := global.DB.WithContext(ctx).Model(&model.OperatorAPIKey{}).
+		Where("id = ? AND revoked_at IS NULL", keyID).
+		Update("revoked_at", now)
+	if result.Error != nil {
+		return WrapInternal("revoke api key failed", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound("api key not found or already revoked")
+	}
+	recordAuditLog(ctx, global.DB.WithContext(ctx), "operator_api_key", keyID, "revoke", nil)
+	return nil
+}
+
+// Authenticate 校验 API Key 并返回操作员身份
+// Key 摘要全局唯一，查找时不限定租户，身份中的租户来自操作员
+func (s *OperatorService) Authenticate(ctx context.Context, rawKey string) (*OperatorIdentity, error) {
+	rawKey = strings.TrimSpace(rawKey)
+	if rawKey == "" {
+		return nil, ErrUnauthorized("api key is required")
+	}
+	db := global.DB.WithContext(model.WithoutTenantScope(ctx))
+	var key model.OperatorAPIKey
+	if err := db.Where("key_hash = ?", hashSecret(rawKey)).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUnauthorized("invalid api key")
+		}
+		return nil, WrapInternal("get api key failed", err)
+	}
+	now := time.Now()
+	if key.RevokedAt != nil {
+		return nil, ErrUnauthorized("api key revoked")
+	}
+	if key.ExpiresAt != nil && now.After(*key.ExpiresAt) {
+		return nil, ErrUnauthorized("api key expired")
+	}
+	var operator model.Operator
+	if err := db.Where("id = ?", key.OperatorID).First(&operator).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUnauthorized("operator not found")
+		}
+		return nil, WrapInternal("get operator failed", err)
+	}
+	if operator.Status != OperatorStatusActive {
+		return nil, ErrUnauthorized("operator disabled")
+	}
+	_ = db.Model(&model.OperatorAPIKey{}).Where("id = ?", key.ID).UpdateColumn("last_used_at", now).Error
+	return &OperatorIdentity{
+		OperatorID:  operator.ID,
+		TenantID:    operator.TenantID,
+		Username:    operator.Username,
+		Role:        operator.Role,
+		Permissions: RolePermissions(operator.Role),
+	}, nil
+}
+
+// EnsureBootstrapOperator 根据配置的初始 API Key 创建超级管理员
+// 已存在相同摘要的 Key 时不做任何修改，便于每次启动重复调用
+// Key 所属或同名的操作员已被禁用或不再是超级管理员时返回错误，不会恢复其权限
+func (s *OperatorService) EnsureBootstrapOperator(ctx context.Context, rawKey string) error {
+	rawKey = strings.TrimSpace(rawKey)
+	if rawKey == "" {
+		return nil
+	}
+	return global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var operator model.Operator
+		var key model.OperatorAPIKey
+		err := tx.Where("key_hash = ?", hashSecret(rawKey)).First(&key).Error
+		if err == nil {
+			if err := tx.Where("id = ?", key.OperatorID).First(&operator).Error; err != nil {
+				return WrapInternal("get bootstrap operator failed", err)
+			}
+			return checkBootstrapOperator(&operator)
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return WrapInternal("check bootstrap api key failed", err)
+		}
+		err = tx.Where("username = ?", bootstrapUsername).First(&operator).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			operator = model.Operator{
+				Username:    bootstrapUsername,
+				DisplayName: "Bootstrap Admin",
+				Role:        RoleSuperAdmin,
+				Status:      OperatorStatusActive,
+			}
+			if err := tx.Create(&operator).Error; err != nil {
+				return WrapInternal("create bootstrap operator failed", err)
+			}
+		} else if err != nil {
+			return WrapInternal("get bootstrap operator failed", err)
+		} else if err := checkBootstrapOperator(&operator); err != nil {
+			return err
+		}
+		if _, _, err := createOperatorAPIKey(ctx, tx, operator.ID, "bootstrap", nil, rawKey); err != nil {
+			return err
+		}
+		recordAuditLog(ctx, tx, "operator", operator.ID, "bootstrap", nil)
+		return nil
+	})
+}
+
+// checkBootstrapOperator 初始 API Key 只能属于启用的超级管理员
+func checkBootstrapOperator(operator *model.Operator) error {
+	if operator.Role != RoleSuperAdmin || operator.Status != OperatorStatusActive {
+		return Conflictf("bootstrap operator %q is not an active super admin", operator.Username)
+	}
+	return nil
+}
+
+// operatorScope 默认租户的操作员可以管理所有租户的操作员，其他租户只能管理本租户
+func operatorScope(ctx context.Context) context.Context {
+	if isDefaultTenantContext(ctx) {
+		return model.WithoutTenantScope(ctx)
+	}
+	return ctx
+}
+
+// getOperator 查询当前请求可管理的操作员
+func getOperator(ctx context.Context, db *gorm.DB, operatorID uint) (*model.Operator, error) {
+	var operator model.Operator
+	if err := db.WithContext(operatorScope(ctx)).Where("id = ?", operatorID).First(&operator).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound("operator not found")
+		}
+		return nil, WrapInternal("get operator failed", err)
+	}
+	return &operator, nil
+}
+
+// createOperatorAPIKey 保存 API Key 摘要，raw 为空时随机生成
+func createOperatorAPIKey(ctx context.Context, db *gorm.DB, operatorID uint, name string, expiresAt *time.Time, raw string) (*model.OperatorAPIKey, string, error) {
+	if raw == "" {
+		buf := make([]byte, 32)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, "", WrapInternal("generate api key failed", err)
+		}
+		raw = apiKeyPrefix + hex.EncodeToString(buf)
+	}
+	prefix := raw
+	if len(prefix) > apiKeyPrefixLength {
+		prefix = prefix[:apiKeyPrefixLength]
+	}
+	key := &model.OperatorAPIKey{
+		OperatorID: operatorID,
+		Name:       name,
+		KeyPrefix:  prefix,
+		KeyHash:    hashSecret(raw),
+		ExpiresAt:  expiresAt,
+	}
+	if err := db.WithContext(ctx).Create(key).Error; err != nil {
+		return nil, "", WrapInternal("create api key failed", err)
+	}
+	return key, raw, nil
+}
+
+func toOperatorData(m *model.Operator) OperatorData {
+	return OperatorData{
+		ID:          m.ID,
+		TenantID:    m.TenantID,
+		Username:    m.Username,
+		DisplayName: m.DisplayName,
+		Role:        m.Role,
+		Status:      m.Status,
+		CreatedAt:   m.CreatedAt,
+	}
+}
+
+func toOperatorAPIKeyData(m *model.OperatorAPIKey) OperatorAPIKeyData {
+	return OperatorAPIKeyData{
+		ID:         m.ID,
+		OperatorID: m.OperatorID,
+		Name:       m.Name,
+		KeyPrefix:  m.KeyPrefix,
+		LastUsedAt: m.LastUsedAt,
+		ExpiresAt:  m.ExpiresAt,
+		RevokedAt:  m.RevokedAt,
+		CreatedAt:  m.CreatedAt,
+	}
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOperatorAPIKeyAuthentication(t *testing.T) {
+	fixture := newFlowFixture(t, 1, 0, 24)
+	operatorService := NewOperatorService()
+
+	viewer, err := operatorService.CreateOperator(fixture.ctx, CreateOperatorCommand{Username: "viewer", Role: RoleViewer})
+	if err != nil {
+		t.Fatalf("create viewer: %v", err)
+	}
+	_, err = operatorService.CreateOperator(fixture.ctx, CreateOperatorCommand{Username: "viewer", Role: RoleViewer})
+	assertAppErrorKind(t, err, ErrorKindConflict)
+	_, err = operatorService.CreateOperator(fixture.ctx, CreateOperatorCommand{Username: "ghost", Role: "root"})
+	assertAppErrorKind(t, err, ErrorKindBadRequest)
+
+	key, err := operatorService.CreateAPIKey(fixture.ctx, CreateOperatorAPIKeyCommand{OperatorID: viewer.ID, Name: "ci"})
+	if err != nil {
+		t.Fatalf("create api key: %v", err)
+	}
+	if key.Key == "" || key.KeyPrefix == "" {
+		t.Fatalf("api key should be returned once with prefix: %#v", key)
+	}
+	listed, err := operatorService.ListAPIKeys(fixture.ctx, viewer.ID)
+	if err != nil || len(listed) != 1 || listed[0].Key != "" {
+		t.Fatalf("listed api keys should hide plaintext: %#v err=%v", listed, err)
+	}
+
+	identity, err := operatorService.Authenticate(fixture.ctx, key.Key)
+	if err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	if identity.Role != RoleViewer || !identity.Can(PermissionRead) || identity.Can(PermissionLicenseManage) {
+		t.Fatalf("viewer permissions mismatch: %#v", identity)
+	}
+
+	_, err = operatorService.Authenticate(fixture.ctx, key.Key+"x")
+	assertAppErrorKind(t, err, ErrorKindUnauthorized)
+
+	disabled := OperatorStatusDisabled
+	if _, err := operatorService.UpdateOperator(fixture.ctx, UpdateOperatorCommand{ID: viewer.ID, Status: &disabled}); err != nil {
+		t.Fatalf("disable operator: %v", err)
+	}
+	_, err = operatorService.Authenticate(fixture.ctx, key.Key)
+	assertAppErrorKind(t, err, ErrorKindUnauthorized)
+
+	active := OperatorStatusActive
+	if _, err := operatorService.UpdateOperator(fixture.ctx, UpdateOperatorCommand{ID: viewer.ID, Status: &active}); err != nil {
+		t.Fatalf("enable operator: %v", err)
+	}
+	if err := operatorService.RevokeAPIKey(fixture.ctx, key.ID); err != nil {
+		t.Fatalf("revoke api key: %v", err)
+	}
+	_, err = operatorService.Authenticate(fixture.ctx, key.Key)
+	assertAppErrorKind(t, err, ErrorKindUnauthorized)
+
+	past := time.Now().Add(-time.Minute)
+	_, err = operatorService.CreateAPIKey(fixture.ctx, CreateOperatorAPIKeyCommand{OperatorID: viewer.ID, ExpiresAt: &past})
+	assertAppErrorKind(t, err, ErrorKindBadRequest)
+}
+
+func TestBootstrapOperatorAndLastSuperAdmin(t *testing.T) {
+	fixture := newFlowFixture(t, 1, 0, 24)
+	operatorService := NewOperatorService()
+
+	for i := 0; i < 2; i++ {
+		if err := operatorService.EnsureBootstrapOperator(fixture.ctx, "bootstrap-secret"); err != nil {
+			t.Fatalf("bootstrap operator: %v", err)
+		}
+	}
+	operators, err := operatorService.ListOperators(fixture.ctx)
+	if err != nil || len(operators) != 1 || operators[0].Role != RoleSuperAdmin {
+		t.Fatalf("bootstrap should create a single super admin: %#v err=%v", operators, err)
+	}
+	identity, err := operatorService.Authenticate(fixture.ctx, "bootstrap-secret")
+	if err != nil || !identity.Can(PermissionOperatorManage) {
+		t.Fatalf("bootstrap key should authenticate as super admin: %#v err=%v", identity, err)
+	}
+
+	viewer := RoleViewer
+	_, err = operatorService.UpdateOperator(fixture.ctx, UpdateOperatorCommand{ID: operators[0].ID, Role: &viewer})
+	assertAppErrorKind(t, err, ErrorKindConflict)
+}
+
+func TestBootstrapOperatorRequiresActiveSuperAdmin(t *testing.T) {
+	fixture := newFlowFixture(t, 1, 0, 24)
+	operatorService := NewOperatorService()
+	if _, err := operatorService.CreateOperator(fixture.ctx, CreateOperatorCommand{Username: "root", Role: RoleSuperAdmin}); err != nil {
+		t.Fatalf("create super admin: %v", err)
+	}
+	admin, err := operatorService.CreateOperator(fixture.ctx, CreateOperatorCommand{Username: "admin", Role: RoleViewer})
+	if err != nil {
+		t.Fatalf("create admin: %v", err)
+	}
+
+	// 同名操作员不是超级管理员时不附加初始 Key
+	assertAppErrorKind(t, operatorService.EnsureBootstrapOperator(fixture.ctx, "bootstrap-secret"), ErrorKindConflict)
+	_, err = operatorService.Authenticate(fixture.ctx, "bootstrap-secret")
+	assertAppErrorKind(t, err, ErrorKindUnauthorized)
+
+	superAdmin := RoleSuperAdmin
+	if _, err := operatorService.UpdateOperator(fixture.ctx, UpdateOperatorCommand{ID: admin.ID, Role: &superAdmin}); err != nil {
+		t.Fatalf("promote admin: %v", err)
+	}
+	if err := operatorService.EnsureBootstrapOperator(fixture.ctx, "bootstrap-secret"); err != nil {
+		t.Fatalf("bootstrap operator: %v", err)
+	}
+
+	// 已附加初始 Key 的操作员被禁用后，启动时报错而不是静默恢复
+	disabled := OperatorStatusDisabled
+	if _, err := operatorService.UpdateOperator(fixture.ctx, UpdateOperatorCommand{ID: admin.ID, Status: &disabled}); err != nil {
+		t.Fatalf("disable admin: %v", err)
+	}
+	assertAppErrorKind(t, operatorService.EnsureBootstrapOperator(fixture.ctx, "bootstrap-secret"), ErrorKindConflict)
+}
@@ -0,0 +1,431 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"nexus-core/domain/entity"
+	"nexus-core/global"
+	"nexus-core/persistence/model"
+
+	"gorm.io/gorm"
+)
+
+const (
+	PlanStatusActive   = 1
+	PlanStatusDisabled = 2
+)
+
+// PlanService 管理订阅套餐，套餐是创建 License 时使用的模板
+// 修改套餐只影响之后创建或切换套餐的 License，不会回写已有 License
+type PlanService struct{}
+
+func NewPlanService() *PlanService {
+	return &PlanService{}
+}
+
+func (s *PlanService) CreatePlan(ctx context.Context, cmd CreatePlanCommand) (*PlanData, error) {
+	name := strings.TrimSpace(cmd.Name)
+	if name == "" {
+		return nil, ErrBadRequest("name is required")
+	}
+	if err := validatePlanLimits(cmd.ValidityHours, cmd.MaxNodes, cmd.MaxConcurrent); err != nil {
+		return nil, err
+	}
+	plan := &model.Plan{
+		ProductID:     cmd.ProductID,
+		Name:          name,
+		Description:   cmd.Description,
+		ValidityHours: cmd.ValidityHours,
+		MaxNodes:      cmd.MaxNodes,
+		MaxConcurrent: cmd.MaxConcurrent,
+		Status:        PlanStatusActive,
+	}
+	err := global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var product model.Product
+		if err := tx.Where("id = ?", cmd.ProductID).First(&product).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotFound("product not found")
+			}
+			return WrapInternal("get product failed", err)
+		}
+		services, err := normalizePlanServices(ctx, tx, cmd.ProductID, cmd.Services)
+		if err != nil {
+			return err
+		}
+		var count int64
+		if err := tx.Model(&model.Plan{}).Where("product_id = ? AND name = ?", cmd.ProductID, name).Count(&count).Error; err != nil {
+			return WrapInternal("check plan name failed", err)
+		}
+		if count > 0 {
+			return ErrConflict("plan name already exists")
+		}
+		if err := tx.Create(plan).Error; err != nil {
+			return WrapInternal("create plan failed", err)
+		}
+		if err := replacePlanServiceScopes(ctx, tx, plan.ID, services); err != nil {
+			return err
+		}
+		recordAuditLog(ctx, tx, "plan", plan.ID, "create", map[string]interface{}{
+			"product_id": plan.ProductID,
+			"name":       name,
+			"services":   services,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetPlan(ctx, plan.ID)
+}
+
+func (s *PlanService) GetPlan(ctx context.Context, id uint) (*PlanData, error) {
+	plan, err := getPlan(ctx, global.DB, id)
+	if err != nil {
+		return nil, err
+	}
+	services, err := planServices(ctx, global.DB, plan.ID)
+	if err != nil {
+		return nil, err
+	}
+	return toPlanData(plan, services), nil
+}
+
+func (s *PlanService) ListPlans(ctx context.Context, cmd ListPlansCommand) ([]PlanData, error) {
+	query := global.DB.WithContext(ctx).Model(&model.Plan{}).Order("id DESC")
+	if cmd.ProductID != nil {
+		query = query.Where("product_id = ?", *cmd.ProductID)
+	}
+	if cmd.Status != nil {
+		query = query.Where("status = ?", *cmd.Status)
+	}
+	if cmd.Limit > 0 {
+		query = query.Limit(cmd.Limit)
+	}
+	if cmd.Offset > 0 {
+		query = query.Offset(cmd.Offset)
+	}
+	var plans []model.Plan
+	if err := query.Find(&plans).Error; err != nil {
+		return nil, WrapInternal("list plans failed", err)
+	}
+	data := make([]PlanData, 0, len(plans))
+	for i := range plans {
+		services, err := planServices(ctx, global.DB, plans[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, *toPlanData(&plans[i], services))
+	}
+	return data, nil
+}
+
+func (s *PlanService) UpdatePlan(ctx context.Context, cmd UpdatePlanCommand) (*PlanData, error) {
+	if cmd.Status != nil && *cmd.Status != PlanStatusActive && *cmd.Status != PlanStatusDisabled {
+		return nil, ErrBadRequest("invalid status")
+	}
+	err := global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		plan, err := getPlan(ctx, tx, cmd.ID)
+		if err != nil {
+			return err
+		}
+		validity, maxNodes, maxConcurrent := plan.ValidityHours, plan.MaxNodes, plan.MaxConcurrent
+		if cmd.ValidityHours != nil {
+			validity = *cmd.ValidityHours
+		}
+		if cmd.MaxNodes != nil {
+			maxNodes = *cmd.MaxNodes
+		}
+		if cmd.MaxConcurrent != nil {
+			maxConcurrent = *cmd.MaxConcurrent
+		}
+		if err := validatePlanLimits(validity, maxNodes, maxConcurrent); err != nil {
+			return err
+		}
+		updates := map[string]interface{}{
+			"validity_hours": validity,
+			"max_nodes":      maxNodes,
+			"max_concurrent": maxConcurrent,
+		}
+		if cmd.Name != nil {
+			name := strings.TrimSpace(*cmd.Name)
+			if name == "" {
+				return ErrBadRequest("name must not be empty")
+			}
+			var count int64
+			if err := tx.Model(&model.Plan{}).
+				Where("product_id = ? AND name = ? AND id <> ?", plan.ProductID, name, plan.ID).
+				Count(&count).Error; err != nil {
+				return WrapInternal("check plan name failed", err)
+			}
+			if count > 0 {
+				return ErrConflict("plan name already exists")
+			}
+			updates["name"] = name
+		}
+		if cmd.Description != nil {
+			updates["description"] = cmd.Description
+		}
+		if cmd.Status != nil {
+			updates["status"] = *cmd.Status
+		}
+		if err := tx.Model(plan).Updates(updates).Error; err != nil {
+			return WrapInternal("update plan failed", err)
+		}
+		auditData := updates
+		if cmd.Services != nil {
+			services, err := normalizePlanServices(ctx, tx, plan.ProductID, *cmd.Services)
+			if err != nil {
+				return err
+			}
+			if err := replacePlanServiceScopes(ctx, tx, plan.ID, services); err != nil {
+				return err
+			}
+			auditData["services"] = services
+		}
+		recordAuditLog(ctx, tx, "plan", plan.ID, "update", auditData)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetPlan(ctx, cmd.ID)
+}
+
+// ChangeLicensePlan 将 License 切换到另一个套餐
+// 在同一事务中更新有效期、节点数、并发数和服务范围，已激活的 License 按新有效期重新计算过期时间
+func (s *LicenseService) ChangeLicensePlan(ctx context.Context, cmd ChangeLicensePlanCommand) (*LicenseData, error) {
+	if cmd.LicenseID == 0 {
+		return nil, ErrBadRequest("id is required")
+	}
+	if cmd.PlanID == 0 {
+		return nil, ErrBadRequest("plan_id is required")
+	}
+	err := global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var license model.License
+		if err := tx.Where("id = ?", cmd.LicenseID).First(&license).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotFound("license not found")
+			}
+			return WrapInternal("get license failed", err)
+		}
+		template, err := loadPlanTemplate(ctx, tx, &cmd.PlanID, license.ProductID)
+		if err != nil {
+			return err
+		}
+		plan := template.plan
+		// 试用许可证切换套餐同样受续期上限约束
+		if license.Type == int(entity.LicenseTypeTrial) && plan.ValidityHours > license.TrialMaxHours {
+			return Conflictf("trial license cannot be renewed beyond %d hours", license.TrialMaxHours)
+		}
+		if plan.MaxNodes > 0 && license.CurrentNodeCount > plan.MaxNodes {
+			return Conflictf("license has %d bound nodes, plan allows %d", license.CurrentNodeCount, plan.MaxNodes)
+		}
+
+		updates := map[string]interface{}{
+			"plan_id":        plan.ID,
+			"validity_hours": plan.ValidityHours,
+			"max_nodes":      plan.MaxNodes,
+			"max_concurrent": plan.MaxConcurrent,
+		}
+		// 永久授权不受套餐有效期影响
+		if license.Perpetual {
+			delete(updates, "validity_hours")
+		} else if license.ActivatedAt != nil {
+			updates["expired_at"] = license.ActivatedAt.Add(time.Duration(plan.ValidityHours) * time.Hour)
+		}
+		before := licenseStateOf(ToEntityLicense(&license))
+		// Updates 会把新值写回 license（包括 PlanID 指向的值），先复制原套餐
+		var fromPlanID *uint
+		if license.PlanID != nil {
+			id := *license.PlanID
+			fromPlanID = &id
+		}
+		if err := tx.Model(&license).Updates(updates).Error; err != nil {
+			return WrapInternal("update license plan failed", err)
+		}
+		if err := replaceLicenseServiceScopes(ctx, tx, license.ID, template.services); err != nil {
+			return err
+		}
+		recordAuditLog(ctx, tx, "license", license.ID, "change_plan", map[string]interface{}{
+			"from_plan_id":   fromPlanID,
+			"to_plan_id":     plan.ID,
+			"validity_hours": plan.ValidityHours,
+			"max_nodes":      plan.MaxNodes,
+			"max_concurrent": plan.MaxConcurrent,
+			"services":       template.services,
+		})
+		after := before
+		after.MaxNodes, after.MaxConcurrent = plan.MaxNodes, plan.MaxConcurrent
+		if expiredAt, ok := updates["expired_at"].(time.Time); ok {
+			after.ExpiredAt = &expiredAt
+		}
+		return recordLicenseHistory(ctx, tx, licenseChange{
+			LicenseID: license.ID,
+			Event:     LicenseEventChangePlan,
+			Before:    before,
+			After:     after,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetLicenseDataByID(ctx, cmd.LicenseID)
+}
+
+// planTemplate 创建或切换 License 时使用的套餐及其服务范围
+type planTemplate struct {
+	plan     *model.Plan
+	services []string
+}
+
+// applyDefaults 用套餐默认值填充未填写（为 0）的限制
+func (t *planTemplate) applyDefaults(validityHours *int, maxNodes *int, maxConcurrent *int) {
+	if t == nil {
+		return
+	}
+	if *validityHours == 0 {
+		*validityHours = t.plan.ValidityHours
+	}
+	if *maxNodes == 0 {
+		*maxNodes = t.plan.MaxNodes
+	}
+	if *maxConcurrent == 0 {
+		*maxConcurrent = t.plan.MaxConcurrent
+	}
+}
+
+// loadPlanTemplate 加载启用的套餐，planID 为空时返回 nil
+// productID 不为 0 时校验套餐属于该产品
+func loadPlanTemplate(ctx context.Context, db *gorm.DB, planID *uint, productID uint) (*planTemplate, error) {
+	if planID == nil {
+		return nil, nil
+	}
+	plan, err := getPlan(ctx, db, *planID)
+	if err != nil {
+		if ErrorKindOf(err) == ErrorKindNotFound {
+			return nil, BadRequestf("plan %d not found", *planID)
+		}
+		return nil, err
+	}
+	if plan.Status != PlanStatusActive {
+		return nil, ErrBadRequest("plan is disabled")
+	}
+	if productID != 0 && plan.ProductID != productID {
+		return nil, ErrBadRequest("plan does not belong to product")
+	}
+	services, err := planServices(ctx, db, plan.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &planTemplate{plan: plan, services: services}, nil
+}
+
+func getPlan(ctx context.Context, db *gorm.DB, id uint) (*model.Plan, error) {
+	var plan model.Plan
+	if err := db.WithContext(ctx).Where("id = ?", id).First(&plan).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound("plan not found")
+		}
+		return nil, WrapInternal("get plan failed", err)
+	}
+	return &plan, nil
+}
+
+func planServices(ctx context.Context, db *gorm.DB, planID uint) ([]string, error) {
+	var services []string
+	if err := db.WithContext(ctx).Model(&model.PlanServiceScope{}).
+		Where("plan_id = ?", planID).Order("id ASC").
+		Pluck("service_identifier", &services).Error; err != nil {
+		return nil, WrapInternal("list plan services failed", err)
+	}
+	return services, nil
+}
+
+// normalizePlanServices 去重并校验控制服务存在且适用于套餐所属产品
+func normalizePlanServices(ctx context.Context, db *gorm.DB, productID uint, services []string) ([]string, error) {
+	result := make([]string, 0, len(services))
+	seen := make(map[string]bool, len(services))
+	for _, identifier := range services {
+		identifier = strings.TrimSpace(identifier)
+		if identifier == "" || seen[identifier] {
+			continue
+		}
+		seen[identifier] = true
+		var count int64
+		if err := db.WithContext(ctx).Model(&model.ControlService{}).
+			Where("identifier = ? AND (product_id IS NULL OR product_id = ?)", identifier, productID).
+			Count(&count).Error; err != nil {
+			return nil, WrapInternal("check control service failed", err)
+		}
+		if count == 0 {
+			return nil, BadRequestf("control service %s not found for product", identifier)
+		}
+		result = append(result, identifier)
+	}
+	return result, nil
+}
+
+func replacePlanServiceScopes(ctx context.Context, tx *gorm.DB, planID uint, services []string) error {
+	if err := tx.WithContext(ctx).Unscoped().Where("plan_id = ?", planID).Delete(&model.PlanServiceScope{}).Error; err != nil {
+		return WrapInternal("clear plan services failed", err)
+	}
+	for _, identifier := range services {
+		scope := model.PlanServiceScope{PlanID: planID, ServiceIdentifier: identifier}
+		if err := tx.WithContext(ctx).Create(&scope).Error; err != nil {
+			return WrapInternal("create plan service failed", err)
+		}
+	}
+	return nil
+}
+
+// replaceLicenseServiceScopes 以套餐的服务列表覆盖 License 的服务范围，空列表表示不限制
+func replaceLicenseServiceScopes(ctx context.Context, tx *gorm.DB, licenseID uint, services []string) error {
+	if err := tx.WithContext(ctx).Unscoped().Where("license_id = ?", licenseID).Delete(&model.LicenseServiceScope{}).Error; err != nil {
+		return WrapInternal("clear license service scopes failed", err)
+	}
+	for _, identifier := range services {
+		scope := model.LicenseServiceScope{
+			LicenseID:         licenseID,
+			ServiceIdentifier: identifier,
+			Status:            int(entity.ScopeStatusEnabled),
+		}
+		if err := tx.WithContext(ctx).Create(&scope).Error; err != nil {
+			return WrapInternal("create license service scope failed", err)
+		}
+	}
+	return nil
+}
+
+func validatePlanLimits(validityHours int, maxNodes int, maxConcurrent int) error {
+	if validityHours <= 0 {
+		return ErrBadRequest("validity_hours must be greater than 0")
+	}
+	if maxNodes < 0 {
+		return ErrBadRequest("max_nodes must be greater than or equal to 0")
+	}
+	if maxConcurrent < 0 {
+		return ErrBadRequest("max_concurrent must be greater than or equal to 0")
+	}
+	return nil
+}
+
+func toPlanData(m *model.Plan, services []string) *PlanData {
+	if services == nil {
+		services = []string{}
+	}
+	return &PlanData{
+		ID:            m.ID,
+		ProductID:     m.ProductID,
+		Name:          m.Name,
+		Description:   m.Description,
+		ValidityHours: m.ValidityHours,
+		MaxNodes:      m.MaxNodes,
+		MaxConcurrent: m.MaxConcurrent,
+		Services:      services,
+		Status:        m.Status,
+		CreatedAt:     m.CreatedAt,
+	}
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+
+	"nexus-core/domain/entity"
+	"nexus-core/persistence/model"
+)
+
+func TestPlanTemplatesAndChangePlan(t *testing.T) {
+	fixture := newFlowFixture(t, 2, 0, 24)
+	planService := NewPlanService()
+	controlService := NewControlService()
+	for _, identifier := range []string{"restart_process", "collect_logs"} {
+		if _, err := controlService.CreateControlService(fixture.ctx, CreateControlServiceCommand{
+			ProductID:    &fixture.product.ID,
+			Identifier:   identifier,
+			Name:         identifier,
+			ServiceType:  "command",
+			InputSchema:  json.RawMessage(`{"type":"object"}`),
+			OutputSchema: json.RawMessage(`{"type":"object"}`),
+		}); err != nil {
+			t.Fatalf("create control service %s: %v", identifier, err)
+		}
+	}
+
+	_, err := planService.CreatePlan(fixture.ctx, CreatePlanCommand{
+		ProductID:     fixture.product.ID,
+		Name:          "bad",
+		ValidityHours: 24,
+		Services:      []string{"missing_service"},
+	})
+	assertAppErrorKind(t, err, ErrorKindBadRequest)
+
+	basic, err := planService.CreatePlan(fixture.ctx, CreatePlanCommand{
+		ProductID:     fixture.product.ID,
+		Name:          "basic",
+		ValidityHours: 720,
+		MaxNodes:      1,
+		MaxConcurrent: 1,
+		Services:      []string{"restart_process", "restart_process"},
+	})
+	if err != nil {
+		t.Fatalf("create basic plan: %v", err)
+	}
+	if len(basic.Services) != 1 {
+		t.Fatalf("duplicate services should be merged: %#v", basic.Services)
+	}
+	_, err = planService.CreatePlan(fixture.ctx, CreatePlanCommand{ProductID: fixture.product.ID, Name: "basic", ValidityHours: 1})
+	assertAppErrorKind(t, err, ErrorKindConflict)
+
+	pro, err := planService.CreatePlan(fixture.ctx, CreatePlanCommand{
+		ProductID:     fixture.product.ID,
+		Name:          "pro",
+		ValidityHours: 8760,
+		MaxNodes:      5,
+		MaxConcurrent: 3,
+		Services:      []string{"restart_process", "collect_logs"},
+	})
+	if err != nil {
+		t.Fatalf("create pro plan: %v", err)
+	}
+
+	license, err := fixture.licenseService.CreateLicense(fixture.ctx, CreateLicenseCommand{
+		ProductID: fixture.product.ID,
+		PlanID:    &basic.ID,
+	})
+	if err != nil {
+		t.Fatalf("create license from plan: %v", err)
+	}
+	if license.PlanID == nil || *license.PlanID != basic.ID || license.ValidityHours != 720 || license.MaxNodes != 1 {
+		t.Fatalf("license should take plan defaults: %#v", license)
+	}
+	assertLicenseScopes(t, fixture, license.ID, 1)
+
+	batch, err := fixture.licenseService.BatchCreateLicenses(fixture.ctx, BatchCreateLicenseCommand{
+		ProductID: fixture.product.ID,
+		PlanID:    &pro.ID,
+		MaxNodes:  10,
+		Count:     2,
+	})
+	if err != nil {
+		t.Fatalf("batch create from plan: %v", err)
+	}
+	if len(batch) != 2 || batch[0].MaxNodes != 10 || batch[0].ValidityHours != 8760 {
+		t.Fatalf("explicit limits should override plan defaults: %#v", batch)
+	}
+	assertLicenseScopes(t, fixture, batch[0].ID, 2)
+
+	// 节点数超过目标套餐限制时不允许降级
+	fixture.register(t, "device-a")
+	fixture.register(t, "device-b")
+	_, err = fixture.licenseService.ChangeLicensePlan(fixture.ctx, ChangeLicensePlanCommand{LicenseID: fixture.license.ID, PlanID: basic.ID})
+	assertAppErrorKind(t, err, ErrorKindConflict)
+
+	changed, err := fixture.licenseService.ChangeLicensePlan(fixture.ctx, ChangeLicensePlanCommand{LicenseID: license.ID, PlanID: pro.ID})
+	if err != nil {
+		t.Fatalf("change plan: %v", err)
+	}
+	if changed.PlanID == nil || *changed.PlanID != pro.ID || changed.MaxNodes != 5 || changed.MaxConcurrent != 3 || changed.ValidityHours != 8760 {
+		t.Fatalf("license should take new plan limits: %#v", changed)
+	}
+	assertLicenseScopes(t, fixture, license.ID, 2)
+
+	var audits []model.AuditLog
+	fixture.db.Where("resource_type = ? AND resource_id = ? AND action = ?", "license", license.ID, "change_plan").Find(&audits)
+	if len(audits) != 1 {
+		t.Fatalf("change plan should be audited once, got %d", len(audits))
+	}
+	var auditData struct {
+		FromPlanID *uint `json:"from_plan_id"`
+		ToPlanID   uint  `json:"to_plan_id"`
+	}
+	if err := json.Unmarshal(audits[0].Data, &auditData); err != nil {
+		t.Fatalf("decode change plan audit: %v", err)
+	}
+	if auditData.FromPlanID == nil || *auditData.FromPlanID != basic.ID || auditData.ToPlanID != pro.ID {
+		t.Fatalf("change plan audit should record %d -> %d: %s", basic.ID, pro.ID, audits[0].Data)
+	}
+
+	disabled := PlanStatusDisabled
+	if _, err := planService.UpdatePlan(fixture.ctx, UpdatePlanCommand{ID: basic.ID, Status: &disabled}); err != nil {
+		t.Fatalf("disable plan: %v", err)
+	}
+	_, err = fixture.licenseService.CreateLicense(fixture.ctx, CreateLicenseCommand{ProductID: fixture.product.ID, PlanID: &basic.ID})
+	assertAppErrorKind(t, err, ErrorKindBadRequest)
+
+	active := PlanStatusActive
+	plans, err := planService.ListPlans(fixture.ctx, ListPlansCommand{ProductID: &fixture.product.ID, Status: &active})
+	if err != nil || len(plans) != 1 || plans[0].ID != pro.ID {
+		t.Fatalf("list active plans: %#v err=%v", plans, err)
+	}
+}
+
+func TestChangePlanRespectsTrialCap(t *testing.T) {
+	fixture := newFlowFixture(t, 1, 0, 24)
+	planService := NewPlanService()
+	yearly, err := planService.CreatePlan(fixture.ctx, CreatePlanCommand{
+		ProductID:     fixture.product.ID,
+		Name:          "yearly",
+		ValidityHours: 8760,
+	})
+	if err != nil {
+		t.Fatalf("create yearly plan: %v", err)
+	}
+	short, err := planService.CreatePlan(fixture.ctx, CreatePlanCommand{
+		ProductID:     fixture.product.ID,
+		Name:          "short",
+		ValidityHours: 48,
+	})
+	if err != nil {
+		t.Fatalf("create short plan: %v", err)
+	}
+	trial, err := fixture.licenseService.CreateLicense(fixture.ctx, CreateLicenseCommand{
+		ProductID:     fixture.product.ID,
+		Type:          int(entity.LicenseTypeTrial),
+		ValidityHours: 24,
+		TrialMaxHours: 72,
+	})
+	if err != nil {
+		t.Fatalf("create trial: %v", err)
+	}
+
+	// 切换到超过试用上限的套餐与续期一样被拒绝，有效期保持不变
+	_, err = fixture.licenseService.ChangeLicensePlan(fixture.ctx, ChangeLicensePlanCommand{LicenseID: trial.ID, PlanID: yearly.ID})
+	assertAppErrorKind(t, err, ErrorKindConflict)
+	var current model.License
+	if err := fixture.db.Where("id = ?", trial.ID).First(&current).Error; err != nil || current.ValidityHours != 24 || current.PlanID != nil {
+		t.Fatalf("rejected plan change should not touch the trial: %#v %v", current, err)
+	}
+
+	changed, err := fixture.licenseService.ChangeLicensePlan(fixture.ctx, ChangeLicensePlanCommand{LicenseID: trial.ID, PlanID: short.ID})
+	if err != nil || changed.ValidityHours != 48 || changed.Type != int(entity.LicenseTypeTrial) {
+		t.Fatalf("plan within trial cap should apply: %#v %v", changed, err)
+	}
+}
+
+func assertLicenseScopes(t *testing.T, fixture *flowFixture, licenseID uint, want int64) {
+	t.Helper()
+	var count int64
+	if err := fixture.db.Model(&model.LicenseServiceScope{}).Where("license_id = ?", licenseID).Count(&count).Error; err != nil {
+		t.Fatalf("count scopes: %v", err)
+	}
+	if count != want {
+		t.Fatalf("license %d should have %d scopes, got %d", licenseID, want, count)
+	}
+}
@@ -26,9 +26,27 @@ func NewProductService() *ProductService {
 // CreateProduct 创建新产品
 // 包括产品基本信息和版本列表的持久化存储
 func (s *ProductService) CreateProduct(ctx context.Context, cmd CreateProductCommand) (*ProductData, error) {
+	if cmd.GracePeriodHours < 0 {
+		return nil, ErrBadRequest("grace_period_hours must be greater than or equal to 0")
+	}
+	if cmd.InactiveUnbindDays < 0 {
+		return nil, ErrBadRequest("inactive_unbind_days must be greater than or equal to 0")
+	}
 	pProduct := &model.Product{
-		Name:        cmd.Name,
-		Description: cmd.Description,
+		Name:               cmd.Name,
+		Description:        cmd.Description,
+		GracePeriodHours:   cmd.GracePeriodHours,
+		GraceAllowControl:  cmd.GraceAllowControl,
+		InactiveUnbindDays: cmd.InactiveUnbindDays,
+	}
+	if cmd.KeyFormat != nil {
+		if err := cmd.KeyFormat.Validate(); err != nil {
+			return nil, ErrBadRequest(err.Error())
+		}
+		pProduct.KeyPrefix = cmd.KeyFormat.Prefix
+		pProduct.KeyGroups = cmd.KeyFormat.Groups
+		pProduct.KeyGroupSize = cmd.KeyFormat.GroupSize
+		pProduct.KeyCheckDigit = cmd.KeyFormat.CheckDigit
 	}
 	err := productRepo.Create(ctx, global.DB.WithContext(ctx), pProduct)
 	if err != nil {
@@ -43,6 +61,10 @@ func (s *ProductService) CreateProduct(ctx context.Context, cmd CreateProductCom
 		Description:           pProduct.Description,
 		Status:                pProduct.Status,
 		MinSupportedVersionID: pProduct.MinSupportedVersionID,
+		GracePeriodHours:      pProduct.GracePeriodHours,
+		GraceAllowControl:     pProduct.GraceAllowControl,
+		InactiveUnbindDays:    pProduct.InactiveUnbindDays,
+		KeyFormat:             productKeyFormat(pProduct),
 		Versions:              []ProductVersionData{},
 	}, nil
 }
@@ -107,6 +129,10 @@ func (s *ProductService) ListProducts(ctx context.Context, cmd ListProductsComma
 			Description:           products[i].Description,
 			Status:                products[i].Status,
 			MinSupportedVersionID: products[i].MinSupportedVersionID,
+			GracePeriodHours:      products[i].GracePeriodHours,
+			GraceAllowControl:     products[i].GraceAllowControl,
+			InactiveUnbindDays:    products[i].InactiveUnbindDays,
+			KeyFormat:             productKeyFormat(&products[i]),
 			Versions:              versionsByProduct[products[i].ID],
 		})
 	}
@@ -129,6 +155,30 @@ func (s *ProductService) UpdateProduct(ctx context.Context, cmd UpdateProductCom
 	if cmd.Description != nil {
 		updates["description"] = cmd.Description
 	}
+	if cmd.GracePeriodHours != nil {
+		if *cmd.GracePeriodHours < 0 {
+			return nil, ErrBadRequest("grace_period_hours must be greater than or equal to 0")
+		}
+		updates["grace_period_hours"] = *cmd.GracePeriodHours
+	}
+	if cmd.GraceAllowControl != nil {
+		updates["grace_allow_control"] = *cmd.GraceAllowControl
+	}
+	if cmd.InactiveUnbindDays != nil {
+		if *cmd.InactiveUnbindDays < 0 {
+			return nil, ErrBadRequest("inactive_unbind_days must be greater than or equal to 0")
+		}
+		updates["inactive_unbind_days"] = *cmd.InactiveUnbindDays
+	}
+	if cmd.KeyFormat != nil {
+		if err := cmd.KeyFormat.Validate(); err != nil {
+			return nil, ErrBadRequest(err.Error())
+		}
+		updates["key_prefix"] = cmd.KeyFormat.Prefix
+		updates["key_groups"] = cmd.KeyFormat.Groups
+		updates["key_group_size"] = cmd.KeyFormat.GroupSize
+		updates["key_check_digit"] = cmd.KeyFormat.CheckDigit
+	}
 	if len(updates) == 0 {
 		return nil, ErrBadRequest("no product fields to update")
 	}
@@ -192,6 +242,13 @@ func (s *ProductService) DeleteProduct(ctx context.Context, id uint) error {
 		if licenseCount > 0 {
 			return ErrConflict("product has licenses")
 		}
+		var scopeCount int64
+		if err := tx.Model(&model.LicenseProductScope{}).Where("product_id = ?", id).Count(&scopeCount).Error; err != nil {
+			return WrapInternal("count product license scopes failed", err)
+		}
+		if scopeCount > 0 {
+			return ErrConflict("product is authorized by licenses")
+		}
 
 		var controlServiceCount int64
 		if err := tx.Model(&model.ControlService{}).Where("product_id = ?", id).Count(&controlServiceCount).Error; err != nil {
@@ -204,6 +261,9 @@ func (s *ProductService) DeleteProduct(ctx context.Context, id uint) error {
 		if err := tx.Where("product_id = ?", id).Delete(&model.ProductVersion{}).Error; err != nil {
 			return WrapInternal("delete product versions failed", err)
 		}
+		if err := tx.Where("product_id = ?", id).Delete(&model.ProductFeature{}).Error; err != nil {
+			return WrapInternal("delete product features failed", err)
+		}
 		if err := tx.Delete(&product).Error; err != nil {
 			return WrapInternal("delete product failed", err)
 		}
@@ -275,6 +335,10 @@ func (s *ProductService) ReleaseVersion(ctx context.Context, cmd ReleaseNewVersi
 	if cmd.ProductID == 0 {
 		return ErrBadRequest("product_id is required")
 	}
+	// 版本表不带租户字段，先按租户确认产品归属
+	if err := ensureProduct(ctx, global.DB, cmd.ProductID); err != nil {
+		return err
+	}
 	versionID, releaseDate := cmd.VersionID, cmd.ReleaseDate
 	if releaseDate == nil {
 		return s.doReleaseVersion(ctx, global.DB.WithContext(ctx), cmd.ProductID, versionID, time.Now())
@@ -488,6 +552,10 @@ func (s *ProductService) productDataFromModel(ctx context.Context, db *gorm.DB,
 		Description:           product.Description,
 		Status:                product.Status,
 		MinSupportedVersionID: product.MinSupportedVersionID,
+		GracePeriodHours:      product.GracePeriodHours,
+		GraceAllowControl:     product.GraceAllowControl,
+		InactiveUnbindDays:    product.InactiveUnbindDays,
+		KeyFormat:             productKeyFormat(product),
 		Versions:              make([]ProductVersionData, 0, len(versions)),
 	}
 	for i := range versions {
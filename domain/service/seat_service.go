@@ -0,0 +1,420 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"nexus-core/domain/entity"
+	"nexus-core/global"
+	"nexus-core/monitor"
+	"nexus-core/persistence/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CheckoutSeat 为节点申请浮动席位
+// 节点已持有席位时直接续期；席位占满时节点进入该产品的等待队列并返回排队位置，
+// 席位释放后按排队先后分配，排队节点需在排队超时前再次申请
+func (s *AccessService) CheckoutSeat(ctx context.Context, cmd SeatCheckoutCommand) (*SeatLeaseData, error) {
+	ttl, err := seatTTL(cmd.TTLSeconds)
+	if err != nil {
+		return nil, err
+	}
+	access, err := authorizeBoundNode(ctx, cmd.AccessCommand)
+	if err != nil {
+		return nil, err
+	}
+	license, node, productID := access.license, access.node, cmd.ProductID
+	now := time.Now()
+	expiresAt := seatExpiresAt(license, now, ttl)
+
+	var result *SeatLeaseData
+	err = global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockSeatPool(ctx, tx, license.ID, now); err != nil {
+			return err
+		}
+
+		var seat model.SeatLease
+		err := tx.Where("license_id = ? AND product_id = ? AND node_id = ?", license.ID, productID, node.ID).First(&seat).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return WrapInternal("get seat lease failed", err)
+		}
+		if err == nil {
+			if err := tx.Model(&seat).Update("expires_at", expiresAt).Error; err != nil {
+				return WrapInternal("renew seat lease failed", err)
+			}
+			result, err = seatUsage(ctx, tx, access, productID)
+			if err != nil {
+				return err
+			}
+			result.SeatsInUse++
+			result.Granted, result.SeatID, result.ExpiresAt = true, seat.ID, &expiresAt
+			return nil
+		}
+
+		usage, err := seatUsage(ctx, tx, access, productID)
+		if err != nil {
+			return err
+		}
+		var waiter model.SeatWaiter
+		err = tx.Where("license_id = ? AND product_id = ? AND node_id = ?", license.ID, productID, node.ID).First(&waiter).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return WrapInternal("get seat waiter failed", err)
+		}
+		ahead := tx.Model(&model.SeatWaiter{}).Where("license_id = ? AND product_id = ?", license.ID, productID)
+		if waiter.ID != 0 {
+			ahead = ahead.Where("id < ?", waiter.ID)
+		}
+		var waitersAhead int64
+		if err := ahead.Count(&waitersAhead).Error; err != nil {
+			return WrapInternal("count seat waiters failed", err)
+		}
+
+		// 空闲席位需要先留给排在前面的节点；已在线的节点本就占用名额，直接转为席位
+		online := monitor.GlobalStat.HasOnlineNode(monitor.NewOnlineNodeKey(license.TenantID, productID, node.DeviceCode, license.KeyDigest).Key())
+		if online || usage.MaxSeats == 0 || int64(usage.MaxSeats-usage.SeatsInUse) > waitersAhead {
+			seat = model.SeatLease{LicenseID: license.ID, ProductID: productID, NodeID: node.ID, ExpiresAt: expiresAt}
+			if err := tx.Create(&seat).Error; err != nil {
+				return WrapInternal("create seat lease failed", err)
+			}
+			if waiter.ID != 0 {
+				if err := tx.Unscoped().Delete(&waiter).Error; err != nil {
+					return WrapInternal("delete seat waiter failed", err)
+				}
+			}
+			recordAuditLog(ctx, tx, "node", node.ID, "seat_checkout", map[string]interface{}{
+				"license_id": license.ID,
+				"product_id": productID,
+				"seat_id":    seat.ID,
+				"expires_at": expiresAt,
+			})
+			usage.SeatsInUse++
+			usage.Granted, usage.SeatID, usage.ExpiresAt = true, seat.ID, &expiresAt
+			result = usage
+			return nil
+		}
+
+		queueTimeout := global.GetConfig().Seat.QueueTimeoutSeconds
+		waitUntil := now.Add(time.Duration(queueTimeout) * time.Second)
+		if waiter.ID == 0 {
+			waiter = model.SeatWaiter{LicenseID: license.ID, ProductID: productID, NodeID: node.ID, ExpiresAt: waitUntil}
+			if err := tx.Create(&waiter).Error; err != nil {
+				return WrapInternal("create seat waiter failed", err)
+			}
+		} else if err := tx.Model(&waiter).Update("expires_at", waitUntil).Error; err != nil {
+			return WrapInternal("refresh seat waiter failed", err)
+		}
+		usage.QueuePosition = int(waitersAhead) + 1
+		usage.RetryAfter = max(queueTimeout/2, 1)
+		result = usage
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// RenewSeat 续期节点持有的浮动席位，席位已过期时需重新申请
+func (s *AccessService) RenewSeat(ctx context.Context, cmd SeatCheckoutCommand) (*SeatLeaseData, error) {
+	ttl, err := seatTTL(cmd.TTLSeconds)
+	if err != nil {
+		return nil, err
+	}
+	access, err := authorizeBoundNode(ctx, cmd.AccessCommand)
+	if err != nil {
+		return nil, err
+	}
+	license, node, productID := access.license, access.node, cmd.ProductID
+	now := time.Now()
+	expiresAt := seatExpiresAt(license, now, ttl)
+
+	var result *SeatLeaseData
+	err = global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var seat model.SeatLease
+		if err := tx.Where("license_id = ? AND product_id = ? AND node_id = ?", license.ID, productID, node.ID).
+			First(&seat).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotFound("seat lease not found")
+			}
+			return WrapInternal("get seat lease failed", err)
+		}
+		if !seat.ExpiresAt.After(now) {
+			return ErrConflict("seat lease expired")
+		}
+		if err := tx.Model(&seat).Update("expires_at", expiresAt).Error; err != nil {
+			return WrapInternal("renew seat lease failed", err)
+		}
+		usage, err := seatUsage(ctx, tx, access, productID)
+		if err != nil {
+			return err
+		}
+		usage.SeatsInUse++
+		usage.Granted, usage.SeatID, usage.ExpiresAt = true, seat.ID, &expiresAt
+		result = usage
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// CheckinSeat 立即释放节点持有的浮动席位，节点在排队时同时退出队列
+// 只校验节点密钥，License 失效后也可以归还席位
+func (s *AccessService) CheckinSeat(ctx context.Context, cmd AccessCommand) error {
+	node, err := GetNodeEntityByCode(ctx, global.DB.WithContext(ctx), cmd.DeviceCode)
+	if err != nil {
+		return WrapInternal("get node failed", err)
+	}
+	if node == nil {
+		return ErrNotFound("node not found")
+	}
+	if err := verifyNodeCredential(node, cmd.NodeSecret); err != nil {
+		return err
+	}
+	license, err := GetLicenseEntityByKey(ctx, global.DB.WithContext(ctx), cmd.LicenseKey)
+	if err != nil {
+		return WrapInternal("get license failed", err)
+	}
+	if license == nil {
+		return ErrBadRequest("invalid license")
+	}
+
+	return global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		const query = "license_id = ? AND product_id = ? AND node_id = ?"
+		released := tx.Unscoped().Where(query, license.ID, cmd.ProductID, node.ID).Delete(&model.SeatLease{})
+		if released.Error != nil {
+			return WrapInternal("release seat lease failed", released.Error)
+		}
+		dequeued := tx.Unscoped().Where(query, license.ID, cmd.ProductID, node.ID).Delete(&model.SeatWaiter{})
+		if dequeued.Error != nil {
+			return WrapInternal("delete seat waiter failed", dequeued.Error)
+		}
+		if released.RowsAffected == 0 && dequeued.RowsAffected == 0 {
+			return ErrNotFound("seat lease not found")
+		}
+		if released.RowsAffected > 0 {
+			recordAuditLog(ctx, tx, "node", node.ID, "seat_checkin", map[string]interface{}{
+				"license_id": license.ID,
+				"product_id": cmd.ProductID,
+			})
+		}
+		return nil
+	})
+}
+
+// ListLicenseSeats 查询 License 当前占用的浮动席位与排队节点
+func (s *LicenseService) ListLicenseSeats(ctx context.Context, licenseID uint) (*LicenseSeatsData, error) {
+	if licenseID == 0 {
+		return nil, ErrBadRequest("id is required")
+	}
+	db := global.DB.WithContext(ctx)
+	license, err := GetLicenseEntityByID(ctx, db, licenseID)
+	if err != nil {
+		return nil, WrapInternal("get license failed", err)
+	}
+	if license == nil {
+		return nil, ErrNotFound("license not found")
+	}
+	now := time.Now()
+	var seats []model.SeatLease
+	if err := db.Where("license_id = ? AND expires_at > ?", licenseID, now).
+		Order("product_id ASC, id ASC").Find(&seats).Error; err != nil {
+		return nil, WrapInternal("list seat leases failed", err)
+	}
+	var waiters []model.SeatWaiter
+	if err := db.Where("license_id = ? AND expires_at > ?", licenseID, now).
+		Order("product_id ASC, id ASC").Find(&waiters).Error; err != nil {
+		return nil, WrapInternal("list seat waiters failed", err)
+	}
+
+	nodeIDs := make([]uint, 0, len(seats)+len(waiters))
+	for _, seat := range seats {
+		nodeIDs = append(nodeIDs, seat.NodeID)
+	}
+	for _, waiter := range waiters {
+		nodeIDs = append(nodeIDs, waiter.NodeID)
+	}
+	deviceCodes := make(map[uint]string, len(nodeIDs))
+	if len(nodeIDs) > 0 {
+		var nodes []model.Node
+		if err := db.Select("id", "device_code").Where("id IN ?", nodeIDs).Find(&nodes).Error; err != nil {
+			return nil, WrapInternal("list nodes failed", err)
+		}
+		for _, node := range nodes {
+			deviceCodes[node.ID] = node.DeviceCode
+		}
+	}
+
+	data := &LicenseSeatsData{
+		LicenseID:     licenseID,
+		MaxConcurrent: license.MaxConcurrent,
+		Seats:         make([]SeatData, 0, len(seats)),
+		Queue:         make([]SeatWaiterData, 0, len(waiters)),
+	}
+	for _, seat := range seats {
+		data.Seats = append(data.Seats, SeatData{
+			ID:         seat.ID,
+			ProductID:  seat.ProductID,
+			NodeID:     seat.NodeID,
+			DeviceCode: deviceCodes[seat.NodeID],
+			ExpiresAt:  seat.ExpiresAt,
+			CreatedAt:  seat.CreatedAt,
+		})
+	}
+	positions := make(map[uint]int)
+	for _, waiter := range waiters {
+		positions[waiter.ProductID]++
+		data.Queue = append(data.Queue, SeatWaiterData{
+			ProductID:  waiter.ProductID,
+			NodeID:     waiter.NodeID,
+			DeviceCode: deviceCodes[waiter.NodeID],
+			Position:   positions[waiter.ProductID],
+			ExpiresAt:  waiter.ExpiresAt,
+		})
+	}
+	return data, nil
+}
+
+// ReleaseSeat 管理员强制释放 License 的浮动席位
+func (s *LicenseService) ReleaseSeat(ctx context.Context, licenseID uint, seatID uint) error {
+	if licenseID == 0 || seatID == 0 {
+		return ErrBadRequest("id and seat_id are required")
+	}
+	return global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := ensureLicenseExists(ctx, tx, licenseID); err != nil {
+			return err
+		}
+		var seat model.SeatLease
+		if err := tx.Where("id = ? AND license_id = ?", seatID, licenseID).First(&seat).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotFound("seat lease not found")
+			}
+			return WrapInternal("get seat lease failed", err)
+		}
+		if err := tx.Unscoped().Delete(&seat).Error; err != nil {
+			return WrapInternal("release seat lease failed", err)
+		}
+		recordAuditLog(ctx, tx, "license", licenseID, "release_seat", map[string]interface{}{
+			"seat_id":    seatID,
+			"node_id":    seat.NodeID,
+			"product_id": seat.ProductID,
+		})
+		return nil
+	})
+}
+
+// lockSeatPool 锁定 License 以串行化席位分配，并清理过期的席位与排队记录
+func lockSeatPool(ctx context.Context, tx *gorm.DB, licenseID uint, now time.Time) error {
+	var license model.License
+	if err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+		Select("id").Where("id = ?", licenseID).First(&license).Error; err != nil {
+		return WrapInternal("lock license failed", err)
+	}
+	if err := tx.WithContext(ctx).Unscoped().Where("license_id = ? AND expires_at <= ?", licenseID, now).
+		Delete(&model.SeatLease{}).Error; err != nil {
+		return WrapInternal("purge expired seat leases failed", err)
+	}
+	if err := tx.WithContext(ctx).Unscoped().Where("license_id = ? AND expires_at <= ?", licenseID, now).
+		Delete(&model.SeatWaiter{}).Error; err != nil {
+		return WrapInternal("purge expired seat waiters failed", err)
+	}
+	return nil
+}
+
+// seatUsage 统计除申请节点外的并发占用，License 总并发与产品并发限制中剩余最少的一项起限制作用
+func seatUsage(ctx context.Context, tx *gorm.DB, access *boundNodeAccess, productID uint) (*SeatLeaseData, error) {
+	licenseUsers, productUsers, err := concurrentUsage(ctx, tx, access.license, productID, access.node, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	usage := &SeatLeaseData{}
+	if limit := access.license.MaxConcurrent; limit > 0 {
+		usage.MaxSeats, usage.SeatsInUse = limit, licenseUsers
+	}
+	if access.scope != nil && access.scope.MaxConcurrent > 0 {
+		limit := access.scope.MaxConcurrent
+		if usage.MaxSeats == 0 || limit-productUsers < usage.MaxSeats-usage.SeatsInUse {
+			usage.MaxSeats, usage.SeatsInUse = limit, productUsers
+		}
+	}
+	return usage, nil
+}
+
+// concurrentUsage 统计 License 的并发占用，返回 License 合计与指定产品的占用数
+// 心跳在线的节点与持有席位的节点共用 MaxConcurrent 名额，同一节点在同一产品上既在线又持有席位只计一次；
+// 不统计 except 节点在该产品上的占用，便于判断其能否占用新名额
+func concurrentUsage(ctx context.Context, db *gorm.DB, license *entity.License, productID uint, except *entity.Node, now time.Time) (int, int, error) {
+	type user struct {
+		productID  uint
+		deviceCode string
+	}
+	users := make(map[user]struct{})
+	for _, key := range monitor.GlobalStat.Snapshot() {
+		if key.TenantID == license.TenantID && key.KeyDigest == license.KeyDigest {
+			users[user{key.ProductID, key.DeviceCode}] = struct{}{}
+		}
+	}
+
+	var seats []model.SeatLease
+	if err := db.WithContext(ctx).Select("product_id", "node_id").
+		Where("license_id = ? AND expires_at > ?", license.ID, now).
+		Find(&seats).Error; err != nil {
+		return 0, 0, WrapInternal("list seat leases failed", err)
+	}
+	if len(seats) > 0 {
+		nodeIDs := make([]uint, 0, len(seats))
+		for _, seat := range seats {
+			nodeIDs = append(nodeIDs, seat.NodeID)
+		}
+		var nodes []model.Node
+		if err := db.WithContext(ctx).Select("id", "device_code").Where("id IN ?", nodeIDs).
+			Find(&nodes).Error; err != nil {
+			return 0, 0, WrapInternal("list nodes failed", err)
+		}
+		deviceCodes := make(map[uint]string, len(nodes))
+		for _, node := range nodes {
+			deviceCodes[node.ID] = node.DeviceCode
+		}
+		for _, seat := range seats {
+			users[user{seat.ProductID, deviceCodes[seat.NodeID]}] = struct{}{}
+		}
+	}
+
+	if except != nil {
+		delete(users, user{productID, except.DeviceCode})
+	}
+	productUsers := 0
+	for u := range users {
+		if u.productID == productID {
+			productUsers++
+		}
+	}
+	return len(users), productUsers, nil
+}
+
+func seatTTL(ttlSeconds int) (time.Duration, error) {
+	cfg := global.GetConfig().Seat
+	if ttlSeconds < 0 {
+		return 0, ErrBadRequest("ttl_seconds must not be negative")
+	}
+	if ttlSeconds == 0 {
+		ttlSeconds = cfg.TTLSeconds
+	}
+	if ttlSeconds > cfg.MaxTTLSeconds {
+		return 0, BadRequestf("ttl_seconds must not exceed %d", cfg.MaxTTLSeconds)
+	}
+	return time.Duration(ttlSeconds) * time.Second, nil
+}
+
+// seatExpiresAt 席位租约不超过 License 的服务截止时间和计划吊销时间
+func seatExpiresAt(license *entity.License, now time.Time, ttl time.Duration) time.Time {
+	expiresAt := now.Add(ttl)
+	if endsAt := license.AccessEndsAt(); endsAt != nil && expiresAt.After(*endsAt) {
+		expiresAt = *endsAt
+	}
+	return expiresAt
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"nexus-core/persistence/model"
+)
+
+func TestFloatingSeats(t *testing.T) {
+	fixture := newFlowFixture(t, 3, 1, 24)
+	for _, device := range []string{"device-a", "device-b", "device-c"} {
+		fixture.register(t, device)
+	}
+	seatCommand := func(device string, ttl int) SeatCheckoutCommand {
+		return SeatCheckoutCommand{
+			AccessCommand: AccessCommand{
+				DeviceCode:  device,
+				LicenseKey:  fixture.license.LicenseKey,
+				ProductID:   fixture.product.ID,
+				VersionCode: "1.0.0",
+				NodeSecret:  fixture.secrets[device],
+			},
+			TTLSeconds: ttl,
+		}
+	}
+	checkout := func(device string) *SeatLeaseData {
+		t.Helper()
+		seat, err := fixture.accessService.CheckoutSeat(fixture.ctx, seatCommand(device, 0))
+		if err != nil {
+			t.Fatalf("checkout %s: %v", device, err)
+		}
+		return seat
+	}
+
+	if seat := checkout("device-a"); !seat.Granted || seat.SeatID == 0 || seat.MaxSeats != 1 || seat.SeatsInUse != 1 {
+		t.Fatalf("device-a should get the seat: %#v", seat)
+	}
+	if seat := checkout("device-b"); seat.Granted || seat.QueuePosition != 1 || seat.RetryAfter == 0 {
+		t.Fatalf("device-b should be queued first: %#v", seat)
+	}
+	if seat := checkout("device-c"); seat.Granted || seat.QueuePosition != 2 {
+		t.Fatalf("device-c should be queued second: %#v", seat)
+	}
+	if seat := checkout("device-a"); !seat.Granted {
+		t.Fatalf("checkout by the holder should renew its seat: %#v", seat)
+	}
+
+	_, err := fixture.accessService.CheckoutSeat(fixture.ctx, seatCommand("device-a", 86400))
+	assertAppErrorKind(t, err, ErrorKindBadRequest)
+	_, err = fixture.accessService.RenewSeat(fixture.ctx, seatCommand("device-b", 0))
+	assertAppErrorKind(t, err, ErrorKindNotFound)
+	renewed, err := fixture.accessService.RenewSeat(fixture.ctx, seatCommand("device-a", 600))
+	if err != nil || !renewed.Granted || time.Until(*renewed.ExpiresAt) < 9*time.Minute {
+		t.Fatalf("renew seat: %#v %v", renewed, err)
+	}
+
+	// 释放后席位先分配给排在前面的节点
+	if err := fixture.accessService.CheckinSeat(fixture.ctx, seatCommand("device-a", 0).AccessCommand); err != nil {
+		t.Fatalf("checkin: %v", err)
+	}
+	err = fixture.accessService.CheckinSeat(fixture.ctx, seatCommand("device-a", 0).AccessCommand)
+	assertAppErrorKind(t, err, ErrorKindNotFound)
+	if seat := checkout("device-c"); seat.Granted || seat.QueuePosition != 2 {
+		t.Fatalf("device-c must wait behind device-b: %#v", seat)
+	}
+	if seat := checkout("device-b"); !seat.Granted {
+		t.Fatalf("device-b should get the released seat: %#v", seat)
+	}
+	if seat := checkout("device-c"); seat.QueuePosition != 1 {
+		t.Fatalf("device-c should move up the queue: %#v", seat)
+	}
+
+	seats, err := fixture.licenseService.ListLicenseSeats(fixture.ctx, fixture.license.ID)
+	if err != nil || len(seats.Seats) != 1 || seats.Seats[0].DeviceCode != "device-b" || len(seats.Queue) != 1 {
+		t.Fatalf("list seats: %#v %v", seats, err)
+	}
+
+	// 过期未续期的席位自动释放
+	if err := fixture.db.Model(&model.SeatLease{}).Where("license_id = ?", fixture.license.ID).
+		Update("expires_at", time.Now().Add(-time.Second)).Error; err != nil {
+		t.Fatalf("expire seat: %v", err)
+	}
+	if seat := checkout("device-c"); !seat.Granted {
+		t.Fatalf("expired seat should be reclaimed: %#v", seat)
+	}
+	if err := fixture.licenseService.ReleaseSeat(fixture.ctx, fixture.license.ID, seats.Seats[0].ID); err == nil {
+		t.Fatalf("releasing a purged seat should fail")
+	}
+}
+
+func TestFloatingSeatsShareConcurrentLimit(t *testing.T) {
+	fixture := newFlowFixture(t, 3, 1, 24)
+	for _, device := range []string{"device-a", "device-b", "device-c"} {
+		fixture.register(t, device)
+	}
+	checkout := func(device string) *SeatLeaseData {
+		t.Helper()
+		seat, err := fixture.accessService.CheckoutSeat(fixture.ctx, SeatCheckoutCommand{AccessCommand: AccessCommand{
+			DeviceCode:  device,
+			LicenseKey:  fixture.license.LicenseKey,
+			ProductID:   fixture.product.ID,
+			VersionCode: "1.0.0",
+			NodeSecret:  fixture.secrets[device],
+		}})
+		if err != nil {
+			t.Fatalf("checkout %s: %v", device, err)
+		}
+		return seat
+	}
+
+	// 在线节点占用的并发名额不能再分配给其他节点的席位
+	if _, err := fixture.heartbeat("device-a"); err != nil {
+		t.Fatalf("heartbeat device-a: %v", err)
+	}
+	if seat := checkout("device-b"); seat.Granted || seat.SeatsInUse != 1 {
+		t.Fatalf("device-b should wait while device-a is online: %#v", seat)
+	}
+	if seat := checkout("device-a"); !seat.Granted || seat.SeatsInUse != 1 {
+		t.Fatalf("online node should take a seat without a second slot: %#v", seat)
+	}
+	if _, err := fixture.heartbeat("device-a"); err != nil {
+		t.Fatalf("seat holder heartbeat: %v", err)
+	}
+
+	// 持有席位的节点同样占用心跳的并发名额
+	_, err := fixture.heartbeat("device-c")
+	assertAppErrorKind(t, err, ErrorKindConflict)
+}
@@ -43,41 +43,123 @@ func GetLicenseEntityByKey(ctx context.Context, db *gorm.DB, key string) (*entit
 	if pLicense == nil {
 		return nil, nil
 	}
-	return hydrateLicenseEntity(ctx, db, pLicense)
+	license, err := hydrateLicenseEntity(ctx, db, pLicense)
+	if err != nil {
+		return nil, err
+	}
+	license.LicenseKey = key
+	return license, nil
 }
 
 func ToEntityLicense(pLicense *model.License) *entity.License {
 	return &entity.License{
-		ID:               pLicense.ID,
-		ProductID:        pLicense.ProductID,
-		LicenseKey:       pLicense.LicenseKey,
-		ValidityHours:    pLicense.ValidityHours,
-		IssuedAt:         pLicense.CreatedAt,
-		ActivatedAt:      pLicense.ActivatedAt,
-		ExpiredAt:        pLicense.ExpiredAt,
-		Status:           entity.LicenseStatus(pLicense.Status),
-		Remark:           pLicense.Remark,
-		MaxNodes:         pLicense.MaxNodes,
-		CurrentNodeCount: pLicense.CurrentNodeCount,
-		MaxConcurrent:    pLicense.MaxConcurrent,
-		FeatureMask:      pLicense.FeatureMask,
+		ID:                 pLicense.ID,
+		TenantID:           pLicense.TenantID,
+		ProductID:          pLicense.ProductID,
+		CustomerID:         pLicense.CustomerID,
+		PlanID:             pLicense.PlanID,
+		Type:               entity.LicenseType(pLicense.Type),
+		TrialMaxHours:      pLicense.TrialMaxHours,
+		ConvertedAt:        pLicense.ConvertedAt,
+		GracePeriodHours:   pLicense.GracePeriodHours,
+		InactiveUnbindDays: pLicense.InactiveUnbindDays,
+		ApprovalRequired:   pLicense.ApprovalRequired,
+		Transfer: entity.TransferPolicy{
+			Limit:           pLicense.TransferLimit,
+			WindowDays:      pLicense.TransferWindowDays,
+			CooldownMinutes: pLicense.RebindCooldownMinutes,
+			ResetAt:         pLicense.TransferResetAt,
+		},
+		KeyDigest:         pLicense.KeyDigest,
+		KeyPrefix:         pLicense.KeyPrefix,
+		ValidityHours:     pLicense.ValidityHours,
+		Perpetual:         pLicense.Perpetual,
+		VersionCutoffAt:   pLicense.VersionCutoffAt,
+		MinVersionID:      pLicense.MinVersionID,
+		MaxVersionID:      pLicense.MaxVersionID,
+		IssuedAt:          pLicense.CreatedAt,
+		ActivatedAt:       pLicense.ActivatedAt,
+		ExpiredAt:         pLicense.ExpiredAt,
+		NotBefore:         pLicense.NotBefore,
+		ScheduledRevokeAt: pLicense.ScheduledRevokeAt,
+		RevokedAt:         pLicense.RevokedAt,
+		Status:            entity.LicenseStatus(pLicense.Status),
+		Remark:            pLicense.Remark,
+		MaxNodes:          pLicense.MaxNodes,
+		CurrentNodeCount:  pLicense.CurrentNodeCount,
+		MaxConcurrent:     pLicense.MaxConcurrent,
+		FeatureMask:       pLicense.FeatureMask,
 	}
 }
 
 func hydrateLicenseEntity(ctx context.Context, db *gorm.DB, pLicense *model.License) (*entity.License, error) {
 	license := ToEntityLicense(pLicense)
-	currentStatus := license.CalculateStatus(time.Now())
+	if err := fillGracePolicies(ctx, db, license); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	currentStatus := license.CalculateStatus(now)
 	if currentStatus != license.Status {
+		before := licenseStateOf(license)
+		occurredAt := statusTransitionAt(license, currentStatus, now)
 		license.Status = currentStatus
-		if err := db.WithContext(ctx).Model(&model.License{}).
-			Where("id = ?", license.ID).
-			Update("status", int(currentStatus)).Error; err != nil {
-			return nil, err
+		// 并发校正同一状态时只有一方写入历史
+		result := db.WithContext(ctx).Model(&model.License{}).
+			Where("id = ? AND status = ?", license.ID, int(before.Status)).
+			Update("status", int(currentStatus))
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected > 0 {
+			event := LicenseEventStatusChange
+			if currentStatus == entity.StatusGrace || currentStatus == entity.StatusExpired {
+				event = LicenseEventExpire
+			}
+			if err := recordLicenseHistory(ctx, db, licenseChange{
+				LicenseID:  license.ID,
+				Event:      event,
+				Before:     before,
+				After:      licenseStateOf(license),
+				OccurredAt: occurredAt,
+			}); err != nil {
+				return nil, err
+			}
 		}
 	}
 	return license, nil
 }
 
+// fillGracePolicies 根据产品设置填充许可证的宽限期策略
+// 许可证单独设置的宽限时长优先于产品默认值，控制命令限制始终取产品设置
+func fillGracePolicies(ctx context.Context, db *gorm.DB, licenses ...*entity.License) error {
+	productIDs := make([]uint, 0, len(licenses))
+	for _, license := range licenses {
+		productIDs = append(productIDs, license.ProductID)
+	}
+	if len(productIDs) == 0 {
+		return nil
+	}
+	var products []model.Product
+	if err := db.WithContext(ctx).Select("id", "grace_period_hours", "grace_allow_control").
+		Where("id IN ?", productIDs).Find(&products).Error; err != nil {
+		return err
+	}
+	policies := make(map[uint]entity.GracePolicy, len(products))
+	for _, product := range products {
+		policies[product.ID] = entity.GracePolicy{
+			Hours:        product.GracePeriodHours,
+			AllowControl: product.GraceAllowControl,
+		}
+	}
+	for _, license := range licenses {
+		license.Grace = policies[license.ProductID]
+		if license.GracePeriodHours != nil {
+			license.Grace.Hours = *license.GracePeriodHours
+		}
+	}
+	return nil
+}
+
 // GetNodeEntityByID 获取node实体
 func GetNodeEntityByID(ctx context.Context, db *gorm.DB, id uint) (*entity.Node, error) {
 	pNode, err := nodeRepo.GetByID(ctx, db, id)
@@ -105,10 +187,13 @@ func GetNodeEntityByCode(ctx context.Context, db *gorm.DB, code string) (*entity
 func ToEntityNode(pNode *model.Node) *entity.Node {
 	metadata := string(pNode.Metadata)
 	return &entity.Node{
-		ID:         pNode.ID,
-		DeviceCode: pNode.DeviceCode,
-		Status:     pNode.Status,
-		Metadata:   &metadata,
+		ID:                  pNode.ID,
+		DeviceCode:          pNode.DeviceCode,
+		Status:              pNode.Status,
+		Metadata:            &metadata,
+		CredentialHash:      pNode.CredentialHash,
+		CredentialRevokedAt: pNode.CredentialRevokedAt,
+		ReservedLicenseID:   pNode.ReservedLicenseID,
 	}
 }
 
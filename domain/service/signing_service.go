@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"nexus-core/domain/entity"
+	"nexus-core/global"
+	"nexus-core/licensefile"
+	"nexus-core/licensekey"
+	"nexus-core/persistence/model"
+
+	"gorm.io/gorm"
+)
+
+const (
+	SigningKeyStatusActive  = 1
+	SigningKeyStatusRetired = 2
+)
+
+// SigningService 管理服务端 Ed25519 签名密钥
+type SigningService struct{}
+
+func NewSigningService() *SigningService {
+	return &SigningService{}
+}
+
+// ListKeys 返回所有签名密钥的公钥信息，客户端据此配置可信密钥
+func (s *SigningService) ListKeys(ctx context.Context) ([]SigningKeyData, error) {
+	var keys []model.SigningKey
+	if err := global.DB.WithContext(ctx).Order("id DESC").Find(&keys).Error; err != nil {
+		return nil, WrapInternal("list signing keys failed", err)
+	}
+	data := make([]SigningKeyData, 0, len(keys))
+	for i := range keys {
+		data = append(data, toSigningKeyData(&keys[i]))
+	}
+	return data, nil
+}
+
+// RotateKey 停用当前签名密钥并生成新密钥
+func (s *SigningService) RotateKey(ctx context.Context) (*SigningKeyData, error) {
+	// 签名密钥由所有租户共享，只允许默认租户轮换
+	if !isDefaultTenantContext(ctx) {
+		return nil, ErrForbidden("only default tenant operators can rotate signing keys")
+	}
+	var created *model.SigningKey
+	err := global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.SigningKey{}).
+			Where("status = ?", SigningKeyStatusActive).
+			Update("status", SigningKeyStatusRetired).Error; err != nil {
+			return WrapInternal("retire signing key failed", err)
+		}
+		key, err := createSigningKey(ctx, tx)
+		if err != nil {
+			return err
+		}
+		created = key
+		recordAuditLog(ctx, tx, "signing_key", key.ID, "rotate", map[string]interface{}{
+			"key_id": key.KeyID,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	data := toSigningKeyData(created)
+	return &data, nil
+}
+
+// EnsureActiveKey 不存在启用的签名密钥时生成一个，服务启动时调用
+// 签发时不再按需生成，避免并发的首次签发各自生成一个启用密钥
+func (s *SigningService) EnsureActiveKey(ctx context.Context) error {
+	var count int64
+	if err := global.DB.WithContext(ctx).Model(&model.SigningKey{}).
+		Where("status = ?", SigningKeyStatusActive).
+		Count(&count).Error; err != nil {
+		return WrapInternal("check signing key failed", err)
+	}
+	if count > 0 {
+		return nil
+	}
+	_, err := createSigningKey(ctx, global.DB)
+	return err
+}
+
+// activeSigningKey 返回当前启用的签名密钥
+func activeSigningKey(ctx context.Context, db *gorm.DB) (string, ed25519.PrivateKey, error) {
+	var key model.SigningKey
+	err := db.WithContext(ctx).
+		Where("status = ?", SigningKeyStatusActive).
+		Order("id DESC").
+		First(&key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil, ErrInternal("no active signing key")
+	} else if err != nil {
+		return "", nil, WrapInternal("get signing key failed", err)
+	}
+	seed, err := base64.StdEncoding.DecodeString(key.PrivateKey)
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return "", nil, ErrInternal("signing key is corrupted")
+	}
+	return key.KeyID, ed25519.NewKeyFromSeed(seed), nil
+}
+
+func createSigningKey(ctx context.Context, db *gorm.DB) (*model.SigningKey, error) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, WrapInternal("generate signing key failed", err)
+	}
+	sum := sha256.Sum256(publicKey)
+	key := &model.SigningKey{
+		KeyID:      hex.EncodeToString(sum[:8]),
+		Algorithm:  licensefile.AlgorithmEd25519,
+		PublicKey:  base64.StdEncoding.EncodeToString(publicKey),
+		PrivateKey: base64.StdEncoding.EncodeToString(privateKey.Seed()),
+		Status:     SigningKeyStatusActive,
+	}
+	if err := db.WithContext(ctx).Create(key).Error; err != nil {
+		return nil, WrapInternal("create signing key failed", err)
+	}
+	return key, nil
+}
+
+// signEnvelope 使用当前启用的密钥签发指定格式的文件
+func signEnvelope(ctx context.Context, db *gorm.DB, format string, claims interface{}) (*licensefile.Envelope, error) {
+	keyID, privateKey, err := activeSigningKey(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	envelope, err := licensefile.Sign(format, keyID, privateKey, claims)
+	if err != nil {
+		return nil, WrapInternal("sign file failed", err)
+	}
+	return envelope, nil
+}
+
+// trustedKeySet 返回全部签名密钥的公钥集合，用于服务端校验自己签发的凭证
+func trustedKeySet(ctx context.Context, db *gorm.DB) (licensefile.KeySet, error) {
+	var keys []model.SigningKey
+	if err := db.WithContext(ctx).Find(&keys).Error; err != nil {
+		return nil, WrapInternal("list signing keys failed", err)
+	}
+	keySet := licensefile.KeySet{}
+	for _, key := range keys {
+		if err := keySet.Add(key.KeyID, key.PublicKey); err != nil {
+			return nil, WrapInternal("parse signing key failed", err)
+		}
+	}
+	return keySet, nil
+}
+
+func toSigningKeyData(key *model.SigningKey) SigningKeyData {
+	return SigningKeyData{
+		ID:        key.ID,
+		KeyID:     key.KeyID,
+		Algorithm: key.Algorithm,
+		PublicKey: key.PublicKey,
+		Status:    key.Status,
+		CreatedAt: key.CreatedAt,
+	}
+}
+
+// licenseFileClaims 组装 License 文件声明，包含产品范围与启用的服务范围
+func licenseFileClaims(ctx context.Context, db *gorm.DB, license *model.License, now time.Time) (*licensefile.LicenseClaims, error) {
+	var productScopes []model.LicenseProductScope
+	if err := db.WithContext(ctx).
+		Where("license_id = ? AND status = ?", license.ID, int(entity.ScopeStatusEnabled)).
+		Order("product_id ASC").
+		Find(&productScopes).Error; err != nil {
+		return nil, WrapInternal("list license product scopes failed", err)
+	}
+	serviceScopes, err := licenseServiceIdentifiers(ctx, db, license.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &licensefile.LicenseClaims{
+		LicenseID:     license.ID,
+		LicenseKey:    licensekey.Mask(license.KeyPrefix), // 服务端不保存明文注册码
+		ProductID:     license.ProductID,
+		ProductScopes: make([]licensefile.ProductScope, 0, len(productScopes)),
+		ServiceScopes: serviceScopes,
+		ValidityHours: license.ValidityHours,
+		MaxNodes:      license.MaxNodes,
+		MaxConcurrent: license.MaxConcurrent,
+		ActivatedAt:   license.ActivatedAt,
+		ExpiredAt:     license.ExpiredAt,
+		NotBefore:     license.NotBefore,
+		RevokeAt:      license.ScheduledRevokeAt,
+		IssuedAt:      now,
+	}
+	for _, scope := range productScopes {
+		claims.ProductScopes = append(claims.ProductScopes, licensefile.ProductScope{
+			ProductID:     scope.ProductID,
+			MaxNodes:      scope.MaxNodes,
+			MaxConcurrent: scope.MaxConcurrent,
+		})
+	}
+	return claims, nil
+}
+
+// licenseServiceIdentifiers 返回 License 启用的控制服务标识
+// 与在线校验保持一致：未配置服务范围时返回 nil 表示不限制，配置后仅包含启用的服务
+func licenseServiceIdentifiers(ctx context.Context, db *gorm.DB, licenseID uint) ([]string, error) {
+	var scopes []model.LicenseServiceScope
+	if err := db.WithContext(ctx).
+		Where("license_id = ?", licenseID).
+		Order("service_identifier ASC").
+		Find(&scopes).Error; err != nil {
+		return nil, WrapInternal("list license service scopes failed", err)
+	}
+	if len(scopes) == 0 {
+		return nil, nil
+	}
+	identifiers := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if scope.Status == int(entity.ScopeStatusEnabled) {
+			identifiers = append(identifiers, scope.ServiceIdentifier)
+		}
+	}
+	return identifiers, nil
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+
+	"nexus-core/global"
+	"nexus-core/persistence/model"
+
+	"gorm.io/gorm"
+)
+
+const (
+	TenantStatusActive   = 1
+	TenantStatusDisabled = 2
+)
+
+// DefaultTenantCode 默认租户编码，对应 model.DefaultTenantID，不落库
+const DefaultTenantCode = "default"
+
+var tenantCodePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// TenantService 管理租户
+// 租户只能由默认租户的操作员创建，其他租户的操作员只能看到自己的租户
+type TenantService struct{}
+
+func NewTenantService() *TenantService {
+	return &TenantService{}
+}
+
+func (s *TenantService) CreateTenant(ctx context.Context, cmd CreateTenantCommand) (*TenantData, error) {
+	if !isDefaultTenantContext(ctx) {
+		return nil, ErrForbidden("only default tenant operators can manage tenants")
+	}
+	code := strings.ToLower(strings.TrimSpace(cmd.Code))
+	if !tenantCodePattern.MatchString(code) {
+		return nil, ErrBadRequest("tenant code must be 1-64 lowercase letters, digits, '-' or '_'")
+	}
+	if code == DefaultTenantCode {
+		return nil, ErrConflict("tenant code is reserved")
+	}
+	name := strings.TrimSpace(cmd.Name)
+	if name == "" {
+		name = code
+	}
+	tenant := &model.Tenant{Code: code, Name: name, Status: TenantStatusActive}
+	err := global.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&model.Tenant{}).Where("code = ?", code).Count(&count).Error; err != nil {
+			return WrapInternal("check tenant code failed", err)
+		}
+		if count > 0 {
+			return ErrConflict("tenant code already exists")
+		}
+		if err := tx.Create(tenant).Error; err != nil {
+			return WrapInternal("create tenant failed", err)
+		}
+		recordAuditLog(ctx, tx, "tenant", tenant.ID, "create", map[string]interface{}{
+			"code": code,
+			"name": name,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	data := toTenantData(tenant)
+	return &data, nil
+}
+
+func (s *TenantService) ListTenants(ctx context.Context) ([]TenantData, error) {
+	query := global.DB.WithContext(ctx).Order("id ASC")
+	if tenantID, ok := model.TenantFromContext(ctx); ok && tenantID != model.DefaultTenantID {
+		query = query.Where("id = ?", tenantID)
+	}
+	var tenants []model.Tenant
+	if err := query.Find(&tenants).Error; err != nil {
+		return nil, WrapInternal("list tenants failed", err)
+	}
+	data := make([]TenantData, 0, len(tenants))
+	for i := range tenants {
+		data = append(data, toTenantData(&tenants[i]))
+	}
+	return data, nil
+}
+
+// ResolveTenant 根据租户编码返回租户 ID，空编码或 default 返回默认租户
+func (s *TenantService) ResolveTenant(ctx context.Context, code string) (uint, error) {
+	code = strings.ToLower(strings.TrimSpace(code))
+	if code == "" || code == DefaultTenantCode {
+		return model.DefaultTenantID, nil
+	}
+	tenant, err := getTenant(ctx, global.DB.WithContext(ctx), "code = ?", code)
+	if err != nil {
+		return 0, err
+	}
+	return tenant.ID, nil
+}
+
+// getTenant 查询启用的租户
+func getTenant(ctx context.Context, db *gorm.DB, query string, args ...interface{}) (*model.Tenant, error) {
+	var tenant model.Tenant
+	if err := db.WithContext(ctx).Where(query, args...).First(&tenant).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound("tenant not found")
+		}
+		return nil, WrapInternal("get tenant failed", err)
+	}
+	if tenant.Status != TenantStatusActive {
+		return nil, ErrForbidden("tenant disabled")
+	}
+	return &tenant, nil
+}
+
+// isDefaultTenantContext 未限定租户（后台任务）或属于默认租户时返回 true
+func isDefaultTenantContext(ctx context.Context) bool {
+	tenantID, ok := model.TenantFromContext(ctx)
+	return !ok || tenantID == model.DefaultTenantID
+}
+
+func toTenantData(m *model.Tenant) TenantData {
+	return TenantData{
+		ID:        m.ID,
+		Code:      m.Code,
+		Name:      m.Name,
+		Status:    m.Status,
+		CreatedAt: m.CreatedAt,
+	}
+}
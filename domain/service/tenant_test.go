@@ -0,0 +1,285 @@
+package service
+
+import (
+	"testing"
+
+	"nexus-core/domain/entity"
+	"nexus-core/monitor"
+	"nexus-core/persistence/model"
+	"nexus-core/persistence/repository"
+)
+
+func TestTenantIsolation(t *testing.T) {
+	fixture := newFlowFixture(t, 2, 0, 24)
+	tenantService := NewTenantService()
+
+	tenant, err := tenantService.CreateTenant(fixture.ctx, CreateTenantCommand{Code: "bu-a", Name: "Business Unit A"})
+	if err != nil {
+		t.Fatalf("create tenant: %v", err)
+	}
+	_, err = tenantService.CreateTenant(fixture.ctx, CreateTenantCommand{Code: "bu-a"})
+	assertAppErrorKind(t, err, ErrorKindConflict)
+	resolved, err := tenantService.ResolveTenant(fixture.ctx, "BU-A")
+	if err != nil || resolved != tenant.ID {
+		t.Fatalf("resolve tenant: id=%d err=%v", resolved, err)
+	}
+
+	defaultCtx := model.WithTenant(fixture.ctx, model.DefaultTenantID)
+	tenantCtx := model.WithTenant(fixture.ctx, tenant.ID)
+	_, err = tenantService.CreateTenant(tenantCtx, CreateTenantCommand{Code: "bu-b"})
+	assertAppErrorKind(t, err, ErrorKindForbidden)
+
+	// 产品名、设备码在租户内唯一，不同租户可以重复
+	product, err := fixture.productService.CreateProduct(tenantCtx, CreateProductCommand{Name: "flow-product"})
+	if err != nil {
+		t.Fatalf("create tenant product: %v", err)
+	}
+	if _, err := fixture.productService.CreateProductVersion(tenantCtx, CreateProductVersionCommand{
+		ProductID:   product.ID,
+		VersionCode: "1.0.0",
+		Method:      ReleaseImmediate,
+	}); err != nil {
+		t.Fatalf("create tenant version: %v", err)
+	}
+	license, err := fixture.licenseService.CreateLicense(tenantCtx, CreateLicenseCommand{
+		ProductID:     product.ID,
+		ValidityHours: 24,
+		MaxNodes:      1,
+	})
+	if err != nil {
+		t.Fatalf("create tenant license: %v", err)
+	}
+
+	fixture.ctx = defaultCtx
+	defaultNode := fixture.register(t, "device-a")
+	tenantNode, err := fixture.accessService.Register(tenantCtx, AccessCommand{
+		DeviceCode:  "device-a",
+		LicenseKey:  license.LicenseKey,
+		ProductID:   product.ID,
+		VersionCode: "1.0.0",
+	})
+	if err != nil {
+		t.Fatalf("register same device code in tenant: %v", err)
+	}
+	if tenantNode.NodeID == defaultNode.NodeID {
+		t.Fatal("tenants should get separate nodes for the same device code")
+	}
+
+	// 跨租户访问视为不存在
+	_, err = fixture.accessService.Register(tenantCtx, AccessCommand{
+		DeviceCode:  "device-b",
+		LicenseKey:  fixture.license.LicenseKey,
+		ProductID:   fixture.product.ID,
+		VersionCode: "1.0.0",
+	})
+	if err == nil {
+		t.Fatal("register with another tenant's license should fail")
+	}
+	_, err = fixture.licenseService.GetLicenseDataByID(tenantCtx, fixture.license.ID)
+	assertAppErrorKind(t, err, ErrorKindNotFound)
+	_, err = fixture.productService.GetProductDataByID(defaultCtx, product.ID)
+	assertAppErrorKind(t, err, ErrorKindNotFound)
+	err = fixture.nodeService.BanNode(tenantCtx, UpdateNodeStatusCommand{NodeID: defaultNode.NodeID})
+	assertAppErrorKind(t, err, ErrorKindNotFound)
+	err = fixture.nodeService.UnbanNode(tenantCtx, UpdateNodeStatusCommand{NodeID: defaultNode.NodeID})
+	assertAppErrorKind(t, err, ErrorKindNotFound)
+
+	licenses, err := fixture.licenseService.ListLicenses(tenantCtx, ListLicensesCommand{})
+	if err != nil {
+		t.Fatalf("list tenant licenses: %v", err)
+	}
+	if len(licenses) != 1 || licenses[0].ID != license.ID {
+		t.Fatalf("tenant should only see its own license: %#v", licenses)
+	}
+	node, err := fixture.nodeService.GetByDeviceCode(tenantCtx, "device-a")
+	if err != nil || node.ID != tenantNode.NodeID {
+		t.Fatalf("device code lookup should stay in tenant: %#v err=%v", node, err)
+	}
+	if _, err := fixture.heartbeat("device-a"); err != nil {
+		t.Fatalf("default tenant heartbeat: %v", err)
+	}
+	if _, err := fixture.accessService.Heartbeat(tenantCtx, AccessCommand{
+		DeviceCode:  "device-a",
+		LicenseKey:  license.LicenseKey,
+		ProductID:   product.ID,
+		VersionCode: "1.0.0",
+		NodeSecret:  tenantNode.NodeSecret,
+	}); err != nil {
+		t.Fatalf("tenant heartbeat: %v", err)
+	}
+	summary, err := NewMonitorService().GetOnlineSummary(tenantCtx)
+	if err != nil {
+		t.Fatalf("online summary: %v", err)
+	}
+	if summary.TotalOnline != 1 || summary.Nodes[0].ProductID != product.ID {
+		t.Fatalf("online summary should only include tenant nodes: %#v", summary)
+	}
+	logs, err := NewAuditService().ListAuditLogs(tenantCtx, ListAuditLogsCommand{})
+	if err != nil {
+		t.Fatalf("list tenant audit logs: %v", err)
+	}
+	for _, log := range logs {
+		if log.ResourceType == "license" && log.ResourceID == fixture.license.ID {
+			t.Fatalf("tenant should not see default tenant audit logs: %#v", log)
+		}
+	}
+
+	// License Key 同样只在租户内唯一
+	duplicate := model.License{ProductID: product.ID, KeyDigest: repository.LicenseKeyDigest(fixture.license.LicenseKey), ValidityHours: 24}
+	if err := fixture.db.WithContext(tenantCtx).Create(&duplicate).Error; err != nil {
+		t.Fatalf("same license key in another tenant should be allowed: %v", err)
+	}
+	if duplicate.TenantID != tenant.ID {
+		t.Fatalf("tenant id should be filled on create, got %d", duplicate.TenantID)
+	}
+	again := model.License{ProductID: product.ID, KeyDigest: repository.LicenseKeyDigest(fixture.license.LicenseKey), ValidityHours: 24}
+	if err := fixture.db.WithContext(tenantCtx).Create(&again).Error; err == nil {
+		t.Fatal("duplicate license key in the same tenant should fail")
+	}
+}
+
+func TestCreateOperatorRespectsTenant(t *testing.T) {
+	fixture := newFlowFixture(t, 1, 0, 24)
+	tenant, err := NewTenantService().CreateTenant(fixture.ctx, CreateTenantCommand{Code: "bu-a"})
+	if err != nil {
+		t.Fatalf("create tenant: %v", err)
+	}
+	operatorService := NewOperatorService()
+
+	admin, err := operatorService.CreateOperator(model.WithTenant(fixture.ctx, model.DefaultTenantID), CreateOperatorCommand{
+		TenantID: tenant.ID,
+		Username: "bu-a-admin",
+		Role:     RoleSuperAdmin,
+	})
+	if err != nil {
+		t.Fatalf("create tenant operator: %v", err)
+	}
+	if admin.TenantID != tenant.ID {
+		t.Fatalf("operator should belong to tenant %d, got %d", tenant.ID, admin.TenantID)
+	}
+	key, err := operatorService.CreateAPIKey(fixture.ctx, CreateOperatorAPIKeyCommand{OperatorID: admin.ID})
+	if err != nil {
+		t.Fatalf("create api key: %v", err)
+	}
+	identity, err := operatorService.Authenticate(model.WithTenant(fixture.ctx, model.DefaultTenantID), key.Key)
+	if err != nil {
+		t.Fatalf("authenticate across tenants: %v", err)
+	}
+	if identity.TenantID != tenant.ID {
+		t.Fatalf("identity should carry tenant %d, got %d", tenant.ID, identity.TenantID)
+	}
+
+	tenantCtx := model.WithTenant(fixture.ctx, tenant.ID)
+	_, err = operatorService.CreateOperator(tenantCtx, CreateOperatorCommand{
+		TenantID: 9999,
+		Username: "escape",
+		Role:     RoleViewer,
+	})
+	assertAppErrorKind(t, err, ErrorKindForbidden)
+	_, err = operatorService.CreateOperator(model.WithTenant(fixture.ctx, model.DefaultTenantID), CreateOperatorCommand{
+		TenantID: 9999,
+		Username: "ghost",
+		Role:     RoleViewer,
+	})
+	assertAppErrorKind(t, err, ErrorKindNotFound)
+
+	viewer, err := operatorService.CreateOperator(tenantCtx, CreateOperatorCommand{Username: "bu-a-viewer", Role: RoleViewer})
+	if err != nil || viewer.TenantID != tenant.ID {
+		t.Fatalf("tenant operator should create operators in its tenant: %#v err=%v", viewer, err)
+	}
+	operators, err := operatorService.ListOperators(tenantCtx)
+	if err != nil {
+		t.Fatalf("list operators: %v", err)
+	}
+	if len(operators) != 2 {
+		t.Fatalf("tenant should only see its own operators: %#v", operators)
+	}
+	platformCtx := model.WithTenant(fixture.ctx, model.DefaultTenantID)
+	all, err := operatorService.ListOperators(platformCtx)
+	if err != nil || len(all) != 2 {
+		t.Fatalf("default tenant should manage operators of all tenants: %#v err=%v", all, err)
+	}
+	root, err := operatorService.CreateOperator(platformCtx, CreateOperatorCommand{Username: "root", Role: RoleSuperAdmin})
+	if err != nil {
+		t.Fatalf("create default tenant operator: %v", err)
+	}
+	_, err = operatorService.ListAPIKeys(tenantCtx, root.ID)
+	assertAppErrorKind(t, err, ErrorKindNotFound)
+	_, err = operatorService.UpdateOperator(tenantCtx, UpdateOperatorCommand{ID: root.ID, Role: &viewer.Role})
+	assertAppErrorKind(t, err, ErrorKindNotFound)
+	// 最后一个超级管理员按租户计算
+	_, err = operatorService.UpdateOperator(platformCtx, UpdateOperatorCommand{ID: admin.ID, Role: &viewer.Role})
+	assertAppErrorKind(t, err, ErrorKindConflict)
+}
+
+func TestTenantCannotTouchOtherTenantChildRows(t *testing.T) {
+	fixture := newFlowFixture(t, 2, 0, 24)
+	tenant, err := NewTenantService().CreateTenant(fixture.ctx, CreateTenantCommand{Code: "bu-a"})
+	if err != nil {
+		t.Fatalf("create tenant: %v", err)
+	}
+	fixture.ctx = model.WithTenant(fixture.ctx, model.DefaultTenantID)
+	tenantCtx := model.WithTenant(fixture.ctx, tenant.ID)
+
+	registered := fixture.register(t, "device-a")
+	if _, err := fixture.licenseService.AddAllowedDevices(fixture.ctx, AddAllowedDevicesCommand{
+		LicenseID: fixture.license.ID,
+		Devices:   []AllowedDeviceEntry{{DeviceCode: "device-a"}},
+	}); err != nil {
+		t.Fatalf("add allowed device: %v", err)
+	}
+	hold, err := fixture.productService.CreateProductVersion(fixture.ctx, CreateProductVersionCommand{
+		ProductID:   fixture.product.ID,
+		VersionCode: "2.0.0",
+		Method:      ReleaseHold,
+	})
+	if err != nil {
+		t.Fatalf("create held version: %v", err)
+	}
+	var binding model.NodeLicenseBinding
+	if err := fixture.db.Where("node_id = ? AND license_id = ?", registered.NodeID, fixture.license.ID).First(&binding).Error; err != nil {
+		t.Fatalf("get binding: %v", err)
+	}
+
+	// 关联表没有租户字段，使用其他租户的 ID 操作一律视为不存在
+	err = fixture.nodeService.UnbindByID(tenantCtx, UnbindCommand{NodeID: registered.NodeID, LicenseID: fixture.license.ID})
+	assertAppErrorKind(t, err, ErrorKindNotFound)
+	_, err = fixture.licenseService.RejectBinding(tenantCtx, BindingDecisionCommand{LicenseID: fixture.license.ID, BindingID: binding.ID})
+	assertAppErrorKind(t, err, ErrorKindNotFound)
+	err = fixture.licenseService.RemoveAllowedDevice(tenantCtx, fixture.license.ID, "device-a")
+	assertAppErrorKind(t, err, ErrorKindNotFound)
+	err = fixture.licenseService.ReleaseSeat(tenantCtx, fixture.license.ID, 1)
+	assertAppErrorKind(t, err, ErrorKindNotFound)
+	err = fixture.licenseService.DeleteLicense(tenantCtx, fixture.license.ID)
+	assertAppErrorKind(t, err, ErrorKindNotFound)
+	err = fixture.productService.ReleaseVersion(tenantCtx, ReleaseNewVersionCommand{ProductID: fixture.product.ID, VersionID: hold.ID})
+	assertAppErrorKind(t, err, ErrorKindNotFound)
+
+	var license model.License
+	if err := fixture.db.First(&license, fixture.license.ID).Error; err != nil || license.CurrentNodeCount != 1 {
+		t.Fatalf("license should survive cross-tenant writes: %#v err=%v", license, err)
+	}
+	if err := fixture.db.First(&binding, binding.ID).Error; err != nil || binding.Status != int(entity.BindingStatusBound) {
+		t.Fatalf("binding should survive cross-tenant unbind: %#v err=%v", binding, err)
+	}
+	devices, err := fixture.licenseService.ListAllowedDevices(fixture.ctx, fixture.license.ID)
+	if err != nil || len(devices) != 1 {
+		t.Fatalf("allowed device should survive cross-tenant removal: %#v err=%v", devices, err)
+	}
+	var version model.ProductVersion
+	if err := fixture.db.First(&version, hold.ID).Error; err != nil || version.Status != hold.Status {
+		t.Fatalf("held version should stay unreleased: %#v err=%v", version, err)
+	}
+
+	// 在线统计按租户区分，同一注册码摘要不会占用其他租户的并发名额
+	if _, err := fixture.heartbeat("device-a"); err != nil {
+		t.Fatalf("heartbeat: %v", err)
+	}
+	digest := repository.LicenseKeyDigest(fixture.license.LicenseKey)
+	if got := monitor.GlobalStat.CountByLicense(model.DefaultTenantID, digest); got != 1 {
+		t.Fatalf("default tenant should have 1 online node, got %d", got)
+	}
+	if got := monitor.GlobalStat.CountByLicense(tenant.ID, digest); got != 0 {
+		t.Fatalf("other tenant should not see online nodes of the same digest, got %d", got)
+	}
+}
@@ -2,7 +2,10 @@ package service
 
 import (
 	"encoding/json"
+	"io"
 	"time"
+
+	"nexus-core/licensekey"
 )
 
 type AccessCommand struct {
@@ -10,42 +13,165 @@ type AccessCommand struct {
 	LicenseKey  string
 	ProductID   uint
 	VersionCode string
-}
+	NodeSecret  string
+	Usage       map[string]int64 // 心跳上报的功能用量增量，按功能标识符
+}
+
+// SeatCheckoutCommand 申请或续期浮动席位
+type SeatCheckoutCommand struct {
+	AccessCommand
+	TTLSeconds int // 席位租约时长，为 0 时使用配置的默认值
+}
+
+// SeatLeaseData 浮动席位申请结果，未获得席位时返回排队位置
+type SeatLeaseData struct {
+	Granted       bool       `json:"granted"`
+	SeatID        uint       `json:"seat_id,omitempty"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	QueuePosition int        `json:"queue_position,omitempty"` // 排队位置，从 1 开始
+	RetryAfter    int        `json:"retry_after,omitempty"`    // 建议的重新申请间隔（秒），需在排队超时前再次申请
+	MaxSeats      int        `json:"max_seats"`                // 起限制作用的席位数，0 表示不限
+	SeatsInUse    int        `json:"seats_in_use"`
+}
+
+// SeatData 已占用的浮动席位
+type SeatData struct {
+	ID         uint      `json:"id"`
+	ProductID  uint      `json:"product_id"`
+	NodeID     uint      `json:"node_id"`
+	DeviceCode string    `json:"device_code"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// SeatWaiterData 排队等待席位的节点
+type SeatWaiterData struct {
+	ProductID  uint      `json:"product_id"`
+	NodeID     uint      `json:"node_id"`
+	DeviceCode string    `json:"device_code"`
+	Position   int       `json:"position"` // 在同一产品队列中的位置
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// LicenseSeatsData License 的浮动席位占用与排队情况
+type LicenseSeatsData struct {
+	LicenseID     uint             `json:"license_id"`
+	MaxConcurrent int              `json:"max_concurrent"`
+	Seats         []SeatData       `json:"seats"`
+	Queue         []SeatWaiterData `json:"queue"`
+}
+
+// LeaseData 注册与心跳成功后签发的租约令牌
+type LeaseData struct {
+	LeaseToken      string    `json:"lease_token"`
+	LeaseExpiresAt  time.Time `json:"lease_expires_at"`
+	LeaseGraceUntil time.Time `json:"lease_grace_until"`
+}
+
+type VerifyLeaseResult struct {
+	NodeID     uint      `json:"node_id"`
+	LicenseID  uint      `json:"license_id"`
+	LicenseKey string    `json:"license_key"`
+	ProductID  uint      `json:"product_id"`
+	Services   []string  `json:"services"`
+	IssuedAt   time.Time `json:"issued_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	GraceUntil time.Time `json:"grace_until"`
+	InGrace    bool      `json:"in_grace"`
+}
+
+// 注册、心跳与绑定审批接口返回的绑定状态
+const (
+	BindingStateBound           = "bound"
+	BindingStatePendingApproval = "pending_approval"
+	BindingStateRejected        = "rejected"
+)
 
 type RegisterResult struct {
-	NodeID             uint   `json:"node_id"`
-	LicenseID          uint   `json:"license_id"`
-	ProductID          uint   `json:"product_id"`
-	LicenseKey         string `json:"license_key"`
-	LicenseStatus      int    `json:"license_status"`
-	FeatureMask        string `json:"feature_mask"`
-	MaxNodes           int    `json:"max_nodes"`
-	CurrentNodeCount   int    `json:"current_node_count"`
-	MaxConcurrent      int    `json:"max_concurrent"`
-	HeartbeatInterval  int    `json:"heartbeat_interval"`
-	BindingEstablished bool   `json:"binding_established"`
+	NodeID             uint               `json:"node_id"`
+	LicenseID          uint               `json:"license_id"`
+	ProductID          uint               `json:"product_id"`
+	LicenseKey         string             `json:"license_key"`
+	LicenseStatus      int                `json:"license_status"`
+	FeatureMask        string             `json:"feature_mask"`
+	MaxNodes           int                `json:"max_nodes"`
+	CurrentNodeCount   int                `json:"current_node_count"`
+	MaxConcurrent      int                `json:"max_concurrent"`
+	HeartbeatInterval  int                `json:"heartbeat_interval"`
+	BindingEstablished bool               `json:"binding_established"`
+	BindingStatus      string             `json:"binding_status"`        // bound 或 pending_approval
+	NodeSecret         string             `json:"node_secret,omitempty"` // 仅在签发新密钥时返回
+	Entitlements       []EntitlementData  `json:"entitlements"`          // 当前产品已生效的功能
+	Quotas             []FeatureQuotaData `json:"quotas"`                // 当前产品功能的用量配额
+	LeaseData
+}
+
+type OfflineActivationCommand struct {
+	Request string
+}
+
+type OfflineActivationData struct {
+	NodeID             uint            `json:"node_id"`
+	LicenseID          uint            `json:"license_id"`
+	BindingEstablished bool            `json:"binding_established"`
+	KeyID              string          `json:"key_id"`
+	FileName           string          `json:"file_name"`
+	Content            json.RawMessage `json:"content"`
 }
 
 type CreateLicenseCommand struct {
-	ProductID     uint
-	ValidityHours int
-	MaxNodes      int
-	MaxConcurrent int
-	Remark        *string
+	ProductID         uint
+	CustomerID        *uint
+	PlanID            *uint      // 指定套餐时，未填写的有效期、节点数、并发数取套餐默认值，并复制套餐的服务范围
+	Type              int        // 0正式，1试用
+	TrialMaxHours     int        // 试用许可证续期后的最长有效时长，为 0 时使用默认值
+	Perpetual         bool       // 永久授权，忽略有效时长
+	VersionCutoffAt   *time.Time // 只授权在此时间及之前发布的版本
+	MinVersionID      *uint      // 授权的版本范围（按发布时间，含边界）
+	MaxVersionID      *uint
+	GracePeriodHours  *int       // 过期后的宽限期（小时），为空时使用产品设置
+	NotBefore         *time.Time // 合同开始时间，之前不能注册和心跳
+	ScheduledRevokeAt *time.Time // 合同结束时间，到期自动吊销，与有效时长无关
+	ValidityHours     int
+	MaxNodes          int
+	MaxConcurrent     int
+	Remark            *string
 }
 
 type BatchCreateLicenseCommand struct {
-	ProductID     uint
-	ValidityHours int
-	MaxNodes      int
-	MaxConcurrent int
-	Remark        *string
-	Count         int
+	ProductID         uint
+	CustomerID        *uint
+	PlanID            *uint
+	Type              int
+	TrialMaxHours     int
+	Perpetual         bool
+	VersionCutoffAt   *time.Time
+	MinVersionID      *uint
+	MaxVersionID      *uint
+	GracePeriodHours  *int
+	NotBefore         *time.Time
+	ScheduledRevokeAt *time.Time
+	ValidityHours     int
+	MaxNodes          int
+	MaxConcurrent     int
+	Remark            *string
+	Count             int
+}
+
+// ImportLicenseKeysCommand 导入外部生成的注册码，每个注册码创建一个 License
+type ImportLicenseKeysCommand struct {
+	BatchCreateLicenseCommand
+	Format *licensekey.Format // 注册码声明的格式，为空时使用产品的格式
+	Keys   []string
 }
 
 type LicenseData struct {
 	ID            uint    `json:"id"`
 	ProductID     uint    `json:"product_id"`
+	CustomerID    *uint   `json:"customer_id"`
+	PlanID        *uint   `json:"plan_id"`
+	Type          int     `json:"type"`
+	TrialMaxHours int     `json:"trial_max_hours,omitempty"`
 	LicenseKey    string  `json:"license_key"`
 	ValidityHours int     `json:"validity_hours"`
 	Status        int     `json:"status"`
@@ -53,6 +179,332 @@ type LicenseData struct {
 	MaxNodes      int     `json:"max_nodes"`
 	MaxConcurrent int     `json:"max_concurrent"`
 	FeatureMask   string  `json:"feature_mask"`
+
+	Perpetual          bool       `json:"perpetual"`
+	VersionCutoffAt    *time.Time `json:"version_cutoff_at,omitempty"` // 只授权在此时间及之前发布的版本
+	MinVersionID       *uint      `json:"min_version_id,omitempty"`
+	MaxVersionID       *uint      `json:"max_version_id,omitempty"`
+	GracePeriodHours   *int       `json:"grace_period_hours"`            // 单独设置的宽限期，为空时使用产品设置
+	GraceEndsAt        *time.Time `json:"grace_ends_at,omitempty"`       // 宽限期结束时间
+	InactiveUnbindDays *int       `json:"inactive_unbind_days"`          // 单独设置的不活跃节点自动解绑天数，为空时使用产品设置
+	ApprovalRequired   bool       `json:"approval_required"`             // 新设备绑定需要审批
+	NotBefore          *time.Time `json:"not_before,omitempty"`          // 合同开始时间
+	ScheduledRevokeAt  *time.Time `json:"scheduled_revoke_at,omitempty"` // 计划吊销时间
+}
+
+type LicenseFileData struct {
+	LicenseID uint            `json:"license_id"`
+	KeyID     string          `json:"key_id"`
+	FileName  string          `json:"file_name"`
+	Content   json.RawMessage `json:"content"`
+}
+
+type SigningKeyData struct {
+	ID        uint      `json:"id"`
+	KeyID     string    `json:"key_id"`
+	Algorithm string    `json:"algorithm"`
+	PublicKey string    `json:"public_key"`
+	Status    int       `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type CreateOperatorCommand struct {
+	TenantID    uint
+	Username    string
+	DisplayName string
+	Role        string
+}
+
+type UpdateOperatorCommand struct {
+	ID          uint
+	DisplayName *string
+	Role        *string
+	Status      *int
+}
+
+type OperatorData struct {
+	ID          uint      `json:"id"`
+	TenantID    uint      `json:"tenant_id"`
+	Username    string    `json:"username"`
+	DisplayName string    `json:"display_name"`
+	Role        string    `json:"role"`
+	Status      int       `json:"status"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type CreateOperatorAPIKeyCommand struct {
+	OperatorID uint
+	Name       string
+	ExpiresAt  *time.Time
+}
+
+type OperatorAPIKeyData struct {
+	ID         uint       `json:"id"`
+	OperatorID uint       `json:"operator_id"`
+	Name       string     `json:"name"`
+	KeyPrefix  string     `json:"key_prefix"`
+	Key        string     `json:"key,omitempty"` // 明文只在创建时返回
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// OperatorIdentity 通过 API Key 认证后的操作员身份
+type OperatorIdentity struct {
+	OperatorID  uint         `json:"operator_id"`
+	TenantID    uint         `json:"tenant_id"`
+	Username    string       `json:"username"`
+	Role        string       `json:"role"`
+	Permissions []Permission `json:"permissions"`
+}
+
+type CreateCustomerCommand struct {
+	Name        string
+	Company     string
+	ContactName string
+	Email       string
+	Phone       string
+}
+
+type UpdateCustomerCommand struct {
+	ID          uint
+	Name        *string
+	Company     *string
+	ContactName *string
+	Email       *string
+	Phone       *string
+	Status      *int
+}
+
+type ListCustomersCommand struct {
+	Name   *string
+	Email  *string
+	Status *int
+	Limit  int
+	Offset int
+}
+
+type CustomerData struct {
+	ID                uint       `json:"id"`
+	Name              string     `json:"name"`
+	Company           string     `json:"company"`
+	ContactName       string     `json:"contact_name"`
+	Email             string     `json:"email"`
+	Phone             string     `json:"phone"`
+	Status            int        `json:"status"`
+	PortalEnabled     bool       `json:"portal_enabled"`
+	PortalKeyIssuedAt *time.Time `json:"portal_key_issued_at"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
+
+type CustomerPortalKeyData struct {
+	CustomerID uint      `json:"customer_id"`
+	PortalKey  string    `json:"portal_key"` // 明文只在签发时返回
+	IssuedAt   time.Time `json:"issued_at"`
+}
+
+// CustomerIdentity 通过门户密钥认证后的客户身份
+type CustomerIdentity struct {
+	CustomerID uint   `json:"customer_id"`
+	TenantID   uint   `json:"tenant_id"`
+	Name       string `json:"name"`
+}
+
+type AssignLicenseCustomerCommand struct {
+	LicenseID  uint
+	CustomerID *uint // 为空表示解除归属
+}
+
+// BoundNodeData License 下已绑定的节点
+type BoundNodeData struct {
+	NodeID     uint       `json:"node_id"`
+	ProductID  uint       `json:"product_id"`
+	DeviceCode string     `json:"device_code"`
+	Status     int        `json:"status"`
+	LastSeenAt *time.Time `json:"last_seen_at"`
+	BoundAt    *time.Time `json:"bound_at"`
+}
+
+type CustomerLicenseData struct {
+	LicenseData
+	ExpiredAt *time.Time      `json:"expired_at"`
+	Nodes     []BoundNodeData `json:"nodes"`
+}
+
+type CustomerUnbindCommand struct {
+	CustomerID uint
+	LicenseID  uint
+	NodeID     uint
+}
+
+type CreateFeatureCommand struct {
+	Identifier  string
+	Type        string // ability / command，默认 ability
+	Name        string
+	Description *string
+	Data        *string
+}
+
+type UpdateFeatureCommand struct {
+	ID          uint
+	Name        *string
+	Description *string
+	Data        *string
+	Status      *int // 1启用，2禁用
+}
+
+type ListFeaturesCommand struct {
+	Type   *string
+	Status *int
+	Limit  int
+	Offset int
+}
+
+type FeatureData struct {
+	ID          uint      `json:"id"`
+	Identifier  string    `json:"identifier"`
+	Type        string    `json:"type"`
+	Name        string    `json:"name"`
+	Description *string   `json:"description"`
+	Data        *string   `json:"data"`
+	Status      int       `json:"status"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type SetProductFeatureCommand struct {
+	ProductID uint
+	FeatureID uint
+	Default   bool // 该产品的所有 License 默认拥有此功能
+}
+
+type ProductFeatureData struct {
+	ProductID uint `json:"product_id"`
+	Default   bool `json:"default"`
+	FeatureData
+}
+
+type RemoveProductFeatureCommand struct {
+	ProductID uint
+	FeatureID uint
+}
+
+type GrantLicenseFeatureCommand struct {
+	LicenseID uint
+	FeatureID uint
+	ExpiresAt *time.Time // 为空表示随 License 有效
+}
+
+type RevokeLicenseFeatureCommand struct {
+	LicenseID uint
+	FeatureID uint
+}
+
+type LicenseFeatureData struct {
+	LicenseID uint       `json:"license_id"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	FeatureData
+}
+
+type SetLicenseFeatureQuotaCommand struct {
+	LicenseID  uint
+	FeatureID  uint
+	Quota      int64  // 每个周期的可用量
+	Period     string // day、month、total，为空时按 month
+	ResetUsage bool   // 是否清零当前周期已用量
+}
+
+type RemoveLicenseFeatureQuotaCommand struct {
+	LicenseID uint
+	FeatureID uint
+}
+
+// FeatureQuotaData 功能用量配额及当前周期用量
+type FeatureQuotaData struct {
+	FeatureID   uint       `json:"feature_id"`
+	Identifier  string     `json:"identifier"`
+	Period      string     `json:"period"`
+	Quota       int64      `json:"quota"`
+	Used        int64      `json:"used"`
+	Remaining   int64      `json:"remaining"`
+	PeriodStart *time.Time `json:"period_start,omitempty"` // total 周期不返回
+	ResetsAt    *time.Time `json:"resets_at,omitempty"`    // total 周期不返回
+}
+
+// EntitlementData 节点当前可用的功能
+type EntitlementData struct {
+	Identifier string     `json:"identifier"`
+	Type       string     `json:"type"`
+	Name       string     `json:"name"`
+	Data       *string    `json:"data,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"` // 单独授予且设置了到期时间时返回
+}
+
+type CreatePlanCommand struct {
+	ProductID     uint
+	Name          string
+	Description   *string
+	ValidityHours int
+	MaxNodes      int
+	MaxConcurrent int
+	Services      []string // 可用控制服务标识，为空表示不限制
+}
+
+type UpdatePlanCommand struct {
+	ID            uint
+	Name          *string
+	Description   *string
+	ValidityHours *int
+	MaxNodes      *int
+	MaxConcurrent *int
+	Services      *[]string // 不为 nil 时整体替换，只影响之后创建或切换的 License
+	Status        *int
+}
+
+type ListPlansCommand struct {
+	ProductID *uint
+	Status    *int
+	Limit     int
+	Offset    int
+}
+
+type PlanData struct {
+	ID            uint      `json:"id"`
+	ProductID     uint      `json:"product_id"`
+	Name          string    `json:"name"`
+	Description   *string   `json:"description"`
+	ValidityHours int       `json:"validity_hours"`
+	MaxNodes      int       `json:"max_nodes"`
+	MaxConcurrent int       `json:"max_concurrent"`
+	Services      []string  `json:"services"`
+	Status        int       `json:"status"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type ConvertTrialLicenseCommand struct {
+	LicenseID     uint
+	PlanID        *uint // 指定套餐时，未填写的有效期、节点数、并发数取套餐默认值，并复制套餐的服务范围
+	ValidityHours int   // 正式有效时长，从转换时刻开始计算
+	MaxNodes      *int  // 为空时取套餐默认值，未指定套餐则保持不变
+	MaxConcurrent *int
+}
+
+type ChangeLicensePlanCommand struct {
+	LicenseID uint
+	PlanID    uint
+}
+
+type CreateTenantCommand struct {
+	Code string
+	Name string
+}
+
+type TenantData struct {
+	ID        uint      `json:"id"`
+	Code      string    `json:"code"`
+	Name      string    `json:"name"`
+	Status    int       `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 type UpdateLicenseCommand struct {
@@ -63,6 +515,132 @@ type UpdateLicenseCommand struct {
 	Remark        *string
 }
 
+type SetLicenseVersionEntitlementCommand struct {
+	LicenseID       uint
+	VersionCutoffAt *time.Time // 三项均为空表示不限制版本
+	MinVersionID    *uint
+	MaxVersionID    *uint
+}
+
+type SetLicenseProductScopeCommand struct {
+	LicenseID     uint
+	ProductID     uint
+	MaxNodes      int
+	MaxConcurrent int
+	Status        int // 1启用，2禁用，为 0 时默认启用
+}
+
+type RemoveLicenseProductScopeCommand struct {
+	LicenseID uint
+	ProductID uint
+}
+
+// LicenseProductScopeData License 授权的单个产品及其使用情况
+type LicenseProductScopeData struct {
+	LicenseID     uint `json:"license_id"`
+	ProductID     uint `json:"product_id"`
+	Primary       bool `json:"primary"` // 是否为 License 的主产品
+	Status        int  `json:"status"`
+	MaxNodes      int  `json:"max_nodes"`
+	MaxConcurrent int  `json:"max_concurrent"`
+	BoundNodes    int  `json:"bound_nodes"`  // 该产品已绑定的节点数
+	OnlineNodes   int  `json:"online_nodes"` // 该产品当前在线的节点数
+}
+
+type SetLicenseGracePeriodCommand struct {
+	LicenseID        uint
+	GracePeriodHours *int // 为空表示使用产品设置
+}
+
+type SetLicenseInactiveUnbindCommand struct {
+	LicenseID          uint
+	InactiveUnbindDays *int // 为空表示使用产品设置，0 表示不自动解绑
+}
+
+type SetLicenseTransferPolicyCommand struct {
+	LicenseID             uint
+	TransferLimit         int // 统计窗口内最多绑定的不同设备数，0 表示不限制
+	TransferWindowDays    int // 统计窗口（天）
+	RebindCooldownMinutes int // 解绑释放的节点名额重新可用前的冷却时长（分钟），0 表示不冷却
+}
+
+// LicenseTransferData License 的换绑限制策略及当前计数
+type LicenseTransferData struct {
+	LicenseID             uint       `json:"license_id"`
+	TransferLimit         int        `json:"transfer_limit"`
+	TransferWindowDays    int        `json:"transfer_window_days"`
+	RebindCooldownMinutes int        `json:"rebind_cooldown_minutes"`
+	TransferResetAt       *time.Time `json:"transfer_reset_at"`
+	DevicesBound          int        `json:"devices_bound"`    // 统计窗口内绑定过的不同设备数
+	CoolingSeats          int        `json:"cooling_seats"`    // 冷却中的节点名额数
+	CooldownEndsAt        *time.Time `json:"cooldown_ends_at"` // 最早一个冷却中的名额重新可用的时间
+}
+
+type SetLicenseApprovalCommand struct {
+	LicenseID        uint
+	ApprovalRequired bool
+}
+
+type BindingDecisionCommand struct {
+	LicenseID uint
+	BindingID uint
+	Reason    *string // 拒绝原因，记录到审计日志
+}
+
+// BindingRequestData 需要审批的设备绑定申请
+type BindingRequestData struct {
+	ID          uint       `json:"id"`
+	NodeID      uint       `json:"node_id"`
+	DeviceCode  string     `json:"device_code"`
+	LicenseID   uint       `json:"license_id"`
+	ProductID   uint       `json:"product_id"`
+	Status      string     `json:"status"` // pending_approval、rejected 或审批通过后的 bound
+	RequestedAt *time.Time `json:"requested_at"`
+	BoundAt     *time.Time `json:"bound_at,omitempty"`
+}
+
+type AllowedDeviceEntry struct {
+	DeviceCode string
+	Remark     *string
+}
+
+type AddAllowedDevicesCommand struct {
+	LicenseID uint
+	Devices   []AllowedDeviceEntry
+	Reserve   bool // 同时预先创建节点并预留给该 License
+}
+
+type ImportAllowedDevicesCommand struct {
+	LicenseID uint
+	Content   io.Reader // CSV 内容，每行为 device_code[,remark]
+	Reserve   bool
+}
+
+// AllowedDeviceData 设备白名单中的设备
+type AllowedDeviceData struct {
+	ID         uint      `json:"id"`
+	LicenseID  uint      `json:"license_id"`
+	DeviceCode string    `json:"device_code"`
+	Remark     *string   `json:"remark"`
+	NodeID     *uint     `json:"node_id"`  // 设备已注册或已预先创建时的节点
+	Reserved   bool      `json:"reserved"` // 节点是否预留给该 License
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// AllowedDeviceImportData 添加或导入设备白名单的结果
+type AllowedDeviceImportData struct {
+	Added    int `json:"added"`    // 新加入名单的设备数
+	Updated  int `json:"updated"`  // 已在名单内的设备数
+	Reserved int `json:"reserved"` // 预留给该 License 的节点数
+}
+
+// SetLicenseScheduleCommand 设置合同起止时间，为空表示取消
+type SetLicenseScheduleCommand struct {
+	LicenseID         uint
+	NotBefore         *time.Time
+	ScheduledRevokeAt *time.Time
+}
+
 type RestoreLicenseCommand struct {
 	ID uint
 }
@@ -72,6 +650,34 @@ type RenewLicenseCommand struct {
 	ExtraHours int
 }
 
+// LicenseStateData License 在某一时刻的状态、有效期和限制
+type LicenseStateData struct {
+	Status        int        `json:"status"`
+	ExpiredAt     *time.Time `json:"expired_at"`
+	MaxNodes      int        `json:"max_nodes"`
+	MaxConcurrent int        `json:"max_concurrent"`
+}
+
+// LicenseHistoryData License 状态历史中的一次变更
+type LicenseHistoryData struct {
+	ID         uint             `json:"id"`
+	LicenseID  uint             `json:"license_id"`
+	Event      string           `json:"event"`
+	Before     LicenseStateData `json:"before"`
+	After      LicenseStateData `json:"after"`
+	ExtraHours int              `json:"extra_hours,omitempty"`
+	Operator   string           `json:"operator"`
+	OccurredAt time.Time        `json:"occurred_at"`
+}
+
+// LicenseStatusAtData 回溯查询的结果，LastEvent 为该时刻之前最近的一次变更
+type LicenseStatusAtData struct {
+	LicenseID uint      `json:"license_id"`
+	At        time.Time `json:"at"`
+	LicenseStateData
+	LastEvent *LicenseHistoryData `json:"last_event"`
+}
+
 type CreateNodeCommand struct {
 	DeviceCode string
 	Metadata   *string
@@ -83,6 +689,12 @@ type UpdateNodeCommand struct {
 	Metadata   *string
 }
 
+type NodeCredentialData struct {
+	NodeID     uint      `json:"node_id"`
+	NodeSecret string    `json:"node_secret"`
+	IssuedAt   time.Time `json:"issued_at"`
+}
+
 type NodeData struct {
 	ID         uint    `json:"id"`
 	DeviceCode string  `json:"device_code"`
@@ -99,6 +711,8 @@ type ListProductsCommand struct {
 
 type ListLicensesCommand struct {
 	ProductID  *uint
+	CustomerID *uint
+	Type       *int
 	Status     *int
 	LicenseKey *string
 	Limit      int
@@ -125,6 +739,7 @@ type AutoBindCommand struct {
 type UnbindCommand struct {
 	NodeID    uint
 	LicenseID uint
+	ProductID uint // 为 0 时解除该 License 在节点上所有产品的绑定
 }
 
 type UpdateNodeStatusCommand struct {
@@ -133,8 +748,12 @@ type UpdateNodeStatusCommand struct {
 }
 
 type CreateProductCommand struct {
-	Name        string
-	Description *string
+	Name               string
+	Description        *string
+	GracePeriodHours   int  // License 过期后的默认宽限期（小时）
+	GraceAllowControl  bool // 宽限期内是否允许下发控制命令
+	InactiveUnbindDays int  // 节点连续多少天无心跳后自动解绑，0 表示不自动解绑
+	KeyFormat          *licensekey.Format
 }
 
 type ProductData struct {
@@ -143,13 +762,21 @@ type ProductData struct {
 	Description           *string              `json:"description"`
 	Status                int                  `json:"status"`
 	MinSupportedVersionID *uint                `json:"min_supported_version_id"`
+	GracePeriodHours      int                  `json:"grace_period_hours"`
+	GraceAllowControl     bool                 `json:"grace_allow_control"`
+	InactiveUnbindDays    int                  `json:"inactive_unbind_days"`
+	KeyFormat             licensekey.Format    `json:"key_format"`
 	Versions              []ProductVersionData `json:"versions"`
 }
 
 type UpdateProductCommand struct {
-	ID          uint
-	Name        *string
-	Description *string
+	ID                 uint
+	Name               *string
+	Description        *string
+	GracePeriodHours   *int
+	GraceAllowControl  *bool
+	InactiveUnbindDays *int
+	KeyFormat          *licensekey.Format // 只影响之后创建的 License
 }
 
 type ReleaseMethod int
@@ -251,6 +878,7 @@ type ListControlCommandsCommand struct {
 
 type ReportNodeCapabilityCommand struct {
 	NodeID            uint
+	NodeSecret        string
 	ServiceIdentifier string
 	Schema            json.RawMessage
 	Protocol          string
@@ -292,6 +920,7 @@ type ControlCommandData struct {
 
 type CompleteControlCommandCommand struct {
 	CommandID    uint
+	NodeSecret   string
 	Status       string
 	Result       json.RawMessage
 	ErrorMessage *string
@@ -301,3 +930,58 @@ type PendingControlSummary struct {
 	Count      int    `json:"count"`
 	CommandIDs []uint `json:"command_ids"`
 }
+
+// GenerateActivationCodesCommand 批量生成兑换码，增加的时长、节点数、并发数至少填写一项
+type GenerateActivationCodesCommand struct {
+	ProductID       *uint // 只能兑换该产品的 License，为空表示不限制
+	ExtraHours      int
+	ExtraNodes      int
+	ExtraConcurrent int
+	ExpiresAt       *time.Time // 兑换截止时间，为空表示不限制
+	Count           int
+	Remark          *string
+}
+
+type ListActivationCodesCommand struct {
+	Batch     *string
+	Status    *int
+	LicenseID *uint // 兑换到的 License
+	Limit     int
+	Offset    int
+}
+
+// RedeemActivationCodeCommand 使用注册码兑换兑换码
+type RedeemActivationCodeCommand struct {
+	LicenseKey string
+	Code       string
+	ClientIP   string // 没有操作人身份时记为兑换人
+}
+
+type ActivationCodeData struct {
+	ID                uint       `json:"id"`
+	Code              string     `json:"code"` // 只在生成时返回完整兑换码，其余情况为脱敏前缀
+	Batch             string     `json:"batch"`
+	ProductID         *uint      `json:"product_id"`
+	ExtraHours        int        `json:"extra_hours"`
+	ExtraNodes        int        `json:"extra_nodes"`
+	ExtraConcurrent   int        `json:"extra_concurrent"`
+	ExpiresAt         *time.Time `json:"expires_at"`
+	Status            int        `json:"status"`
+	RedeemedLicenseID *uint      `json:"redeemed_license_id"`
+	RedeemedAt        *time.Time `json:"redeemed_at"`
+	RedeemedBy        string     `json:"redeemed_by,omitempty"`
+	Remark            *string    `json:"remark"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
+
+// ActivationCodeBatchData 一次生成的兑换码
+type ActivationCodeBatchData struct {
+	Batch string               `json:"batch"`
+	Codes []ActivationCodeData `json:"codes"`
+}
+
+// RedeemActivationCodeData 兑换结果，License 为兑换后的状态
+type RedeemActivationCodeData struct {
+	Code    ActivationCodeData `json:"code"`
+	License LicenseData        `json:"license"`
+}
@@ -1,20 +1,28 @@
 package global
 
 import (
+	"errors"
 	"os"
 
 	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	Port            int           `yaml:"port"`
-	DBConfig        *DBConfig     `yaml:"db_list"`
-	SwaggerEnabled  bool          `yaml:"swagger_enabled"`
-	AutoOpenBrowser bool          `yaml:"auto_open_browser"`
-	SwaggerURL      string        `yaml:"swagger_url"`
-	SwaggerDocURL   string        `yaml:"swagger_doc_url"`
-	MQTT            MQTTConfig    `yaml:"mqtt"`
-	Control         ControlConfig `yaml:"control"`
+	Port            int              `yaml:"port"`
+	DBConfig        *DBConfig        `yaml:"db_list"`
+	SwaggerEnabled  bool             `yaml:"swagger_enabled"`
+	AutoOpenBrowser bool             `yaml:"auto_open_browser"`
+	SwaggerURL      string           `yaml:"swagger_url"`
+	SwaggerDocURL   string           `yaml:"swagger_doc_url"`
+	MQTT            MQTTConfig       `yaml:"mqtt"`
+	Control         ControlConfig    `yaml:"control"`
+	Lease           LeaseConfig      `yaml:"lease"`
+	Seat            SeatConfig       `yaml:"seat"`
+	AdminAuth       AdminAuthConfig  `yaml:"admin_auth"`
+	LicenseKey      LicenseKeyConfig `yaml:"license_key"`
+	// TrustedProxies 可信反向代理的 IP 或网段，只有来自这些地址的请求才采信 X-Forwarded-For 等请求头
+	// 为空时直接使用连接的对端地址
+	TrustedProxies []string `yaml:"trusted_proxies"`
 }
 
 type DBConfig struct {
@@ -45,6 +53,55 @@ type ControlConfig struct {
 	NodeOnlineTTLSeconds   int `yaml:"node_online_ttl_seconds"`
 }
 
+// LeaseConfig 注册与心跳签发的租约令牌配置
+type LeaseConfig struct {
+	TTLSeconds          int `yaml:"ttl_seconds"`           // 租约有效期
+	OfflineGraceSeconds int `yaml:"offline_grace_seconds"` // 租约过期后允许离线运行的时长
+}
+
+// SeatConfig 浮动席位配置
+type SeatConfig struct {
+	TTLSeconds          int `yaml:"ttl_seconds"`           // 未指定时的席位租约时长
+	MaxTTLSeconds       int `yaml:"max_ttl_seconds"`       // 客户端可申请的最长租约时长
+	QueueTimeoutSeconds int `yaml:"queue_timeout_seconds"` // 排队节点超过该时长未再次申请时出队
+}
+
+// AdminAuthConfig 管理接口认证配置
+type AdminAuthConfig struct {
+	Enabled         bool   `yaml:"enabled"`           // 是否要求管理接口携带 API Key，默认开启
+	BootstrapAPIKey string `yaml:"bootstrap_api_key"` // 启动时为 admin 超级管理员登记的初始 API Key
+	// TrustedOperatorHeader 由可信网关写入的操作人请求头，未通过 API Key 认证时用作操作人
+	// 仅在网关会覆盖或剥离客户端同名请求头时配置
+	TrustedOperatorHeader string `yaml:"trusted_operator_header"`
+}
+
+// devLicenseKeyHashSecret 关闭管理认证的本地开发环境未配置密钥时使用的摘要密钥
+const devLicenseKeyHashSecret = "nexus-core-license-key"
+
+// LicenseKeyHashSecretEnv 注入注册码摘要密钥的环境变量，设置后覆盖配置文件中的 hash_secret
+const LicenseKeyHashSecretEnv = "NEXUS_LICENSE_KEY_HASH_SECRET"
+
+// LicenseKeyConfig 注册码存储配置
+type LicenseKeyConfig struct {
+	// HashSecret 计算注册码 HMAC 摘要的密钥，上线后修改会导致已有注册码无法匹配
+	HashSecret string `yaml:"hash_secret"`
+
+	devSecret bool // 使用了开发环境的内置密钥
+}
+
+// UsingDevSecret 是否使用了开发环境的内置摘要密钥
+func (c LicenseKeyConfig) UsingDevSecret() bool {
+	return c.devSecret
+}
+
+// ValidateLicenseKeySecret 检查注册码摘要密钥，开启管理认证时必须显式配置
+func (c *Config) ValidateLicenseKeySecret() error {
+	if c.LicenseKey.HashSecret == "" {
+		return errors.New("license_key.hash_secret (or " + LicenseKeyHashSecretEnv + ") is required when admin_auth is enabled")
+	}
+	return nil
+}
+
 var cfg *Config
 
 func LoadConfig() *Config {
@@ -72,6 +129,18 @@ func LoadConfig() *Config {
 			DispatchMaxRetries:     0,
 			NodeOnlineTTLSeconds:   120,
 		},
+		Lease: LeaseConfig{
+			TTLSeconds:          3600,
+			OfflineGraceSeconds: 86400,
+		},
+		Seat: SeatConfig{
+			TTLSeconds:          300,
+			MaxTTLSeconds:       3600,
+			QueueTimeoutSeconds: 60,
+		},
+		AdminAuth: AdminAuthConfig{
+			Enabled: true,
+		},
 	}
 
 	f, err := os.ReadFile("config-dev.yml")
@@ -100,6 +169,29 @@ func LoadConfig() *Config {
 	if cfg.Control.NodeOnlineTTLSeconds <= 0 {
 		cfg.Control.NodeOnlineTTLSeconds = 120
 	}
+	if cfg.Lease.TTLSeconds <= 0 {
+		cfg.Lease.TTLSeconds = 3600
+	}
+	if cfg.Lease.OfflineGraceSeconds < 0 {
+		cfg.Lease.OfflineGraceSeconds = 0
+	}
+	if cfg.Seat.TTLSeconds <= 0 {
+		cfg.Seat.TTLSeconds = 300
+	}
+	if cfg.Seat.MaxTTLSeconds < cfg.Seat.TTLSeconds {
+		cfg.Seat.MaxTTLSeconds = cfg.Seat.TTLSeconds
+	}
+	if cfg.Seat.QueueTimeoutSeconds <= 0 {
+		cfg.Seat.QueueTimeoutSeconds = 60
+	}
+	if secret := os.Getenv(LicenseKeyHashSecretEnv); secret != "" {
+		cfg.LicenseKey.HashSecret = secret
+	}
+	// 内置密钥是公开的，只允许在关闭管理认证的开发环境下使用
+	if cfg.LicenseKey.HashSecret == "" && !cfg.AdminAuth.Enabled {
+		cfg.LicenseKey.HashSecret = devLicenseKeyHashSecret
+		cfg.LicenseKey.devSecret = true
+	}
 
 	return cfg
 }
@@ -0,0 +1,119 @@
+package licensefile
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+const (
+	FormatActivation = "nexus-activation"
+
+	// ActivationRequestPrefix 离线激活请求串前缀，便于人工识别与复制
+	ActivationRequestPrefix = "NXREQ-"
+)
+
+var (
+	ErrInvalidActivationRequest = errors.New("licensefile: invalid activation request")
+	ErrActivationMismatch       = errors.New("licensefile: activation response does not match request")
+)
+
+// ActivationRequest 是无法联网的节点生成的离线激活请求
+// Nonce 由节点随机生成，用于将激活响应与本次请求绑定
+type ActivationRequest struct {
+	DeviceCode  string    `json:"device_code"`
+	ProductID   uint      `json:"product_id"`
+	VersionCode string    `json:"version_code"`
+	LicenseKey  string    `json:"license_key"`
+	Nonce       string    `json:"nonce"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ActivationClaims 是服务端签发的离线激活响应内容
+type ActivationClaims struct {
+	Nonce         string     `json:"nonce"`
+	NodeID        uint       `json:"node_id"`
+	DeviceCode    string     `json:"device_code"`
+	LicenseID     uint       `json:"license_id"`
+	LicenseKey    string     `json:"license_key"`
+	ProductID     uint       `json:"product_id"`
+	VersionCode   string     `json:"version_code"`
+	Services      []string   `json:"services"` // nil 表示不限制服务范围
+	MaxNodes      int        `json:"max_nodes"`
+	MaxConcurrent int        `json:"max_concurrent"`
+	ActivatedAt   *time.Time `json:"activated_at"`
+	ExpiredAt     *time.Time `json:"expired_at"`
+	IssuedAt      time.Time  `json:"issued_at"`
+}
+
+// NewActivationRequest 生成带随机 Nonce 的离线激活请求
+func NewActivationRequest(deviceCode string, productID uint, versionCode string, licenseKey string) (*ActivationRequest, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return &ActivationRequest{
+		DeviceCode:  deviceCode,
+		ProductID:   productID,
+		VersionCode: versionCode,
+		LicenseKey:  licenseKey,
+		Nonce:       hex.EncodeToString(nonce),
+		CreatedAt:   time.Now(),
+	}, nil
+}
+
+// Encode 将激活请求编码为可复制传输的文本
+func (r *ActivationRequest) Encode() (string, error) {
+	raw, err := json.Marshal(r)
+	if err != nil {
+		return "", err
+	}
+	return ActivationRequestPrefix + base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// DecodeActivationRequest 解析离线激活请求文本
+func DecodeActivationRequest(blob string) (*ActivationRequest, error) {
+	blob = strings.TrimSpace(blob)
+	if !strings.HasPrefix(blob, ActivationRequestPrefix) {
+		return nil, ErrInvalidActivationRequest
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(blob, ActivationRequestPrefix))
+	if err != nil {
+		return nil, ErrInvalidActivationRequest
+	}
+	var request ActivationRequest
+	if err := json.Unmarshal(raw, &request); err != nil {
+		return nil, ErrInvalidActivationRequest
+	}
+	if request.DeviceCode == "" || request.LicenseKey == "" || request.ProductID == 0 ||
+		request.VersionCode == "" || request.Nonce == "" {
+		return nil, ErrInvalidActivationRequest
+	}
+	return &request, nil
+}
+
+// SignActivation 签发离线激活响应文件
+func SignActivation(keyID string, privateKey ed25519.PrivateKey, claims ActivationClaims) (*Envelope, error) {
+	return Sign(FormatActivation, keyID, privateKey, claims)
+}
+
+// VerifyActivation 节点导入激活响应时校验签名、请求匹配关系与有效期
+func VerifyActivation(data []byte, keys KeySet, request *ActivationRequest, now time.Time) (*ActivationClaims, error) {
+	var claims ActivationClaims
+	if _, err := Open(data, FormatActivation, keys, &claims); err != nil {
+		return nil, err
+	}
+	if request == nil || claims.Nonce != request.Nonce || claims.DeviceCode != request.DeviceCode ||
+		claims.ProductID != request.ProductID || claims.LicenseKey != request.LicenseKey {
+		return nil, ErrActivationMismatch
+	}
+	if claims.ExpiredAt != nil && now.After(*claims.ExpiredAt) {
+		return nil, ErrLicenseExpired
+	}
+	return &claims, nil
+}
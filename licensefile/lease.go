@@ -0,0 +1,84 @@
+package licensefile
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+const FormatLease = "nexus-lease"
+
+var ErrLeaseExpired = errors.New("licensefile: lease expired")
+
+// LeaseClaims 是注册与心跳返回的短期租约内容
+// ExpiresAt 之前租约有效；服务端不可达时客户端可继续运行到 GraceUntil
+type LeaseClaims struct {
+	NodeID     uint      `json:"node_id"`
+	LicenseID  uint      `json:"license_id"`
+	LicenseKey string    `json:"license_key"`
+	ProductID  uint      `json:"product_id"`
+	Services   []string  `json:"services"` // nil 表示不限制服务范围
+	IssuedAt   time.Time `json:"issued_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	GraceUntil time.Time `json:"grace_until"`
+}
+
+// InGrace 判断租约是否已过期但仍处于离线宽限期内
+func (c *LeaseClaims) InGrace(now time.Time) bool {
+	return now.After(c.ExpiresAt) && !now.After(c.GraceUntil)
+}
+
+// AllowsService 判断租约是否允许使用指定控制服务
+func (c *LeaseClaims) AllowsService(identifier string) bool {
+	if c.Services == nil {
+		return true
+	}
+	for _, service := range c.Services {
+		if service == identifier {
+			return true
+		}
+	}
+	return false
+}
+
+// SignLease 签发租约令牌
+func SignLease(keyID string, privateKey ed25519.PrivateKey, claims LeaseClaims) (string, error) {
+	envelope, err := Sign(FormatLease, keyID, privateKey, claims)
+	if err != nil {
+		return "", err
+	}
+	return EncodeToken(envelope)
+}
+
+// VerifyLease 离线校验租约令牌，超过离线宽限期的租约视为失效
+func VerifyLease(token string, keys KeySet, now time.Time) (*LeaseClaims, error) {
+	var claims LeaseClaims
+	if _, err := OpenToken(token, FormatLease, keys, &claims); err != nil {
+		return nil, err
+	}
+	if now.After(claims.GraceUntil) {
+		return nil, ErrLeaseExpired
+	}
+	return &claims, nil
+}
+
+// EncodeToken 将签名信封编码为适合在请求头或 JSON 中传输的紧凑令牌
+func EncodeToken(envelope *Envelope) (string, error) {
+	raw, err := json.Marshal(envelope)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// OpenToken 解码并校验紧凑令牌
+func OpenToken(token string, format string, keys KeySet, claims interface{}) (*Envelope, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(token))
+	if err != nil {
+		return nil, ErrInvalidFile
+	}
+	return Open(raw, format, keys, claims)
+}
@@ -0,0 +1,98 @@
+package licensefile
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"time"
+)
+
+const FormatLicense = "nexus-license"
+
+var (
+	ErrLicenseExpired       = errors.New("licensefile: license expired")
+	ErrLicenseNotActivated  = errors.New("licensefile: license not activated")
+	ErrLicenseNotYetValid   = errors.New("licensefile: license not yet valid")
+	ErrLicenseRevoked       = errors.New("licensefile: license revoked")
+	ErrProductNotAuthorized = errors.New("licensefile: product not authorized")
+)
+
+// ProductScope 表示 License 文件中可授权的产品
+type ProductScope struct {
+	ProductID     uint `json:"product_id"`
+	MaxNodes      int  `json:"max_nodes,omitempty"`      // 该产品的最大节点数，0 表示只受 License 总数限制
+	MaxConcurrent int  `json:"max_concurrent,omitempty"` // 该产品的并发限制，0 表示只受 License 总数限制
+}
+
+// LicenseClaims 是 License 文件中经过签名的授权内容
+type LicenseClaims struct {
+	LicenseID     uint           `json:"license_id"`
+	LicenseKey    string         `json:"license_key"`
+	ProductID     uint           `json:"product_id"`
+	ProductScopes []ProductScope `json:"product_scopes"`
+	ServiceScopes []string       `json:"service_scopes"` // nil 表示不限制服务范围
+	ValidityHours int            `json:"validity_hours"`
+	MaxNodes      int            `json:"max_nodes"`
+	MaxConcurrent int            `json:"max_concurrent"`
+	ActivatedAt   *time.Time     `json:"activated_at"`
+	ExpiredAt     *time.Time     `json:"expired_at"`
+	NotBefore     *time.Time     `json:"not_before,omitempty"` // 合同开始时间
+	RevokeAt      *time.Time     `json:"revoke_at,omitempty"`  // 合同结束时间，之后不可使用
+	IssuedAt      time.Time      `json:"issued_at"`
+}
+
+// AllowsProduct 判断 License 是否授权指定产品
+func (c *LicenseClaims) AllowsProduct(productID uint) bool {
+	if c.ProductID == productID {
+		return true
+	}
+	for _, scope := range c.ProductScopes {
+		if scope.ProductID == productID {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsService 判断 License 是否授权指定控制服务
+// 未配置服务范围时视为不限制
+func (c *LicenseClaims) AllowsService(identifier string) bool {
+	if c.ServiceScopes == nil {
+		return true
+	}
+	for _, scope := range c.ServiceScopes {
+		if scope == identifier {
+			return true
+		}
+	}
+	return false
+}
+
+// SignLicense 签发 License 文件
+func SignLicense(keyID string, privateKey ed25519.PrivateKey, claims LicenseClaims) (*Envelope, error) {
+	return Sign(FormatLicense, keyID, privateKey, claims)
+}
+
+// VerifyLicense 离线校验 License 文件
+// 依次校验签名、激活状态、合同起止时间、有效期以及产品授权范围
+func VerifyLicense(data []byte, keys KeySet, productID uint, now time.Time) (*LicenseClaims, error) {
+	var claims LicenseClaims
+	if _, err := Open(data, FormatLicense, keys, &claims); err != nil {
+		return nil, err
+	}
+	if claims.ActivatedAt == nil {
+		return nil, ErrLicenseNotActivated
+	}
+	if claims.NotBefore != nil && now.Before(*claims.NotBefore) {
+		return nil, ErrLicenseNotYetValid
+	}
+	if claims.RevokeAt != nil && !now.Before(*claims.RevokeAt) {
+		return nil, ErrLicenseRevoked
+	}
+	if claims.ExpiredAt != nil && now.After(*claims.ExpiredAt) {
+		return nil, ErrLicenseExpired
+	}
+	if !claims.AllowsProduct(productID) {
+		return nil, ErrProductNotAuthorized
+	}
+	return &claims, nil
+}
@@ -0,0 +1,148 @@
+// Package licensefile 定义服务端签发的离线授权文件格式及其校验逻辑。
+//
+// 本包只依赖标准库，客户端可以直接嵌入，在无法连接服务端的环境中
+// 校验 License 文件的签名、有效期和产品授权范围。
+package licensefile
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+//
+// @Author yfy2001
+// @Date 2026/4/7 10 12
+//
+
+const (
+	AlgorithmEd25519 = "ed25519"
+	CurrentVersion   = 1
+)
+
+var (
+	ErrInvalidFile        = errors.New("licensefile: invalid file")
+	ErrUnsupportedFormat  = errors.New("licensefile: unsupported format")
+	ErrUnsupportedVersion = errors.New("licensefile: unsupported version")
+	ErrUnknownKey         = errors.New("licensefile: unknown signing key")
+	ErrInvalidSignature   = errors.New("licensefile: invalid signature")
+)
+
+// Envelope 是所有签名文件的外层结构
+// Payload 为 base64 编码后的业务声明，签名覆盖 Format、Version、KeyID 与 Payload
+type Envelope struct {
+	Format    string `json:"format"`
+	Version   int    `json:"version"`
+	KeyID     string `json:"key_id"`
+	Algorithm string `json:"algorithm"`
+	Payload   string `json:"payload"`
+	Signature string `json:"signature"`
+}
+
+// KeySet 保存可信的公钥，key 为签名密钥 ID
+type KeySet map[string]ed25519.PublicKey
+
+// ParsePublicKey 解析 base64 编码的 Ed25519 公钥
+func ParsePublicKey(encoded string) (ed25519.PublicKey, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("licensefile: decode public key: %w", err)
+	}
+	if len(raw) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("licensefile: public key must be %d bytes", ed25519.PublicKeySize)
+	}
+	return ed25519.PublicKey(raw), nil
+}
+
+// Add 向密钥集中添加一个 base64 编码的公钥
+func (k KeySet) Add(keyID string, encoded string) error {
+	publicKey, err := ParsePublicKey(encoded)
+	if err != nil {
+		return err
+	}
+	k[keyID] = publicKey
+	return nil
+}
+
+// Sign 使用私钥对声明进行签名，返回指定格式的签名信封
+func Sign(format string, keyID string, privateKey ed25519.PrivateKey, claims interface{}) (*Envelope, error) {
+	if len(privateKey) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("licensefile: private key must be %d bytes", ed25519.PrivateKeySize)
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return nil, fmt.Errorf("licensefile: marshal claims: %w", err)
+	}
+	envelope := &Envelope{
+		Format:    format,
+		Version:   CurrentVersion,
+		KeyID:     keyID,
+		Algorithm: AlgorithmEd25519,
+		Payload:   base64.StdEncoding.EncodeToString(payload),
+	}
+	signature := ed25519.Sign(privateKey, envelope.signingInput())
+	envelope.Signature = base64.StdEncoding.EncodeToString(signature)
+	return envelope, nil
+}
+
+// Open 解析并校验签名信封，校验通过后将声明解码到 claims
+func Open(data []byte, format string, keys KeySet, claims interface{}) (*Envelope, error) {
+	var envelope Envelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, ErrInvalidFile
+	}
+	if err := envelope.Verify(format, keys); err != nil {
+		return nil, err
+	}
+	payload, err := base64.StdEncoding.DecodeString(envelope.Payload)
+	if err != nil {
+		return nil, ErrInvalidFile
+	}
+	if err := json.Unmarshal(payload, claims); err != nil {
+		return nil, ErrInvalidFile
+	}
+	return &envelope, nil
+}
+
+// Verify 校验信封格式、版本与签名
+func (e *Envelope) Verify(format string, keys KeySet) error {
+	if e.Format != format {
+		return ErrUnsupportedFormat
+	}
+	if e.Version != CurrentVersion {
+		return ErrUnsupportedVersion
+	}
+	if e.Algorithm != AlgorithmEd25519 {
+		return ErrUnsupportedFormat
+	}
+	publicKey, ok := keys[e.KeyID]
+	if !ok {
+		return ErrUnknownKey
+	}
+	signature, err := base64.StdEncoding.DecodeString(e.Signature)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if !ed25519.Verify(publicKey, e.signingInput(), signature) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// Marshal 将信封序列化为便于保存的 JSON 文本
+func (e *Envelope) Marshal() ([]byte, error) {
+	return json.MarshalIndent(e, "", "  ")
+}
+
+func (e *Envelope) signingInput() []byte {
+	return []byte(strings.Join([]string{
+		e.Format,
+		strconv.Itoa(e.Version),
+		e.KeyID,
+		e.Payload,
+	}, "."))
+}
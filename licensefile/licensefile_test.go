@@ -0,0 +1,171 @@
+package licensefile
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestKeySet(t *testing.T) (ed25519.PrivateKey, KeySet) {
+	t.Helper()
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	keys := KeySet{}
+	if err := keys.Add("test-key", base64.StdEncoding.EncodeToString(publicKey)); err != nil {
+		t.Fatalf("add public key: %v", err)
+	}
+	return privateKey, keys
+}
+
+func TestVerifyLicense(t *testing.T) {
+	privateKey, keys := newTestKeySet(t)
+	now := time.Now()
+	activatedAt := now.Add(-time.Hour)
+	expiredAt := now.Add(time.Hour)
+
+	envelope, err := SignLicense("test-key", privateKey, LicenseClaims{
+		LicenseID:     1,
+		LicenseKey:    "key",
+		ProductID:     10,
+		ProductScopes: []ProductScope{{ProductID: 11}},
+		ActivatedAt:   &activatedAt,
+		ExpiredAt:     &expiredAt,
+		IssuedAt:      now,
+	})
+	if err != nil {
+		t.Fatalf("sign license: %v", err)
+	}
+	data, err := envelope.Marshal()
+	if err != nil {
+		t.Fatalf("marshal envelope: %v", err)
+	}
+
+	claims, err := VerifyLicense(data, keys, 11, now)
+	if err != nil {
+		t.Fatalf("verify license: %v", err)
+	}
+	if claims.LicenseID != 1 || !claims.AllowsService("anything") {
+		t.Fatalf("claims mismatch: %#v", claims)
+	}
+
+	if _, err := VerifyLicense(data, keys, 12, now); !errors.Is(err, ErrProductNotAuthorized) {
+		t.Fatalf("expected product error, got %v", err)
+	}
+	if _, err := VerifyLicense(data, keys, 10, now.Add(2*time.Hour)); !errors.Is(err, ErrLicenseExpired) {
+		t.Fatalf("expected expired error, got %v", err)
+	}
+	if _, err := VerifyLicense(data, KeySet{}, 10, now); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("expected unknown key error, got %v", err)
+	}
+
+	notBefore, revokeAt := now.Add(-30*time.Minute), now.Add(30*time.Minute)
+	scheduled, err := SignLicense("test-key", privateKey, LicenseClaims{
+		LicenseID:   1,
+		ProductID:   10,
+		ActivatedAt: &activatedAt,
+		ExpiredAt:   &expiredAt,
+		NotBefore:   &notBefore,
+		RevokeAt:    &revokeAt,
+		IssuedAt:    now,
+	})
+	if err != nil {
+		t.Fatalf("sign scheduled license: %v", err)
+	}
+	scheduledData, _ := scheduled.Marshal()
+	if _, err := VerifyLicense(scheduledData, keys, 10, now); err != nil {
+		t.Fatalf("verify scheduled license: %v", err)
+	}
+	if _, err := VerifyLicense(scheduledData, keys, 10, now.Add(-time.Hour)); !errors.Is(err, ErrLicenseNotYetValid) {
+		t.Fatalf("expected not yet valid error, got %v", err)
+	}
+	if _, err := VerifyLicense(scheduledData, keys, 10, revokeAt); !errors.Is(err, ErrLicenseRevoked) {
+		t.Fatalf("expected revoked error, got %v", err)
+	}
+
+	tampered := strings.Replace(string(data), envelope.Payload, base64.StdEncoding.EncodeToString([]byte(`{"license_id":2}`)), 1)
+	if _, err := VerifyLicense([]byte(tampered), keys, 10, now); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("expected invalid signature, got %v", err)
+	}
+}
+
+func TestVerifyLease(t *testing.T) {
+	privateKey, keys := newTestKeySet(t)
+	now := time.Now()
+
+	token, err := SignLease("test-key", privateKey, LeaseClaims{
+		NodeID:     1,
+		LicenseKey: "key",
+		ProductID:  10,
+		Services:   []string{"device.reboot"},
+		IssuedAt:   now,
+		ExpiresAt:  now.Add(time.Hour),
+		GraceUntil: now.Add(2 * time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("sign lease: %v", err)
+	}
+
+	claims, err := VerifyLease(token, keys, now.Add(90*time.Minute))
+	if err != nil {
+		t.Fatalf("verify lease: %v", err)
+	}
+	if !claims.InGrace(now.Add(90*time.Minute)) || claims.InGrace(now) {
+		t.Fatal("unexpected grace state")
+	}
+	if !claims.AllowsService("device.reboot") || claims.AllowsService("device.shutdown") {
+		t.Fatalf("unexpected services: %#v", claims.Services)
+	}
+	if _, err := VerifyLease(token, keys, now.Add(3*time.Hour)); !errors.Is(err, ErrLeaseExpired) {
+		t.Fatalf("expected lease expired, got %v", err)
+	}
+	if _, err := VerifyLease(token+"x", keys, now); err == nil {
+		t.Fatal("tampered token should fail")
+	}
+}
+
+func TestActivationRoundTrip(t *testing.T) {
+	privateKey, keys := newTestKeySet(t)
+	request, err := NewActivationRequest("device-a", 10, "1.0.0", "key")
+	if err != nil {
+		t.Fatalf("new activation request: %v", err)
+	}
+	blob, err := request.Encode()
+	if err != nil {
+		t.Fatalf("encode request: %v", err)
+	}
+	decoded, err := DecodeActivationRequest(blob)
+	if err != nil {
+		t.Fatalf("decode request: %v", err)
+	}
+	if decoded.Nonce != request.Nonce || decoded.DeviceCode != "device-a" {
+		t.Fatalf("decoded request mismatch: %#v", decoded)
+	}
+	if _, err := DecodeActivationRequest("garbage"); !errors.Is(err, ErrInvalidActivationRequest) {
+		t.Fatalf("expected invalid request, got %v", err)
+	}
+
+	envelope, err := SignActivation("test-key", privateKey, ActivationClaims{
+		Nonce:      decoded.Nonce,
+		DeviceCode: decoded.DeviceCode,
+		LicenseKey: decoded.LicenseKey,
+		ProductID:  decoded.ProductID,
+		IssuedAt:   time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("sign activation: %v", err)
+	}
+	data, _ := envelope.Marshal()
+	if _, err := VerifyActivation(data, keys, request, time.Now()); err != nil {
+		t.Fatalf("verify activation: %v", err)
+	}
+
+	other, _ := NewActivationRequest("device-a", 10, "1.0.0", "key")
+	if _, err := VerifyActivation(data, keys, other, time.Now()); !errors.Is(err, ErrActivationMismatch) {
+		t.Fatalf("expected mismatch for other request, got %v", err)
+	}
+}
@@ -0,0 +1,243 @@
+// Package licensekey 生成与校验 License 注册码
+// 客户端可以在联网前使用产品声明的格式检查用户输入的注册码
+package licensekey
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Alphabet Crockford Base32 字符集，去掉了容易混淆的 I、L、O、U
+const Alphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+const (
+	MaxPrefixLength = 12
+	MaxGroups       = 8
+	MinGroupSize    = 2
+	MaxGroupSize    = 8
+	MaxKeyLength    = 128
+
+	// minRandomChars 随机部分至少 12 个字符（60 bit）
+	minRandomChars   = 12
+	legacyLength     = 32
+	maxDisplayPrefix = 12
+)
+
+var (
+	ErrMalformed = errors.New("licensekey: malformed license key")
+	ErrChecksum  = errors.New("licensekey: check digit mismatch")
+)
+
+// Format 注册码格式：可选前缀 + 若干组 Base32 字符，校验位占最后一组的最后一个字符
+// Groups 为 0 表示沿用去掉横线的 UUID
+type Format struct {
+	Prefix     string `json:"prefix,omitempty"`
+	Groups     int    `json:"groups"`
+	GroupSize  int    `json:"group_size"`
+	CheckDigit bool   `json:"check_digit"`
+}
+
+// IsLegacy 是否为默认的 UUID 格式
+func (f Format) IsLegacy() bool {
+	return f.Groups == 0
+}
+
+// Validate 检查格式配置本身是否合法
+func (f Format) Validate() error {
+	if f.IsLegacy() {
+		if f.Prefix != "" || f.GroupSize != 0 || f.CheckDigit {
+			return errors.New("licensekey: groups is required when prefix, group_size or check_digit is set")
+		}
+		return nil
+	}
+	if len(f.Prefix) > MaxPrefixLength {
+		return fmt.Errorf("licensekey: prefix must be at most %d characters", MaxPrefixLength)
+	}
+	for i := 0; i < len(f.Prefix); i++ {
+		if !isUpperAlnum(f.Prefix[i]) {
+			return errors.New("licensekey: prefix must contain only A-Z and 0-9")
+		}
+	}
+	if f.Groups < 1 || f.Groups > MaxGroups {
+		return fmt.Errorf("licensekey: groups must be between 1 and %d", MaxGroups)
+	}
+	if f.GroupSize < MinGroupSize || f.GroupSize > MaxGroupSize {
+		return fmt.Errorf("licensekey: group_size must be between %d and %d", MinGroupSize, MaxGroupSize)
+	}
+	if f.randomChars() < minRandomChars {
+		return fmt.Errorf("licensekey: format must carry at least %d random characters", minRandomChars)
+	}
+	return nil
+}
+
+// Generate 按格式生成新的注册码
+func (f Format) Generate() (string, error) {
+	if err := f.Validate(); err != nil {
+		return "", err
+	}
+	if f.IsLegacy() {
+		return strings.ReplaceAll(uuid.New().String(), "-", ""), nil
+	}
+	random := make([]byte, f.randomChars())
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	data := make([]byte, 0, f.Groups*f.GroupSize)
+	for _, b := range random {
+		// 256 是 32 的整数倍，取低 5 位不会产生偏差
+		data = append(data, Alphabet[b&31])
+	}
+	if f.CheckDigit {
+		data = append(data, checkChar(data))
+	}
+	return f.join(string(data)), nil
+}
+
+// Normalize 规整用户输入：去掉空白、统一大小写、修正易混淆字符，缺少分隔符时按分组补齐
+func (f Format) Normalize(key string) string {
+	key = strings.Join(strings.Fields(key), "")
+	if f.IsLegacy() {
+		return strings.ToLower(key)
+	}
+	key = strings.ToUpper(key)
+	body := key
+	if f.Prefix != "" {
+		if !strings.HasPrefix(key, f.Prefix+"-") {
+			return key
+		}
+		body = key[len(f.Prefix)+1:]
+	}
+	body = strings.NewReplacer("O", "0", "I", "1", "L", "1").Replace(body)
+	if !strings.Contains(body, "-") && len(body) == f.Groups*f.GroupSize {
+		return f.join(body)
+	}
+	if f.Prefix != "" {
+		return f.Prefix + "-" + body
+	}
+	return body
+}
+
+// Check 校验注册码是否符合格式及校验位，不访问服务端
+// 用户输入应先经过 Normalize
+func (f Format) Check(key string) error {
+	if f.IsLegacy() {
+		if len(key) != legacyLength {
+			return ErrMalformed
+		}
+		for i := 0; i < len(key); i++ {
+			if !isLowerHex(key[i]) {
+				return ErrMalformed
+			}
+		}
+		return nil
+	}
+	body := key
+	if f.Prefix != "" {
+		if !strings.HasPrefix(key, f.Prefix+"-") {
+			return ErrMalformed
+		}
+		body = key[len(f.Prefix)+1:]
+	}
+	groups := strings.Split(body, "-")
+	if len(groups) != f.Groups {
+		return ErrMalformed
+	}
+	for _, group := range groups {
+		if len(group) != f.GroupSize {
+			return ErrMalformed
+		}
+		for i := 0; i < len(group); i++ {
+			if strings.IndexByte(Alphabet, group[i]) < 0 {
+				return ErrMalformed
+			}
+		}
+	}
+	if f.CheckDigit {
+		data := strings.Join(groups, "")
+		if checkChar([]byte(data[:len(data)-1])) != data[len(data)-1] {
+			return ErrChecksum
+		}
+	}
+	return nil
+}
+
+// CheckSyntax 与格式无关的基本检查，用于不知道产品格式时提前拒绝明显错误的输入
+func CheckSyntax(key string) error {
+	if key == "" || len(key) > MaxKeyLength {
+		return ErrMalformed
+	}
+	if key[0] == '-' || key[len(key)-1] == '-' || strings.Contains(key, "--") {
+		return ErrMalformed
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] != '-' && !isUpperAlnum(key[i]) && !(key[i] >= 'a' && key[i] <= 'z') {
+			return ErrMalformed
+		}
+	}
+	return nil
+}
+
+// Digest 注册码的 HMAC-SHA256 摘要，服务端只保存摘要并按摘要查询
+func Digest(secret string, key string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(key))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// DisplayPrefix 注册码用于展示的前缀，最多取前三分之一且不超过 12 个字符
+func DisplayPrefix(key string) string {
+	n := min(len(key)/3, maxDisplayPrefix)
+	return key[:n]
+}
+
+// Mask 返回脱敏后的注册码
+func Mask(prefix string) string {
+	return prefix + "****"
+}
+
+func (f Format) randomChars() int {
+	n := f.Groups * f.GroupSize
+	if f.CheckDigit {
+		n--
+	}
+	return n
+}
+
+func (f Format) join(data string) string {
+	parts := make([]string, 0, f.Groups+1)
+	if f.Prefix != "" {
+		parts = append(parts, f.Prefix)
+	}
+	for i := 0; i < len(data); i += f.GroupSize {
+		parts = append(parts, data[i:i+f.GroupSize])
+	}
+	return strings.Join(parts, "-")
+}
+
+// checkChar 按 Luhn mod 32 计算校验字符，可以发现单个字符错误和大部分相邻字符颠倒
+func checkChar(data []byte) byte {
+	const n = len(Alphabet)
+	sum := 0
+	factor := 2
+	for i := len(data) - 1; i >= 0; i-- {
+		addend := factor * strings.IndexByte(Alphabet, data[i])
+		sum += addend/n + addend%n
+		factor = 3 - factor
+	}
+	return Alphabet[(n-sum%n)%n]
+}
+
+func isUpperAlnum(c byte) bool {
+	return (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+func isLowerHex(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f')
+}
@@ -0,0 +1,97 @@
+package licensekey
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestGenerateAndCheck(t *testing.T) {
+	format := Format{Prefix: "ACME", Groups: 4, GroupSize: 5, CheckDigit: true}
+	key, err := format.Generate()
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if len(key) != len("ACME-XXXXX-XXXXX-XXXXX-XXXXX") || !strings.HasPrefix(key, "ACME-") {
+		t.Fatalf("unexpected key layout: %s", key)
+	}
+	if err := format.Check(key); err != nil {
+		t.Fatalf("check generated key: %v", err)
+	}
+
+	// 修改任意一个字符都会被校验位发现
+	tampered := []byte(key)
+	last := len(tampered) - 2
+	tampered[last] = Alphabet[(strings.IndexByte(Alphabet, tampered[last])+1)%len(Alphabet)]
+	if err := format.Check(string(tampered)); !errors.Is(err, ErrChecksum) {
+		t.Fatalf("expected checksum error, got %v", err)
+	}
+
+	typed := strings.ToLower(strings.ReplaceAll(key[len("ACME-"):], "-", ""))
+	if normalized := format.Normalize(" ACME-" + typed + " "); normalized != key {
+		t.Fatalf("normalize %q = %q, want %q", typed, normalized, key)
+	}
+	for _, bad := range []string{"", "ACME", "ACME-" + key[5:10], "ZZZZ" + key[4:], key + "-AAAAA", strings.Replace(key, key[5:6], "U", 1)} {
+		if err := format.Check(bad); err == nil {
+			t.Fatalf("expected %q to be rejected", bad)
+		}
+	}
+
+	legacy, err := Format{}.Generate()
+	if err != nil || (Format{}).Check(legacy) != nil || strings.Contains(legacy, "-") {
+		t.Fatalf("legacy key %q: %v", legacy, err)
+	}
+}
+
+func TestFormatValidate(t *testing.T) {
+	for _, format := range []Format{
+		{},
+		{Groups: 3, GroupSize: 4},
+		{Prefix: "X1", Groups: 4, GroupSize: 4, CheckDigit: true},
+	} {
+		if err := format.Validate(); err != nil {
+			t.Fatalf("%#v should be valid: %v", format, err)
+		}
+	}
+	for _, format := range []Format{
+		{Prefix: "ACME"},
+		{Prefix: "acme", Groups: 4, GroupSize: 4},
+		{Groups: 2, GroupSize: 4},
+		{Groups: 3, GroupSize: 4, CheckDigit: true},
+		{Groups: 9, GroupSize: 4},
+		{Groups: 2, GroupSize: 9},
+	} {
+		if err := format.Validate(); err == nil {
+			t.Fatalf("%#v should be rejected", format)
+		}
+	}
+}
+
+func TestCheckSyntax(t *testing.T) {
+	for _, key := range []string{"ACME-7K2M-9XHF", "0123456789abcdef0123456789abcdef"} {
+		if err := CheckSyntax(key); err != nil {
+			t.Fatalf("%q should pass: %v", key, err)
+		}
+	}
+	for _, key := range []string{"", "-ABC", "ABC-", "AB--CD", "ABC DEF", "ABC/DEF", strings.Repeat("A", MaxKeyLength+1)} {
+		if err := CheckSyntax(key); !errors.Is(err, ErrMalformed) {
+			t.Fatalf("%q should be malformed, got %v", key, err)
+		}
+	}
+}
+
+func TestDigestAndMask(t *testing.T) {
+	key := "ACME-7K2MQ-9XHFD-3TZ0A-WB4N5"
+	if Digest("secret", key) != Digest("secret", key) || Digest("secret", key) == Digest("other", key) {
+		t.Fatal("digest should be stable and keyed")
+	}
+	if len(Digest("secret", key)) != 64 {
+		t.Fatalf("unexpected digest length: %s", Digest("secret", key))
+	}
+	if prefix := DisplayPrefix(key); prefix != "ACME-7K2M" || Mask(prefix) != "ACME-7K2M****" {
+		t.Fatalf("unexpected display prefix: %s", prefix)
+	}
+	if prefix := DisplayPrefix("0123456789abcdef0123456789abcdef"); prefix != "0123456789" {
+		t.Fatalf("unexpected legacy display prefix: %s", prefix)
+	}
+}
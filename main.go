@@ -4,6 +4,9 @@
 // @host localhost:8080
 // @BasePath /
 // @schemes http
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
 
 package main
 
@@ -52,9 +55,27 @@ func execCommand(name string, arg ...string) error {
 func main() {
 	cfg := global.LoadConfig()
 	fmt.Println("Nexus Core starting...")
+	if err := cfg.ValidateLicenseKeySecret(); err != nil {
+		fmt.Println("ERROR:", err)
+		os.Exit(1)
+	}
+	if cfg.LicenseKey.UsingDevSecret() {
+		fmt.Println("WARNING: license_key.hash_secret is empty; using the built-in development secret, license key digests are NOT protected")
+	}
 	base.MainDBManager = base.InitDBManager(cfg.DBConfig)
 	global.DB = base.MainDBManager.GetDefaultDB()
 	base.AutoMigrate(global.DB)
+	if err := service.NewSigningService().EnsureActiveKey(context.Background()); err != nil {
+		panic(err)
+	}
+	if cfg.AdminAuth.Enabled {
+		if cfg.AdminAuth.BootstrapAPIKey == "" {
+			fmt.Println("WARNING: admin_auth.bootstrap_api_key is empty; management APIs only accept existing operator API keys")
+		}
+		if err := service.NewOperatorService().EnsureBootstrapOperator(context.Background(), cfg.AdminAuth.BootstrapAPIKey); err != nil {
+			panic(err)
+		}
+	}
 	r := api.WebEngine
 
 	// register default routes
@@ -65,6 +86,8 @@ func main() {
 	appCtx, appCancel := context.WithCancel(context.Background())
 	defer appCancel()
 	service.NewProductService().StartScheduledReleaseWorker(appCtx, time.Minute)
+	service.NewNodeService().StartInactiveBindingReclaimWorker(appCtx, time.Hour)
+	service.NewLicenseService().StartScheduledRevokeWorker(appCtx, time.Minute)
 
 	// construct swagger URL based on config.SwaggerURL
 	var swaggerUrl string
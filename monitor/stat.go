@@ -13,40 +13,50 @@ import (
 // @Date 2026/1/31 16 52
 //
 
+// OnlineNodeKey 在线节点标识，License 使用注册码摘要表示，不包含明文注册码
+// 在线统计是进程内全局数据，标识中带上租户，避免不同租户的节点互相占用并发名额
 type OnlineNodeKey struct {
+	TenantID   uint
 	ProductID  uint
 	DeviceCode string
-	LicenseKey string
+	KeyDigest  string
 }
 
-func NewOnlineNodeKey(productID uint, deviceCode string, licenseKey string) *OnlineNodeKey {
+func NewOnlineNodeKey(tenantID uint, productID uint, deviceCode string, keyDigest string) *OnlineNodeKey {
 	return &OnlineNodeKey{
+		TenantID:   tenantID,
 		ProductID:  productID,
 		DeviceCode: deviceCode,
-		LicenseKey: licenseKey,
+		KeyDigest:  keyDigest,
 	}
 }
 
 // From 将字符串解析为 OnlineNodeKey
-// 格式: "ProductID|DeviceCode|LicenseKey"
+// 格式: "TenantID|ProductID|DeviceCode|KeyDigest"
 func From(key string) (*OnlineNodeKey, error) {
 	keys := strings.Split(key, "|")
-	if len(keys) != 3 {
+	if len(keys) != 4 {
 		return nil, fmt.Errorf("invalid key format: %s", key)
 	}
 
+	// 转换 TenantID
+	tenantID, err := strconv.ParseUint(keys[0], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tenantID: %v", err)
+	}
+
 	// 转换 ProductID
-	id, err := strconv.ParseUint(keys[0], 10, 64)
+	id, err := strconv.ParseUint(keys[1], 10, 64)
 	if err != nil {
 		return nil, fmt.Errorf("invalid productID: %v", err)
 	}
 
-	return NewOnlineNodeKey(uint(id), keys[1], keys[2]), nil
+	return NewOnlineNodeKey(uint(tenantID), uint(id), keys[2], keys[3]), nil
 }
 
 // Key 将 OnlineNodeKey 序列化为字符串
 func (k *OnlineNodeKey) Key() string {
-	return fmt.Sprintf("%d|%s|%s", k.ProductID, k.DeviceCode, k.LicenseKey)
+	return fmt.Sprintf("%d|%d|%s|%s", k.TenantID, k.ProductID, k.DeviceCode, k.KeyDigest)
 }
 
 type OnlineStat struct {
@@ -106,10 +116,10 @@ func (s *OnlineStat) CountByProduct(productID uint) int {
 	return count
 }
 
-func (s *OnlineStat) CountByLicense(licenseKey string) int {
+func (s *OnlineStat) CountByLicense(tenantID uint, keyDigest string) int {
 	count := 0
 	for _, onlineNodeKey := range s.Snapshot() {
-		if onlineNodeKey.LicenseKey == licenseKey {
+		if onlineNodeKey.TenantID == tenantID && onlineNodeKey.KeyDigest == keyDigest {
 			count++
 		}
 	}
@@ -117,14 +127,14 @@ func (s *OnlineStat) CountByLicense(licenseKey string) int {
 }
 
 // GetConcurrentByLicenseForProduct 获取许可证下某个产品并发使用情况
-func (s *OnlineStat) GetConcurrentByLicenseForProduct(licenseKey string, productID uint) int {
+func (s *OnlineStat) GetConcurrentByLicenseForProduct(tenantID uint, keyDigest string, productID uint) int {
 	s.mu.Lock()
 	onlineMapCopy := maps.Clone(s.OnlineMap)
 	s.mu.Unlock()
 
 	res := 0
 	for _, onlineNodeKey := range onlineMapCopy {
-		if onlineNodeKey.LicenseKey == licenseKey && onlineNodeKey.ProductID == productID {
+		if onlineNodeKey.TenantID == tenantID && onlineNodeKey.KeyDigest == keyDigest && onlineNodeKey.ProductID == productID {
 			res++
 		}
 	}
@@ -132,10 +142,10 @@ func (s *OnlineStat) GetConcurrentByLicenseForProduct(licenseKey string, product
 }
 
 // GetOnlineLicense 获取所有许可证的在线情况
-func (s *OnlineStat) GetOnlineLicense(licenseKey string) map[string]struct{} {
+func (s *OnlineStat) GetOnlineLicense(tenantID uint, keyDigest string) map[string]struct{} {
 	m := map[string]struct{}{}
 	for _, onlineNodeKey := range s.Snapshot() {
-		if onlineNodeKey.LicenseKey == licenseKey {
+		if onlineNodeKey.TenantID == tenantID && onlineNodeKey.KeyDigest == keyDigest {
 			m[onlineNodeKey.Key()] = struct{}{}
 		}
 	}
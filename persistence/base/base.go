@@ -93,21 +93,47 @@ func AutoMigrate(db *gorm.DB) {
 	// 自动迁移模型，确保表存在
 	if err := db.AutoMigrate(
 		&model.License{},
+		&model.LicenseStatusHistory{},
 		&model.LicenseProductScope{},
 		&model.LicenseServiceScope{},
 		&model.Product{},
 		&model.ProductVersion{},
 		&model.Node{},
 		&model.NodeLicenseBinding{},
+		&model.LicenseAllowedDevice{},
+		&model.SeatLease{},
+		&model.SeatWaiter{},
 		&model.CommonFeature{},
+		&model.ProductFeature{},
+		&model.LicenseFeature{},
+		&model.LicenseFeatureQuota{},
 		&model.ControlService{},
 		&model.NodeServiceCapability{},
 		&model.ControlCommand{},
 		&model.ControlCommandLog{},
 		&model.AuditLog{},
+		&model.SigningKey{},
+		&model.Operator{},
+		&model.OperatorAPIKey{},
+		&model.Tenant{},
+		&model.Customer{},
+		&model.Plan{},
+		&model.PlanServiceScope{},
+		&model.TrialConsumption{},
+		&model.ActivationCode{},
 	); err != nil {
 		panic(fmt.Sprintf("failed to automigrate database: %v", err))
 	}
+	if err := dropLegacyUniqueIndexes(db); err != nil {
+		panic(fmt.Sprintf("failed to drop legacy indexes: %v", err))
+	}
+	if err := migratePlaintextLicenseKeys(db, global.GetConfig().LicenseKey.HashSecret); err != nil {
+		panic(fmt.Sprintf("failed to migrate license keys: %v", err))
+	}
+	// 租户隔离依赖迁移后的 tenant_id 列，统一在此注册
+	if err := RegisterTenantScope(db); err != nil {
+		panic(fmt.Sprintf("failed to register tenant scope: %v", err))
+	}
 }
 
 func ConfigureSQLDB(sqlDB *sql.DB, maxOpenConns, maxIdleConns, connMaxLifetimeMinutes *int) error {
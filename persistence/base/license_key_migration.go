@@ -0,0 +1,42 @@
+package base
+
+import (
+	"nexus-core/licensekey"
+	"nexus-core/persistence/model"
+
+	"gorm.io/gorm"
+)
+
+const licenseKeyMigrationBatch = 500
+
+// migratePlaintextLicenseKeys 为旧版明文保存的注册码计算摘要与展示前缀，并清空明文
+// 包括已删除的 License，它们仍占用租户内的唯一索引；每批单独提交，中断后重启会继续迁移
+func migratePlaintextLicenseKeys(db *gorm.DB, secret string) error {
+	for {
+		var licenses []model.License
+		if err := db.Unscoped().Select("id", "license_key").
+			Where("license_key <> '' AND (key_digest IS NULL OR key_digest = '')").
+			Order("id ASC").Limit(licenseKeyMigrationBatch).
+			Find(&licenses).Error; err != nil {
+			return err
+		}
+		if len(licenses) == 0 {
+			return nil
+		}
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			for _, license := range licenses {
+				if err := tx.Unscoped().Model(&model.License{}).Where("id = ?", license.ID).
+					Updates(map[string]interface{}{
+						"key_digest":  licensekey.Digest(secret, license.LicenseKey),
+						"key_prefix":  licensekey.DisplayPrefix(license.LicenseKey),
+						"license_key": "",
+					}).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+}
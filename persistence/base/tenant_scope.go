@@ -0,0 +1,108 @@
+package base
+
+import (
+	"reflect"
+
+	"nexus-core/persistence/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	tenantField        = "TenantID"
+	tenantCallbackName = "nexus:tenant_scope"
+)
+
+// legacyUniqueIndexes 已被替换的旧唯一索引，迁移时删除
+// 包括改为租户内唯一之前的全局唯一索引、改为按产品绑定之前的节点绑定索引，以及改存摘要之前的明文注册码索引
+var legacyUniqueIndexes = []struct {
+	model interface{}
+	name  string
+}{
+	{&model.Product{}, "idx_product_name"},
+	{&model.License{}, "idx_license_license_key"},
+	{&model.License{}, "idx_license_tenant_key"},
+	{&model.Node{}, "idx_node_device_code"},
+	{&model.ControlService{}, "idx_control_service_identifier"},
+	{&model.NodeLicenseBinding{}, "idx_node_license"},
+}
+
+// dropLegacyUniqueIndexes 删除旧的全局唯一索引，允许不同租户使用相同的设备码、License Key 等
+func dropLegacyUniqueIndexes(db *gorm.DB) error {
+	migrator := db.Migrator()
+	for _, item := range legacyUniqueIndexes {
+		if !migrator.HasIndex(item.model, item.name) {
+			continue
+		}
+		if err := migrator.DropIndex(item.model, item.name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RegisterTenantScope 注册租户隔离回调
+// 上下文带有租户时，含 TenantID 字段的模型在查询、更新、删除时自动追加租户条件，创建时写入租户
+// 原生 SQL（Exec/Raw）不经过该回调，需要自行处理租户条件
+func RegisterTenantScope(db *gorm.DB) error {
+	callback := db.Callback()
+	if callback.Query().Get(tenantCallbackName) != nil {
+		return nil
+	}
+	if err := callback.Create().Before("gorm:create").Register(tenantCallbackName, fillTenant); err != nil {
+		return err
+	}
+	if err := callback.Query().Before("gorm:query").Register(tenantCallbackName, scopeTenant); err != nil {
+		return err
+	}
+	if err := callback.Update().Before("gorm:update").Register(tenantCallbackName, scopeTenant); err != nil {
+		return err
+	}
+	if err := callback.Delete().Before("gorm:delete").Register(tenantCallbackName, scopeTenant); err != nil {
+		return err
+	}
+	return callback.Row().Before("gorm:row").Register(tenantCallbackName, scopeTenant)
+}
+
+func scopeTenant(db *gorm.DB) {
+	tenantID, ok := model.TenantFromContext(db.Statement.Context)
+	if !ok || db.Statement.Schema == nil {
+		return
+	}
+	field := db.Statement.Schema.LookUpField(tenantField)
+	if field == nil {
+		return
+	}
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: tenantID},
+	}})
+}
+
+// fillTenant 创建时强制写入上下文租户，防止跨租户写入
+func fillTenant(db *gorm.DB) {
+	tenantID, ok := model.TenantFromContext(db.Statement.Context)
+	if !ok || db.Statement.Schema == nil {
+		return
+	}
+	field := db.Statement.Schema.LookUpField(tenantField)
+	if field == nil {
+		return
+	}
+	ctx := db.Statement.Context
+	value := db.Statement.ReflectValue
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			item := reflect.Indirect(value.Index(i))
+			if err := field.Set(ctx, item, tenantID); err != nil {
+				_ = db.AddError(err)
+				return
+			}
+		}
+	case reflect.Struct:
+		if err := field.Set(ctx, value, tenantID); err != nil {
+			_ = db.AddError(err)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// ActivationCode 一次性兑换码，兑换后为 License 增加有效时长或节点数、并发数
+// 与注册码相同，只保存兑换码的摘要和展示前缀
+type ActivationCode struct {
+	BaseModel
+	TenantID          uint       `gorm:"uniqueIndex:idx_activation_code_tenant_digest;index;not null;default:0"`  // 所属租户
+	CodeDigest        string     `gorm:"uniqueIndex:idx_activation_code_tenant_digest;type:varchar(64);not null"` // 兑换码 HMAC-SHA256 摘要，租户内唯一
+	CodePrefix        string     `gorm:"type:varchar(16);not null;default:''"`                                    // 兑换码展示前缀
+	Batch             string     `gorm:"type:varchar(64);index;not null"`                                         // 生成批次
+	ProductID         *uint      `gorm:"index"`                                                                   // 只能兑换该产品的 License，为空表示不限制
+	ExtraHours        int        `gorm:"type:int;not null;default:0"`                                             // 增加的有效时长（小时）
+	ExtraNodes        int        `gorm:"type:int;not null;default:0"`                                             // 增加的最大节点数
+	ExtraConcurrent   int        `gorm:"type:int;not null;default:0"`                                             // 增加的并发限制
+	ExpiresAt         *time.Time `gorm:"type:datetime"`                                                           // 兑换截止时间
+	Status            int        `gorm:"type:int;index;not null;default:0"`                                       // 0未使用，1已兑换，2已作废
+	RedeemedLicenseID *uint      `gorm:"index"`                                                                   // 兑换到的 License
+	RedeemedAt        *time.Time `gorm:"type:datetime"`                                                           // 兑换时间
+	RedeemedBy        string     `gorm:"type:varchar(100);not null;default:''"`                                   // 兑换人：操作人或客户端 IP
+	Remark            *string    `gorm:"type:text"`
+}
+
+func (ActivationCode) TableName() string {
+	return "activation_code"
+}
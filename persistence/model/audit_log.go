@@ -5,6 +5,7 @@ import "gorm.io/datatypes"
 // AuditLog 记录产品、License、节点、绑定和控制指令的关键操作。
 type AuditLog struct {
 	BaseModel
+	TenantID     uint           `gorm:"index;not null;default:0"`
 	ResourceType string         `gorm:"type:varchar(50);index;not null"`
 	ResourceID   uint           `gorm:"index;not null;default:0"`
 	Action       string         `gorm:"type:varchar(100);index;not null"`
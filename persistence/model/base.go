@@ -1,7 +1,9 @@
 package model
 
 import (
+	"context"
 	"time"
+	"unicode/utf8"
 
 	"gorm.io/gorm"
 )
@@ -20,14 +22,49 @@ type BaseModel struct {
 	UpdatedBy string         `gorm:"size:64"` // 记录修改人
 }
 
+// SystemOperator 无请求身份时（后台任务、节点接口等）记录的操作人
+const SystemOperator = "system"
+
+type operatorKey struct{}
+
+// maxOperatorLength 与 CreatedBy/UpdatedBy 列宽一致
+const maxOperatorLength = 64
+
+// WithOperator 将操作人写入上下文，经 db.WithContext 传递给模型钩子
+func WithOperator(ctx context.Context, operator string) context.Context {
+	if operator == "" {
+		return ctx
+	}
+	if len(operator) > maxOperatorLength {
+		// 按字符边界截断，避免把多字节字符截成无效的 UTF-8
+		end := maxOperatorLength
+		for end > 0 && !utf8.RuneStart(operator[end]) {
+			end--
+		}
+		operator = operator[:end]
+	}
+	return context.WithValue(ctx, operatorKey{}, operator)
+}
+
+// OperatorFromContext 读取上下文中的操作人，缺省为 system
+func OperatorFromContext(ctx context.Context) string {
+	if ctx != nil {
+		if operator, ok := ctx.Value(operatorKey{}).(string); ok && operator != "" {
+			return operator
+		}
+	}
+	return SystemOperator
+}
+
 func (m *BaseModel) BeforeCreate(tx *gorm.DB) (err error) {
-	// 假设从上下文获取当前用户
-	m.CreatedBy = "system"
-	m.UpdatedBy = "system"
+	operator := OperatorFromContext(tx.Statement.Context)
+	m.CreatedBy = operator
+	m.UpdatedBy = operator
 	return
 }
 
 func (m *BaseModel) BeforeUpdate(tx *gorm.DB) (err error) {
-	m.UpdatedBy = "system"
+	// map 方式更新时修改结构体字段不会生效，需要通过 SetColumn 写入更新列
+	tx.Statement.SetColumn("UpdatedBy", OperatorFromContext(tx.Statement.Context))
 	return
 }
@@ -16,14 +16,15 @@ const (
 // ControlService 定义服务端可下发给节点的控制服务。
 type ControlService struct {
 	BaseModel
-	ProductID    *uint          `gorm:"index"`                                  // 为空表示通用服务
-	Identifier   string         `gorm:"uniqueIndex;type:varchar(100);not null"` // 服务唯一标识
-	Name         string         `gorm:"type:varchar(100);not null"`             // 服务名称
-	Description  *string        `gorm:"type:text"`                              // 服务描述
-	ServiceType  string         `gorm:"type:varchar(50);index;not null"`        // command/config/query/action
-	InputSchema  datatypes.JSON `gorm:"type:json"`                              // 标准输入 Schema
-	OutputSchema datatypes.JSON `gorm:"type:json"`                              // 标准输出 Schema
-	Status       int            `gorm:"type:int;index;not null;default:1"`      // 1启用，2禁用
+	TenantID     uint           `gorm:"uniqueIndex:idx_control_service_tenant_identifier;index;not null;default:0"`   // 所属租户
+	ProductID    *uint          `gorm:"index"`                                                                        // 为空表示通用服务
+	Identifier   string         `gorm:"uniqueIndex:idx_control_service_tenant_identifier;type:varchar(100);not null"` // 服务标识，租户内唯一
+	Name         string         `gorm:"type:varchar(100);not null"`                                                   // 服务名称
+	Description  *string        `gorm:"type:text"`                                                                    // 服务描述
+	ServiceType  string         `gorm:"type:varchar(50);index;not null"`                                              // command/config/query/action
+	InputSchema  datatypes.JSON `gorm:"type:json"`                                                                    // 标准输入 Schema
+	OutputSchema datatypes.JSON `gorm:"type:json"`                                                                    // 标准输出 Schema
+	Status       int            `gorm:"type:int;index;not null;default:1"`                                            // 1启用，2禁用
 }
 
 func (ControlService) TableName() string {
@@ -33,6 +34,7 @@ func (ControlService) TableName() string {
 // NodeServiceCapability 记录某个节点实际支持的控制服务及其节点侧 Schema。
 type NodeServiceCapability struct {
 	BaseModel
+	TenantID          uint           `gorm:"index;not null;default:0"` // 所属租户，与节点一致
 	NodeID            uint           `gorm:"uniqueIndex:idx_node_service_capability;index;not null"`
 	ServiceIdentifier string         `gorm:"uniqueIndex:idx_node_service_capability;type:varchar(100);not null"`
 	Schema            datatypes.JSON `gorm:"type:json"`                         // 节点侧字段 Schema
@@ -48,6 +50,7 @@ func (NodeServiceCapability) TableName() string {
 // ControlCommand 记录服务端对节点发起的一次控制调用。
 type ControlCommand struct {
 	BaseModel
+	TenantID          uint           `gorm:"index;not null;default:0"` // 所属租户，与节点一致
 	NodeID            uint           `gorm:"index;not null"`
 	ServiceIdentifier string         `gorm:"type:varchar(100);index;not null"`
 	Payload           datatypes.JSON `gorm:"type:json"`                         // 服务端标准请求
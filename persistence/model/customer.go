@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// Customer 客户账号，License 归属于客户，客户可通过自助门户查看和解绑
+type Customer struct {
+	BaseModel
+	TenantID          uint       `gorm:"index;not null;default:0"` // 所属租户
+	Name              string     `gorm:"type:varchar(100);index;not null"`
+	Company           string     `gorm:"type:varchar(200)"`
+	ContactName       string     `gorm:"type:varchar(100)"`
+	Email             string     `gorm:"type:varchar(255);index"`
+	Phone             string     `gorm:"type:varchar(50)"`
+	Status            int        `gorm:"type:int;index;not null;default:1"` // 1启用，2停用
+	PortalKeyHash     string     `gorm:"type:varchar(64);index"`            // 门户访问密钥的 SHA-256 摘要，空表示未开通
+	PortalKeyIssuedAt *time.Time `gorm:"type:datetime"`
+}
+
+func (Customer) TableName() string {
+	return "customer"
+}
@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 //
 // @Author yfy2001
 // @Date 2026/3/19 11 06
@@ -14,15 +16,16 @@ const (
 )
 
 type Feature struct {
-	Identifier  string  `json:"identifier" gorm:"type:varchar(255);unique"` // 功能标识符（通用表内唯一，产品内唯一）
-	Type        string  `json:"type" gorm:"type:varchar(255);not null"`     // 功能类型
-	Name        string  `json:"name" gorm:"type:varchar(255);not null"`     // 功能名称
-	Description *string `json:"description" gorm:"type:text"`               // 功能描述
-	Data        *string `json:"data" gorm:"type:text"`                      // 相关数据
+	Identifier  string  `json:"identifier" gorm:"uniqueIndex:idx_feature_tenant_identifier;type:varchar(255);not null"` // 功能标识符（租户内唯一）
+	Type        string  `json:"type" gorm:"type:varchar(255);not null"`                                                 // 功能类型
+	Name        string  `json:"name" gorm:"type:varchar(255);not null"`                                                 // 功能名称
+	Description *string `json:"description" gorm:"type:text"`                                                           // 功能描述
+	Data        *string `json:"data" gorm:"type:text"`                                                                  // 相关数据
 }
 
 type CommonFeature struct {
 	BaseModel
+	TenantID uint `json:"tenant_id" gorm:"uniqueIndex:idx_feature_tenant_identifier;index;not null;default:0"` // 所属租户
 	Feature
 	Status int `json:"status" gorm:"type:int;index;not null;default:1"` // 状态：1启用，2禁用
 }
@@ -30,3 +33,44 @@ type CommonFeature struct {
 func (CommonFeature) TableName() string {
 	return "common_feature"
 }
+
+// ProductFeature 产品提供的功能
+// IsDefault 为 true 时该产品的所有 License 默认拥有此功能，否则需要单独授予
+type ProductFeature struct {
+	BaseModel
+	ProductID uint `gorm:"uniqueIndex:idx_product_feature;index;not null"`
+	FeatureID uint `gorm:"uniqueIndex:idx_product_feature;index;not null"`
+	IsDefault bool `gorm:"not null;default:false"`
+}
+
+func (ProductFeature) TableName() string {
+	return "product_feature"
+}
+
+// LicenseFeature 单个 License 授予的功能，ExpiresAt 为空表示随 License 有效
+type LicenseFeature struct {
+	BaseModel
+	LicenseID uint       `gorm:"uniqueIndex:idx_license_feature;index;not null"`
+	FeatureID uint       `gorm:"uniqueIndex:idx_license_feature;index;not null"`
+	ExpiresAt *time.Time `gorm:"type:datetime"`
+}
+
+func (LicenseFeature) TableName() string {
+	return "license_feature"
+}
+
+// LicenseFeatureQuota License 按功能计量的用量配额
+// Used 为当前周期内已用量，周期滚动时归零并更新 PeriodStart
+type LicenseFeatureQuota struct {
+	BaseModel
+	LicenseID   uint      `gorm:"uniqueIndex:idx_license_feature_quota;index;not null"`
+	FeatureID   uint      `gorm:"uniqueIndex:idx_license_feature_quota;index;not null"`
+	Quota       int64     `gorm:"not null"`                  // 每个周期的可用量
+	Period      string    `gorm:"type:varchar(20);not null"` // 重置周期：day、month、total
+	Used        int64     `gorm:"not null;default:0"`        // 当前周期已用量
+	PeriodStart time.Time `gorm:"type:datetime;not null"`    // 当前周期开始时间
+}
+
+func (LicenseFeatureQuota) TableName() string {
+	return "license_feature_quota"
+}
@@ -10,17 +10,39 @@ import "time"
 // License 许可
 type License struct {
 	BaseModel
-	ProductID        uint       `gorm:"index;not null"`                         // 产品id
-	LicenseKey       string     `gorm:"uniqueIndex;type:varchar(255);not null"` // 注册码
-	ValidityHours    int        `gorm:"type:int;not null"`                      // 有效时长（小时）
-	ActivatedAt      *time.Time `gorm:"type:datetime"`                          // 激活时间
-	ExpiredAt        *time.Time `gorm:"type:datetime"`                          // 过期时间
-	Status           int        `gorm:"type:int;index;not null;default:0"`      // 状态：0未激活，1激活，2过期，3吊销
-	MaxNodes         int        `gorm:"type:int;not null;default:0"`            // 最大节点数 (0 = 不限制)
-	CurrentNodeCount int        `gorm:"type:int;not null;default:0"`            // 当前绑定数量
-	MaxConcurrent    int        `gorm:"type:int;not null;default:0"`            // 并发限制 (0 = 不限制)
-	FeatureMask      string     `gorm:"type:varchar(255)"`                      // 兼容旧字段，后续迁移至 license_service_scope
-	Remark           *string    `gorm:"type:text"`                              // 备注
+	TenantID              uint       `gorm:"uniqueIndex:idx_license_tenant_key_digest;index;not null;default:0"` // 所属租户
+	ProductID             uint       `gorm:"index;not null"`                                                     // 产品id
+	CustomerID            *uint      `gorm:"index"`                                                              // 所属客户，为空表示未分配
+	PlanID                *uint      `gorm:"index"`                                                              // 创建或最近切换的套餐
+	Type                  int        `gorm:"type:int;index;not null;default:0"`                                  // 类型：0正式，1试用
+	TrialMaxHours         int        `gorm:"type:int;not null;default:0"`                                        // 试用许可证续期后的最长有效时长（小时）
+	ConvertedAt           *time.Time `gorm:"type:datetime"`                                                      // 试用转正式的时间
+	LicenseKey            string     `gorm:"type:varchar(255);not null"`                                         // 旧版明文注册码，迁移为摘要后清空
+	KeyDigest             string     `gorm:"uniqueIndex:idx_license_tenant_key_digest;type:varchar(64)"`         // 注册码 HMAC-SHA256 摘要，租户内唯一
+	KeyPrefix             string     `gorm:"type:varchar(16);not null;default:''"`                               // 注册码展示前缀
+	ValidityHours         int        `gorm:"type:int;not null"`                                                  // 有效时长（小时），永久授权为 0
+	Perpetual             bool       `gorm:"not null;default:false"`                                             // 永久授权，激活后不过期
+	VersionCutoffAt       *time.Time `gorm:"type:datetime"`                                                      // 只授权在此时间及之前发布的版本
+	MinVersionID          *uint      `gorm:"index"`                                                              // 授权的最低版本（按发布时间，含）
+	MaxVersionID          *uint      `gorm:"index"`                                                              // 授权的最高版本（按发布时间，含）
+	ActivatedAt           *time.Time `gorm:"type:datetime"`                                                      // 激活时间
+	ExpiredAt             *time.Time `gorm:"type:datetime"`                                                      // 过期时间
+	NotBefore             *time.Time `gorm:"type:datetime"`                                                      // 合同开始时间，之前不能注册和心跳
+	ScheduledRevokeAt     *time.Time `gorm:"type:datetime;index"`                                                // 计划吊销时间，到期由后台任务吊销
+	RevokedAt             *time.Time `gorm:"type:datetime"`                                                      // 最近一次吊销时间，早于该时间签发的租约失效
+	Status                int        `gorm:"type:int;index;not null;default:0"`                                  // 状态：0未激活，1激活，2过期，3吊销，4宽限期
+	GracePeriodHours      *int       `gorm:"type:int"`                                                           // 过期后的宽限期（小时），为空时使用产品设置
+	InactiveUnbindDays    *int       `gorm:"type:int"`                                                           // 节点连续多少天无心跳后自动解绑，为空时使用产品设置
+	ApprovalRequired      bool       `gorm:"not null;default:false"`                                             // 新设备绑定需要审批
+	TransferLimit         int        `gorm:"type:int;not null;default:0"`                                        // 统计窗口内最多绑定的不同设备数，0 表示不限制
+	TransferWindowDays    int        `gorm:"type:int;not null;default:0"`                                        // 换绑统计窗口（天）
+	RebindCooldownMinutes int        `gorm:"type:int;not null;default:0"`                                        // 解绑释放的节点名额重新可用前的冷却时长（分钟）
+	TransferResetAt       *time.Time `gorm:"type:datetime"`                                                      // 最近一次重置换绑计数的时间
+	MaxNodes              int        `gorm:"type:int;not null;default:0"`                                        // 最大节点数 (0 = 不限制)
+	CurrentNodeCount      int        `gorm:"type:int;not null;default:0"`                                        // 当前绑定数量
+	MaxConcurrent         int        `gorm:"type:int;not null;default:0"`                                        // 并发限制 (0 = 不限制)
+	FeatureMask           string     `gorm:"type:varchar(255)"`                                                  // 兼容旧字段，后续迁移至 license_service_scope
+	Remark                *string    `gorm:"type:text"`                                                          // 备注
 }
 
 func (License) TableName() string {
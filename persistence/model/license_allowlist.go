@@ -0,0 +1,13 @@
+package model
+
+// LicenseAllowedDevice License 的设备白名单，设置后只有名单内的设备可以注册
+type LicenseAllowedDevice struct {
+	BaseModel
+	LicenseID  uint    `gorm:"uniqueIndex:idx_license_allowed_device;index;not null"`             // 对应 License.ID
+	DeviceCode string  `gorm:"uniqueIndex:idx_license_allowed_device;type:varchar(100);not null"` // 允许注册的设备识别码
+	Remark     *string `gorm:"type:text"`                                                         // 备注
+}
+
+func (LicenseAllowedDevice) TableName() string {
+	return "license_allowed_device"
+}
@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// LicenseStatusHistory License 状态、有效期和限制的变更历史，用于回溯任意时刻的授权状态
+// 每条记录保存变更前后的取值，create 事件的变更前取值与变更后相同
+type LicenseStatusHistory struct {
+	BaseModel
+	TenantID          uint       `gorm:"index;not null;default:0"`
+	LicenseID         uint       `gorm:"index:idx_license_history_time;not null"`
+	Event             string     `gorm:"type:varchar(32);index;not null"`
+	FromStatus        int        `gorm:"not null;default:0"`
+	ToStatus          int        `gorm:"not null;default:0"`
+	FromExpiredAt     *time.Time `gorm:"type:datetime"`
+	ToExpiredAt       *time.Time `gorm:"type:datetime"`
+	FromMaxNodes      int        `gorm:"not null;default:0"`
+	ToMaxNodes        int        `gorm:"not null;default:0"`
+	FromMaxConcurrent int        `gorm:"not null;default:0"`
+	ToMaxConcurrent   int        `gorm:"not null;default:0"`
+	ExtraHours        int        `gorm:"not null;default:0"` // 仅 renew 事件
+	Operator          string     `gorm:"type:varchar(100);not null;default:system"`
+	OccurredAt        time.Time  `gorm:"type:datetime;index:idx_license_history_time;not null"`
+}
+
+func (LicenseStatusHistory) TableName() string {
+	return "license_status_history"
+}
@@ -1,12 +1,14 @@
 package model
 
 // LicenseProductScope 表示 License 可授权的产品范围。
-// 当前 License 表仍保留 ProductID 作为主产品字段；该表用于后续扩展多产品授权。
+// License.ProductID 为主产品，始终在授权范围内；该表列出额外授权的产品，也可为主产品单独设置限制。
 type LicenseProductScope struct {
 	BaseModel
-	LicenseID uint `gorm:"uniqueIndex:idx_license_product_scope;index;not null"`
-	ProductID uint `gorm:"uniqueIndex:idx_license_product_scope;index;not null"`
-	Status    int  `gorm:"type:int;index;not null;default:1"` // 1启用，2禁用
+	LicenseID     uint `gorm:"uniqueIndex:idx_license_product_scope;index;not null"`
+	ProductID     uint `gorm:"uniqueIndex:idx_license_product_scope;index;not null"`
+	Status        int  `gorm:"type:int;index;not null;default:1"` // 1启用，2禁用
+	MaxNodes      int  `gorm:"type:int;not null;default:0"`       // 该产品的最大节点数 (0 = 仅受 License 总数限制)
+	MaxConcurrent int  `gorm:"type:int;not null;default:0"`       // 该产品的并发限制 (0 = 仅受 License 总数限制)
 }
 
 func (LicenseProductScope) TableName() string {
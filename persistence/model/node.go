@@ -14,16 +14,21 @@ import (
 // Node 节点信息
 type Node struct {
 	BaseModel
-	DeviceCode          string         `gorm:"type:varchar(100);uniqueIndex;not null"` // 设备唯一识别码
-	Status              int            `gorm:"type:int;index;not null;default:0"`      // 状态：0正常，1离线，2封禁，3强制下线
-	Metadata            datatypes.JSON `gorm:"type:json"`                              // 其他元信息
-	LastSeenAt          *time.Time     `gorm:"type:datetime;index"`                    // 最近心跳时间
-	OnlineAt            *time.Time     `gorm:"type:datetime"`                          // 最近上线时间
-	OfflineAt           *time.Time     `gorm:"type:datetime"`                          // 最近离线时间
-	BannedAt            *time.Time     `gorm:"type:datetime"`                          // 封禁时间
-	BanReason           *string        `gorm:"type:text"`                              // 封禁原因
-	ForcedOfflineAt     *time.Time     `gorm:"type:datetime"`                          // 强制下线时间
-	ForcedOfflineReason *string        `gorm:"type:text"`                              // 强制下线原因
+	TenantID            uint           `gorm:"uniqueIndex:idx_node_tenant_device;index;not null;default:0"`   // 所属租户
+	DeviceCode          string         `gorm:"uniqueIndex:idx_node_tenant_device;type:varchar(100);not null"` // 设备识别码，租户内唯一
+	Status              int            `gorm:"type:int;index;not null;default:0"`                             // 状态：0正常，1离线，2封禁，3强制下线
+	Metadata            datatypes.JSON `gorm:"type:json"`                                                     // 其他元信息
+	LastSeenAt          *time.Time     `gorm:"type:datetime;index"`                                           // 最近心跳时间
+	OnlineAt            *time.Time     `gorm:"type:datetime"`                                                 // 最近上线时间
+	OfflineAt           *time.Time     `gorm:"type:datetime"`                                                 // 最近离线时间
+	BannedAt            *time.Time     `gorm:"type:datetime"`                                                 // 封禁时间
+	BanReason           *string        `gorm:"type:text"`                                                     // 封禁原因
+	ForcedOfflineAt     *time.Time     `gorm:"type:datetime"`                                                 // 强制下线时间
+	ForcedOfflineReason *string        `gorm:"type:text"`                                                     // 强制下线原因
+	CredentialHash      string         `gorm:"type:varchar(64)"`                                              // 节点密钥的 SHA-256 摘要，空表示未签发
+	CredentialIssuedAt  *time.Time     `gorm:"type:datetime"`                                                 // 节点密钥签发时间
+	CredentialRevokedAt *time.Time     `gorm:"type:datetime"`                                                 // 节点密钥吊销时间，重新签发前拒绝注册
+	ReservedLicenseID   *uint          `gorm:"index"`                                                         // 预留给指定 License，只能注册到该 License
 }
 
 func (Node) TableName() string {
@@ -10,13 +10,15 @@ import "time"
 // NodeLicenseBinding 节点绑定关系
 type NodeLicenseBinding struct {
 	BaseModel
-	NodeID    uint       `gorm:"uniqueIndex:idx_node_license;index;not null"`     // 节点唯一标识 Node.ID
-	LicenseID uint       `gorm:"uniqueIndex:idx_node_license;index;not null"`     // 对应 License.ID
-	ProductID uint       `gorm:"index;not null;default:0"`                        // 关联产品
-	Status    int        `gorm:"type:int;index;not null;default:0"`               // 状态：0未绑定，1已绑定
-	IsBound   bool       `json:"-" gorm:"column:is_bound;not null;default:false"` // 兼容旧 SQLite 表，业务逻辑统一使用 Status
-	BoundAt   *time.Time `gorm:"type:datetime"`                                   // 绑定时间
-	UnboundAt *time.Time `gorm:"type:datetime"`                                   // 解绑时间
+	NodeID       uint       `gorm:"uniqueIndex:idx_node_license_product;index;not null"`           // 节点唯一标识 Node.ID
+	LicenseID    uint       `gorm:"uniqueIndex:idx_node_license_product;index;not null"`           // 对应 License.ID
+	ProductID    uint       `gorm:"uniqueIndex:idx_node_license_product;index;not null;default:0"` // 关联产品，多产品 License 按产品分别绑定
+	Status       int        `gorm:"type:int;index;not null;default:0"`                             // 状态：0未绑定，1已绑定，2待审批，3已拒绝
+	IsBound      bool       `json:"-" gorm:"column:is_bound;not null;default:false"`               // 兼容旧 SQLite 表，业务逻辑统一使用 Status
+	RequestedAt  *time.Time `gorm:"type:datetime"`                                                 // 申请绑定的时间，需要审批时设置
+	BoundAt      *time.Time `gorm:"type:datetime"`                                                 // 绑定时间
+	UnboundAt    *time.Time `gorm:"type:datetime"`                                                 // 解绑时间
+	LastActiveAt *time.Time `gorm:"type:datetime;index"`                                           // 最近一次注册或心跳时间，为空时按绑定时间计算
 }
 
 func (NodeLicenseBinding) TableName() string {
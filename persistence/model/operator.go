@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+// Operator 管理后台操作员，通过角色决定可访问的管理接口
+type Operator struct {
+	BaseModel
+	TenantID    uint   `gorm:"index;not null;default:0"` // 所属租户，只能管理本租户数据
+	Username    string `gorm:"uniqueIndex;type:varchar(64);not null"`
+	DisplayName string `gorm:"type:varchar(100)"`
+	Role        string `gorm:"type:varchar(32);index;not null"`   // viewer、license-admin、node-operator、super-admin
+	Status      int    `gorm:"type:int;index;not null;default:1"` // 1启用，2停用
+}
+
+func (Operator) TableName() string {
+	return "operator"
+}
+
+// OperatorAPIKey 操作员 API Key，仅保存摘要，明文只在创建时返回一次
+type OperatorAPIKey struct {
+	BaseModel
+	OperatorID uint       `gorm:"index;not null"`
+	Name       string     `gorm:"type:varchar(100)"`
+	KeyPrefix  string     `gorm:"type:varchar(16);index;not null"`       // 明文前缀，便于识别
+	KeyHash    string     `gorm:"uniqueIndex;type:varchar(64);not null"` // sha256 摘要
+	LastUsedAt *time.Time `gorm:"type:datetime"`
+	ExpiresAt  *time.Time `gorm:"type:datetime"`
+	RevokedAt  *time.Time `gorm:"type:datetime"`
+}
+
+func (OperatorAPIKey) TableName() string {
+	return "operator_api_key"
+}
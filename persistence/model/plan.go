@@ -0,0 +1,29 @@
+package model
+
+// Plan 订阅套餐，作为 License 模板定义默认有效期、节点数、并发数和可用控制服务
+type Plan struct {
+	BaseModel
+	TenantID      uint    `gorm:"index;not null;default:0"`                                     // 所属租户
+	ProductID     uint    `gorm:"uniqueIndex:idx_plan_product_name;index;not null"`             // 所属产品
+	Name          string  `gorm:"uniqueIndex:idx_plan_product_name;type:varchar(100);not null"` // 套餐名称，产品内唯一
+	Description   *string `gorm:"type:text"`
+	ValidityHours int     `gorm:"type:int;not null"`                 // 有效时长（小时）
+	MaxNodes      int     `gorm:"type:int;not null;default:0"`       // 最大节点数 (0 = 不限制)
+	MaxConcurrent int     `gorm:"type:int;not null;default:0"`       // 并发限制 (0 = 不限制)
+	Status        int     `gorm:"type:int;index;not null;default:1"` // 1启用，2停用
+}
+
+func (Plan) TableName() string {
+	return "plan"
+}
+
+// PlanServiceScope 套餐包含的控制服务，创建或切换套餐时复制为 LicenseServiceScope
+type PlanServiceScope struct {
+	BaseModel
+	PlanID            uint   `gorm:"uniqueIndex:idx_plan_service_scope;index;not null"`
+	ServiceIdentifier string `gorm:"uniqueIndex:idx_plan_service_scope;type:varchar(100);not null"`
+}
+
+func (PlanServiceScope) TableName() string {
+	return "plan_service_scope"
+}
@@ -10,11 +10,19 @@ import "gorm.io/datatypes"
 // Product 产品信息
 type Product struct {
 	BaseModel
-	Name                  string         `gorm:"uniqueIndex;type:varchar(100);not null"` // 产品名称
-	Description           *string        `gorm:"type:text"`                              // 产品描述
-	Status                int            `gorm:"type:int;index;not null;default:1"`      // 状态：1启用，2禁用，3废弃
-	MinSupportedVersionID *uint          `gorm:"index"`                                  // 最低支持版本
-	FeatureList           datatypes.JSON `gorm:"type:json"`                              // 兼容旧字段，后续迁移至服务/功能关联表
+	TenantID              uint           `gorm:"uniqueIndex:idx_product_tenant_name;index;not null;default:0"`   // 所属租户
+	Name                  string         `gorm:"uniqueIndex:idx_product_tenant_name;type:varchar(100);not null"` // 产品名称，租户内唯一
+	Description           *string        `gorm:"type:text"`                                                      // 产品描述
+	Status                int            `gorm:"type:int;index;not null;default:1"`                              // 状态：1启用，2禁用，3废弃
+	MinSupportedVersionID *uint          `gorm:"index"`                                                          // 最低支持版本
+	GracePeriodHours      int            `gorm:"type:int;not null;default:0"`                                    // License 过期后的默认宽限期（小时），0 表示无宽限期
+	GraceAllowControl     bool           `gorm:"not null;default:false"`                                         // 宽限期内是否允许下发控制命令
+	InactiveUnbindDays    int            `gorm:"type:int;not null;default:0"`                                    // 节点连续多少天无心跳后自动解绑，0 表示不自动解绑
+	KeyPrefix             string         `gorm:"type:varchar(12);not null;default:''"`                           // 注册码前缀
+	KeyGroups             int            `gorm:"type:int;not null;default:0"`                                    // 注册码分组数，0 表示使用去掉横线的 UUID
+	KeyGroupSize          int            `gorm:"type:int;not null;default:0"`                                    // 每组 Base32 字符数
+	KeyCheckDigit         bool           `gorm:"not null;default:false"`                                         // 注册码最后一位是否为校验位
+	FeatureList           datatypes.JSON `gorm:"type:json"`                                                      // 兼容旧字段，后续迁移至服务/功能关联表
 }
 
 func (Product) TableName() string {
@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+// SeatLease 浮动席位租约，每个节点在同一 License 的同一产品下最多占用一个席位
+// 超过 ExpiresAt 未续期视为已释放
+type SeatLease struct {
+	BaseModel
+	LicenseID uint      `gorm:"uniqueIndex:idx_seat_lease_node;index;not null"`
+	ProductID uint      `gorm:"uniqueIndex:idx_seat_lease_node;not null"`
+	NodeID    uint      `gorm:"uniqueIndex:idx_seat_lease_node;index;not null"`
+	ExpiresAt time.Time `gorm:"type:datetime;index;not null"`
+}
+
+func (SeatLease) TableName() string {
+	return "seat_lease"
+}
+
+// SeatWaiter 席位占满时排队等待的节点，按 ID 先后排队
+// 超过 ExpiresAt 未再次申请视为放弃排队
+type SeatWaiter struct {
+	BaseModel
+	LicenseID uint      `gorm:"uniqueIndex:idx_seat_waiter_node;index;not null"`
+	ProductID uint      `gorm:"uniqueIndex:idx_seat_waiter_node;not null"`
+	NodeID    uint      `gorm:"uniqueIndex:idx_seat_waiter_node;index;not null"`
+	ExpiresAt time.Time `gorm:"type:datetime;index;not null"`
+}
+
+func (SeatWaiter) TableName() string {
+	return "seat_waiter"
+}
@@ -0,0 +1,18 @@
+package model
+
+// SigningKey 服务端签名密钥，用于签发离线 License 文件等可离线校验的凭证。
+// 停用的密钥不再用于签发，但其公钥仍会对外公布，保证历史文件可以继续校验。
+type SigningKey struct {
+	BaseModel
+	KeyID     string `gorm:"uniqueIndex;type:varchar(64);not null"` // 密钥标识，写入签名文件
+	Algorithm string `gorm:"type:varchar(20);not null"`             // 签名算法
+	PublicKey string `gorm:"type:text;not null"`                    // base64 公钥
+	// PrivateKey base64 私钥种子，以明文保存：拿到数据库或备份即可伪造任意 License 文件和租约，
+	// 数据库访问权限和备份需按密钥同等级别保护，泄露后应立即轮换密钥并停用旧密钥
+	PrivateKey string `gorm:"type:text;not null"`
+	Status     int    `gorm:"type:int;index;not null;default:1"` // 1启用，2停用
+}
+
+func (SigningKey) TableName() string {
+	return "signing_key"
+}
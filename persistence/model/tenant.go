@@ -0,0 +1,47 @@
+package model
+
+import "context"
+
+// DefaultTenantID 默认租户，未指定租户的请求与历史数据都归属该租户
+const DefaultTenantID uint = 0
+
+// Tenant 租户，产品、License、节点与控制数据按租户隔离
+type Tenant struct {
+	BaseModel
+	Code   string `gorm:"uniqueIndex;type:varchar(64);not null"` // 租户编码，请求头 X-Tenant 使用
+	Name   string `gorm:"type:varchar(100);not null"`
+	Status int    `gorm:"type:int;index;not null;default:1"` // 1启用，2停用
+}
+
+func (Tenant) TableName() string {
+	return "tenant"
+}
+
+type tenantKey struct{}
+
+type tenantScope struct {
+	id     uint
+	scoped bool
+}
+
+// WithTenant 将租户写入上下文，经 db.WithContext 传递后查询、更新、删除自动附加租户条件，创建时自动填充租户
+func WithTenant(ctx context.Context, tenantID uint) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantScope{id: tenantID, scoped: true})
+}
+
+// WithoutTenantScope 取消上下文中的租户限制，仅用于认证等需要跨租户查找的场景
+func WithoutTenantScope(ctx context.Context) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantScope{})
+}
+
+// TenantFromContext 读取上下文中的租户，ok 为 false 表示不做租户限制（后台任务等）
+func TenantFromContext(ctx context.Context) (uint, bool) {
+	if ctx == nil {
+		return DefaultTenantID, false
+	}
+	scope, ok := ctx.Value(tenantKey{}).(tenantScope)
+	if !ok || !scope.scoped {
+		return DefaultTenantID, false
+	}
+	return scope.id, true
+}
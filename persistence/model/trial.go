@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// TrialConsumption 记录设备已使用过的产品试用，同一设备在同一产品下只能使用一次试用
+type TrialConsumption struct {
+	BaseModel
+	TenantID   uint      `gorm:"uniqueIndex:idx_trial_device;index;not null;default:0"`   // 所属租户
+	ProductID  uint      `gorm:"uniqueIndex:idx_trial_device;not null"`                   // 产品id
+	DeviceCode string    `gorm:"uniqueIndex:idx_trial_device;type:varchar(255);not null"` // 设备码
+	LicenseID  uint      `gorm:"index;not null"`                                          // 使用的试用许可证
+	ConsumedAt time.Time `gorm:"type:datetime;not null"`                                  // 首次注册时间
+}
+
+func (TrialConsumption) TableName() string {
+	return "trial_consumption"
+}
@@ -2,7 +2,11 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"nexus-core/global"
+	"nexus-core/licensekey"
 	"nexus-core/persistence/model"
+	"strings"
 
 	"gorm.io/gorm"
 )
@@ -45,7 +49,7 @@ func (r *LicenseRepository) UpdateLicense(ctx context.Context, db *gorm.DB, lice
 	if err := db.WithContext(ctx).
 		Model(&model.License{}).
 		Where("id = ?", license.ID).
-		Where("license_key = ?", license.LicenseKey).
+		Where("key_digest = ?", license.KeyDigest).
 		Updates(*license).Error; err != nil {
 		return err
 	}
@@ -66,9 +70,16 @@ func (r *LicenseRepository) GetByID(ctx context.Context, db *gorm.DB, id uint) (
 	return m, nil
 }
 
-// GetByKey 根据 LicenseKey 获取领域对象 License
+// GetByKey 根据注册码获取 License，按注册码摘要查询
+// 不符合格式的注册码不查询 License，按不存在返回
 func (r *LicenseRepository) GetByKey(ctx context.Context, db *gorm.DB, key string) (*model.License, error) {
-	m, err := GetOneByUniqueColumn[model.License](ctx, db, "license_key", key)
+	if err := r.CheckKey(ctx, db, key); err != nil {
+		if IsMalformedLicenseKey(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	m, err := GetOneByUniqueColumn[model.License](ctx, db, "key_digest", LicenseKeyDigest(key))
 	if err != nil {
 		return nil, err
 	}
@@ -79,6 +90,53 @@ func (r *LicenseRepository) GetByKey(ctx context.Context, db *gorm.DB, key strin
 	return m, nil
 }
 
+// CheckKey 查询 License 前校验注册码
+// 先做与格式无关的检查；前缀对应产品配置的格式时再按格式校验分组与校验位，同一前缀被多个产品使用时符合其一即可
+// 前缀不对应任何产品（如导入的外部注册码）时只做基本检查
+func (r *LicenseRepository) CheckKey(ctx context.Context, db *gorm.DB, key string) error {
+	if err := licensekey.CheckSyntax(key); err != nil {
+		return err
+	}
+	prefix, _, ok := strings.Cut(key, "-")
+	if !ok {
+		return nil
+	}
+	var products []model.Product
+	if err := db.WithContext(ctx).
+		Select("id", "key_prefix", "key_groups", "key_group_size", "key_check_digit").
+		Where("key_prefix = ? AND key_groups > 0", prefix).
+		Find(&products).Error; err != nil {
+		return err
+	}
+	var checkErr error
+	for i := range products {
+		if checkErr = ProductKeyFormat(&products[i]).Check(key); checkErr == nil {
+			return nil
+		}
+	}
+	return checkErr
+}
+
+// IsMalformedLicenseKey 判断 CheckKey 的错误是否为注册码格式错误
+func IsMalformedLicenseKey(err error) bool {
+	return errors.Is(err, licensekey.ErrMalformed) || errors.Is(err, licensekey.ErrChecksum)
+}
+
+// ProductKeyFormat 产品配置的注册码格式，未配置时为 UUID 格式
+func ProductKeyFormat(product *model.Product) licensekey.Format {
+	return licensekey.Format{
+		Prefix:     product.KeyPrefix,
+		Groups:     product.KeyGroups,
+		GroupSize:  product.KeyGroupSize,
+		CheckDigit: product.KeyCheckDigit,
+	}
+}
+
+// LicenseKeyDigest 计算注册码摘要，数据库只保存摘要
+func LicenseKeyDigest(key string) string {
+	return licensekey.Digest(global.GetConfig().LicenseKey.HashSecret, key)
+}
+
 func (r *LicenseRepository) GetIdListByStatus(ctx context.Context, db *gorm.DB, status int) ([]uint, error) {
 	licenses, err := gorm.G[model.License](db).
 		Select("id").
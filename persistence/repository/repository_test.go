@@ -113,7 +113,7 @@ func TestNodeAndLicenseRepositories(t *testing.T) {
 
 	license := &model.License{
 		ProductID:     1,
-		LicenseKey:    "repo-license-key",
+		KeyDigest:     LicenseKeyDigest("repo-license-key"),
 		ValidityHours: 24,
 		Status:        1,
 		MaxNodes:      2,
@@ -12,6 +12,8 @@ $ConfigPath = Join-Path $RepoRoot "config-dev.yml"
 $ConfigBackup = $null
 $ServerProcess = $null
 $BaseUrl = "http://127.0.0.1:$Port"
+$ApiKey = "release-validate-" + [System.Guid]::NewGuid().ToString("N")
+$HashSecret = "release-validate-" + [System.Guid]::NewGuid().ToString("N")
 
 function Write-Step {
     param([string]$Message)
@@ -47,11 +49,16 @@ function Invoke-Checked {
 function Invoke-JsonPost {
     param(
         [string]$Path,
-        [hashtable]$Body
+        [hashtable]$Body,
+        [switch]$Management
     )
 
+    $headers = @{}
+    if ($Management) {
+        $headers["X-API-Key"] = $ApiKey
+    }
     $json = $Body | ConvertTo-Json -Depth 20
-    $response = Invoke-RestMethod -Method Post -Uri "$BaseUrl$Path" -ContentType "application/json" -Body $json
+    $response = Invoke-RestMethod -Method Post -Uri "$BaseUrl$Path" -Headers $headers -ContentType "application/json" -Body $json
     if ($response.code -lt 200 -or $response.code -ge 300) {
         throw "POST $Path failed: code=$($response.code) message=$($response.message)"
     }
@@ -153,6 +160,12 @@ control:
   dispatch_timeout_seconds: 5
   dispatch_max_retries: 0
   node_online_ttl_seconds: 120
+admin_auth:
+  enabled: true
+  bootstrap_api_key: "$ApiKey"
+  trusted_operator_header: ""
+license_key:
+  hash_secret: "$HashSecret"
 "@
     Set-Content -LiteralPath $ConfigPath -Value $config -Encoding utf8
     Start-Server $serverBin
@@ -171,6 +184,7 @@ control:
     Invoke-Checked "go" @(
         "run", "./cmd/demo-product",
         "-server", $BaseUrl,
+        "-api-key", $ApiKey,
         "-device", "release-demo-basic",
         "-heartbeats", "2",
         "-heartbeat-interval", "100ms"
@@ -180,25 +194,26 @@ control:
     Invoke-Checked "go" @(
         "run", "./cmd/protocol-demo-product",
         "-server", $BaseUrl,
+        "-api-key", $ApiKey,
         "-device", "release-demo-protocol"
     )
 
     Write-Step "Scheduled release restart recovery"
     $suffix = (Get-Date).ToString("yyyyMMddHHmmss")
-    $product = Invoke-JsonPost "/products" @{
+    $product = Invoke-JsonPost -Management "/products" @{
         name = "restart-release-product-$suffix"
         description = "release validation restart recovery"
     }
     $releaseDate = (Get-Date).ToUniversalTime().AddSeconds(4).ToString("o")
     $versionCode = "restart-$suffix"
-    Invoke-JsonPost "/products/versions" @{
+    Invoke-JsonPost -Management "/products/versions" @{
         product_id = [uint32]$product.id
         version_code = $versionCode
         release_method = 1
         release_date = $releaseDate
         description = "scheduled release restart recovery"
     } | Out-Null
-    $license = Invoke-JsonPost "/licenses" @{
+    $license = Invoke-JsonPost -Management "/licenses" @{
         product_id = [uint32]$product.id
         validity_hours = 24
         max_nodes = 1